@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"patchmon-agent/internal/constants"
+	"patchmon-agent/internal/system"
+	"patchmon-agent/internal/version"
+	"patchmon-agent/pkg/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// backgroundActions tracks server-requested actions that outlive the report
+// cycle that started them. Defender scans can take hours, so they run in the
+// background and post their result when they finish.
+var (
+	backgroundActions   sync.WaitGroup
+	backgroundDetached  atomic.Bool
+	defenderScanRunning atomic.Bool
+)
+
+// runServerActions carries out the actions the server requested in the
+// report response. Each action reports its own result; failures are logged
+// and do not stop the remaining actions.
+func runServerActions(actions []models.AgentAction) {
+	for _, action := range actions {
+		log := logger.WithFields(logrus.Fields{
+			"action_id": action.ID,
+			"type":      action.Type,
+		})
+		log.Info("Running server-requested action")
+
+		var err error
+		switch action.Type {
+		case constants.ActionHide:
+			err = setUpdatesHidden(action.KBs, true, action.ID, false)
+		case constants.ActionUnhide:
+			err = setUpdatesHidden(action.KBs, false, action.ID, false)
+		case constants.ActionReboot:
+			delay := time.Duration(action.DelaySeconds) * time.Second
+			if delay <= 0 {
+				delay = defaultRebootDelay
+			}
+			err = scheduleReboot(delay, action.Message, action.Force, action.ID)
+		case constants.ActionCancelReboot:
+			err = cancelReboot(action.ID)
+		case constants.ActionScan:
+			err = triggerScan(action.ID)
+		case constants.ActionDefenderScan:
+			if !defenderScanRunning.CompareAndSwap(false, true) {
+				err = errors.New("a Defender scan is already running")
+				reportActionResult(constants.ActionDefenderScan, action.ID, err)
+				break
+			}
+			backgroundActions.Add(1)
+			go func() {
+				defer backgroundActions.Done()
+				defer defenderScanRunning.Store(false)
+				if err := runDefenderScan(action.ScanType, action.ID); err != nil {
+					log.WithError(err).Warn("Server-requested action failed")
+				}
+			}()
+			continue
+		default:
+			log.Warn("Ignoring unsupported server action")
+			continue
+		}
+
+		if err != nil {
+			log.WithError(err).Warn("Server-requested action failed")
+		}
+	}
+}
+
+// waitForBackgroundActions blocks until actions started in the background
+// have finished and reported their result, so a one-shot command does not
+// exit in the middle of a server-requested scan
+func waitForBackgroundActions() {
+	if backgroundDetached.Load() {
+		return
+	}
+	if defenderScanRunning.Load() {
+		logger.Info("Waiting for the server-requested Defender scan to finish")
+	}
+	backgroundActions.Wait()
+}
+
+// detachBackgroundActions stops waitForBackgroundActions from blocking, so a
+// running scan does not hold up stopping serve
+func detachBackgroundActions() {
+	backgroundDetached.Store(true)
+}
+
+// reportActionResult sends the outcome of a server-requested action that has
+// no per-update results (reboot, scan). Actions run from the command line
+// (empty actionID) are not reported.
+func reportActionResult(action, actionID string, actionErr error) {
+	if actionID == "" {
+		return
+	}
+
+	now := time.Now()
+	sendActionResult(newActionPayload(action, actionID, now, now, actionErr))
+}
+
+// newActionPayload builds the result payload of a server-requested action
+// without per-update results
+func newActionPayload(action, actionID string, startedAt, finishedAt time.Time, actionErr error) *models.InstallResultPayload {
+	systemDetector := system.New(logger)
+	hostname, _ := systemDetector.GetHostname()
+	payload := &models.InstallResultPayload{
+		Hostname:     hostname,
+		MachineID:    systemDetector.GetMachineID(cfgManager.GetConfig().MachineIDSource),
+		AgentVersion: version.Version,
+		Action:       action,
+		ActionID:     actionID,
+		StartedAt:    startedAt.UTC().Format(time.RFC3339),
+		FinishedAt:   finishedAt.UTC().Format(time.RFC3339),
+		Results:      []models.UpdateInstallResult{},
+	}
+	if actionErr != nil {
+		payload.Error = actionErr.Error()
+	}
+	return payload
+}
+
+// sendActionResult sends an action result payload, logging failures
+func sendActionResult(payload *models.InstallResultPayload) {
+	if err := publishInstallResults(payload, false); err != nil {
+		logger.WithError(err).Warnf("Failed to report %s action result", payload.Action)
+	}
+}
@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	collectOut      string
+	collectSections []string
+)
+
+// collectCmd collects a report without sending it
+var collectCmd = &cobra.Command{
+	Use:   "collect",
+	Short: "Collect a report and write it to a file",
+	Long: `Collect system and package information and write the report payload as JSON,
+without contacting the PatchMon server. No credentials are needed, so the file
+can be attached to support tickets or carried off air-gapped hosts.
+
+Example:
+  patchmon-agent collect --out report.json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkAdmin(); err != nil {
+			return err
+		}
+
+		sections, err := parseReportSections(collectSections)
+		if err != nil {
+			return withExitCode(ExitConfig, err)
+		}
+		if collectOut == "" {
+			return sendReport(cmd.Context(), os.Stdout, sections)
+		}
+		return collectToFile(cmd.Context(), collectOut, sections)
+	},
+}
+
+func init() {
+	collectCmd.Flags().StringVar(&collectOut, "out", "", "file to write the JSON payload to (default: stdout)")
+	collectCmd.Flags().StringSliceVar(&collectSections, "sections", nil, "only collect these report sections: packages, hardware, network, security, inventory (default: all)")
+	rootCmd.AddCommand(collectCmd)
+}
+
+// collectToFile collects a report into path. The file is only written once
+// collection has finished, also when some of the data is missing.
+func collectToFile(ctx context.Context, path string, sections reportSections) error {
+	var buf bytes.Buffer
+	err := sendReport(ctx, &buf, sections)
+	if err != nil && ExitCode(err) != ExitPartial {
+		return err
+	}
+	if writeErr := os.WriteFile(path, buf.Bytes(), 0600); writeErr != nil {
+		return fmt.Errorf("failed to write report: %w", writeErr)
+	}
+	logger.WithField("path", path).Info("Report written")
+	return err
+}
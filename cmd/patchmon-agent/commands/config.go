@@ -2,11 +2,15 @@ package commands
 
 import (
 	"fmt"
+	"maps"
 	"net/url"
+	"slices"
 	"strings"
 
+	"patchmon-agent/internal/config"
 	"patchmon-agent/internal/version"
 
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -23,24 +27,62 @@ var configShowCmd = &cobra.Command{
 	Short: "Show current configuration",
 	Long:  "Display the current configuration settings for the PatchMon agent.",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return showConfig()
+		if err := checkOutputFormat(configShowOutput); err != nil {
+			return err
+		}
+		return showConfig(configShowOutput)
 	},
 }
 
+var configShowOutput string
+
+// configShowResult is the JSON output of config show. The API key is never
+// included.
+type configShowResult struct {
+	Server          string            `json:"server"`
+	AgentVersion    string            `json:"agentVersion"`
+	ConfigFile      string            `json:"configFile"`
+	CredentialsFile string            `json:"credentialsFile"`
+	LogFile         string            `json:"logFile"`
+	LogLevel        string            `json:"logLevel"`
+	APIID           string            `json:"apiId,omitempty"`
+	APIKeySet       bool              `json:"apiKeySet"`
+	Site            string            `json:"site,omitempty"`
+	Environment     string            `json:"environment,omitempty"`
+	Role            string            `json:"role,omitempty"`
+	Tags            map[string]string `json:"tags,omitempty"`
+}
+
 // configSetAPICmd configures API credentials
 var configSetAPICmd = &cobra.Command{
 	Use:   "set-api <API_ID> <API_KEY> <SERVER_URL>",
 	Short: "Configure API credentials for this host",
 	Long: `Configure API credentials for the PatchMon server.
 
+--site, --environment and --role set the grouping hints sent with every report,
+so hosts enrolled in bulk can be assigned to groups by the server.
+
 Example:
-  patchmon-agent config set-api patchmon_1a2b3c4d abcd1234567890abcdef1234567890abcdef1234567890abcdef1234567890 http://patchmon.example.com`,
+  patchmon-agent config set-api patchmon_1a2b3c4d abcd1234567890abcdef1234567890abcdef1234567890abcdef1234567890 http://patchmon.example.com
+  patchmon-agent config set-api <API_ID> <API_KEY> <SERVER_URL> --site AMS-1 --environment prod --role sql`,
 	Args: cobra.ExactArgs(3),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if err := checkAdmin(); err != nil {
 			return err
 		}
 
+		// Saved together with the server URL by configureCreds
+		cfg := cfgManager.GetConfig()
+		if cmd.Flags().Changed("site") {
+			cfg.Site = strings.TrimSpace(setAPISite)
+		}
+		if cmd.Flags().Changed("environment") {
+			cfg.Environment = strings.TrimSpace(setAPIEnvironment)
+		}
+		if cmd.Flags().Changed("role") {
+			cfg.Role = strings.TrimSpace(setAPIRole)
+		}
+
 		apiID := args[0]
 		apiKey := args[1]
 		serverURL := args[2]
@@ -49,43 +91,175 @@ Example:
 	},
 }
 
+var setAPISite, setAPIEnvironment, setAPIRole string
+
+// configImportCmd takes over the configuration of the Linux agent
+var configImportCmd = &cobra.Command{
+	Use:   "import <path>",
+	Short: "Import the configuration of the Linux agent",
+	Long: `Import the configuration and credentials of the Linux PatchMon agent, given its
+config directory (/etc/patchmon) or its config.yml, and save them in the Windows
+locations. File paths keep their Windows defaults and integrations that do not
+exist on Windows are skipped.
+
+Example:
+  patchmon-agent config import D:\linux-backup\etc\patchmon`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkAdmin(); err != nil {
+			return err
+		}
+
+		return importConfig(args[0])
+	},
+}
+
+// configSetCmd sets a grouping hint
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> [value]",
+	Short: "Set the site, environment or role of this host",
+	Long: `Set one of the grouping hints sent with every report (site, environment or
+role) without re-entering the API credentials. Without a value the hint is
+cleared.
+
+Example:
+  patchmon-agent config set site AMS-1
+  patchmon-agent config set role`,
+	Args:      cobra.RangeArgs(1, 2),
+	ValidArgs: config.GroupingHints,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkAdmin(); err != nil {
+			return err
+		}
+
+		value := ""
+		if len(args) == 2 {
+			value = args[1]
+		}
+		if err := cfgManager.SetGroupingHint(args[0], value); err != nil {
+			return withExitCode(ExitConfig, err)
+		}
+		if value == "" {
+			logger.WithField("key", args[0]).Info("Value cleared")
+		} else {
+			logger.WithFields(logrus.Fields{"key": args[0], "value": value}).Info("Value set")
+		}
+		return nil
+	},
+}
+
+// configSetTagCmd sets a host tag
+var configSetTagCmd = &cobra.Command{
+	Use:   "set-tag <name> [value]",
+	Short: "Set or remove a tag sent with every report",
+	Long: `Set a tag that is sent with every report, so hosts can be filtered and grouped
+by owner, environment, site and so on. Without a value the tag is removed. Tag
+names are case-insensitive and stored in lower case.
+
+Example:
+  patchmon-agent config set-tag owner "Finance IT"
+  patchmon-agent config set-tag owner`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkAdmin(); err != nil {
+			return err
+		}
+
+		value := ""
+		if len(args) == 2 {
+			value = args[1]
+		}
+		if err := cfgManager.SetTag(args[0], value); err != nil {
+			return withExitCode(ExitConfig, err)
+		}
+		if value == "" {
+			logger.WithField("tag", args[0]).Info("Tag removed")
+		} else {
+			logger.WithFields(logrus.Fields{"tag": args[0], "value": value}).Info("Tag set")
+		}
+		return nil
+	},
+}
+
 func init() {
 	// Add subcommands to config
 	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configSetCmd)
 	configCmd.AddCommand(configSetAPICmd)
+	configCmd.AddCommand(configImportCmd)
+	configCmd.AddCommand(configSetTagCmd)
+
+	addOutputFlag(configShowCmd, &configShowOutput)
+	configSetAPICmd.Flags().StringVar(&setAPISite, "site", "", "site or datacenter of this host")
+	configSetAPICmd.Flags().StringVar(&setAPIEnvironment, "environment", "", "environment of this host, e.g. prod or test")
+	configSetAPICmd.Flags().StringVar(&setAPIRole, "role", "", "role of this host, e.g. web or sql")
 }
 
-func showConfig() error {
+func showConfig(output string) error {
 	cfg := cfgManager.GetConfig()
 	err := cfgManager.LoadCredentials()
 	if err != nil {
-		return fmt.Errorf("failed to load credentials: %w", err)
+		return withExitCode(ExitConfig, fmt.Errorf("failed to load credentials: %w", err))
 	}
 	creds := cfgManager.GetCredentials()
 
-	fmt.Printf("Configuration:\n")
+	if output == outputJSON {
+		result := configShowResult{
+			Server:          cfg.PatchmonServer,
+			AgentVersion:    version.Version,
+			ConfigFile:      cfgManager.GetConfigFile(),
+			CredentialsFile: cfg.CredentialsFile,
+			LogFile:         cfg.LogFile,
+			LogLevel:        cfg.LogLevel,
+			Site:            cfg.Site,
+			Environment:     cfg.Environment,
+			Role:            cfg.Role,
+			Tags:            cfg.Tags,
+		}
+		if creds != nil {
+			result.APIID = creds.APIID
+			result.APIKeySet = creds.APIKey != ""
+		}
+		return printJSON(result)
+	}
+
+	fmt.Fprintf(console, "Configuration:\n")
 	if cfg.PatchmonServer != "" {
-		fmt.Printf("  Server: %s\n", cfg.PatchmonServer)
+		fmt.Fprintf(console, "  Server: %s\n", cfg.PatchmonServer)
 	} else {
-		fmt.Printf("  Server: Not configured\n")
+		fmt.Fprintf(console, "  Server: Not configured\n")
+	}
+	fmt.Fprintf(console, "  Agent Version: %s\n", version.Version)
+	fmt.Fprintf(console, "  Config File: %s\n", cfgManager.GetConfigFile())
+	fmt.Fprintf(console, "  Credentials File: %s\n", cfg.CredentialsFile)
+	fmt.Fprintf(console, "  Log File: %s\n", cfg.LogFile)
+	fmt.Fprintf(console, "  Log Level: %s\n", cfg.LogLevel)
+	for _, hint := range []struct{ name, value string }{
+		{"Site", cfg.Site}, {"Environment", cfg.Environment}, {"Role", cfg.Role},
+	} {
+		if hint.value != "" {
+			fmt.Fprintf(console, "  %s: %s\n", hint.name, hint.value)
+		}
+	}
+	if len(cfg.Tags) > 0 {
+		names := slices.Sorted(maps.Keys(cfg.Tags))
+		fmt.Fprintf(console, "  Tags:\n")
+		for _, name := range names {
+			fmt.Fprintf(console, "    %s: %s\n", name, cfg.Tags[name])
+		}
 	}
-	fmt.Printf("  Agent Version: %s\n", version.Version)
-	fmt.Printf("  Config File: %s\n", cfgManager.GetConfigFile())
-	fmt.Printf("  Credentials File: %s\n", cfg.CredentialsFile)
-	fmt.Printf("  Log File: %s\n", cfg.LogFile)
-	fmt.Printf("  Log Level: %s\n", cfg.LogLevel)
 
-	fmt.Printf("\nCredentials:\n")
+	fmt.Fprintf(console, "\nCredentials:\n")
 	if creds != nil {
-		fmt.Printf("  API ID: %s\n", creds.APIID)
+		fmt.Fprintf(console, "  API ID: %s\n", creds.APIID)
 		// Show only first 8 characters of API key for security
 		if len(creds.APIKey) >= 0 {
-			fmt.Print("  API Key: Set ✅\n")
+			fmt.Fprint(console, "  API Key: Set ✅\n")
 		} else {
-			fmt.Print("  API Key: Not set ❌\n")
+			fmt.Fprint(console, "  API Key: Not set ❌\n")
 		}
 	} else {
-		fmt.Printf("  Credentials: Not configured\n")
+		fmt.Fprintf(console, "  Credentials: Not configured\n")
 	}
 
 	return nil
@@ -96,16 +270,16 @@ func configureCreds(apiID, apiKey, serverURL string) error {
 
 	// Validate credentials not empty
 	if strings.TrimSpace(apiID) == "" || strings.TrimSpace(apiKey) == "" {
-		return fmt.Errorf("API ID and API Key must be set")
+		return withExitCode(ExitConfig, fmt.Errorf("API ID and API Key must be set"))
 	}
 
 	// Validate server URL format
 	if _, err := url.Parse(serverURL); err != nil {
-		return fmt.Errorf("invalid server URL format: %w", err)
+		return withExitCode(ExitConfig, fmt.Errorf("invalid server URL format: %w", err))
 	}
 
 	if !strings.HasPrefix(serverURL, "http://") && !strings.HasPrefix(serverURL, "https://") {
-		return fmt.Errorf("invalid server URL format. Must start with http:// or https://")
+		return withExitCode(ExitConfig, fmt.Errorf("invalid server URL format. Must start with http:// or https://"))
 	}
 
 	// Set server URL in config
@@ -139,3 +313,32 @@ func configureCreds(apiID, apiKey, serverURL string) error {
 
 	return nil
 }
+
+func importConfig(path string) error {
+	logger.WithField("path", path).Info("Importing Linux agent configuration...")
+
+	result, err := cfgManager.ImportLinuxConfig(path)
+	if err != nil {
+		return withExitCode(ExitConfig, fmt.Errorf("failed to import configuration: %w", err))
+	}
+
+	logger.WithField("path", result.ConfigFile).Info("Imported config")
+	logger.WithField("path", result.CredentialsFile).Info("Imported credentials")
+	for _, setting := range result.Ignored {
+		logger.WithField("setting", setting).Warn("Setting does not apply on Windows, skipped")
+	}
+	logger.WithField("path", cfgManager.GetConfigFile()).Info("Config saved")
+	logger.WithField("path", cfgManager.GetConfig().CredentialsFile).Info("Credentials saved")
+
+	// Test credentials
+	logger.Info("Testing connection...")
+	if _, err := pingServer(); err != nil {
+		logger.WithError(err).Error("Connection test failed")
+		return err
+	}
+
+	logger.Info("✅ Connectivity test successful")
+	logger.Info("✅ API credentials are valid")
+
+	return nil
+}
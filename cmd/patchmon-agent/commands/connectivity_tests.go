@@ -3,8 +3,11 @@ package commands
 import (
 	"context"
 	"fmt"
+	"slices"
+	"time"
 
 	"patchmon-agent/internal/client"
+	"patchmon-agent/internal/constants"
 	"patchmon-agent/pkg/models"
 
 	"github.com/spf13/cobra"
@@ -19,22 +22,53 @@ var pingCmd = &cobra.Command{
 		if err := checkAdmin(); err != nil {
 			return err
 		}
+		if err := checkOutputFormat(pingOutput); err != nil {
+			return err
+		}
 
-		_, err := pingServer()
+		response, err := pingServer()
+		if pingOutput == outputJSON {
+			result := pingResult{Success: err == nil, Server: cfgManager.GetConfig().PatchmonServer, ExitCode: ExitCode(err)}
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Status, result.Message = response.Status, response.Message
+			}
+			if jsonErr := printJSON(result); jsonErr != nil {
+				return jsonErr
+			}
+			return err
+		}
 		if err != nil {
 			return err
 		}
 
-		fmt.Println("✅ API credentials are valid")
-		fmt.Println("✅ Connectivity test successful")
+		fmt.Fprintln(console, "✅ API credentials are valid")
+		fmt.Fprintln(console, "✅ Connectivity test successful")
 		return nil
 	},
 }
 
+var pingOutput string
+
+// pingResult is the JSON output of ping
+type pingResult struct {
+	Success  bool   `json:"success"`
+	Server   string `json:"server"`
+	Status   string `json:"status,omitempty"`
+	Message  string `json:"message,omitempty"`
+	Error    string `json:"error,omitempty"`
+	ExitCode int    `json:"exitCode"`
+}
+
+func init() {
+	addOutputFlag(pingCmd, &pingOutput)
+}
+
 // pingServer tests connectivity to the server and validates credentials
 func pingServer() (*models.PingResponse, error) {
 	// Load credentials
-	if err := cfgManager.LoadCredentials(); err != nil {
+	if err := loadCredentials(); err != nil {
 		return nil, fmt.Errorf("failed to load credentials: %w", err)
 	}
 
@@ -48,3 +82,16 @@ func pingServer() (*models.PingResponse, error) {
 
 	return response, nil
 }
+
+// serverAcceptsPartialReports reports whether the server announces support
+// for reports limited to some sections
+func serverAcceptsPartialReports() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	response, err := client.New(cfgManager, logger).Ping(ctx)
+	if err != nil {
+		logger.WithError(err).Debug("Failed to query server capabilities")
+		return false
+	}
+	return slices.Contains(response.Capabilities, constants.CapabilityPartialReports)
+}
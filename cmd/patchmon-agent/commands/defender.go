@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"patchmon-agent/internal/constants"
+	"patchmon-agent/internal/security"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	defenderScanQuick bool
+	defenderScanFull  bool
+)
+
+// defenderScanCmd represents the defender-scan command
+var defenderScanCmd = &cobra.Command{
+	Use:   "defender-scan",
+	Short: "Run a Microsoft Defender quick or full scan",
+	Long: `Run an on-demand Microsoft Defender scan with MpCmdRun and wait for it to finish,
+then print the duration and any threats detected. --quick (the default) scans the
+locations malware usually uses; --full (or --quick=false) scans every file and can
+take hours.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkAdmin(); err != nil {
+			return err
+		}
+
+		scanType := security.ScanTypeQuick
+		if defenderScanFull || !defenderScanQuick {
+			scanType = security.ScanTypeFull
+		}
+		return runDefenderScan(scanType, "")
+	},
+}
+
+func init() {
+	defenderScanCmd.Flags().BoolVar(&defenderScanQuick, "quick", true, "Run a quick scan (default)")
+	defenderScanCmd.Flags().BoolVar(&defenderScanFull, "full", false, "Run a full scan")
+	defenderScanCmd.MarkFlagsMutuallyExclusive("quick", "full")
+	rootCmd.AddCommand(defenderScanCmd)
+}
+
+// runDefenderScan runs a Defender scan and, when the server requested it
+// (actionID set), posts the result back. An empty scanType means a quick scan.
+func runDefenderScan(scanType, actionID string) error {
+	if scanType == "" {
+		scanType = security.ScanTypeQuick
+	}
+
+	startedAt := time.Now()
+	result, err := security.New(logger).RunDefenderScan(scanType)
+	if err != nil {
+		err = fmt.Errorf("defender scan failed: %w", err)
+	}
+
+	if actionID != "" {
+		payload := newActionPayload(constants.ActionDefenderScan, actionID, startedAt, time.Now(), err)
+		payload.DefenderScan = result
+		sendActionResult(payload)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(console, "Defender %s scan completed in %s\n", result.ScanType, time.Duration(result.DurationSeconds)*time.Second)
+	if result.ThreatsFound == 0 {
+		fmt.Fprintln(console, "No threats found")
+	} else {
+		fmt.Fprintf(console, "Threats found: %d\n", result.ThreatsFound)
+		if len(result.Threats) > 0 {
+			fmt.Fprintf(console, "  %s\n", strings.Join(result.Threats, "\n  "))
+		}
+	}
+	return nil
+}
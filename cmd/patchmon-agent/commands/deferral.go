@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"fmt"
+
+	"patchmon-agent/internal/updatepolicy"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	deferQualityDays int
+	deferFeatureDays int
+	deferClear       bool
+)
+
+// setDeferralCmd represents the set-deferral command
+var setDeferralCmd = &cobra.Command{
+	Use:   "set-deferral",
+	Short: "Configure quality/feature update deferral days",
+	Long: `Set how many days Windows Update defers quality (0-30) and feature (0-365)
+updates after release, using the Windows Update for Business policy values. This
+lets PatchMon stagger update rings on machines that are not managed by WSUS.
+Use --clear to remove the deferral policy.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkAdmin(); err != nil {
+			return err
+		}
+
+		policyMgr := updatepolicy.New(logger)
+		if deferClear {
+			if err := policyMgr.ClearDeferral(updatepolicy.TypeAll); err != nil {
+				return err
+			}
+			fmt.Fprintln(console, "Update deferral policy cleared")
+			return nil
+		}
+
+		qualitySet := cmd.Flags().Changed("quality")
+		featureSet := cmd.Flags().Changed("feature")
+		if !qualitySet && !featureSet {
+			return fmt.Errorf("specify --quality and/or --feature, or --clear")
+		}
+
+		if qualitySet {
+			if err := policyMgr.SetDeferral(updatepolicy.TypeQuality, deferQualityDays); err != nil {
+				return err
+			}
+			fmt.Fprintf(console, "Quality updates deferred by %d days\n", deferQualityDays)
+		}
+		if featureSet {
+			if err := policyMgr.SetDeferral(updatepolicy.TypeFeature, deferFeatureDays); err != nil {
+				return err
+			}
+			fmt.Fprintf(console, "Feature updates deferred by %d days\n", deferFeatureDays)
+		}
+		return nil
+	},
+}
+
+func init() {
+	setDeferralCmd.Flags().IntVar(&deferQualityDays, "quality", 0, "Days to defer quality updates (0-30)")
+	setDeferralCmd.Flags().IntVar(&deferFeatureDays, "feature", 0, "Days to defer feature updates (0-365)")
+	setDeferralCmd.Flags().BoolVar(&deferClear, "clear", false, "Remove the deferral policy")
+	rootCmd.AddCommand(setDeferralCmd)
+}
@@ -2,16 +2,23 @@ package commands
 
 import (
 	"bufio"
+	"context"
 	"fmt"
-	"io"
+	"net"
 	"os"
 	"runtime"
 	"strings"
 
+	"patchmon-agent/internal/client"
+	"patchmon-agent/internal/config"
+	"patchmon-agent/internal/packages"
+	"patchmon-agent/internal/reachability"
 	"patchmon-agent/internal/system"
 	"patchmon-agent/internal/utils"
 	"patchmon-agent/internal/version"
+	"patchmon-agent/pkg/models"
 
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -28,102 +35,163 @@ var diagnosticsCmd = &cobra.Command{
 func showDiagnostics() error {
 	cfg := cfgManager.GetConfig()
 
-	fmt.Printf("PatchMon Agent Diagnostics v%s\n\n", version.Version)
+	fmt.Fprintf(console, "PatchMon Agent Diagnostics v%s\n\n", version.Version)
 
 	// System Information
-	fmt.Printf("System Information:\n")
+	fmt.Fprintf(console, "System Information:\n")
 
 	systemDetector := system.New(logger)
 
 	osType, osVersion, err := systemDetector.DetectOS()
 	if err != nil {
-		fmt.Printf("  OS: %s (detection failed: %v)\n", runtime.GOOS, err)
+		fmt.Fprintf(console, "  OS: %s (detection failed: %v)\n", runtime.GOOS, err)
 	} else {
-		fmt.Printf("  OS: %s %s\n", osType, osVersion)
+		fmt.Fprintf(console, "  OS: %s %s\n", osType, osVersion)
 	}
 
-	fmt.Printf("  Architecture: %s\n", runtime.GOARCH)
+	fmt.Fprintf(console, "  Architecture: %s\n", runtime.GOARCH)
 
 	kernelVersion := systemDetector.GetKernelVersion()
-	fmt.Printf("  Kernel: %s\n", kernelVersion)
+	fmt.Fprintf(console, "  Kernel: %s\n", kernelVersion)
 
 	if hostname, err := os.Hostname(); err == nil {
-		fmt.Printf("  Hostname: %s\n", hostname)
+		fmt.Fprintf(console, "  Hostname: %s\n", hostname)
 	}
 
 	// Show machine ID
-	machineID := systemDetector.GetMachineID()
-	fmt.Printf("  Machine ID: %s\n", machineID)
+	machineID := systemDetector.GetMachineID(cfgManager.GetConfig().MachineIDSource)
+	fmt.Fprintf(console, "  Machine ID: %s\n", machineID)
 
-	fmt.Printf("\n")
+	fmt.Fprintf(console, "\n")
 
 	// Agent Information
-	fmt.Printf("Agent Information:\n")
-	fmt.Printf("  Version: %s\n", version.Version)
-	fmt.Printf("  Config File: %s\n", cfgManager.GetConfigFile())
-	fmt.Printf("  Credentials File: %s\n", cfg.CredentialsFile)
-	fmt.Printf("  Log File: %s\n", cfg.LogFile)
-	fmt.Printf("  Log Level: %s\n", cfg.LogLevel)
-	fmt.Printf("\n")
+	fmt.Fprintf(console, "Agent Information:\n")
+	fmt.Fprintf(console, "  Version: %s\n", version.Version)
+	fmt.Fprintf(console, "  Config File: %s\n", cfgManager.GetConfigFile())
+	fmt.Fprintf(console, "  Credentials File: %s\n", cfg.CredentialsFile)
+	fmt.Fprintf(console, "  Log File: %s\n", cfg.LogFile)
+	fmt.Fprintf(console, "  Log Level: %s\n", cfg.LogLevel)
+	fmt.Fprintf(console, "\n")
 
 	// Configuration Status
-	fmt.Printf("Configuration Status:\n")
+	fmt.Fprintf(console, "Configuration Status:\n")
 	configFile := cfgManager.GetConfigFile()
 	if _, err := os.Stat(configFile); err == nil {
-		fmt.Printf("  ✅ Config file exists\n")
+		fmt.Fprintf(console, "  ✅ Config file exists\n")
 	} else {
-		fmt.Printf("  ❌ Config file not found (using defaults)\n")
+		fmt.Fprintf(console, "  ❌ Config file not found (using defaults)\n")
 	}
 	if _, err := os.Stat(cfg.CredentialsFile); err == nil {
-		fmt.Printf("  ✅ Credentials file exists\n")
+		fmt.Fprintf(console, "  ✅ Credentials file exists\n")
 	} else {
-		fmt.Printf("  ❌ Credentials file not found\n")
+		fmt.Fprintf(console, "  ❌ Credentials file not found\n")
 	}
-	fmt.Printf("\n")
+	for _, file := range []string{configFile, cfg.CredentialsFile} {
+		if groups, err := config.BroadReadAccess(file); err == nil && len(groups) > 0 {
+			fmt.Fprintf(console, "  ⚠️  %s is readable by %s (re-run config set-api or restrict the file to SYSTEM and Administrators)\n", file, strings.Join(groups, ", "))
+		}
+	}
+	fmt.Fprintf(console, "\n")
 
 	// Network Connectivity & API Credentials
-	fmt.Printf("Network Connectivity & API Credentials:\n")
-	fmt.Printf("  Server URL: %s\n", cfg.PatchmonServer)
+	fmt.Fprintf(console, "Network Connectivity & API Credentials:\n")
+	fmt.Fprintf(console, "  Server URL: %s\n", cfg.PatchmonServer)
 
 	// Basic network connectivity test
 	serverHost, serverPort := extractUrlHostAndPort(cfg.PatchmonServer)
 	if isReachable := utils.TcpPing(serverHost, serverPort); isReachable {
-		fmt.Printf("  ✅ Server is reachable\n")
+		fmt.Fprintf(console, "  ✅ Server is reachable\n")
 	} else {
-		fmt.Printf("  ❌ Server is not reachable\n")
+		fmt.Fprintf(console, "  ❌ Server is not reachable\n")
 	}
 
 	// API credentials and server connectivity test
-	fmt.Printf("  ⏳ API connectivity test in progress...")
+	fmt.Fprintf(console, "  ⏳ API connectivity test in progress...")
 
-	// Temporarily disable logging output during diagnostics
-	originalOutput := logger.Out
-	logger.SetOutput(io.Discard)
+	// Temporarily disable logging during diagnostics, including the
+	// console and other hooks
+	originalLevel := logger.GetLevel()
+	logger.SetLevel(logrus.PanicLevel)
 	_, pingErr := pingServer()
-	logger.SetOutput(originalOutput)
+	logger.SetLevel(originalLevel)
 
 	// Clear the progress line and show result
-	fmt.Printf("\r") // Return to beginning of line
+	fmt.Fprintf(console, "\r") // Return to beginning of line
 	if pingErr != nil {
-		fmt.Printf("  ❌ API connectivity not available: %v\n", pingErr)
+		fmt.Fprintf(console, "  ❌ API connectivity not available: %v\n", pingErr)
 	} else {
-		fmt.Printf("  ✅ API is reachable and credentials are valid\n")
+		fmt.Fprintf(console, "  ✅ API is reachable and credentials are valid\n")
 	}
-	fmt.Printf("\n")
+	fmt.Fprintf(console, "\n")
+
+	showUpdateSourceConnectivity(cfg)
 
 	// Recent Logs
-	fmt.Printf("Last 10 log entries:\n")
+	fmt.Fprintf(console, "Last 10 log entries:\n")
 	if logLines := getRecentLogs(cfg.LogFile); len(logLines) > 0 {
 		for _, line := range logLines {
-			fmt.Printf("  %s\n", line)
+			fmt.Fprintf(console, "  %s\n", line)
 		}
 	} else {
-		fmt.Printf("  No recent logs found or log file does not exist.\n")
+		fmt.Fprintf(console, "  No recent logs found or log file does not exist.\n")
 	}
 
 	return nil
 }
 
+// showUpdateSourceConnectivity checks that the WSUS server and the Microsoft
+// update endpoints can be reached, so "no updates found" can be told apart
+// from an unreachable update source
+func showUpdateSourceConnectivity(cfg *models.Config) {
+	fmt.Fprintf(console, "Update Source Connectivity:\n")
+	if cfg.OfflineScanCab != "" {
+		fmt.Fprintf(console, "  Update source: offline catalog %s (no update source is contacted)\n\n", cfg.OfflineScanCab)
+		return
+	}
+
+	endpoints := reachability.MicrosoftUpdateEndpoints()
+	wsusServer := packages.WSUSServer()
+	if wsusServer != "" {
+		fmt.Fprintf(console, "  Update source: WSUS %s\n", wsusServer)
+		endpoints = append([]reachability.Endpoint{{Name: "WSUS server", URL: wsusServer}}, endpoints...)
+	} else {
+		fmt.Fprintf(console, "  Update source: Microsoft Update\n")
+	}
+
+	// The checks use the agent's proxy, like requests to the PatchMon server
+	results := reachability.CheckAll(context.Background(), endpoints, client.Proxy(cfg), reachability.DefaultTimeout)
+	unreachable := 0
+	for _, result := range results {
+		if result.Reachable {
+			fmt.Fprintf(console, "  ✅ %s (%s:%s) is reachable (%d ms)\n", result.Name, result.Host, result.Port, result.DurationMs)
+			continue
+		}
+		unreachable++
+		target := result.URL
+		if result.Host != "" {
+			target = net.JoinHostPort(result.Host, result.Port)
+		}
+		if result.Proxy != "" {
+			target += " via proxy " + result.Proxy
+		}
+		fmt.Fprintf(console, "  ❌ %s (%s): %s check failed: %s\n", result.Name, target, strings.ToUpper(result.FailedAt), result.Error)
+	}
+
+	switch {
+	case wsusServer != "" && !results[0].Reachable:
+		fmt.Fprintf(console, "  ⚠️  The WSUS server cannot be reached, so Windows Update cannot find updates: \"no updates\" does not mean this host is up to date\n")
+	case wsusServer == "" && unreachable == len(results):
+		fmt.Fprintf(console, "  ⚠️  Microsoft Update cannot be reached, so Windows Update cannot find updates: \"no updates\" does not mean this host is up to date\n")
+	case unreachable > 0:
+		fmt.Fprintf(console, "  ⚠️  Some endpoints cannot be reached; searches or downloads may fail\n")
+	}
+	if unreachable > 0 {
+		// Windows Update uses the WinHTTP proxy, which may differ from the agent's
+		fmt.Fprintf(console, "  ⚠️  The checks use the agent's proxy setting; Windows Update uses the WinHTTP proxy (netsh winhttp show proxy), so results may differ on proxied networks\n")
+	}
+	fmt.Fprintf(console, "\n")
+}
+
 // extractUrlHostAndPort extracts the host and port from a URL string
 func extractUrlHostAndPort(url string) (host string, port string) {
 	trimmed := strings.TrimPrefix(url, "http://")
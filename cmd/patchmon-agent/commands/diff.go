@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"patchmon-agent/internal/config"
+	"patchmon-agent/internal/snapshot"
+	"patchmon-agent/pkg/models"
+
+	"github.com/spf13/cobra"
+)
+
+var diffOutput string
+
+// diffCmd compares the current state to the last report
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show package and hardware changes since the last report",
+	Long: `Collect packages and hardware and compare them to the last report sent to the
+PatchMon server, listing added, removed and changed updates, Appx packages,
+drivers, disks, memory and BIOS. Nothing is sent, so running diff after a
+maintenance window shows what it changed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkOutputFormat(diffOutput); err != nil {
+			return err
+		}
+		if err := checkAdmin(); err != nil {
+			return err
+		}
+		return showDiff(cmd.Context(), diffOutput)
+	},
+}
+
+func init() {
+	addOutputFlag(diffCmd, &diffOutput)
+	rootCmd.AddCommand(diffCmd)
+}
+
+func showDiff(ctx context.Context, output string) error {
+	last, err := snapshot.Load(config.DefaultStateFile)
+	if err != nil {
+		return err
+	}
+	if last == nil {
+		return fmt.Errorf("no report has been sent from this host yet")
+	}
+
+	var buf bytes.Buffer
+	collectErr := sendReport(ctx, &buf, reportSections{sectionPackages: true, sectionHardware: true})
+	if collectErr != nil && ExitCode(collectErr) != ExitPartial {
+		return collectErr
+	}
+	var current models.ReportPayload
+	if err := json.Unmarshal(buf.Bytes(), &current); err != nil {
+		return fmt.Errorf("failed to parse collected report: %w", err)
+	}
+
+	diff := last.Compare(&current)
+	if output == outputJSON {
+		if err := printJSON(diff); err != nil {
+			return err
+		}
+		return collectErr
+	}
+
+	if diff.Empty() {
+		fmt.Fprintf(console, "No changes since the last report (%s)\n", diff.Since)
+		return collectErr
+	}
+	fmt.Fprintf(console, "Changes since the last report (%s):\n", diff.Since)
+	printChanges("Packages", diff.Packages)
+	printChanges("Hardware", diff.Hardware)
+	if current.PackagesIncomplete {
+		fmt.Fprintf(console, "\nUpdates could not be fully collected, so some may be missing above.\n")
+	}
+	return collectErr
+}
+
+// printChanges lists changes as + added, - removed and ~ changed
+func printChanges(title string, changes []snapshot.Change) {
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Fprintf(console, "\n%s:\n", title)
+	for _, c := range changes {
+		switch c.Change {
+		case snapshot.Added:
+			fmt.Fprintf(console, "  + %s %s: %s\n", c.Category, c.Name, c.New)
+		case snapshot.Removed:
+			fmt.Fprintf(console, "  - %s %s: %s\n", c.Category, c.Name, c.Old)
+		default:
+			fmt.Fprintf(console, "  ~ %s %s: %s → %s\n", c.Category, c.Name, c.Old, c.New)
+		}
+	}
+}
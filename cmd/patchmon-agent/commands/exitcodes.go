@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"errors"
+
+	"patchmon-agent/internal/client"
+)
+
+// Exit codes, so deployment and monitoring scripts can tell failures apart
+// without parsing the log
+const (
+	ExitOK         = 0
+	ExitError      = 1 // any other failure, including invalid arguments
+	ExitConfig     = 2 // missing or invalid configuration or credentials
+	ExitAuth       = 3 // the server rejected the API credentials
+	ExitNetwork    = 4 // the server could not be reached or returned a 5xx
+	ExitCollection = 5 // system or package information could not be collected
+	ExitPartial    = 6 // the command completed, but not entirely (e.g. incomplete report)
+	ExitTimeout    = 7 // the command ran longer than --timeout
+)
+
+// exitError attaches an exit code to an error
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// withExitCode returns err with an exit code, or nil if err is nil
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, err: err}
+}
+
+// ExitCode returns the process exit code for the error returned by Execute.
+// Errors from the PatchMon server are classified even when a command did not
+// set a code.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	switch {
+	case client.IsAuthError(err):
+		return ExitAuth
+	case client.IsNetworkError(err):
+		return ExitNetwork
+	}
+	return ExitError
+}
+
+// loadCredentials loads the API credentials, failing with ExitConfig
+func loadCredentials() error {
+	return withExitCode(ExitConfig, cfgManager.LoadCredentials())
+}
@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"patchmon-agent/internal/constants"
+	"patchmon-agent/internal/packages"
+	"patchmon-agent/internal/system"
+	"patchmon-agent/internal/version"
+	"patchmon-agent/pkg/models"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	hideOutput string
+	hideJson   bool
+)
+
+// hideUpdateCmd represents the hide-update command
+var hideUpdateCmd = &cobra.Command{
+	Use:   "hide-update <KB>...",
+	Short: "Hide Windows updates so they are no longer offered",
+	Long: `Hide one or more applicable Windows updates (e.g. KB5034441) so Windows Update
+no longer offers or installs them, then report the result to the PatchMon server.
+With --output json the result is printed instead of being sent.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outputJson, err := jsonRequested(hideOutput, hideJson)
+		if err != nil {
+			return err
+		}
+		if err := checkAdmin(); err != nil {
+			return err
+		}
+
+		return setUpdatesHidden(args, true, "", outputJson)
+	},
+}
+
+// unhideUpdateCmd represents the unhide-update command
+var unhideUpdateCmd = &cobra.Command{
+	Use:   "unhide-update <KB>...",
+	Short: "Unhide previously hidden Windows updates",
+	Long:  "Unhide one or more Windows updates so they are offered again, then report the result to the PatchMon server.",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outputJson, err := jsonRequested(hideOutput, hideJson)
+		if err != nil {
+			return err
+		}
+		if err := checkAdmin(); err != nil {
+			return err
+		}
+
+		return setUpdatesHidden(args, false, "", outputJson)
+	},
+}
+
+func init() {
+	addJSONOutputFlags(hideUpdateCmd, &hideOutput, &hideJson)
+	addJSONOutputFlags(unhideUpdateCmd, &hideOutput, &hideJson)
+	rootCmd.AddCommand(hideUpdateCmd)
+	rootCmd.AddCommand(unhideUpdateCmd)
+}
+
+// setUpdatesHidden hides or unhides updates and publishes the results.
+// actionID is set when the server requested the change.
+func setUpdatesHidden(kbs []string, hidden bool, actionID string, outputJson bool) error {
+	if !outputJson {
+		if err := loadCredentials(); err != nil {
+			return err
+		}
+	}
+
+	systemDetector := system.New(logger)
+	packageMgr := packages.New(cfgManager, logger)
+
+	action := constants.ActionHide
+	if !hidden {
+		action = constants.ActionUnhide
+	}
+
+	startedAt := time.Now()
+	results, hideErr := packageMgr.SetUpdatesHidden(kbs, hidden)
+	if results == nil {
+		results = []models.UpdateInstallResult{}
+	}
+
+	hostname, _ := systemDetector.GetHostname()
+	payload := &models.InstallResultPayload{
+		Hostname:     hostname,
+		MachineID:    systemDetector.GetMachineID(cfgManager.GetConfig().MachineIDSource),
+		AgentVersion: version.Version,
+		Action:       action,
+		ActionID:     actionID,
+		StartedAt:    startedAt.UTC().Format(time.RFC3339),
+		FinishedAt:   time.Now().UTC().Format(time.RFC3339),
+		Results:      results,
+	}
+	if hideErr != nil {
+		payload.Error = hideErr.Error()
+	}
+
+	if err := publishInstallResults(payload, outputJson); err != nil {
+		return err
+	}
+
+	if hideErr != nil {
+		return fmt.Errorf("failed to %s updates: %w", action, hideErr)
+	}
+	for _, result := range results {
+		if result.Status == packages.InstallStatusFailed {
+			return fmt.Errorf("failed to %s %s: %s", action, result.Name, result.Error)
+		}
+	}
+	return nil
+}
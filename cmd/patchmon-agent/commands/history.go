@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"patchmon-agent/internal/config"
+	"patchmon-agent/internal/history"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyOutput string
+	historyLimit  int
+)
+
+// historyCmd shows the recent report runs
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the recent report runs",
+	Long: `Show when the last reports were sent, how long they took, how many packages
+and updates they contained, what the server answered and why runs failed. The
+last report_history runs (default 50) are kept.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkOutputFormat(historyOutput); err != nil {
+			return err
+		}
+		return showHistory(historyOutput, historyLimit)
+	},
+}
+
+func init() {
+	addOutputFlag(historyCmd, &historyOutput)
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 0, "only show the last n runs (default: all kept)")
+	rootCmd.AddCommand(historyCmd)
+}
+
+func showHistory(output string, limit int) error {
+	entries, err := history.Load(config.DefaultStateFile)
+	if err != nil {
+		return err
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+
+	if output == outputJSON {
+		if entries == nil {
+			entries = []history.Entry{}
+		}
+		return printJSON(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintln(console, "No reports have been sent yet")
+		return nil
+	}
+	fmt.Fprintf(console, "%-20s %9s  %-8s %8s %8s %8s %9s  %s\n",
+		"STARTED", "DURATION", "RESULT", "PACKAGES", "UPDATES", "SECURITY", "PROCESSED", "DETAILS")
+	for _, e := range entries {
+		started := e.StartedAt
+		if t, err := time.Parse(time.RFC3339, e.StartedAt); err == nil {
+			started = t.Local().Format("2006-01-02 15:04:05")
+		}
+		details := e.Error
+		if details == "" && len(e.Sections) > 0 {
+			details = "sections: " + strings.Join(e.Sections, ",")
+		}
+		if details == "" && e.Actions > 0 {
+			details = fmt.Sprintf("%d server actions", e.Actions)
+		}
+		fmt.Fprintf(console, "%-20s %8.1fs  %-8s %8d %8d %8d %9d  %s\n",
+			started, e.DurationSeconds, e.Result, e.Packages, e.UpdatesAvailable, e.SecurityUpdates, e.PackagesProcessed, details)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"patchmon-agent/internal/client"
+	"patchmon-agent/internal/constants"
+	"patchmon-agent/internal/hooks"
+	"patchmon-agent/internal/packages"
+	"patchmon-agent/internal/system"
+	"patchmon-agent/internal/version"
+	"patchmon-agent/pkg/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	installKBs          []string
+	installSecurityOnly bool
+	installDownloadOnly bool
+	installOutput       string
+	installJson         bool
+)
+
+// installUpdatesCmd represents the install-updates command
+var installUpdatesCmd = &cobra.Command{
+	Use:   "install-updates",
+	Short: "Download and install available Windows updates",
+	Long: `Download and install available Windows updates via the Windows Update Agent,
+then report the per-update results to the PatchMon server.
+
+Without flags every available update is installed. Use --kb to install specific
+updates and --security-only to limit the run to security and critical updates.
+Use --download-only to pre-stage updates in the local Windows Update cache ahead
+of a maintenance window without installing them. With --output json the results
+are printed instead of being sent.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outputJson, err := jsonRequested(installOutput, installJson)
+		if err != nil {
+			return err
+		}
+		if err := checkAdmin(); err != nil {
+			return err
+		}
+
+		return installUpdates(cmd.Context(), packages.InstallOptions{
+			KBs:          installKBs,
+			SecurityOnly: installSecurityOnly,
+			DownloadOnly: installDownloadOnly,
+		}, outputJson)
+	},
+}
+
+func init() {
+	installUpdatesCmd.Flags().StringSliceVar(&installKBs, "kb", nil, "Comma-separated KB articles to install (e.g. KB5034441,KB5035853)")
+	installUpdatesCmd.Flags().BoolVar(&installSecurityOnly, "security-only", false, "Only install security and critical updates")
+	installUpdatesCmd.Flags().BoolVar(&installDownloadOnly, "download-only", false, "Download updates to the local cache without installing them")
+	addJSONOutputFlags(installUpdatesCmd, &installOutput, &installJson)
+	rootCmd.AddCommand(installUpdatesCmd)
+}
+
+// installUpdates installs or downloads updates and reports the results. An
+// installation in progress is not interrupted when ctx is cancelled, but none
+// is started, and the post-install hooks and the results report still run.
+func installUpdates(ctx context.Context, opts packages.InstallOptions, outputJson bool) error {
+	if !outputJson {
+		if err := loadCredentials(); err != nil {
+			return err
+		}
+	}
+
+	systemDetector := system.New(logger)
+	packageMgr := packages.New(cfgManager, logger)
+
+	logger.WithFields(logrus.Fields{
+		"kbs":           opts.KBs,
+		"security_only": opts.SecurityOnly,
+		"download_only": opts.DownloadOnly,
+	}).Info("Starting update installation")
+
+	action := constants.ActionInstall
+	if opts.DownloadOnly {
+		action = constants.ActionDownload
+	}
+
+	// Stream progress to the server while the updates are processed
+	var progress *progressReporter
+	if !outputJson {
+		progress = newProgressReporter(action, "")
+		opts.Progress = progress.Report
+	}
+
+	// Hooks run around installation only, not when pre-staging downloads
+	cfg := cfgManager.GetConfig()
+	runHooks := !opts.DownloadOnly
+	hookMgr := hooks.New(logger)
+
+	startedAt := time.Now()
+	var hookResults []models.HookResult
+	if runHooks && len(cfg.PreInstallHooks) > 0 {
+		hookResults = hookMgr.Run(hooks.StagePreInstall, cfg.PreInstallHooks, nil, true)
+	}
+
+	var results []models.UpdateInstallResult
+	var installErr error
+	if hooks.Failed(hookResults) {
+		installErr = errors.New("a pre-install hook failed, no updates were installed")
+	} else if err := ctx.Err(); err != nil {
+		installErr = fmt.Errorf("no updates were installed: %w", err)
+	} else {
+		results, installErr = packageMgr.InstallUpdates(opts)
+	}
+	progress.Close()
+	if installErr != nil {
+		logger.WithError(installErr).Error("Update installation failed")
+	}
+	if results == nil {
+		results = []models.UpdateInstallResult{}
+	}
+
+	hostname, _ := systemDetector.GetHostname()
+	payload := &models.InstallResultPayload{
+		Hostname:     hostname,
+		MachineID:    systemDetector.GetMachineID(cfgManager.GetConfig().MachineIDSource),
+		AgentVersion: version.Version,
+		Action:       action,
+		StartedAt:    startedAt.UTC().Format(time.RFC3339),
+		Results:      results,
+	}
+	if installErr != nil {
+		payload.Error = installErr.Error()
+	}
+
+	succeeded, failed := 0, 0
+	for _, result := range results {
+		switch result.Status {
+		case packages.InstallStatusInstalled, packages.InstallStatusDownloaded:
+			succeeded++
+		case packages.InstallStatusFailed, packages.InstallStatusDownloadFailed:
+			failed++
+		}
+		if result.RebootRequired {
+			payload.RebootRequired = true
+		}
+	}
+
+	// Post-install hooks run even if installation failed, so services stopped
+	// by a pre-install hook are brought back
+	if runHooks && len(cfg.PostInstallHooks) > 0 {
+		env := map[string]string{
+			"PATCHMON_UPDATES_INSTALLED": strconv.Itoa(succeeded),
+			"PATCHMON_UPDATES_FAILED":    strconv.Itoa(failed),
+			"PATCHMON_REBOOT_REQUIRED":   strconv.FormatBool(payload.RebootRequired),
+		}
+		hookResults = append(hookResults, hookMgr.Run(hooks.StagePostInstall, cfg.PostInstallHooks, env, false)...)
+	}
+	payload.Hooks = hookResults
+	payload.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+	logger.WithFields(logrus.Fields{
+		"succeeded":       succeeded,
+		"failed":          failed,
+		"reboot_required": payload.RebootRequired,
+	}).Info("Update installation completed")
+
+	if payload.RebootRequired {
+		notifyPendingReboot()
+	}
+
+	if err := publishInstallResults(payload, outputJson); err != nil {
+		return err
+	}
+
+	if installErr != nil {
+		return fmt.Errorf("failed to install updates: %w", installErr)
+	}
+	if failed > 0 {
+		err := fmt.Errorf("%d of %d updates failed", failed, succeeded+failed)
+		if succeeded > 0 {
+			return withExitCode(ExitPartial, err)
+		}
+		return err
+	}
+	if hooks.Failed(hookResults) {
+		return withExitCode(ExitPartial, fmt.Errorf("updates installed, but a post-install hook failed"))
+	}
+	return nil
+}
+
+// publishInstallResults prints payload as JSON or sends it to the server.
+// Results are sent even after --timeout has expired, so the server learns
+// what was installed.
+func publishInstallResults(payload *models.InstallResultPayload, outputJson bool) error {
+	if outputJson {
+		jsonData, err := json.MarshalIndent(payload, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		if _, err := fmt.Fprintf(os.Stdout, "%s\n", jsonData); err != nil {
+			return fmt.Errorf("failed to write JSON output: %w", err)
+		}
+		return nil
+	}
+
+	logger.Info("Sending install results to PatchMon server...")
+	httpClient := client.New(cfgManager, logger)
+	if _, err := httpClient.SendInstallResults(context.Background(), payload); err != nil {
+		return fmt.Errorf("failed to send install results: %w", err)
+	}
+	logger.Info("Install results sent successfully")
+	return nil
+}
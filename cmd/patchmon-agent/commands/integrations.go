@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"context"
+	"time"
+
+	"patchmon-agent/internal/client"
+
+	"github.com/sirupsen/logrus"
+)
+
+// syncIntegrations applies the integration toggles set on the server to the
+// local config. Failures are logged and the local settings are kept, so a
+// server without the integration status endpoint does not block reports.
+func syncIntegrations(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	status, err := client.New(cfgManager, logger).GetIntegrationStatus(ctx)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to get integration status from server, using local settings")
+		return
+	}
+
+	changed, err := cfgManager.ApplyIntegrationStatus(status.Integrations)
+	for _, name := range changed {
+		logger.WithFields(logrus.Fields{
+			"integration": name,
+			"enabled":     cfgManager.IsIntegrationEnabled(name),
+		}).Info("Integration toggled on server")
+	}
+	if err != nil {
+		logger.WithError(err).Warn("Failed to save integration status")
+	}
+}
@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// Output formats of the informational commands
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// console receives the informational output of commands; --quiet discards it.
+// Requested results such as JSON are written to stdout directly.
+var console io.Writer = os.Stdout
+
+// addOutputFlag adds --output to an informational command
+func addOutputFlag(cmd *cobra.Command, output *string) {
+	cmd.Flags().StringVarP(output, "output", "o", outputText, "output format (text or json)")
+}
+
+// addJSONOutputFlags adds --output to a command that prints its result as JSON
+// instead of sending it to the server, with --json as an alias of -o json
+func addJSONOutputFlags(cmd *cobra.Command, output *string, jsonAlias *bool) {
+	addOutputFlag(cmd, output)
+	cmd.Flags().BoolVar(jsonAlias, "json", false, "same as --output json")
+	cmd.MarkFlagsMutuallyExclusive("output", "json")
+}
+
+// jsonRequested validates the --output value and reports whether JSON output
+// was requested with --output json or --json
+func jsonRequested(output string, jsonAlias bool) (bool, error) {
+	if err := checkOutputFormat(output); err != nil {
+		return false, err
+	}
+	return jsonAlias || output == outputJSON, nil
+}
+
+// checkOutputFormat validates the --output value
+func checkOutputFormat(output string) error {
+	if output != outputText && output != outputJSON {
+		return fmt.Errorf("invalid output format %q (expected text or json)", output)
+	}
+	return nil
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v any) error {
+	jsonData, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if _, err := fmt.Fprintf(os.Stdout, "%s\n", jsonData); err != nil {
+		return fmt.Errorf("failed to write JSON output: %w", err)
+	}
+	return nil
+}
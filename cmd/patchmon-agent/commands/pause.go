@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"patchmon-agent/internal/updatepolicy"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultPauseDays is how long updates are paused when no end date is given
+const defaultPauseDays = 7
+
+var (
+	pauseType  string
+	pauseUntil string
+	pauseDays  int
+)
+
+// pauseUpdatesCmd represents the pause-updates command
+var pauseUpdatesCmd = &cobra.Command{
+	Use:   "pause-updates",
+	Short: "Pause Windows quality and/or feature updates until a date",
+	Long: `Pause Windows Update until --until (YYYY-MM-DD) or for --days days, for at most
+35 days. Use --type to pause only quality or feature updates.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkAdmin(); err != nil {
+			return err
+		}
+
+		until := time.Now().AddDate(0, 0, pauseDays)
+		if pauseUntil != "" {
+			date, err := time.ParseInLocation("2006-01-02", pauseUntil, time.Local)
+			if err != nil {
+				return fmt.Errorf("invalid --until date %q (expected YYYY-MM-DD): %w", pauseUntil, err)
+			}
+			// Pause through the end of the given day
+			until = date.AddDate(0, 0, 1).Add(-time.Second)
+		}
+
+		if err := updatepolicy.New(logger).Pause(pauseType, until); err != nil {
+			return err
+		}
+		fmt.Fprintf(console, "Windows Update (%s) paused until %s\n", pauseType, until.Format("2006-01-02 15:04"))
+		return nil
+	},
+}
+
+// resumeUpdatesCmd represents the resume-updates command
+var resumeUpdatesCmd = &cobra.Command{
+	Use:   "resume-updates",
+	Short: "Resume paused Windows updates",
+	Long:  "Clear a Windows Update pause set from the Settings app, Group Policy or pause-updates.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkAdmin(); err != nil {
+			return err
+		}
+
+		if err := updatepolicy.New(logger).Resume(pauseType); err != nil {
+			return err
+		}
+		fmt.Fprintf(console, "Windows Update (%s) resumed\n", pauseType)
+		return nil
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{pauseUpdatesCmd, resumeUpdatesCmd} {
+		cmd.Flags().StringVar(&pauseType, "type", updatepolicy.TypeAll, "Update type: quality, feature or all")
+	}
+	pauseUpdatesCmd.Flags().StringVar(&pauseUntil, "until", "", "Pause until the end of this date (YYYY-MM-DD)")
+	pauseUpdatesCmd.Flags().IntVar(&pauseDays, "days", defaultPauseDays, "Pause for this many days (ignored with --until)")
+	rootCmd.AddCommand(pauseUpdatesCmd)
+	rootCmd.AddCommand(resumeUpdatesCmd)
+}
@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"context"
+	"time"
+
+	"patchmon-agent/internal/client"
+	"patchmon-agent/internal/system"
+	"patchmon-agent/pkg/models"
+)
+
+const (
+	// progressSendTimeout bounds a single progress request
+	progressSendTimeout = 10 * time.Second
+	// progressQueueSize is how many events may wait to be sent; further
+	// events are dropped rather than slowing down the installation
+	progressQueueSize = 32
+)
+
+// progressReporter streams install progress events to the server in the
+// background. A nil progressReporter discards all events.
+type progressReporter struct {
+	events    chan models.InstallProgressEvent
+	done      chan struct{}
+	hostname  string
+	machineID string
+	action    string
+	actionID  string
+}
+
+// newProgressReporter starts a reporter for action. It returns nil if the
+// credentials needed to reach the server cannot be loaded.
+func newProgressReporter(action, actionID string) *progressReporter {
+	if cfgManager.GetCredentials() == nil {
+		if err := loadCredentials(); err != nil {
+			logger.WithError(err).Debug("Not streaming progress, credentials unavailable")
+			return nil
+		}
+	}
+
+	systemDetector := system.New(logger)
+	hostname, _ := systemDetector.GetHostname()
+	r := &progressReporter{
+		events:    make(chan models.InstallProgressEvent, progressQueueSize),
+		done:      make(chan struct{}),
+		hostname:  hostname,
+		machineID: systemDetector.GetMachineID(cfgManager.GetConfig().MachineIDSource),
+		action:    action,
+		actionID:  actionID,
+	}
+	go r.run(client.New(cfgManager, logger))
+	return r
+}
+
+// run sends queued events until the reporter is closed. After the first
+// failure (for example a server without a progress endpoint) the remaining
+// events are discarded.
+func (r *progressReporter) run(httpClient *client.Client) {
+	defer close(r.done)
+
+	failed := false
+	for event := range r.events {
+		if failed {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), progressSendTimeout)
+		err := httpClient.SendInstallProgress(ctx, &event)
+		cancel()
+		if err != nil {
+			logger.WithError(err).Debug("Failed to send install progress, not sending further progress")
+			failed = true
+		}
+	}
+}
+
+// Report queues event for sending, filling in the host and action fields.
+// It never blocks; events are dropped when the queue is full.
+func (r *progressReporter) Report(event models.InstallProgressEvent) {
+	if r == nil {
+		return
+	}
+	event.Hostname = r.hostname
+	event.MachineID = r.machineID
+	event.Action = r.action
+	event.ActionID = r.actionID
+	event.Timestamp = time.Now().UTC().Format(time.RFC3339)
+
+	select {
+	case r.events <- event:
+	default:
+		logger.Debug("Install progress queue full, dropping event")
+	}
+}
+
+// Phase reports a step of an operation without per-update detail
+func (r *progressReporter) Phase(phase string, percent int) {
+	r.Report(models.InstallProgressEvent{Phase: phase, Percent: percent})
+}
+
+// Close waits for queued events to be sent and stops the reporter
+func (r *progressReporter) Close() {
+	if r == nil {
+		return
+	}
+	close(r.events)
+	<-r.done
+}
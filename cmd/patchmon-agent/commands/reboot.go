@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"patchmon-agent/internal/constants"
+	"patchmon-agent/internal/system"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// defaultRebootDelay gives logged-on users time to save their work
+const defaultRebootDelay = 15 * time.Minute
+
+var (
+	rebootDelay   time.Duration
+	rebootMessage string
+	rebootCancel  bool
+	rebootForce   bool
+)
+
+// rebootCmd represents the reboot command
+var rebootCmd = &cobra.Command{
+	Use:   "reboot",
+	Short: "Schedule (or cancel) a restart to finish installing updates",
+	Long: `Schedule a restart of this computer after --delay, showing --message to logged-on
+users in the Windows shutdown notification. Use --cancel to abort a scheduled restart.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkAdmin(); err != nil {
+			return err
+		}
+
+		if rebootCancel {
+			return cancelReboot("")
+		}
+		return scheduleReboot(rebootDelay, rebootMessage, rebootForce, "")
+	},
+}
+
+func init() {
+	rebootCmd.Flags().DurationVar(&rebootDelay, "delay", defaultRebootDelay, "Time before the restart (e.g. 15m, 1h)")
+	rebootCmd.Flags().StringVar(&rebootMessage, "message", "", "Message shown to logged-on users")
+	rebootCmd.Flags().BoolVar(&rebootCancel, "cancel", false, "Cancel a scheduled restart")
+	rebootCmd.Flags().BoolVar(&rebootForce, "force", false, "Close applications without waiting for users to save")
+	rootCmd.AddCommand(rebootCmd)
+}
+
+// defaultRebootMessage is shown when no message is given
+func defaultRebootMessage(delay time.Duration) string {
+	return fmt.Sprintf("This computer will restart in %s to finish installing updates. Please save your work.", delay.Round(time.Second))
+}
+
+// scheduleReboot schedules a restart and, when the server requested it
+// (actionID set), reports the outcome back
+func scheduleReboot(delay time.Duration, message string, force bool, actionID string) error {
+	if message == "" {
+		message = defaultRebootMessage(delay)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"delay":   delay.String(),
+		"message": message,
+	}).Info("Scheduling restart")
+
+	err := system.ScheduleRestart(delay, message, force)
+	if err == nil {
+		logger.WithField("at", time.Now().Add(delay).Format(time.RFC3339)).Info("Restart scheduled")
+	}
+	reportActionResult(constants.ActionReboot, actionID, err)
+	return err
+}
+
+// cancelReboot aborts a scheduled restart
+func cancelReboot(actionID string) error {
+	logger.Info("Cancelling scheduled restart")
+	err := system.CancelRestart()
+	if err == nil {
+		logger.Info("Scheduled restart cancelled")
+	}
+	reportActionResult(constants.ActionCancelReboot, actionID, err)
+	return err
+}
+
+// rebootNotificationTitle is the title of the pending reboot notification
+const rebootNotificationTitle = "Restart required"
+
+// notifyPendingReboot tells logged-on users that a restart is needed to
+// finish installing updates, if reboot notifications are enabled
+func notifyPendingReboot() {
+	cfg := cfgManager.GetConfig()
+	if !cfg.RebootNotification {
+		return
+	}
+
+	message := "Updates have been installed on this computer. Please restart it when convenient to finish installing them."
+	notified, err := system.NotifyRebootRequired(rebootNotificationTitle, message, time.Duration(cfg.RebootSnoozeMinutes)*time.Minute)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to notify logged-on users of the pending restart")
+	}
+	if notified > 0 {
+		logger.WithField("sessions", notified).Info("Notified logged-on users of the pending restart")
+	}
+}
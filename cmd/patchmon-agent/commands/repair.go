@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"fmt"
+
+	"patchmon-agent/internal/packages"
+
+	"github.com/spf13/cobra"
+)
+
+// repairWUCmd represents the repair-wu command
+var repairWUCmd = &cobra.Command{
+	Use:   "repair-wu",
+	Short: "Reset the Windows Update components",
+	Long: `Perform the standard Windows Update remediation: stop the wuauserv, BITS and
+cryptsvc services, rename the SoftwareDistribution and catroot2 folders so they are
+recreated, start the services again and re-register the Windows Update and BITS
+libraries. The services are started again also when the reset fails part way.
+
+Use this when update searches keep failing with datastore errors. The renamed
+folders are kept as backups and can be deleted once updates work again.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkAdmin(); err != nil {
+			return err
+		}
+
+		packageMgr := packages.New(cfgManager, logger)
+		result, err := packageMgr.ResetComponents()
+		if result != nil {
+			for folder, backup := range result.Renamed {
+				fmt.Fprintf(console, "Renamed %s to %s\n", folder, backup)
+			}
+			for _, dll := range result.Registered {
+				if dll.Error != "" {
+					fmt.Fprintf(console, "Failed to register %s: %s\n", dll.Name, dll.Error)
+				} else {
+					fmt.Fprintf(console, "Registered %s\n", dll.Name)
+				}
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to reset Windows Update components: %w", err)
+		}
+
+		fmt.Fprintln(console, "Windows Update components reset; run 'patchmon-agent scan' to start a new detection")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(repairWUCmd)
+}
@@ -4,15 +4,27 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"time"
 
 	"patchmon-agent/internal/client"
+	"patchmon-agent/internal/config"
+	"patchmon-agent/internal/constants"
+	"patchmon-agent/internal/containers"
 	"patchmon-agent/internal/hardware"
+	"patchmon-agent/internal/history"
+	"patchmon-agent/internal/hyperv"
+	"patchmon-agent/internal/inventory"
+	"patchmon-agent/internal/metrics"
 	"patchmon-agent/internal/network"
 	"patchmon-agent/internal/packages"
 	"patchmon-agent/internal/repositories"
+	"patchmon-agent/internal/security"
+	"patchmon-agent/internal/snapshot"
 	"patchmon-agent/internal/system"
+	"patchmon-agent/internal/updatepolicy"
 	"patchmon-agent/internal/version"
 	"patchmon-agent/pkg/models"
 
@@ -20,7 +32,12 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var reportJson bool
+var (
+	reportOutput       string
+	reportJson         bool
+	reportExport       string
+	reportSectionsFlag []string
+)
 
 // reportCmd represents the report command
 var reportCmd = &cobra.Command{
@@ -28,44 +45,153 @@ var reportCmd = &cobra.Command{
 	Short: "Report system and package information to server",
 	Long:  "Collect and report system, package, and repository information to the PatchMon server.",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		outputJson, err := jsonRequested(reportOutput, reportJson)
+		if err != nil {
+			return err
+		}
+		if outputJson && reportExport != "" {
+			return fmt.Errorf("--output json and --export cannot be used together")
+		}
 		if err := checkAdmin(); err != nil {
 			return err
 		}
 
-		return sendReport(reportJson)
+		names := reportSectionsFlag
+		if len(names) == 0 {
+			names = cfgManager.GetConfig().ReportSections
+		}
+		sections, err := parseReportSections(names)
+		if err != nil {
+			return withExitCode(ExitConfig, err)
+		}
+		if reportExport != "" {
+			return exportReport(cmd.Context(), reportExport, sections)
+		}
+		var jsonOut io.Writer
+		if outputJson {
+			jsonOut = os.Stdout
+		}
+		return sendReport(cmd.Context(), jsonOut, sections)
 	},
 }
 
 func init() {
-	reportCmd.Flags().BoolVar(&reportJson, "json", false, "Output the JSON report payload to stdout instead of sending to server")
+	addJSONOutputFlags(reportCmd, &reportOutput, &reportJson)
+	reportCmd.Flags().StringSliceVar(&reportSectionsFlag, "sections", nil, "only collect these report sections: packages, hardware, network, security, inventory (default: report_sections or all)")
+	reportCmd.Flags().StringVar(&reportExport, "export", "", "Write a signed report to this file for submission from another host instead of sending it")
 }
 
-func sendReport(outputJson bool) error {
+// packageResult carries the outcome of the background package collection
+type packageResult struct {
+	packages []models.Package
+	hidden   []models.Package
+	err      error
+}
+
+// sendReport collects and sends a report with the given sections; nil
+// sections send a full report. With jsonOut set, the payload is written there
+// as JSON instead of being sent, and no credentials are needed. Sent reports
+// are recorded in the report history. Requests to the server stop when ctx is
+// cancelled.
+func sendReport(ctx context.Context, jsonOut io.Writer, sections reportSections) error {
+	startTime := time.Now()
+	run := &history.Entry{StartedAt: startTime.UTC().Format(time.RFC3339)}
+	err := collectAndSendReport(ctx, jsonOut, sections, run)
+	if jsonOut == nil {
+		recordReport(run, startTime, err)
+	}
+	return err
+}
+
+// recordReport adds a report run to the history
+func recordReport(run *history.Entry, startTime time.Time, err error) {
+	run.DurationSeconds = time.Since(startTime).Round(time.Millisecond).Seconds()
+	switch {
+	case err == nil:
+		run.Result = history.ResultSent
+	case ExitCode(err) == ExitPartial:
+		run.Result = history.ResultPartial
+		run.Error = err.Error()
+	default:
+		run.Result = history.ResultFailed
+		run.Error = err.Error()
+	}
+	if err := history.Append(config.DefaultStateFile, *run, cfgManager.GetConfig().ReportHistory); err != nil {
+		logger.WithError(err).Warn("Failed to record report history")
+	}
+}
+
+// collectAndSendReport does the work of sendReport, filling in run
+func collectAndSendReport(ctx context.Context, jsonOut io.Writer, sections reportSections, run *history.Entry) error {
 	// Start tracking execution time
 	startTime := time.Now()
 	logger.Debug("Starting report process")
 
 	// Load API credentials only if we're sending the report (not just outputting JSON)
-	if !outputJson {
+	if jsonOut == nil {
 		logger.Debug("Loading API credentials")
-		if err := cfgManager.LoadCredentials(); err != nil {
+		if err := loadCredentials(); err != nil {
 			logger.WithError(err).Debug("Failed to load credentials")
 			return err
 		}
+
+		// Take the integration toggles from the server before collecting
+		if cfgManager.GetConfig().IntegrationsSync {
+			syncIntegrations(ctx)
+		}
+
+		// A server that does not know partial reports would replace its
+		// stored data with that of the sections left out
+		if sections != nil && !serverAcceptsPartialReports() {
+			logger.Info("The server does not accept partial reports, sending a full report")
+			sections = nil
+		}
 	}
 
 	// Initialise managers
 	systemDetector := system.New(logger)
-	packageMgr := packages.New(logger)
+	packageMgr := packages.New(cfgManager, logger)
 	repoMgr := repositories.New(logger)
 	hardwareMgr := hardware.New(logger)
 	networkMgr := network.New(logger)
+	if err := networkMgr.SetInterfaceExclusions(cfgManager.GetConfig().ExcludeInterfaces); err != nil {
+		logger.WithError(err).Warn("Ignoring invalid exclude_interfaces patterns")
+	}
+	if err := networkMgr.SetPrimaryIPPolicy(cfgManager.GetConfig().PrimaryIPSubnet, cfgManager.GetConfig().PrimaryIPInterface); err != nil {
+		logger.WithError(err).Warn("Ignoring invalid primary IP policy")
+	}
+	networkMgr.SetInterfaceCounters(cfgManager.GetConfig().InterfaceCounters)
+	securityMgr := security.New(logger)
+	policyMgr := updatepolicy.New(logger)
+	hypervMgr := hyperv.New(logger)
+	containerMgr := containers.New(logger)
+	inventoryMgr := inventory.New(logger)
+	metricsMgr := loadSampler
+	if metricsMgr == nil {
+		metricsMgr = metrics.New(logger)
+	}
+
+	// Windows Update searches are by far the slowest part of the report, so
+	// start them first and collect everything else while they run
+	packagesDone := make(chan packageResult, 1)
+	if sections.has(sectionPackages) {
+		go func() {
+			logger.Info("Collecting package information...")
+			searchStart := time.Now()
+			pkgs, err := packageMgr.GetPackages()
+			agentStats.SetWUASearchDuration(time.Since(searchStart))
+			hidden := packageMgr.GetHiddenUpdates()
+			packagesDone <- packageResult{packages: pkgs, hidden: hidden, err: err}
+		}()
+	} else {
+		packagesDone <- packageResult{}
+	}
 
 	// Detect OS
 	logger.Info("Detecting operating system...")
 	osType, osVersion, err := systemDetector.DetectOS()
 	if err != nil {
-		return fmt.Errorf("failed to detect OS: %w", err)
+		return withExitCode(ExitCollection, fmt.Errorf("failed to detect OS: %w", err))
 	}
 	logger.WithFields(logrus.Fields{
 		"osType":    osType,
@@ -76,23 +202,199 @@ func sendReport(outputJson bool) error {
 	logger.Info("Collecting system information...")
 	hostname, err := systemDetector.GetHostname()
 	if err != nil {
-		return fmt.Errorf("failed to get hostname: %w", err)
+		return withExitCode(ExitCollection, fmt.Errorf("failed to get hostname: %w", err))
 	}
 
 	architecture := systemDetector.GetArchitecture()
+	machineID := systemDetector.GetMachineID(cfgManager.GetConfig().MachineIDSource)
+	// Lets the server merge the old host record if machine_id_source changed
+	previousMachineID := systemDetector.PreviousMachineID(machineID)
+	if previousMachineID != "" {
+		logger.WithFields(logrus.Fields{
+			"machine_id":  machineID,
+			"previous_id": previousMachineID,
+		}).Info("Machine ID changed since the last report")
+	}
 	systemInfo := systemDetector.GetSystemInfo()
 	ipAddress := systemDetector.GetIPAddress()
 
-	// Get hardware information
-	logger.Info("Collecting hardware information...")
-	hardwareInfo := hardwareMgr.GetHardwareInfo()
+	osEdition := systemDetector.GetOSEdition()
+
+	// Check whether the Windows release is still in support
+	var osEolDate string
+	var osSupported *bool
+	var osSupportEndingSoon bool
+	if lifecycle := systemDetector.GetOSLifecycle(); lifecycle != nil {
+		osEolDate = lifecycle.EOLDate
+		osSupported = &lifecycle.Supported
+		osSupportEndingSoon = lifecycle.EndingSoon
+		logger.WithFields(logrus.Fields{
+			"release":     lifecycle.Release,
+			"eol_date":    lifecycle.EOLDate,
+			"supported":   lifecycle.Supported,
+			"ending_soon": lifecycle.EndingSoon,
+		}).Info("OS lifecycle status")
+	}
+
+	// Get domain membership
+	domainInfo := systemDetector.GetDomainInfo()
+	cloudJoinInfo := systemDetector.GetCloudJoinInfo()
+
+	// Get enabled Windows features, the extended inventory, the event log
+	// summary and resource metrics
+	var windowsFeatures []models.WindowsFeature
+	var extendedInventory *models.ExtendedInventory
+	var eventLog *models.EventLogSummary
+	var resourceMetrics *models.ResourceMetrics
+	if sections.has(sectionInventory) {
+		// Get enabled Windows features and server roles
+		logger.Info("Collecting Windows features...")
+		windowsFeatures = systemDetector.GetWindowsFeatures()
+		logger.WithField("count", len(windowsFeatures)).Info("Found enabled Windows features")
+
+		// Get the extended software inventory if enabled
+		if cfg := cfgManager.GetConfig(); cfg.ExtendedInventory {
+			logger.Info("Collecting extended inventory...")
+			extendedInventory = inventoryMgr.GetExtendedInventory(cfg.InventoryPerUser)
+		}
+
+		// Summarize recent System log errors if enabled
+		if cfgManager.GetConfig().EventLogSummary {
+			logger.Info("Summarizing System event log...")
+			if eventLog, err = systemDetector.GetEventLogSummary(); err != nil {
+				logger.WithError(err).Warn("Failed to summarize System event log")
+			}
+		}
+
+		// Sample resource utilization if enabled; this also replaces the load
+		// average placeholder with the sampled Windows load
+		if cfg := cfgManager.GetConfig(); cfg.ResourceMetrics {
+			logger.Info("Sampling resource utilization...")
+			if resourceMetrics = metricsMgr.Collect(cfg.MetricsSampleSeconds); resourceMetrics != nil {
+				systemInfo.LoadAverage = metricsMgr.LoadAverage()
+			}
+		}
+	}
+
+	var hardwareInfo models.HardwareInfo
+	var drivers []models.Driver
+	var storageArrays *models.StorageArrays
+	var hypervGuests []models.HyperVGuest
+	var containerInfo *models.ContainerInfo
+	if sections.has(sectionHardware) {
+		// Get hardware information
+		logger.Info("Collecting hardware information...")
+		hardwareInfo = hardwareMgr.GetHardwareInfo()
+
+		// Get installed device drivers
+		logger.Info("Collecting driver inventory...")
+		drivers = hardwareMgr.GetDrivers()
+
+		// Get Storage Spaces and hardware RAID arrays
+		storageArrays = hardwareMgr.GetStorageArrays()
+
+		// Get the virtual machines if this is a Hyper-V host
+		hypervGuests = hypervMgr.GetGuests()
+		if hypervGuests != nil {
+			logger.WithField("count", len(hypervGuests)).Info("Found Hyper-V virtual machines")
+		}
+
+		// Get the container engine and running containers
+		if cfgManager.IsIntegrationEnabled(constants.IntegrationDocker) {
+			containerInfo = containerMgr.GetContainerInfo()
+		}
+	}
 
-	// Get network information
-	logger.Info("Collecting network information...")
-	networkInfo := networkMgr.GetNetworkInfo()
-	// Ensure DNSServers is never nil (should be empty slice, not nil)
-	if networkInfo.DNSServers == nil {
-		networkInfo.DNSServers = []string{}
+	var networkInfo models.NetworkInfo
+	var publicIP string
+	if sections.has(sectionNetwork) {
+		// Get network information
+		logger.Info("Collecting network information...")
+		networkInfo = networkMgr.GetNetworkInfo()
+		// Ensure DNSServers is never nil (should be empty slice, not nil)
+		if networkInfo.DNSServers == nil {
+			networkInfo.DNSServers = []string{}
+		}
+		// Report the primary IP chosen by policy rather than the first IPv4 found
+		if primaryIP := networkMgr.PrimaryIP(networkInfo.NetworkInterfaces); primaryIP != "" {
+			ipAddress = primaryIP
+		}
+
+		// Resolve the public egress IP through the PatchMon server if enabled.
+		// Collecting without sending loads no credentials and contacts no server.
+		if cfgManager.GetConfig().PublicIP && jsonOut == nil {
+			ipCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			if publicIP, err = client.New(cfgManager, logger).GetPublicIP(ipCtx); err != nil {
+				logger.WithError(err).Warn("Failed to resolve public IP")
+			}
+			cancel()
+		}
+	}
+
+	var defenderInfo *models.DefenderInfo
+	var rdpInfo *models.RDPInfo
+	var localUsers []models.LocalUser
+	var localAdministrators []models.LocalGroupMember
+	var expiringCerts []models.Certificate
+	var securityPosture *models.SecurityPosture
+	if sections.has(sectionSecurity) {
+		// Get Microsoft Defender signature information
+		logger.Info("Collecting Microsoft Defender information...")
+		defenderInfo = securityMgr.GetDefenderInfo()
+		if defenderInfo != nil {
+			logger.WithFields(logrus.Fields{
+				"engine":     defenderInfo.EngineVersion,
+				"signatures": defenderInfo.AntivirusSignatureVersion,
+				"age_days":   defenderInfo.SignatureAgeDays,
+			}).Info("Microsoft Defender status collected")
+
+			if cfgManager.IsIntegrationEnabled(constants.IntegrationDefender) {
+				defenderInfo.Health = securityMgr.GetDefenderHealth()
+			}
+		}
+
+		// Get Remote Desktop exposure
+		rdpInfo = securityMgr.GetRDPInfo()
+
+		// Get local accounts and administrators
+		logger.Info("Collecting local accounts...")
+		localUsers = securityMgr.GetLocalUsers()
+		localAdministrators = securityMgr.GetLocalAdministrators()
+
+		// Get machine certificates that are expired or expire soon
+		if expiringCerts, err = securityMgr.GetExpiringCertificates(cfgManager.GetConfig().CertExpiryDays); err != nil {
+			logger.WithError(err).Warn("Failed to read machine certificates")
+		} else if len(expiringCerts) > 0 {
+			logger.WithField("count", len(expiringCerts)).Warn("Machine certificates expired or expiring soon")
+		}
+
+		// Get OS hardening settings if enabled
+		if cfgManager.GetConfig().SecurityPosture {
+			logger.Info("Collecting security posture...")
+			securityPosture = securityMgr.GetSecurityPosture()
+		}
+	}
+
+	var updatePause *models.UpdatePauseState
+	var updateDeferral *models.UpdateDeferral
+	var updateActivity *models.UpdateActivity
+	var wuaVersion string
+	if sections.has(sectionPackages) {
+		// Check whether Windows Update has been paused
+		updatePause = policyMgr.GetPauseState()
+		if updatePause != nil {
+			logger.WithFields(logrus.Fields{
+				"quality_until": updatePause.QualityPausedUntil,
+				"feature_until": updatePause.FeaturePausedUntil,
+			}).Info("Windows Update is paused")
+		}
+
+		// Get the update deferral (ring) policy
+		updateDeferral = policyMgr.GetDeferral()
+
+		// Get when Windows Update last checked for and installed updates
+		updateActivity = policyMgr.GetUpdateActivity()
+		wuaVersion = packageMgr.GetAgentVersion()
 	}
 
 	// Check if reboot is required and get installed kernel
@@ -106,62 +408,104 @@ func sendReport(outputJson bool) error {
 		"running_kernel":   systemInfo.KernelVersion,
 	}).Info("Reboot status check completed")
 
-	// Get package information
-	logger.Info("Collecting package information...")
-	packageList, err := packageMgr.GetPackages()
+	// Wait for package information
+	pkgResult := <-packagesDone
+	packageList, err := pkgResult.packages, pkgResult.err
 	if err != nil {
-		return fmt.Errorf("failed to get packages: %w", err)
+		return withExitCode(ExitCollection, fmt.Errorf("failed to get packages: %w", err))
 	}
 	// Ensure packageList is never nil (should be empty slice, not nil)
 	if packageList == nil {
 		packageList = []models.Package{}
 	}
+	hiddenUpdates := pkgResult.hidden
+	if len(hiddenUpdates) > 0 {
+		logger.WithField("count", len(hiddenUpdates)).Info("Found hidden updates")
+	}
+	collectionErrors := packageMgr.CollectionErrors()
+	agentStats.SetCollectorErrors(len(collectionErrors))
+	if len(collectionErrors) > 0 {
+		logger.WithField("errors", collectionErrors).Warn("Package information is incomplete")
+	}
 
-	// Count packages for debug logging
-	needsUpdateCount := 0
-	securityUpdateCount := 0
-	for _, pkg := range packageList {
-		if pkg.NeedsUpdate {
-			needsUpdateCount++
-		}
-		if pkg.IsSecurityUpdate {
-			securityUpdateCount++
+	if sections.has(sectionPackages) {
+		// Count packages for debug logging
+		needsUpdateCount := 0
+		securityUpdateCount := 0
+		for _, pkg := range packageList {
+			if pkg.NeedsUpdate {
+				needsUpdateCount++
+			}
+			if pkg.IsSecurityUpdate {
+				securityUpdateCount++
+			}
 		}
-	}
-	logger.WithField("count", len(packageList)).Info("Found packages")
-	for _, pkg := range packageList {
-		updateMsg := ""
-		if pkg.NeedsUpdate {
-			updateMsg = "update available"
-		} else {
-			updateMsg = "latest"
+		logger.WithField("count", len(packageList)).Info("Found packages")
+		for _, pkg := range packageList {
+			updateMsg := ""
+			if pkg.NeedsUpdate {
+				updateMsg = "update available"
+			} else {
+				updateMsg = "latest"
+			}
+			logger.WithFields(logrus.Fields{
+				"name":    pkg.Name,
+				"version": pkg.CurrentVersion,
+				"status":  updateMsg,
+			}).Debug("Package info")
 		}
 		logger.WithFields(logrus.Fields{
-			"name":    pkg.Name,
-			"version": pkg.CurrentVersion,
-			"status":  updateMsg,
-		}).Debug("Package info")
+			"total_updates":    needsUpdateCount,
+			"security_updates": securityUpdateCount,
+		}).Debug("Package summary")
 	}
-	logger.WithFields(logrus.Fields{
-		"total_updates":    needsUpdateCount,
-		"security_updates": securityUpdateCount,
-	}).Debug("Package summary")
 
-	// Get repository information
-	logger.Info("Collecting repository information...")
-	repoList, err := repoMgr.GetRepositories()
-	if err != nil {
-		logger.WithError(err).Warn("Failed to get repositories")
-		repoList = []models.Repository{}
-	}
-	logger.WithField("count", len(repoList)).Info("Found repositories")
-	for _, repo := range repoList {
+	// Report an offered Windows feature release separately from quality updates
+	featureUpdate := packages.FindFeatureUpdate(packageList)
+	if featureUpdate != nil {
 		logger.WithFields(logrus.Fields{
-			"name":    repo.Name,
-			"type":    repo.RepoType,
-			"url":     repo.URL,
-			"enabled": repo.IsEnabled,
-		}).Debug("Repository info")
+			"title":          featureUpdate.Title,
+			"target_version": featureUpdate.TargetVersion,
+		}).Info("Windows feature update available")
+	}
+
+	var appxPackages []models.AppxPackage
+	repoList := []models.Repository{}
+	var configMgrInfo *models.ConfigMgrInfo
+	if sections.has(sectionPackages) {
+		// Get Microsoft Store / Appx package information
+		logger.Info("Collecting Appx package information...")
+		appxPackages = packageMgr.GetAppxPackages()
+		logger.WithField("count", len(appxPackages)).Info("Found Appx packages")
+
+		// Get repository information
+		logger.Info("Collecting repository information...")
+		repos, err := repoMgr.GetRepositories()
+		if err != nil {
+			logger.WithError(err).Warn("Failed to get repositories")
+		} else {
+			repoList = repos
+		}
+		logger.WithField("count", len(repoList)).Info("Found repositories")
+		for _, repo := range repoList {
+			logger.WithFields(logrus.Fields{
+				"name":    repo.Name,
+				"type":    repo.RepoType,
+				"url":     repo.URL,
+				"enabled": repo.IsEnabled,
+			}).Debug("Repository info")
+		}
+
+		// Detect the Configuration Manager client, which may own patching on this host
+		configMgrInfo = repoMgr.GetConfigMgrInfo()
+		if configMgrInfo != nil {
+			logger.WithFields(logrus.Fields{
+				"version":            configMgrInfo.ClientVersion,
+				"site":               configMgrInfo.SiteCode,
+				"co_managed":         configMgrInfo.CoManaged,
+				"updates_managed_by": configMgrInfo.UpdatesManagedBy,
+			}).Info("Configuration Manager client detected")
+		}
 	}
 
 	// Calculate execution time (in seconds, with millisecond precision)
@@ -178,49 +522,119 @@ func sendReport(outputJson bool) error {
 		IP:                     ipAddress,
 		Architecture:           architecture,
 		AgentVersion:           version.Version,
-		MachineID:              systemDetector.GetMachineID(),
+		MachineID:              machineID,
+		PreviousMachineID:      previousMachineID,
 		KernelVersion:          systemInfo.KernelVersion,
 		InstalledKernelVersion: installedKernel,
 		SELinuxStatus:          systemInfo.SELinuxStatus,
 		SystemUptime:           systemInfo.SystemUptime,
+		SystemUptimeSeconds:    systemInfo.SystemUptimeSeconds,
+		LastBootTime:           systemInfo.LastBootTime,
 		LoadAverage:            systemInfo.LoadAverage,
 		CPUModel:               hardwareInfo.CPUModel,
 		CPUCores:               hardwareInfo.CPUCores,
 		RAMInstalled:           hardwareInfo.RAMInstalled,
 		SwapSize:               hardwareInfo.SwapSize,
+		PageFiles:              hardwareInfo.PageFiles,
 		DiskDetails:            hardwareInfo.DiskDetails,
+		MemoryModules:          hardwareInfo.MemoryModules,
+		SystemIdentity:         hardwareInfo.Identity,
+		IsVirtual:              hardwareInfo.IsVirtual,
+		Hypervisor:             hardwareInfo.Hypervisor,
+		HyperVGuests:           hypervGuests,
+		Drivers:                drivers,
+		StorageArrays:          storageArrays,
+		Containers:             containerInfo,
+		ExtendedInventory:      extendedInventory,
+		EventLog:               eventLog,
+		Metrics:                resourceMetrics,
 		GatewayIP:              networkInfo.GatewayIP,
 		DNSServers:             networkInfo.DNSServers,
+		PrimaryDNSSuffix:       networkInfo.PrimaryDNSSuffix,
+		DNSSearchList:          networkInfo.DNSSearchList,
 		NetworkInterfaces:      networkInfo.NetworkInterfaces,
+		PublicIP:               publicIP,
+		VPN:                    networkInfo.VPN,
 		ExecutionTime:          executionTime,
 		NeedsReboot:            needsReboot,
 		RebootReason:           rebootReason,
+		AppxPackages:           appxPackages,
+		WindowsFeatures:        windowsFeatures,
+		PowerShellVersion:      systemInfo.PowerShellVersion,
+		PowerShellCoreVersions: systemInfo.PowerShellCoreVersions,
+		BootMode:               systemInfo.BootMode,
+		SecureBootEnabled:      systemInfo.SecureBootEnabled,
+		OSInstallDate:          systemInfo.OSInstallDate,
+		OSOriginalInstallDate:  systemInfo.OSOriginalInstallDate,
+		OSOriginalBuild:        systemInfo.OSOriginalBuild,
+		Domain:                 domainInfo,
+		CloudJoin:              cloudJoinInfo,
+		PackagesIncomplete:     len(collectionErrors) > 0,
+		CollectionErrors:       collectionErrors,
+		Defender:               defenderInfo,
+		RDP:                    rdpInfo,
+		SecurityPosture:        securityPosture,
+		LocalUsers:             localUsers,
+		LocalAdministrators:    localAdministrators,
+		ExpiringCertificates:   expiringCerts,
+		FeatureUpdate:          featureUpdate,
+		OSEdition:              osEdition,
+		OSEolDate:              osEolDate,
+		OSSupported:            osSupported,
+		OSSupportEndingSoon:    osSupportEndingSoon,
+		ConfigMgr:              configMgrInfo,
+		HiddenUpdates:          hiddenUpdates,
+		UpdatePause:            updatePause,
+		UpdateDeferral:         updateDeferral,
+		UpdateActivity:         updateActivity,
+		WUAVersion:             wuaVersion,
+		Sections:               sections.names(),
+		Tags:                   cfgManager.GetConfig().Tags,
+		Site:                   cfgManager.GetConfig().Site,
+		Environment:            cfgManager.GetConfig().Environment,
+		Role:                   cfgManager.GetConfig().Role,
 	}
+	run.SetPayload(payload)
 
-	// If --report-json flag is set, output JSON and exit
-	if outputJson {
+	// With --json or collect, output JSON and exit
+	if jsonOut != nil {
 		jsonData, err := json.MarshalIndent(payload, "", "  ")
 		if err != nil {
 			return fmt.Errorf("failed to marshal JSON: %w", err)
 		}
-		if _, err := fmt.Fprintf(os.Stdout, "%s\n", jsonData); err != nil {
+		if _, err := fmt.Fprintf(jsonOut, "%s\n", jsonData); err != nil {
 			return fmt.Errorf("failed to write JSON output: %w", err)
 		}
-		return nil
+		return incompleteReport(collectionErrors)
 	}
 
 	// Send report
 	logger.Info("Sending report to PatchMon server...")
 	httpClient := client.New(cfgManager, logger)
-	ctx := context.Background()
 	response, err := httpClient.SendUpdate(ctx, payload)
 	if err != nil {
 		return fmt.Errorf("failed to send report: %w", err)
 	}
+	run.SetResponse(response)
 
 	logger.Info("Report sent successfully")
+	if err := systemDetector.SaveMachineID(machineID); err != nil {
+		logger.WithError(err).Warn("Failed to record reported machine ID")
+	}
+	// Keep the report for diff; only reports with complete package and
+	// hardware data are useful to compare against
+	if sections.has(sectionPackages) && sections.has(sectionHardware) && !payload.PackagesIncomplete {
+		if err := snapshot.Save(config.DefaultStateFile, payload, time.Now()); err != nil {
+			logger.WithError(err).Warn("Failed to save report snapshot")
+		}
+	}
 	logger.WithField("count", response.PackagesProcessed).Info("Processed packages")
 
+	// Carry out update actions requested by the server (hide/unhide, ...)
+	if len(response.Actions) > 0 {
+		runServerActions(response.Actions)
+	}
+
 	// Handle agent auto-update (server-initiated)
 	if response.AutoUpdate != nil && response.AutoUpdate.ShouldUpdate {
 		logger.WithFields(logrus.Fields{
@@ -230,11 +644,11 @@ func sendReport(outputJson bool) error {
 		}).Info("PatchMon agent update detected")
 
 		logger.Info("Automatically updating PatchMon agent to latest version...")
-		if err := updateAgent(); err != nil {
+		if err := updateAgent(ctx); err != nil {
 			logger.WithError(err).Warn("PatchMon agent update failed, but data was sent successfully")
 		} else {
 			logger.Info("PatchMon agent update completed successfully")
-			return nil
+			return incompleteReport(collectionErrors)
 		}
 	} else {
 		// Proactive update check after report (non-blocking with timeout)
@@ -242,7 +656,7 @@ func sendReport(outputJson bool) error {
 			time.Sleep(5 * time.Second)
 
 			logger.Info("Checking for agent updates...")
-			versionInfo, err := getServerVersionInfo()
+			versionInfo, err := getServerVersionInfo(ctx)
 			if err != nil {
 				logger.WithError(err).Warn("Failed to check for updates after report (non-critical)")
 				return
@@ -253,7 +667,7 @@ func sendReport(outputJson bool) error {
 					"latest":  versionInfo.LatestVersion,
 				}).Info("Update available, automatically updating...")
 
-				if err := updateAgent(); err != nil {
+				if err := updateAgent(ctx); err != nil {
 					logger.WithError(err).Warn("PatchMon agent update failed, but data was sent successfully")
 				} else {
 					logger.Info("PatchMon agent update completed successfully")
@@ -271,5 +685,14 @@ func sendReport(outputJson bool) error {
 	}
 
 	logger.Debug("Report process completed")
-	return nil
+	return incompleteReport(collectionErrors)
+}
+
+// incompleteReport returns an ExitPartial error if package information could
+// not be fully collected
+func incompleteReport(collectionErrors []string) error {
+	if len(collectionErrors) == 0 {
+		return nil
+	}
+	return withExitCode(ExitPartial, fmt.Errorf("report is incomplete: %s", strings.Join(collectionErrors, "; ")))
 }
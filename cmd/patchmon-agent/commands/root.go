@@ -1,14 +1,20 @@
 package commands
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	"patchmon-agent/internal/config"
 	"patchmon-agent/internal/constants"
+	"patchmon-agent/internal/logging"
 	"patchmon-agent/internal/utils"
 	"patchmon-agent/internal/version"
+	"patchmon-agent/pkg/models"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -21,6 +27,10 @@ var (
 	logger     *logrus.Logger
 	configFile string
 	logLevel   string
+	logStdout  bool
+	timeout    time.Duration
+	quiet      bool
+	noColor    bool
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -30,15 +40,35 @@ var rootCmd = &cobra.Command{
 	Long: `PatchMon Agent v` + version.Version + `
 
 A monitoring agent that sends package information to PatchMon.`,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		initialiseAgent()
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Arguments are valid by now; don't print usage for runtime errors
+		cmd.SilenceUsage = true
+		if err := initialiseAgent(); err != nil {
+			return withExitCode(ExitConfig, err)
+		}
 		updateLogLevel(cmd)
+		applyTimeouts(cmd)
+		return nil
 	},
 }
 
+// timeoutGracePeriod is how long a command may keep running after --timeout
+// expires, to finish the step in progress and clean up, before the process
+// is stopped
+const timeoutGracePeriod = 2 * time.Minute
+
+// cancelTimeout releases the --timeout context, see applyTimeouts
+var cancelTimeout context.CancelFunc = func() {}
+
 // Execute adds all child commands to the root command and sets flags appropriately
 func Execute() error {
-	return rootCmd.Execute()
+	cmd, err := rootCmd.ExecuteC()
+	waitForBackgroundActions()
+	cancelTimeout()
+	if err != nil && cmd.Context() != nil && errors.Is(cmd.Context().Err(), context.DeadlineExceeded) {
+		err = withExitCode(ExitTimeout, fmt.Errorf("%s timed out after %s: %w", cmd.CommandPath(), timeout, err))
+	}
+	return err
 }
 
 func init() {
@@ -49,6 +79,10 @@ func init() {
 	// Add global flags
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", configFile, "config file path")
 	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", logLevel, "log level (debug, info, warn, error)")
+	rootCmd.PersistentFlags().BoolVar(&logStdout, "log-stdout", false, "write logs to the console instead of the log file")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "only print errors to the console (JSON output is still printed)")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable colored console output")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0, "stop the command if it runs longer than this, e.g. 30m (0 = no limit, ignored by serve)")
 
 	// Add all subcommands
 	rootCmd.AddCommand(reportCmd)
@@ -59,17 +93,14 @@ func init() {
 	rootCmd.AddCommand(diagnosticsCmd)
 }
 
-// initialiseAgent initialises the configuration manager and logger
-func initialiseAgent() {
+// initialiseAgent initialises the configuration manager and logger. An
+// invalid log_output is returned after logging is set up with the log file.
+func initialiseAgent() error {
 	// Initialise logger
 	logger = logrus.New()
 	// Get timezone for log timestamps
 	tz_loc := utils.GetTimezoneLocation()
-	logger.SetFormatter(&logrus.TextFormatter{
-		DisableTimestamp: false,
-		FullTimestamp:    true,
-		TimestampFormat:  "2006-01-02T15:04:05",
-	})
+	logger.SetFormatter(logFormatter(false))
 	// Store timezone location for future use if needed
 	_ = tz_loc
 
@@ -79,12 +110,98 @@ func initialiseAgent() {
 
 	// Load config early to determine log file path
 	_ = cfgManager.LoadConfig()
-	logFile := cfgManager.GetConfig().LogFile
+	toFile, toConsole, outputErr := logging.ParseLogOutput(cfgManager.GetConfig().LogOutput, logStdout)
+	if outputErr != nil {
+		toFile, toConsole = true, true
+	}
+	logger.SetOutput(io.Discard)
+	if toFile {
+		logger.SetOutput(logFile(cfgManager.GetConfig()))
+	}
+	if toConsole {
+		addConsoleLogging()
+	}
+	if quiet {
+		console = io.Discard
+	}
+
+	addLogHooks(cfgManager.GetConfig())
+	return outputErr
+}
+
+// logFormatter returns the formatter of log lines, with ANSI colors if color
+// is set
+func logFormatter(color bool) *logrus.TextFormatter {
+	return &logrus.TextFormatter{
+		DisableTimestamp: false,
+		FullTimestamp:    true,
+		TimestampFormat:  "2006-01-02T15:04:05",
+		ForceColors:      color,
+		DisableColors:    !color,
+	}
+}
+
+// addConsoleLogging writes log entries to stderr, so they never mix with
+// JSON printed on stdout. They are colored on an interactive console unless
+// --no-color or NO_COLOR is set, and only errors are written with --quiet.
+// Console output is written by a hook so it can be colored and filtered
+// separately from the log file.
+func addConsoleLogging() {
+	level := logrus.TraceLevel
+	if quiet {
+		level = logrus.ErrorLevel
+	}
+	color := !noColor && os.Getenv("NO_COLOR") == "" && logging.EnableConsoleColors(os.Stderr)
+	logger.AddHook(logging.NewConsoleHook(os.Stderr, logFormatter(color), level))
+}
+
+// logFile returns the rotating log file writer
+func logFile(cfg *models.Config) io.Writer {
+	logFile := cfg.LogFile
 	if logFile == "" {
 		logFile = config.DefaultLogFile
 	}
 	_ = os.MkdirAll(filepath.Dir(logFile), 0755)
-	logger.SetOutput(&lumberjack.Logger{Filename: logFile, MaxSize: 10, MaxBackups: 5, MaxAge: 14, Compress: true})
+	file := &lumberjack.Logger{
+		Filename:   logFile,
+		MaxSize:    cfg.LogMaxSize,
+		MaxBackups: cfg.LogMaxBackups,
+		MaxAge:     cfg.LogMaxAge,
+		Compress:   true,
+	}
+	return file
+}
+
+// addLogHooks copies log entries to the Windows Event Log and a syslog server
+// when configured
+func addLogHooks(cfg *models.Config) {
+	level, enabled, err := logging.ParseEventLogLevel(cfg.EventLog)
+	if err != nil {
+		logger.WithError(err).Warn("Event log disabled")
+	} else if enabled {
+		hook, err := logging.NewEventLogHook(level)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to open the Windows Event Log")
+		} else {
+			logger.AddHook(hook)
+		}
+	}
+
+	if cfg.SyslogServer != "" {
+		level := logrus.InfoLevel
+		if cfg.SyslogLevel != "" {
+			if level, err = logrus.ParseLevel(cfg.SyslogLevel); err != nil {
+				logger.WithError(err).Warn("Invalid syslog_level, forwarding info and above")
+				level = logrus.InfoLevel
+			}
+		}
+		hook, err := logging.NewSyslogHook(cfg.SyslogServer, level, cfg.SyslogSkipTLSVerify)
+		if err != nil {
+			logger.WithError(err).Warn("Syslog forwarding disabled")
+		} else {
+			logger.AddHook(hook)
+		}
+	}
 }
 
 // updateLogLevel sets the logger level based on the flag value
@@ -121,6 +238,37 @@ func updateLogLevel(cmd *cobra.Command) {
 	}
 }
 
+// applyTimeouts sets the PowerShell command timeout from the config and, with
+// --timeout, cancels the command's context when it runs too long. Commands
+// stop at the next step that checks the context, so the PowerShell session,
+// the store and the report history are closed normally. If the command is
+// still running after timeoutGracePeriod, the process is stopped. serve runs
+// indefinitely, so the overall timeout does not apply to it.
+func applyTimeouts(cmd *cobra.Command) {
+	utils.SetPowerShellTimeout(time.Duration(cfgManager.GetConfig().PowerShellTimeout) * time.Second)
+
+	if timeout <= 0 || cmd == serveCmd {
+		return
+	}
+	ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+	cmd.SetContext(ctx)
+	context.AfterFunc(ctx, func() {
+		if ctx.Err() == context.DeadlineExceeded {
+			logger.WithField("timeout", timeout).Errorf("%s did not complete in time, stopping", cmd.CommandPath())
+		}
+	})
+	exitTimer := time.AfterFunc(timeout+timeoutGracePeriod, func() {
+		logger.WithField("timeout", timeout).Errorf("%s did not stop within %s of the timeout, exiting", cmd.CommandPath(), timeoutGracePeriod)
+		fmt.Fprintf(os.Stderr, "Error: %s timed out after %s\n", cmd.CommandPath(), timeout)
+		utils.ClosePowerShell()
+		os.Exit(ExitTimeout)
+	})
+	cancelTimeout = func() {
+		exitTimer.Stop()
+		cancel()
+	}
+}
+
 // checkAdmin ensures the command is run as Administrator
 func checkAdmin() error {
 	if !isAdmin() {
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"fmt"
+
+	"patchmon-agent/internal/constants"
+	"patchmon-agent/internal/packages"
+
+	"github.com/spf13/cobra"
+)
+
+var scanReport bool
+
+// scanCmd represents the scan command
+var scanCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "Trigger a Windows Update detection scan now",
+	Long: `Ask Windows Update to run a detection cycle immediately instead of waiting for
+its own schedule, so newly approved WSUS updates are picked up. With --report a
+report is sent to the server once the scan has been started.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkAdmin(); err != nil {
+			return err
+		}
+
+		if err := triggerScan(""); err != nil {
+			return err
+		}
+		if scanReport {
+			return sendReport(cmd.Context(), nil, nil)
+		}
+		return nil
+	},
+}
+
+func init() {
+	scanCmd.Flags().BoolVar(&scanReport, "report", false, "Send a report after starting the scan")
+	rootCmd.AddCommand(scanCmd)
+}
+
+// triggerScan starts a Windows Update detection cycle and, when the server
+// requested it (actionID set), reports the outcome back
+func triggerScan(actionID string) error {
+	packageMgr := packages.New(cfgManager, logger)
+	err := packageMgr.TriggerDetection()
+	if err != nil {
+		err = fmt.Errorf("failed to start Windows Update scan: %w", err)
+	} else {
+		logger.Info("Windows Update detection scan started")
+	}
+	reportActionResult(constants.ActionScan, actionID, err)
+	return err
+}
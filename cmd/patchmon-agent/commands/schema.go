@@ -0,0 +1,33 @@
+package commands
+
+import (
+	"fmt"
+
+	"patchmon-agent/internal/schema"
+	"patchmon-agent/internal/version"
+	"patchmon-agent/pkg/models"
+
+	"github.com/spf13/cobra"
+)
+
+// schemaCmd prints the JSON Schema of the report payload
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of the report payload",
+	Long: `Print a JSON Schema (draft 2020-12) of the report payload sent to the PatchMon
+server, generated from this agent version, so server developers and integrators
+can validate reports and compare payloads across agent versions.
+
+Example:
+  patchmon-agent schema > report-schema.json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return printJSON(schema.Generate(models.ReportPayload{},
+			"PatchMon Windows agent report",
+			fmt.Sprintf("Report payload of patchmon-agent %s", version.Version)))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
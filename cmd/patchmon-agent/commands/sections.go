@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Optional sections of the report. The system section (OS, hostname, machine
+// ID, uptime, reboot state) is always sent.
+const (
+	sectionPackages  = "packages"  // Windows Update, Appx, repositories, update policy
+	sectionHardware  = "hardware"  // hardware, drivers, storage, Hyper-V guests, containers
+	sectionNetwork   = "network"   // interfaces, DNS, VPN, public IP
+	sectionSecurity  = "security"  // Defender, RDP, accounts, certificates, posture
+	sectionInventory = "inventory" // Windows features, extended inventory, event log, metrics
+)
+
+var reportSectionNames = []string{sectionPackages, sectionHardware, sectionNetwork, sectionSecurity, sectionInventory}
+
+// reportSections is the set of optional sections to collect. A nil set
+// stands for a full report.
+type reportSections map[string]bool
+
+// parseReportSections parses a list of section names. An empty list or "all"
+// selects a full report.
+func parseReportSections(names []string) (reportSections, error) {
+	sections := reportSections{}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch {
+		case name == "":
+			continue
+		case name == "all":
+			return nil, nil
+		case !slices.Contains(reportSectionNames, name):
+			return nil, fmt.Errorf("unknown report section %q (expected %s or all)", name, strings.Join(reportSectionNames, ", "))
+		}
+		sections[name] = true
+	}
+	if len(sections) == 0 {
+		return nil, nil
+	}
+	return sections, nil
+}
+
+// has reports whether a section is collected
+func (s reportSections) has(name string) bool {
+	return s == nil || s[name]
+}
+
+// names returns the selected sections in a fixed order, or nil for a full
+// report
+func (s reportSections) names() []string {
+	if s == nil {
+		return nil
+	}
+	var names []string
+	for _, name := range reportSectionNames {
+		if s[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
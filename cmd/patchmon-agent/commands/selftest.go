@@ -0,0 +1,246 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"patchmon-agent/internal/config"
+	"patchmon-agent/internal/logging"
+	"patchmon-agent/internal/packages"
+	"patchmon-agent/internal/utils"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+// Disk space below which self-test warns or fails, in bytes. Cumulative
+// updates need several gigabytes to download and install.
+const (
+	selfTestDiskWarnBytes = 10 << 30
+	selfTestDiskFailBytes = 2 << 30
+)
+
+// Self-test results
+const (
+	checkPass = "pass"
+	checkWarn = "warn"
+	checkFail = "fail"
+)
+
+var selfTestOutput string
+
+// selfTestCmd verifies that the agent can run on this host
+var selfTestCmd = &cobra.Command{
+	Use:   "self-test",
+	Short: "Check that the agent can run on this host",
+	Long: `Check administrator rights, the configuration, the API credentials, registry
+access, PowerShell, the Windows Update Agent, disk space and server connectivity,
+and print a pass/fail table. The command exits with code 1 if any check fails,
+so it can be used to verify deployments.
+
+Example:
+  patchmon-agent self-test --output json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkOutputFormat(selfTestOutput); err != nil {
+			return err
+		}
+		return runSelfTest(selfTestOutput)
+	},
+}
+
+func init() {
+	addOutputFlag(selfTestCmd, &selfTestOutput)
+	rootCmd.AddCommand(selfTestCmd)
+}
+
+// selfTestCheck is the result of one self-test check
+type selfTestCheck struct {
+	Name    string `json:"name"`
+	Result  string `json:"result"` // pass, warn or fail
+	Details string `json:"details,omitempty"`
+}
+
+func runSelfTest(output string) error {
+	// Failures are reported in the table, not as log entries on the console
+	originalLevel := logger.GetLevel()
+	logger.SetLevel(logrus.PanicLevel)
+	checks := []selfTestCheck{
+		checkAdminRights(),
+		checkConfig(),
+		checkCredentials(),
+		checkRegistry(),
+		checkPowerShell(),
+		checkWindowsUpdate(),
+	}
+	checks = append(checks, checkDiskSpace()...)
+	checks = append(checks, checkServer())
+	logger.SetLevel(originalLevel)
+
+	failed := 0
+	for _, check := range checks {
+		if check.Result == checkFail {
+			failed++
+		}
+	}
+	var err error
+	if failed > 0 {
+		err = fmt.Errorf("%d of %d self-test checks failed", failed, len(checks))
+	}
+
+	if output == outputJSON {
+		if jsonErr := printJSON(checks); jsonErr != nil {
+			return jsonErr
+		}
+		return err
+	}
+
+	fmt.Fprintf(console, "%-24s %-6s %s\n", "CHECK", "RESULT", "DETAILS")
+	for _, check := range checks {
+		fmt.Fprintf(console, "%-24s %-6s %s\n", check.Name, strings.ToUpper(check.Result), check.Details)
+	}
+	if err == nil {
+		fmt.Fprintf(console, "\nAll %d checks passed\n", len(checks))
+	}
+	return err
+}
+
+// newCheck returns a passed check, or a failed one if err is set
+func newCheck(name string, err error, details string) selfTestCheck {
+	if err != nil {
+		return selfTestCheck{Name: name, Result: checkFail, Details: err.Error()}
+	}
+	return selfTestCheck{Name: name, Result: checkPass, Details: details}
+}
+
+func checkAdminRights() selfTestCheck {
+	if !isAdmin() {
+		return newCheck("Administrator rights", errors.New("not running as Administrator; most commands will fail"), "")
+	}
+	return newCheck("Administrator rights", nil, "running as Administrator")
+}
+
+func checkConfig() selfTestCheck {
+	const name = "Configuration"
+	if err := cfgManager.LoadConfig(); err != nil {
+		return newCheck(name, err, "")
+	}
+	cfg := cfgManager.GetConfig()
+	errs := []error{config.Validate(cfg)}
+	if _, err := parseReportSections(cfg.ReportSections); err != nil {
+		errs = append(errs, fmt.Errorf("report_sections: %w", err))
+	}
+	if _, _, err := logging.ParseEventLogLevel(cfg.EventLog); err != nil {
+		errs = append(errs, fmt.Errorf("event_log: %w", err))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return newCheck(name, errors.New(strings.ReplaceAll(err.Error(), "\n", "; ")), "")
+	}
+
+	details := cfgManager.GetConfigFile()
+	if _, err := os.Stat(details); err != nil {
+		details = "no config file, using defaults and environment"
+	}
+	if warnings := config.Warnings(cfg); len(warnings) > 0 {
+		check := newCheck(name, nil, strings.Join(warnings, "; "))
+		check.Result = checkWarn
+		return check
+	}
+	return newCheck(name, nil, details)
+}
+
+func checkCredentials() selfTestCheck {
+	if err := loadCredentials(); err != nil {
+		return newCheck("API credentials", err, "")
+	}
+	return newCheck("API credentials", nil, "API ID "+cfgManager.GetCredentials().APIID)
+}
+
+func checkRegistry() selfTestCheck {
+	const name = "Registry access"
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, `SOFTWARE\Microsoft\Windows NT\CurrentVersion`, registry.QUERY_VALUE)
+	if err != nil {
+		return newCheck(name, err, "")
+	}
+	defer key.Close()
+	build, _, err := key.GetStringValue("CurrentBuild")
+	if err != nil {
+		return newCheck(name, err, "")
+	}
+	return newCheck(name, nil, "Windows build "+build)
+}
+
+func checkPowerShell() selfTestCheck {
+	version, err := utils.RunPowerShell("$PSVersionTable.PSVersion.ToString()")
+	return newCheck("PowerShell", err, "version "+strings.TrimSpace(version))
+}
+
+func checkWindowsUpdate() selfTestCheck {
+	const name = "Windows Update Agent"
+	if err := packages.New(cfgManager, logger).CheckWUA(); err != nil {
+		return newCheck(name, err, "")
+	}
+	for _, service := range packages.CheckComponents().Services {
+		if service.Name == "wuauserv" && service.StartType == "disabled" {
+			return newCheck(name, errors.New("the Windows Update service (wuauserv) is disabled"), "")
+		}
+	}
+	return newCheck(name, nil, "COM API available")
+}
+
+// checkDiskSpace checks the free space of the system drive and, if it is on
+// another volume, the drive holding the agent's data
+func checkDiskSpace() []selfTestCheck {
+	systemDrive := filepath.VolumeName(os.Getenv("SystemRoot"))
+	if systemDrive == "" {
+		systemDrive = "C:"
+	}
+	drives := []string{systemDrive}
+	if dataDrive := filepath.VolumeName(config.DefaultConfigDir); !strings.EqualFold(dataDrive, systemDrive) {
+		drives = append(drives, dataDrive)
+	}
+
+	var checks []selfTestCheck
+	for _, drive := range drives {
+		name := "Disk space " + drive
+		free, err := freeDiskSpace(drive + `\`)
+		if err != nil {
+			checks = append(checks, newCheck(name, err, ""))
+			continue
+		}
+		check := newCheck(name, nil, fmt.Sprintf("%.1f GB free", float64(free)/(1<<30)))
+		switch {
+		case free < selfTestDiskFailBytes:
+			check.Result = checkFail
+		case free < selfTestDiskWarnBytes:
+			check.Result = checkWarn
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// freeDiskSpace returns the bytes available to the caller on a volume
+func freeDiskSpace(root string) (uint64, error) {
+	path, err := windows.UTF16PtrFromString(root)
+	if err != nil {
+		return 0, err
+	}
+	var free, total, totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(path, &free, &total, &totalFree); err != nil {
+		return 0, err
+	}
+	return free, nil
+}
+
+func checkServer() selfTestCheck {
+	if _, err := pingServer(); err != nil {
+		return newCheck("Server connectivity", err, "")
+	}
+	return newCheck("Server connectivity", nil, cfgManager.GetConfig().PatchmonServer)
+}
@@ -1,27 +1,279 @@
 package commands
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
+	"patchmon-agent/internal/client"
+	"patchmon-agent/internal/metrics"
+	"patchmon-agent/internal/utils"
+
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"golang.org/x/sys/windows/svc"
 )
 
+// agentStats collects the agent health metrics served on /metrics. It is nil
+// unless serve runs with prometheus_metrics enabled.
+var agentStats *metrics.AgentStats
+
+// loadSampler keeps the Windows load averages for the lifetime of serve when
+// resource_metrics is enabled. It is nil for one-off commands, whose reports
+// only sample the load at report time.
+var loadSampler *metrics.Manager
+
 // serveCmd runs the agent as a long-lived service
 var serveCmd = &cobra.Command{
 	Use:   "serve",
-	Short: "Run the agent as a Windows service (V2)",
-	Long:  "Run the agent as a Windows service with async updates. This feature will be available in V2.",
+	Short: "Run the agent in the foreground, reporting on a schedule",
+	Long: `Run the agent as a long-lived process that sends a report at startup and then
+every update_interval minutes, staggered by the report offset. The interval is
+refreshed from the server before each report. With prometheus_metrics enabled,
+agent health metrics are served on http://127.0.0.1:<prometheus_port>/metrics.
+
+serve stops on Ctrl+C, console close, logoff and shutdown, and when started by
+the Service Control Manager, on a service stop.
+
+Registering the agent with the Windows Service Control Manager will be
+available in V2; until then run serve under Task Scheduler (setup can register
+the task) or a service wrapper.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if err := checkAdmin(); err != nil {
 			return err
 		}
-		fmt.Println("Windows Service mode will be available in V2.")
-		fmt.Println("For now, use 'patchmon-agent report' to send a one-time report,")
-		fmt.Println("or schedule it via Windows Task Scheduler.")
-		return nil
+		if err := loadCredentials(); err != nil {
+			return err
+		}
+
+		// Ctrl+C, and console close, logoff or shutdown (delivered as SIGTERM)
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if isService, err := svc.IsWindowsService(); err == nil && isService {
+			return runService(ctx, serveLoop)
+		}
+		return serveLoop(ctx)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(serveCmd)
 }
+
+// serveLoop sends a report at startup and then on the schedule until ctx is
+// cancelled. The interval is refreshed from the server before each report.
+func serveLoop(ctx context.Context) error {
+	cfg := cfgManager.GetConfig()
+	if cfg.PrometheusMetrics {
+		agentStats = metrics.NewAgentStats()
+		port := cfg.PrometheusPort
+		if port <= 0 {
+			port = metrics.DefaultPrometheusPort
+		}
+		if err := serveMetrics(ctx, net.JoinHostPort("127.0.0.1", strconv.Itoa(port))); err != nil {
+			return err
+		}
+	}
+
+	if cfg.ResourceMetrics {
+		loadSampler = metrics.New(logger)
+		go loadSampler.SampleLoad(ctx)
+	}
+
+	// With report_sections set, only the startup report and one every
+	// full_report_interval minutes are full reports
+	sections, err := parseReportSections(cfg.ReportSections)
+	if err != nil {
+		return withExitCode(ExitConfig, err)
+	}
+	fullInterval := time.Duration(cfg.FullReportInterval) * time.Minute
+
+	syncUpdateInterval()
+	lastFull := time.Now()
+	if !runScheduledReport(ctx, nil) {
+		return stopServe()
+	}
+	for {
+		interval, offset := reportSchedule()
+		next := utils.NextReportTime(time.Now(), interval, offset)
+		logger.WithField("next", next.Format(time.RFC3339)).Info("Waiting for next report")
+		select {
+		case <-ctx.Done():
+			return stopServe()
+		case <-time.After(time.Until(next)):
+			syncUpdateInterval()
+			reportSections := sections
+			if fullInterval > 0 && time.Since(lastFull) >= fullInterval {
+				reportSections = nil
+			}
+			if reportSections == nil {
+				lastFull = time.Now()
+			}
+			if !runScheduledReport(ctx, reportSections) {
+				return stopServe()
+			}
+		}
+	}
+}
+
+// stopServe ends serve without waiting for background actions such as a
+// Defender scan, whose result is then not reported
+func stopServe() error {
+	logger.Info("Stopping agent")
+	detachBackgroundActions()
+	return nil
+}
+
+// reportSchedule returns the report interval and the offset from the
+// interval boundary. A missing offset is calculated from the API ID and saved,
+// so the agent keeps reporting at the same time across restarts.
+func reportSchedule() (interval, offset time.Duration) {
+	cfg := cfgManager.GetConfig()
+	interval = time.Duration(cfg.UpdateInterval) * time.Minute
+	offset = time.Duration(cfg.ReportOffset) * time.Second
+	if offset == 0 {
+		offset = utils.CalculateReportOffset(cfgManager.GetCredentials().APIID, cfg.UpdateInterval)
+		if err := cfgManager.SetReportOffset(int(offset.Seconds())); err != nil {
+			logger.WithError(err).Warn("Failed to save report offset")
+		}
+	}
+	return interval, offset
+}
+
+// syncUpdateInterval applies the update interval set on the server to the
+// local config. The report offset is recalculated for the new interval.
+// Failures are logged and the local interval is kept.
+func syncUpdateInterval() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	response, err := client.New(cfgManager, logger).GetUpdateInterval(ctx)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to get update interval from server, using local setting")
+		return
+	}
+	current := cfgManager.GetConfig().UpdateInterval
+	if response.Interval <= 0 || response.Interval == current {
+		return
+	}
+
+	logger.WithFields(logrus.Fields{
+		"old": current,
+		"new": response.Interval,
+	}).Info("Update interval changed on server")
+	if err := cfgManager.SetUpdateInterval(response.Interval); err != nil {
+		logger.WithError(err).Warn("Failed to save update interval")
+	}
+	offset := utils.CalculateReportOffset(cfgManager.GetCredentials().APIID, response.Interval)
+	if err := cfgManager.SetReportOffset(int(offset.Seconds())); err != nil {
+		logger.WithError(err).Warn("Failed to save report offset")
+	}
+}
+
+// runScheduledReport sends a report, logging rather than returning failures
+// so the loop keeps running. It returns false if ctx is cancelled first; the
+// report is abandoned as the process is about to exit.
+func runScheduledReport(ctx context.Context, sections reportSections) bool {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		start := time.Now()
+		err := sendReport(ctx, nil, sections)
+		if ExitCode(err) == ExitPartial {
+			// The report was sent; the missing data is already logged
+			err = nil
+		}
+		agentStats.RecordReport(start, time.Since(start), err)
+		if err != nil {
+			logger.WithError(err).Error("Scheduled report failed")
+		}
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// agentService runs serve under the Service Control Manager when the agent
+// was registered as a service (e.g. with sc.exe create), stopping it when the
+// service is stopped or the system shuts down
+type agentService struct {
+	ctx context.Context
+	run func(ctx context.Context) error
+	err error
+}
+
+// runService runs serve as a Windows service and returns its error
+func runService(ctx context.Context, run func(ctx context.Context) error) error {
+	service := &agentService{ctx: ctx, run: run}
+	// The name is ignored for services running in their own process
+	if err := svc.Run(scheduledTaskName, service); err != nil {
+		return fmt.Errorf("failed to run as a service: %w", err)
+	}
+	return service.err
+}
+
+// Execute implements svc.Handler
+func (s *agentService) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- s.run(ctx) }()
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for {
+		select {
+		case s.err = <-done:
+			status <- svc.Status{State: svc.StopPending}
+			if s.err != nil {
+				return true, uint32(ExitCode(s.err))
+			}
+			return false, 0
+		case request := <-requests:
+			switch request.Cmd {
+			case svc.Interrogate:
+				status <- request.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				logger.Info("Service stop requested")
+				status <- svc.Status{State: svc.StopPending}
+				cancel()
+			}
+		}
+	}
+}
+
+// serveMetrics serves agentStats on addr until ctx is cancelled
+func serveMetrics(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for metrics on %s: %w", addr, err)
+	}
+	server := &http.Server{
+		Handler:           agentStats.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.WithError(err).Error("Metrics endpoint stopped")
+		}
+	}()
+	logger.WithField("address", addr).Info("Serving Prometheus metrics on /metrics")
+	return nil
+}
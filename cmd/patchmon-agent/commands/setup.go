@@ -0,0 +1,173 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"patchmon-agent/internal/utils"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/sys/windows"
+)
+
+// scheduledTaskName is the Task Scheduler task that runs serve at startup
+const scheduledTaskName = "PatchMon Agent"
+
+// setupCmd walks a new user through configuring the agent
+var setupCmd = &cobra.Command{
+	Use:   "setup",
+	Short: "Interactively configure the agent",
+	Long: `Prompt for the PatchMon server URL and API credentials, test the connection,
+optionally register a scheduled task that runs the agent at startup, and send a
+first report.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkAdmin(); err != nil {
+			return err
+		}
+		return runSetup(cmd.Context(), bufio.NewReader(os.Stdin))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(setupCmd)
+}
+
+// runSetup runs the setup wizard, reading answers from in
+func runSetup(ctx context.Context, in *bufio.Reader) error {
+	fmt.Println("PatchMon agent setup")
+	fmt.Println("Find the API ID and key for this host in the PatchMon web interface.")
+	fmt.Println()
+
+	cfg := cfgManager.GetConfig()
+	serverURL, err := prompt(in, "Server URL", cfg.PatchmonServer)
+	if err != nil {
+		return err
+	}
+	apiID, err := prompt(in, "API ID", "")
+	if err != nil {
+		return err
+	}
+	fmt.Print("API key: ")
+	apiKey, err := readSecret(in)
+	fmt.Println()
+	if err != nil {
+		return err
+	}
+
+	// Saves the configuration and tests the connection
+	if err := configureCreds(apiID, apiKey, serverURL); err != nil {
+		return err
+	}
+	fmt.Println("✅ Connected to", serverURL)
+
+	install, err := confirm(in, "Run the agent in the background at startup (scheduled task)?", true)
+	if err != nil {
+		return err
+	}
+	if install {
+		if err := createScheduledTask(); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Scheduled task %q created and started\n", scheduledTaskName)
+	}
+
+	// The scheduled task reports at startup, so only offer a report without it
+	if !install {
+		report, err := confirm(in, "Send a first report now?", true)
+		if err != nil {
+			return err
+		}
+		if report {
+			if err := sendReport(ctx, nil, nil); err != nil {
+				return err
+			}
+			fmt.Println("✅ First report sent")
+		}
+	}
+
+	fmt.Println()
+	fmt.Println("Setup complete.")
+	return nil
+}
+
+// prompt asks for a value, returning def if the answer is empty
+func prompt(in *bufio.Reader, label, def string) (string, error) {
+	if def != "" {
+		fmt.Printf("%s [%s]: ", label, def)
+	} else {
+		fmt.Printf("%s: ", label)
+	}
+	line, err := in.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read %s: %w", strings.ToLower(label), err)
+	}
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+// confirm asks a yes/no question
+func confirm(in *bufio.Reader, question string, def bool) (bool, error) {
+	choices := "y/N"
+	if def {
+		choices = "Y/n"
+	}
+	answer, err := prompt(in, question+" ("+choices+")", "")
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "":
+		return def, nil
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// readSecret reads a line without echoing it when stdin is a console
+func readSecret(in *bufio.Reader) (string, error) {
+	console := windows.Handle(os.Stdin.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(console, &mode); err == nil {
+		if err := windows.SetConsoleMode(console, mode&^windows.ENABLE_ECHO_INPUT); err == nil {
+			defer windows.SetConsoleMode(console, mode)
+		}
+	}
+	line, err := in.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read API key: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// createScheduledTask registers a task that runs serve as SYSTEM at startup,
+// replacing an existing one, and starts it. The task has no execution time
+// limit (Task Scheduler stops tasks after 3 days by default) and is restarted
+// if the agent exits with an error.
+func createScheduledTask() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the agent executable: %w", err)
+	}
+
+	script := fmt.Sprintf(`$action = New-ScheduledTaskAction -Execute '%s' -Argument 'serve'
+$trigger = New-ScheduledTaskTrigger -AtStartup
+$settings = New-ScheduledTaskSettingsSet -ExecutionTimeLimit ([TimeSpan]::Zero) -RestartCount 3 -RestartInterval (New-TimeSpan -Minutes 5) -StartWhenAvailable
+Register-ScheduledTask -TaskName '%s' -Action $action -Trigger $trigger -Settings $settings -User 'SYSTEM' -RunLevel Highest -Force -ErrorAction Stop | Out-Null
+Start-ScheduledTask -TaskName '%s' -ErrorAction Stop`,
+		psQuote(exe), psQuote(scheduledTaskName), psQuote(scheduledTaskName))
+	if _, err := utils.RunPowerShellOnce(script); err != nil {
+		return fmt.Errorf("failed to create scheduled task: %w", err)
+	}
+	return nil
+}
+
+// psQuote escapes a value for a single-quoted PowerShell string
+func psQuote(value string) string {
+	return strings.ReplaceAll(value, "'", "''")
+}
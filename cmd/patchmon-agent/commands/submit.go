@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"patchmon-agent/internal/client"
+	"patchmon-agent/internal/export"
+	"patchmon-agent/pkg/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	submitCredentials string
+	submitMaxAge      time.Duration
+)
+
+// submitCmd sends a report exported on an air-gapped host
+var submitCmd = &cobra.Command{
+	Use:   "submit <file>",
+	Short: "Send a report exported with report --export",
+	Long: `Send a report exported on an isolated host with report --export to the PatchMon
+server. Run it on a host that can reach the server, with the credentials of the
+host that exported the report. The signature of the report is checked against
+its API key and reports older than --max-age are refused; the signature is
+forwarded to the server with the report.
+
+Example:
+  patchmon-agent submit --credentials E:\srv01\credentials.yml E:\srv01\report.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkAdmin(); err != nil {
+			return err
+		}
+
+		if submitCredentials != "" {
+			cfgManager.GetConfig().CredentialsFile = submitCredentials
+		}
+		if err := loadCredentials(); err != nil {
+			return err
+		}
+		return submitReport(cmd.Context(), args[0])
+	},
+}
+
+func init() {
+	submitCmd.Flags().StringVar(&submitCredentials, "credentials", "", "credentials file of the host that exported the report (default: this host's)")
+	submitCmd.Flags().DurationVar(&submitMaxAge, "max-age", export.DefaultMaxAge, "refuse reports exported longer ago than this")
+	rootCmd.AddCommand(submitCmd)
+}
+
+// exportReport collects a report and writes it to path, signed with the API
+// key of this host
+func exportReport(ctx context.Context, path string, sections reportSections) error {
+	if err := loadCredentials(); err != nil {
+		return err
+	}
+	creds := cfgManager.GetCredentials()
+
+	var buf bytes.Buffer
+	err := sendReport(ctx, &buf, sections)
+	if err != nil && ExitCode(err) != ExitPartial {
+		return err
+	}
+	report, exportErr := export.New(creds.APIID, creds.APIKey, buf.Bytes(), time.Now())
+	if exportErr == nil {
+		exportErr = export.Write(path, report)
+	}
+	if exportErr != nil {
+		return exportErr
+	}
+	logger.WithField("path", path).Info("Report exported")
+	return err
+}
+
+// submitReport verifies an exported report and sends it to the server
+func submitReport(ctx context.Context, path string) error {
+	report, err := export.Read(path)
+	if err != nil {
+		return withExitCode(ExitConfig, err)
+	}
+
+	creds := cfgManager.GetCredentials()
+	if report.APIID != creds.APIID {
+		return withExitCode(ExitConfig, fmt.Errorf("report was exported by %s, but the credentials are for %s", report.APIID, creds.APIID))
+	}
+	if err := export.Verify(report, creds.APIKey, submitMaxAge, time.Now()); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	body, err := export.CompactPayload(report)
+	if err != nil {
+		return err
+	}
+	var payload models.ReportPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return fmt.Errorf("failed to parse report payload: %w", err)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"hostname": payload.Hostname,
+		"exported": report.ExportedAt,
+	}).Info("Sending exported report to PatchMon server...")
+	response, err := client.New(cfgManager, logger).SendExportedUpdate(ctx, report, body)
+	if err != nil {
+		return fmt.Errorf("failed to send report: %w", err)
+	}
+
+	logger.Info("Report sent successfully")
+	logger.WithField("count", response.PackagesProcessed).Info("Processed packages")
+	// Actions and agent updates are meant for the exporting host, not this one
+	if len(response.Actions) > 0 || (response.AutoUpdate != nil && response.AutoUpdate.ShouldUpdate) {
+		logger.Warn("Server actions and agent updates are not carried out for submitted reports")
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"patchmon-agent/internal/constants"
+	"patchmon-agent/internal/packages"
+	"patchmon-agent/internal/system"
+	"patchmon-agent/internal/version"
+	"patchmon-agent/pkg/models"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	uninstallOutput string
+	uninstallJson   bool
+)
+
+// uninstallUpdateCmd represents the uninstall-update command
+var uninstallUpdateCmd = &cobra.Command{
+	Use:   "uninstall-update <KB>",
+	Short: "Uninstall an installed Windows update",
+	Long: `Uninstall an installed Windows update by KB article (e.g. KB5034441) using wusa.exe,
+then report the result to the PatchMon server. The computer is not restarted;
+check rebootRequired in the result.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outputJson, err := jsonRequested(uninstallOutput, uninstallJson)
+		if err != nil {
+			return err
+		}
+		if err := checkAdmin(); err != nil {
+			return err
+		}
+
+		return uninstallUpdate(args[0], outputJson)
+	},
+}
+
+func init() {
+	addJSONOutputFlags(uninstallUpdateCmd, &uninstallOutput, &uninstallJson)
+	rootCmd.AddCommand(uninstallUpdateCmd)
+}
+
+func uninstallUpdate(kb string, outputJson bool) error {
+	if !outputJson {
+		if err := loadCredentials(); err != nil {
+			return err
+		}
+	}
+
+	systemDetector := system.New(logger)
+	packageMgr := packages.New(cfgManager, logger)
+
+	startedAt := time.Now()
+	result, uninstallErr := packageMgr.UninstallUpdate(kb)
+
+	hostname, _ := systemDetector.GetHostname()
+	payload := &models.InstallResultPayload{
+		Hostname:       hostname,
+		MachineID:      systemDetector.GetMachineID(cfgManager.GetConfig().MachineIDSource),
+		AgentVersion:   version.Version,
+		Action:         constants.ActionUninstall,
+		StartedAt:      startedAt.UTC().Format(time.RFC3339),
+		FinishedAt:     time.Now().UTC().Format(time.RFC3339),
+		Results:        []models.UpdateInstallResult{result},
+		RebootRequired: result.RebootRequired,
+	}
+	if uninstallErr != nil {
+		payload.Error = uninstallErr.Error()
+	}
+
+	if err := publishInstallResults(payload, outputJson); err != nil {
+		return err
+	}
+
+	if uninstallErr != nil {
+		return fmt.Errorf("failed to uninstall %s: %w", kb, uninstallErr)
+	}
+	if result.Status == packages.InstallStatusFailed {
+		return fmt.Errorf("failed to uninstall %s: %s", result.Name, result.Error)
+	}
+	return nil
+}
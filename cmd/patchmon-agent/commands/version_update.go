@@ -3,7 +3,6 @@ package commands
 import (
 	"context"
 	"crypto/sha256"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -15,7 +14,10 @@ import (
 	"strings"
 	"time"
 
+	"patchmon-agent/internal/client"
 	"patchmon-agent/internal/config"
+	"patchmon-agent/internal/constants"
+	"patchmon-agent/internal/store"
 	"patchmon-agent/internal/version"
 
 	"github.com/spf13/cobra"
@@ -26,6 +28,13 @@ const (
 	versionCheckTimeout = 10 * time.Second // Shorter timeout for version checks
 )
 
+// lastUpdateMarker is the state store key of the time of the last agent update
+const lastUpdateMarker = "last-update"
+
+// legacyUpdateMarkerFile is the marker file of older agent versions, whose
+// modification time is the time of the last update
+const legacyUpdateMarkerFile = ".last_update_timestamp"
+
 type ServerVersionResponse struct {
 	Version      string `json:"version"`
 	Architecture string `json:"architecture"`
@@ -55,10 +64,29 @@ var checkVersionCmd = &cobra.Command{
 			return err
 		}
 
-		return checkVersion()
+		if err := checkOutputFormat(checkVersionOutput); err != nil {
+			return err
+		}
+		return checkVersion(cmd.Context(), checkVersionOutput)
 	},
 }
 
+var checkVersionOutput string
+
+// checkVersionResult is the JSON output of check-version
+type checkVersionResult struct {
+	CurrentVersion           string `json:"currentVersion"`
+	LatestVersion            string `json:"latestVersion,omitempty"`
+	HasUpdate                bool   `json:"hasUpdate"`
+	AutoUpdateDisabled       bool   `json:"autoUpdateDisabled"`
+	AutoUpdateDisabledReason string `json:"autoUpdateDisabledReason,omitempty"`
+	Error                    string `json:"error,omitempty"`
+}
+
+func init() {
+	addOutputFlag(checkVersionCmd, &checkVersionOutput)
+}
+
 // updateAgentCmd represents the update-agent command
 var updateAgentCmd = &cobra.Command{
 	Use:   "update-agent",
@@ -69,45 +97,60 @@ var updateAgentCmd = &cobra.Command{
 			return err
 		}
 
-		return updateAgent()
+		return updateAgent(cmd.Context())
 	},
 }
 
-func checkVersion() error {
+func checkVersion(ctx context.Context, output string) error {
 	logger.Info("Checking for agent updates...")
 
-	versionInfo, err := getServerVersionInfo()
+	currentVersion := strings.TrimPrefix(version.Version, "v")
+	versionInfo, err := getServerVersionInfo(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to check for updates: %w", err)
+		err = fmt.Errorf("failed to check for updates: %w", err)
+		if output == outputJSON {
+			if jsonErr := printJSON(checkVersionResult{CurrentVersion: currentVersion, Error: err.Error()}); jsonErr != nil {
+				return jsonErr
+			}
+		}
+		return err
 	}
 
-	currentVersion := strings.TrimPrefix(version.Version, "v")
 	latestVersion := strings.TrimPrefix(versionInfo.LatestVersion, "v")
+	if output == outputJSON {
+		return printJSON(checkVersionResult{
+			CurrentVersion:           currentVersion,
+			LatestVersion:            latestVersion,
+			HasUpdate:                versionInfo.HasUpdate,
+			AutoUpdateDisabled:       versionInfo.AutoUpdateDisabled,
+			AutoUpdateDisabledReason: versionInfo.AutoUpdateDisabledReason,
+		})
+	}
 
 	if versionInfo.HasUpdate {
 		logger.Info("Agent update available!")
-		fmt.Printf("  Current version: %s\n", currentVersion)
-		fmt.Printf("  Latest version: %s\n", latestVersion)
-		fmt.Printf("\nTo update, run: patchmon-agent update-agent\n")
+		fmt.Fprintf(console, "  Current version: %s\n", currentVersion)
+		fmt.Fprintf(console, "  Latest version: %s\n", latestVersion)
+		fmt.Fprintf(console, "\nTo update, run: patchmon-agent update-agent\n")
 	} else if versionInfo.AutoUpdateDisabled && latestVersion != currentVersion {
 		logger.WithFields(map[string]interface{}{
 			"current": currentVersion,
 			"latest":  latestVersion,
 			"reason":  versionInfo.AutoUpdateDisabledReason,
 		}).Info("New update available but auto-update is disabled")
-		fmt.Printf("Current version: %s\n", currentVersion)
-		fmt.Printf("Latest version: %s\n", latestVersion)
-		fmt.Printf("Status: %s\n", versionInfo.AutoUpdateDisabledReason)
-		fmt.Printf("\nTo update manually, run: patchmon-agent update-agent\n")
+		fmt.Fprintf(console, "Current version: %s\n", currentVersion)
+		fmt.Fprintf(console, "Latest version: %s\n", latestVersion)
+		fmt.Fprintf(console, "Status: %s\n", versionInfo.AutoUpdateDisabledReason)
+		fmt.Fprintf(console, "\nTo update manually, run: patchmon-agent update-agent\n")
 	} else {
 		logger.WithField("version", currentVersion).Info("Agent is up to date")
-		fmt.Printf("Agent is up to date (version %s)\n", currentVersion)
+		fmt.Fprintf(console, "Agent is up to date (version %s)\n", currentVersion)
 	}
 
 	return nil
 }
 
-func updateAgent() error {
+func updateAgent(ctx context.Context) error {
 	logger.Info("Updating agent...")
 
 	// Check if we recently updated to prevent update loops
@@ -136,7 +179,7 @@ func updateAgent() error {
 
 	// First, check server version info to see if update is needed
 	logger.Debug("Checking server for latest version...")
-	versionInfo, err := getServerVersionInfo()
+	versionInfo, err := getServerVersionInfo(ctx)
 	if err != nil {
 		logger.WithError(err).Warn("Failed to get version info, proceeding with update anyway")
 	} else {
@@ -150,8 +193,12 @@ func updateAgent() error {
 		}
 	}
 
+	progress := newProgressReporter(constants.ActionSelfUpdate, "")
+	defer progress.Close()
+
 	// Get latest binary info from server
-	binaryInfo, err := getLatestBinaryFromServer()
+	progress.Phase(constants.PhaseDownloading, 0)
+	binaryInfo, err := getLatestBinaryFromServer(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get latest binary information: %w", err)
 	}
@@ -187,6 +234,7 @@ func updateAgent() error {
 	}
 
 	// Verify the new executable works and check its version
+	progress.Phase(constants.PhaseVerifying, 50)
 	logger.Debug("Validating new executable...")
 	testCmd := exec.Command(tempPath, "check-version")
 	testCmd.Env = os.Environ()
@@ -224,6 +272,7 @@ func updateAgent() error {
 	// Replace current executable
 	// On Windows, we cannot rename over a running executable directly.
 	// Instead, rename the current exe to .old, then rename .new to the target.
+	progress.Phase(constants.PhaseInstalling, 75)
 	logger.Debug("Replacing executable...")
 	oldPath := executablePath + ".old"
 	// Remove any previous .old file
@@ -246,6 +295,7 @@ func updateAgent() error {
 	_ = os.Remove(oldPath)
 
 	logger.WithField("version", newVersion).Info("Agent updated successfully")
+	progress.Phase(constants.PhaseCompleted, 100)
 
 	// Mark that we just updated to prevent immediate re-update loops
 	markRecentUpdate()
@@ -253,13 +303,13 @@ func updateAgent() error {
 	// On Windows, we can't restart ourselves easily like on Linux with systemd.
 	// Just inform the user to restart manually or via Task Scheduler.
 	logger.Info("Agent binary has been updated. Please restart the agent to use the new version.")
-	fmt.Printf("Agent updated to version %s. Please restart the agent.\n", newVersion)
+	fmt.Fprintf(console, "Agent updated to version %s. Please restart the agent.\n", newVersion)
 
 	return nil
 }
 
 // getServerVersionInfo fetches version information from the PatchMon server
-func getServerVersionInfo() (*ServerVersionInfo, error) {
+func getServerVersionInfo(ctx context.Context) (*ServerVersionInfo, error) {
 	cfgManager := config.New()
 	if err := cfgManager.LoadConfig(); err != nil {
 		return nil, fmt.Errorf("failed to load config: %w", err)
@@ -267,7 +317,7 @@ func getServerVersionInfo() (*ServerVersionInfo, error) {
 	cfg := cfgManager.GetConfig()
 
 	// Load credentials for API authentication
-	if err := cfgManager.LoadCredentials(); err != nil {
+	if err := loadCredentials(); err != nil {
 		return nil, fmt.Errorf("failed to load credentials: %w", err)
 	}
 	credentials := cfgManager.GetCredentials()
@@ -276,7 +326,7 @@ func getServerVersionInfo() (*ServerVersionInfo, error) {
 	currentVersion := strings.TrimPrefix(version.Version, "v")
 	url := fmt.Sprintf("%s/api/v1/hosts/agent/version?arch=%s&type=go&currentVersion=%s", cfg.PatchmonServer, architecture, currentVersion)
 
-	ctx, cancel := context.WithTimeout(context.Background(), versionCheckTimeout)
+	ctx, cancel := context.WithTimeout(ctx, versionCheckTimeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -289,21 +339,11 @@ func getServerVersionInfo() (*ServerVersionInfo, error) {
 	req.Header.Set("X-API-KEY", credentials.APIKey)
 
 	// Create HTTP client with proper timeouts
+	transport := client.NewTransport(cfg)
+	transport.ResponseHeaderTimeout = 5 * time.Second
 	httpClient := &http.Client{
-		Timeout: versionCheckTimeout,
-		Transport: &http.Transport{
-			ResponseHeaderTimeout: 5 * time.Second,
-		},
-	}
-
-	// Configure for insecure SSL if needed
-	if cfg.SkipSSLVerify {
-		httpClient.Transport = &http.Transport{
-			ResponseHeaderTimeout: 5 * time.Second,
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-			},
-		}
+		Timeout:   versionCheckTimeout,
+		Transport: transport,
 	}
 
 	resp, err := httpClient.Do(req)
@@ -329,7 +369,7 @@ func getServerVersionInfo() (*ServerVersionInfo, error) {
 }
 
 // getLatestBinaryFromServer fetches the latest binary information from the PatchMon server
-func getLatestBinaryFromServer() (*ServerVersionResponse, error) {
+func getLatestBinaryFromServer(ctx context.Context) (*ServerVersionResponse, error) {
 	cfgManager := config.New()
 	if err := cfgManager.LoadConfig(); err != nil {
 		return nil, fmt.Errorf("failed to load config: %w", err)
@@ -337,7 +377,7 @@ func getLatestBinaryFromServer() (*ServerVersionResponse, error) {
 	cfg := cfgManager.GetConfig()
 
 	// Load credentials for API authentication
-	if err := cfgManager.LoadCredentials(); err != nil {
+	if err := loadCredentials(); err != nil {
 		return nil, fmt.Errorf("failed to load credentials: %w", err)
 	}
 	credentials := cfgManager.GetCredentials()
@@ -345,7 +385,7 @@ func getLatestBinaryFromServer() (*ServerVersionResponse, error) {
 	architecture := getArchitecture()
 	url := fmt.Sprintf("%s/api/v1/hosts/agent/download?arch=%s", cfg.PatchmonServer, architecture)
 
-	ctx, cancel := context.WithTimeout(context.Background(), serverTimeout)
+	ctx, cancel := context.WithTimeout(ctx, serverTimeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -357,18 +397,10 @@ func getLatestBinaryFromServer() (*ServerVersionResponse, error) {
 	req.Header.Set("X-API-ID", credentials.APIID)
 	req.Header.Set("X-API-KEY", credentials.APIKey)
 
-	// Configure HTTP client for insecure SSL if needed
-	httpClient := http.DefaultClient
 	if cfg.SkipSSLVerify {
 		logger.Warn("⚠️  SSL certificate verification is disabled for binary download")
-		httpClient = &http.Client{
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{
-					InsecureSkipVerify: true,
-				},
-			},
-		}
 	}
+	httpClient := &http.Client{Transport: client.NewTransport(cfg)}
 
 	resp, err := httpClient.Do(req)
 	if err != nil {
@@ -480,19 +512,25 @@ func cleanupOldBackups(executablePath string) {
 
 // checkRecentUpdate checks if we updated recently to prevent update loops
 func checkRecentUpdate() error {
-	updateMarkerPath := filepath.Join(config.DefaultConfigDir, ".last_update_timestamp")
-
-	// Check if marker file exists
-	info, err := os.Stat(updateMarkerPath)
+	var updatedAt time.Time
+	found, err := store.Get(config.DefaultStateFile, store.BucketMarkers, lastUpdateMarker, &updatedAt)
 	if err != nil {
-		if os.IsNotExist(err) {
+		return nil
+	}
+	if !found {
+		// An older agent version may have just updated itself to this one
+		info, err := os.Stat(filepath.Join(config.DefaultConfigDir, legacyUpdateMarkerFile))
+		if err != nil {
 			return nil
 		}
-		return nil
+		updatedAt = info.ModTime()
+		if err := store.Put(config.DefaultStateFile, store.BucketMarkers, lastUpdateMarker, updatedAt); err == nil {
+			_ = os.Remove(filepath.Join(config.DefaultConfigDir, legacyUpdateMarkerFile))
+		}
 	}
 
 	// Check if update was within last 5 minutes
-	timeSinceUpdate := time.Since(info.ModTime())
+	timeSinceUpdate := time.Since(updatedAt)
 	if timeSinceUpdate < 5*time.Minute {
 		return fmt.Errorf("update was performed %v ago, waiting to prevent update loop", timeSinceUpdate)
 	}
@@ -500,25 +538,13 @@ func checkRecentUpdate() error {
 	return nil
 }
 
-// markRecentUpdate creates a timestamp file to mark that we just updated
+// markRecentUpdate records that we just updated
 func markRecentUpdate() {
-	updateMarkerPath := filepath.Join(config.DefaultConfigDir, ".last_update_timestamp")
-
-	// Ensure directory exists
-	if err := os.MkdirAll(config.DefaultConfigDir, 0755); err != nil {
-		logger.WithError(err).Debug("Could not create PatchMon config directory (non-critical)")
-		return
-	}
-
-	// Create or update the timestamp file
-	file, err := os.Create(updateMarkerPath)
-	if err != nil {
-		logger.WithError(err).Debug("Could not create update marker file (non-critical)")
+	if err := store.Put(config.DefaultStateFile, store.BucketMarkers, lastUpdateMarker, time.Now()); err != nil {
+		logger.WithError(err).Debug("Could not record update marker (non-critical)")
 		return
 	}
-	if err := file.Close(); err != nil {
-		logger.WithError(err).Debug("Could not close update marker file (non-critical)")
-	}
+	_ = os.Remove(filepath.Join(config.DefaultConfigDir, legacyUpdateMarkerFile))
 
 	logger.Debug("Marked recent update to prevent update loops")
 }
@@ -4,10 +4,14 @@ import (
 	"os"
 
 	"patchmon-agent/cmd/patchmon-agent/commands"
+	"patchmon-agent/internal/utils"
 )
 
 func main() {
-	if err := commands.Execute(); err != nil {
-		os.Exit(1)
+	err := commands.Execute()
+	// os.Exit skips deferred calls
+	utils.ClosePowerShell()
+	if err != nil {
+		os.Exit(commands.ExitCode(err))
 	}
 }
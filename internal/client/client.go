@@ -3,7 +3,12 @@ package client
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"patchmon-agent/internal/config"
@@ -13,6 +18,43 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// DefaultTimeout bounds a request when http_timeout is not set
+const DefaultTimeout = 30 * time.Second
+
+// ErrNoCredentials is returned by requests made before the API credentials
+// were loaded
+var ErrNoCredentials = errors.New("API credentials are not loaded")
+
+// StatusError is returned when the server answers a request with a status
+// other than 200
+type StatusError struct {
+	Request    string
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s request failed with status %d: %s", e.Request, e.StatusCode, e.Body)
+}
+
+// IsAuthError reports whether the server rejected the API credentials
+func IsAuthError(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) &&
+		(statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden)
+}
+
+// IsNetworkError reports whether the server could not be reached or failed
+// to handle a request (5xx)
+func IsNetworkError(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
 // Client handles HTTP communications with the PatchMon server
 type Client struct {
 	client      *resty.Client
@@ -23,22 +65,24 @@ type Client struct {
 
 // New creates a new HTTP client
 func New(configMgr *config.Manager, logger *logrus.Logger) *Client {
+	cfg := configMgr.GetConfig()
+	timeout := DefaultTimeout
+	if cfg.HTTPTimeout > 0 {
+		timeout = time.Duration(cfg.HTTPTimeout) * time.Second
+	}
+
 	client := resty.New()
-	client.SetTimeout(30 * time.Second)
+	client.SetTimeout(timeout)
 	client.SetRetryCount(3)
 	client.SetRetryWaitTime(2 * time.Second)
 
 	// Configure Resty to use our logger
 	client.SetLogger(logger)
 
-	// Configure TLS based on skip_ssl_verify setting
-	cfg := configMgr.GetConfig()
 	if cfg.SkipSSLVerify {
 		logger.Warn("⚠️  SSL certificate verification is disabled (skip_ssl_verify=true)")
-		client.SetTLSClientConfig(&tls.Config{
-			InsecureSkipVerify: true,
-		})
 	}
+	client.SetTransport(NewTransport(cfg))
 
 	return &Client{
 		client:      client,
@@ -48,6 +92,29 @@ func New(configMgr *config.Manager, logger *logrus.Logger) *Client {
 	}
 }
 
+// NewTransport returns an HTTP transport that honors the proxy and
+// skip_ssl_verify settings. Every HTTP client of the agent uses it, so a
+// proxied network needs no further setup.
+func NewTransport(cfg *models.Config) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = Proxy(cfg)
+	if cfg.SkipSSLVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	return transport
+}
+
+// Proxy returns the proxy selection of the proxy setting: the configured
+// proxy, or HTTPS_PROXY / HTTP_PROXY without one
+func Proxy(cfg *models.Config) func(*http.Request) (*url.URL, error) {
+	if cfg.Proxy != "" {
+		if proxyURL, err := url.Parse(cfg.Proxy); err == nil {
+			return http.ProxyURL(proxyURL)
+		}
+	}
+	return http.ProxyFromEnvironment
+}
+
 // Ping sends a ping request to the server
 func (c *Client) Ping(ctx context.Context) (*models.PingResponse, error) {
 	url := fmt.Sprintf("%s/api/%s/hosts/ping", c.config.PatchmonServer, c.config.APIVersion)
@@ -70,7 +137,7 @@ func (c *Client) Ping(ctx context.Context) (*models.PingResponse, error) {
 	}
 
 	if resp.StatusCode() != 200 {
-		return nil, fmt.Errorf("ping request failed with status %d: %s", resp.StatusCode(), resp.String())
+		return nil, &StatusError{Request: "ping", StatusCode: resp.StatusCode(), Body: resp.String()}
 	}
 
 	result, ok := resp.Result().(*models.PingResponse)
@@ -104,7 +171,7 @@ func (c *Client) SendUpdate(ctx context.Context, payload *models.ReportPayload)
 	}
 
 	if resp.StatusCode() != 200 {
-		return nil, fmt.Errorf("update request failed with status %d: %s", resp.StatusCode(), resp.String())
+		return nil, &StatusError{Request: "update", StatusCode: resp.StatusCode(), Body: resp.String()}
 	}
 
 	result, ok := resp.Result().(*models.UpdateResponse)
@@ -115,6 +182,103 @@ func (c *Client) SendUpdate(ctx context.Context, payload *models.ReportPayload)
 	return result, nil
 }
 
+// SendExportedUpdate sends a report exported on another host with report
+// --export. The payload is sent exactly as it was signed, and the signature,
+// export time and format version are forwarded in headers so the server can
+// check them against the API key.
+func (c *Client) SendExportedUpdate(ctx context.Context, report *models.ExportedReport, payload []byte) (*models.UpdateResponse, error) {
+	url := fmt.Sprintf("%s/api/%s/hosts/update", c.config.PatchmonServer, c.config.APIVersion)
+
+	c.logger.WithFields(logrus.Fields{
+		"url":    url,
+		"method": "POST",
+	}).Debug("Sending exported update to server")
+
+	resp, err := c.client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("X-API-ID", c.credentials.APIID).
+		SetHeader("X-API-KEY", c.credentials.APIKey).
+		SetHeader("X-Export-Version", strconv.Itoa(report.Version)).
+		SetHeader("X-Exported-At", report.ExportedAt).
+		SetHeader("X-Export-Signature", report.Signature).
+		SetBody(payload).
+		SetResult(&models.UpdateResponse{}).
+		Post(url)
+
+	if err != nil {
+		return nil, fmt.Errorf("update request failed: %w", err)
+	}
+
+	if resp.StatusCode() != 200 {
+		return nil, &StatusError{Request: "update", StatusCode: resp.StatusCode(), Body: resp.String()}
+	}
+
+	result, ok := resp.Result().(*models.UpdateResponse)
+	if !ok {
+		return nil, fmt.Errorf("invalid response format")
+	}
+
+	return result, nil
+}
+
+// SendInstallResults reports the outcome of an update installation run to the server
+func (c *Client) SendInstallResults(ctx context.Context, payload *models.InstallResultPayload) (*models.InstallResultResponse, error) {
+	url := fmt.Sprintf("%s/api/%s/hosts/install-results", c.config.PatchmonServer, c.config.APIVersion)
+
+	c.logger.WithFields(logrus.Fields{
+		"url":    url,
+		"method": "POST",
+	}).Debug("Sending install results to server")
+
+	resp, err := c.client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("X-API-ID", c.credentials.APIID).
+		SetHeader("X-API-KEY", c.credentials.APIKey).
+		SetBody(payload).
+		SetResult(&models.InstallResultResponse{}).
+		Post(url)
+
+	if err != nil {
+		return nil, fmt.Errorf("install results request failed: %w", err)
+	}
+
+	if resp.StatusCode() != 200 {
+		return nil, &StatusError{Request: "install results", StatusCode: resp.StatusCode(), Body: resp.String()}
+	}
+
+	result, ok := resp.Result().(*models.InstallResultResponse)
+	if !ok {
+		return nil, fmt.Errorf("invalid response format")
+	}
+
+	return result, nil
+}
+
+// SendInstallProgress sends an intermediate install or self-update progress event
+func (c *Client) SendInstallProgress(ctx context.Context, event *models.InstallProgressEvent) error {
+	url := fmt.Sprintf("%s/api/%s/hosts/install-progress", c.config.PatchmonServer, c.config.APIVersion)
+
+	resp, err := c.client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("X-API-ID", c.credentials.APIID).
+		SetHeader("X-API-KEY", c.credentials.APIKey).
+		SetBody(event).
+		Post(url)
+
+	if err != nil {
+		return fmt.Errorf("install progress request failed: %w", err)
+	}
+
+	if resp.StatusCode() != 200 {
+		return &StatusError{Request: "install progress", StatusCode: resp.StatusCode(), Body: resp.String()}
+	}
+
+	return nil
+}
+
 // GetUpdateInterval gets the current update interval from server
 func (c *Client) GetUpdateInterval(ctx context.Context) (*models.UpdateIntervalResponse, error) {
 	url := fmt.Sprintf("%s/api/%s/settings/update-interval", c.config.PatchmonServer, c.config.APIVersion)
@@ -134,7 +298,7 @@ func (c *Client) GetUpdateInterval(ctx context.Context) (*models.UpdateIntervalR
 	}
 
 	if resp.StatusCode() != 200 {
-		return nil, fmt.Errorf("update interval request failed with status %d: %s", resp.StatusCode(), resp.String())
+		return nil, &StatusError{Request: "update interval", StatusCode: resp.StatusCode(), Body: resp.String()}
 	}
 
 	result, ok := resp.Result().(*models.UpdateIntervalResponse)
@@ -145,6 +309,45 @@ func (c *Client) GetUpdateInterval(ctx context.Context) (*models.UpdateIntervalR
 	return result, nil
 }
 
+// GetPublicIP asks the server for the source address of this request, which
+// is the agent's public egress IP when the host is behind NAT
+func (c *Client) GetPublicIP(ctx context.Context) (string, error) {
+	if c.credentials == nil {
+		return "", ErrNoCredentials
+	}
+	url := fmt.Sprintf("%s/api/%s/hosts/public-ip", c.config.PatchmonServer, c.config.APIVersion)
+
+	c.logger.Debug("Getting public IP from server")
+
+	resp, err := c.client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("X-API-ID", c.credentials.APIID).
+		SetHeader("X-API-KEY", c.credentials.APIKey).
+		SetResult(&models.PublicIPResponse{}).
+		Get(url)
+
+	if err != nil {
+		return "", fmt.Errorf("public IP request failed: %w", err)
+	}
+
+	if resp.StatusCode() != 200 {
+		return "", &StatusError{Request: "public IP", StatusCode: resp.StatusCode(), Body: resp.String()}
+	}
+
+	result, ok := resp.Result().(*models.PublicIPResponse)
+	if !ok {
+		return "", fmt.Errorf("invalid response format")
+	}
+
+	ip := net.ParseIP(result.IP)
+	if ip == nil {
+		return "", fmt.Errorf("server returned an invalid IP address %q", result.IP)
+	}
+
+	return ip.String(), nil
+}
+
 // SendDockerData sends Docker integration data to the server
 func (c *Client) SendDockerData(ctx context.Context, payload *models.DockerPayload) (*models.DockerResponse, error) {
 	url := fmt.Sprintf("%s/api/%s/integrations/docker", c.config.PatchmonServer, c.config.APIVersion)
@@ -168,7 +371,7 @@ func (c *Client) SendDockerData(ctx context.Context, payload *models.DockerPaylo
 	}
 
 	if resp.StatusCode() != 200 {
-		return nil, fmt.Errorf("docker data request failed with status %d: %s", resp.StatusCode(), resp.String())
+		return nil, &StatusError{Request: "docker data", StatusCode: resp.StatusCode(), Body: resp.String()}
 	}
 
 	result, ok := resp.Result().(*models.DockerResponse)
@@ -198,7 +401,7 @@ func (c *Client) GetIntegrationStatus(ctx context.Context) (*models.IntegrationS
 	}
 
 	if resp.StatusCode() != 200 {
-		return nil, fmt.Errorf("integration status request failed with status %d: %s", resp.StatusCode(), resp.String())
+		return nil, &StatusError{Request: "integration status", StatusCode: resp.StatusCode(), Body: resp.String()}
 	}
 
 	result, ok := resp.Result().(*models.IntegrationStatusResponse)
@@ -0,0 +1,133 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"patchmon-agent/internal/config"
+	"patchmon-agent/pkg/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantAuth    bool
+		wantNetwork bool
+	}{
+		{"unauthorized", &StatusError{Request: "update", StatusCode: 401}, true, false},
+		{"forbidden, wrapped", fmt.Errorf("failed to send report: %w", &StatusError{Request: "update", StatusCode: 403}), true, false},
+		{"bad gateway", &StatusError{Request: "ping", StatusCode: 502}, false, true},
+		{"bad request", &StatusError{Request: "ping", StatusCode: 400}, false, false},
+		{"connection refused", fmt.Errorf("ping request failed: %w", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}), false, true},
+		{"DNS failure", &net.DNSError{Err: "no such host", Name: "patchmon.example.com"}, false, true},
+		{"other", errors.New("invalid response format"), false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsAuthError(tt.err); got != tt.wantAuth {
+				t.Errorf("IsAuthError() = %v, want %v", got, tt.wantAuth)
+			}
+			if got := IsNetworkError(tt.err); got != tt.wantNetwork {
+				t.Errorf("IsNetworkError() = %v, want %v", got, tt.wantNetwork)
+			}
+		})
+	}
+}
+
+func TestGetPublicIPWithoutCredentials(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"ip":"203.0.113.7"}`)
+	}))
+	defer server.Close()
+
+	cfgManager := config.New()
+	cfgManager.GetConfig().PatchmonServer = server.URL
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	// Collecting a report without sending it never loads the credentials
+	if _, err := New(cfgManager, logger).GetPublicIP(context.Background()); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("GetPublicIP() error = %v, want ErrNoCredentials", err)
+	}
+	if requests != 0 {
+		t.Errorf("server received %d requests, want none", requests)
+	}
+}
+
+func TestSendExportedUpdate(t *testing.T) {
+	var headers http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		body, _ = io.ReadAll(r.Body)
+		fmt.Fprint(w, `{"message":"ok","packagesProcessed":1}`)
+	}))
+	defer server.Close()
+
+	t.Setenv("PATCHMON_API_ID", "patchmon_abc")
+	t.Setenv("PATCHMON_API_KEY", "secret")
+	cfgManager := config.New()
+	cfgManager.GetConfig().PatchmonServer = server.URL
+	if err := cfgManager.LoadCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	report := &models.ExportedReport{Version: 2, APIID: "patchmon_abc", ExportedAt: "2024-03-12T08:00:00Z", Signature: "abcdef"}
+	payload := []byte(`{"hostname":"srv01","extra":true}`)
+	if _, err := New(cfgManager, logger).SendExportedUpdate(context.Background(), report, payload); err != nil {
+		t.Fatalf("SendExportedUpdate() error = %v", err)
+	}
+	if string(body) != string(payload) {
+		t.Errorf("body = %s, want the signed payload unchanged", body)
+	}
+	want := map[string]string{"X-Export-Version": "2", "X-Exported-At": "2024-03-12T08:00:00Z", "X-Export-Signature": "abcdef"}
+	for name, value := range want {
+		if got := headers.Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://patchmon.example.com", nil)
+	envProxy, _ := http.ProxyFromEnvironment(req)
+
+	tests := []struct {
+		name      string
+		cfg       models.Config
+		wantProxy *url.URL
+		wantSkip  bool
+	}{
+		{"environment", models.Config{}, envProxy, false},
+		{"proxy setting", models.Config{Proxy: "http://proxy:8080"}, &url.URL{Scheme: "http", Host: "proxy:8080"}, false},
+		{"skip verification", models.Config{SkipSSLVerify: true}, envProxy, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := NewTransport(&tt.cfg)
+			proxyURL, err := transport.Proxy(req)
+			if err != nil || fmt.Sprint(proxyURL) != fmt.Sprint(tt.wantProxy) {
+				t.Errorf("Proxy() = %v, %v, want %v", proxyURL, err, tt.wantProxy)
+			}
+			skip := transport.TLSClientConfig != nil && transport.TLSClientConfig.InsecureSkipVerify
+			if skip != tt.wantSkip {
+				t.Errorf("InsecureSkipVerify = %v, want %v", skip, tt.wantSkip)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// restrictedFileSDDL grants full control to SYSTEM and Administrators only,
+// without inheriting the permissions of the parent directory
+const restrictedFileSDDL = "D:P(A;;FA;;;SY)(A;;FA;;;BA)"
+
+// readAccessMask covers the rights that allow reading a file's contents
+const readAccessMask = windows.FILE_READ_DATA | windows.GENERIC_READ | windows.GENERIC_ALL
+
+// broadGroups are the principals that cover ordinary users
+var broadGroups = []struct {
+	sid  windows.WELL_KNOWN_SID_TYPE
+	name string
+}{
+	{windows.WinWorldSid, "Everyone"},
+	{windows.WinAuthenticatedUserSid, "Authenticated Users"},
+	{windows.WinBuiltinUsersSid, "Users"},
+}
+
+// RestrictFileACL replaces the permissions of a file so that only SYSTEM and
+// Administrators can access it
+func RestrictFileACL(path string) error {
+	sd, err := windows.SecurityDescriptorFromString(restrictedFileSDDL)
+	if err != nil {
+		return err
+	}
+	dacl, _, err := sd.DACL()
+	if err != nil {
+		return err
+	}
+	if err := windows.SetNamedSecurityInfo(path, windows.SE_FILE_OBJECT,
+		windows.DACL_SECURITY_INFORMATION|windows.PROTECTED_DACL_SECURITY_INFORMATION, nil, nil, dacl, nil); err != nil {
+		return fmt.Errorf("error setting permissions on %s: %w", path, err)
+	}
+	return nil
+}
+
+// BroadReadAccess returns the broad groups (Everyone, Authenticated Users,
+// Users) that are allowed to read a file
+func BroadReadAccess(path string) ([]string, error) {
+	sd, err := windows.GetNamedSecurityInfo(path, windows.SE_FILE_OBJECT, windows.DACL_SECURITY_INFORMATION)
+	if err != nil {
+		return nil, err
+	}
+	dacl, _, err := sd.DACL()
+	if err != nil {
+		return nil, err
+	}
+	// A NULL DACL grants everyone full access
+	if dacl == nil {
+		return []string{"Everyone"}, nil
+	}
+
+	var groups []string
+	seen := make(map[string]bool)
+	for i := uint16(0); i < dacl.AceCount; i++ {
+		var ace *windows.ACCESS_ALLOWED_ACE
+		if err := windows.GetAce(dacl, uint32(i), &ace); err != nil {
+			return nil, err
+		}
+		if ace.Header.AceType != windows.ACCESS_ALLOWED_ACE_TYPE || !grantsRead(ace.Mask) {
+			continue
+		}
+		sid := (*windows.SID)(unsafe.Pointer(&ace.SidStart))
+		for _, g := range broadGroups {
+			if sid.IsWellKnown(g.sid) && !seen[g.name] {
+				groups = append(groups, g.name)
+				seen[g.name] = true
+			}
+		}
+	}
+	return groups, nil
+}
+
+// grantsRead reports whether an access mask allows reading file contents
+func grantsRead(mask windows.ACCESS_MASK) bool {
+	return mask&readAccessMask != 0
+}
@@ -6,7 +6,10 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 
+	"patchmon-agent/internal/constants"
 	"patchmon-agent/pkg/models"
 
 	"github.com/spf13/viper"
@@ -18,13 +21,24 @@ const (
 	DefaultConfigFile      = `C:\ProgramData\PatchMon\config.yml`
 	DefaultCredentialsFile = `C:\ProgramData\PatchMon\credentials.yml`
 	DefaultLogFile         = `C:\ProgramData\PatchMon\logs\patchmon-agent.log`
+	DefaultStateFile       = `C:\ProgramData\PatchMon\state.db` // caches, report history and markers
 	DefaultLogLevel        = "info"
+	DefaultLogMaxSize      = 10 // megabytes
+	DefaultLogMaxBackups   = 5
+	DefaultLogMaxAge       = 14 // days
 )
 
+// Host tags: names start with a letter or digit, values are free text
+var tagNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+const maxTagValueLength = 255
+
 // AvailableIntegrations lists all integrations that can be enabled/disabled
 // Add new integrations here as they are implemented
 var AvailableIntegrations = []string{
-	// Future: Windows-specific integrations
+	constants.IntegrationScoop,
+	constants.IntegrationDefender,
+	constants.IntegrationDocker,
 }
 
 // Manager handles configuration management
@@ -32,6 +46,9 @@ type Manager struct {
 	config      *models.Config
 	credentials *models.Credentials
 	configFile  string
+	// envOverrides holds the keys loaded from PATCHMON_* variables and their
+	// values, which are not saved to the config file
+	envOverrides map[string]any
 }
 
 // New creates a new configuration manager
@@ -45,6 +62,9 @@ func New() *Manager {
 			LogLevel:        DefaultLogLevel,
 			UpdateInterval:  60, // Default to 60 minutes
 			Integrations:    make(map[string]bool),
+			LogMaxSize:      DefaultLogMaxSize,
+			LogMaxBackups:   DefaultLogMaxBackups,
+			LogMaxAge:       DefaultLogMaxAge,
 		},
 		configFile: DefaultConfigFile,
 	}
@@ -70,20 +90,19 @@ func (m *Manager) GetCredentials() *models.Credentials {
 	return m.credentials
 }
 
-// LoadConfig loads configuration from file
+// LoadConfig loads configuration from file. PATCHMON_* environment variables
+// override the file, which is optional.
 func (m *Manager) LoadConfig() error {
-	// Check if config file exists
-	if _, err := os.Stat(m.configFile); errors.Is(err, fs.ErrNotExist) {
-		// Use defaults if config file doesn't exist
-		return nil
-	}
+	// Use defaults if config file doesn't exist
+	if _, err := os.Stat(m.configFile); err == nil {
+		viper.SetConfigFile(m.configFile)
+		viper.SetConfigType("yaml")
 
-	viper.SetConfigFile(m.configFile)
-	viper.SetConfigType("yaml")
-
-	if err := viper.ReadInConfig(); err != nil {
-		return fmt.Errorf("error reading config file: %w", err)
+		if err := viper.ReadInConfig(); err != nil {
+			return fmt.Errorf("error reading config file: %w", err)
+		}
 	}
+	bindEnv(viper.GetViper())
 
 	if err := viper.Unmarshal(m.config); err != nil {
 		return fmt.Errorf("error unmarshaling config: %w", err)
@@ -95,6 +114,17 @@ func (m *Manager) LoadConfig() error {
 		m.config.UpdateInterval = 60
 	}
 
+	// Log rotation settings that are missing or not positive use the defaults
+	if m.config.LogMaxSize <= 0 {
+		m.config.LogMaxSize = DefaultLogMaxSize
+	}
+	if m.config.LogMaxBackups <= 0 {
+		m.config.LogMaxBackups = DefaultLogMaxBackups
+	}
+	if m.config.LogMaxAge <= 0 {
+		m.config.LogMaxAge = DefaultLogMaxAge
+	}
+
 	// If Integrations map is nil (not set in old configs), initialize it
 	if m.config.Integrations == nil {
 		m.config.Integrations = make(map[string]bool)
@@ -111,11 +141,18 @@ func (m *Manager) LoadConfig() error {
 	// ReportOffset can be 0 - it will be recalculated if missing
 	// No need to set a default here as it's calculated dynamically
 
+	m.envOverrides = envOverrides(m.config)
 	return nil
 }
 
-// LoadCredentials loads API credentials from file
+// LoadCredentials loads API credentials from PATCHMON_API_ID and
+// PATCHMON_API_KEY if both are set, otherwise from file
 func (m *Manager) LoadCredentials() error {
+	if creds := envCredentials(); creds != nil {
+		m.credentials = creds
+		return nil
+	}
+
 	if _, err := os.Stat(m.config.CredentialsFile); errors.Is(err, fs.ErrNotExist) {
 		return fmt.Errorf("credentials file not found at %s", m.config.CredentialsFile)
 	}
@@ -160,8 +197,8 @@ func (m *Manager) SaveCredentials(apiID, apiKey string) error {
 		return fmt.Errorf("error writing credentials file: %w", err)
 	}
 
-	// Set restrictive permissions
-	if err := os.Chmod(m.config.CredentialsFile, 0600); err != nil {
+	// Only SYSTEM and Administrators may read the API key
+	if err := RestrictFileACL(m.config.CredentialsFile); err != nil {
 		return fmt.Errorf("error setting credentials file permissions: %w", err)
 	}
 
@@ -174,16 +211,6 @@ func (m *Manager) SaveConfig() error {
 		return err
 	}
 
-	configViper := viper.New()
-	configViper.Set("patchmon_server", m.config.PatchmonServer)
-	configViper.Set("api_version", m.config.APIVersion)
-	configViper.Set("credentials_file", m.config.CredentialsFile)
-	configViper.Set("log_file", m.config.LogFile)
-	configViper.Set("log_level", m.config.LogLevel)
-	configViper.Set("skip_ssl_verify", m.config.SkipSSLVerify)
-	configViper.Set("update_interval", m.config.UpdateInterval)
-	configViper.Set("report_offset", m.config.ReportOffset)
-
 	// Always save integrations map with all available integrations
 	// This ensures config.yml always shows all integrations with their current state
 	// Ensure all available integrations are present before saving
@@ -195,15 +222,104 @@ func (m *Manager) SaveConfig() error {
 			m.config.Integrations[integrationName] = false
 		}
 	}
-	configViper.Set("integrations", m.config.Integrations)
+	cfg, err := m.savedConfig()
+	if err != nil {
+		return err
+	}
+
+	configViper := viper.New()
+	configViper.Set("patchmon_server", cfg.PatchmonServer)
+	configViper.Set("api_version", cfg.APIVersion)
+	configViper.Set("credentials_file", cfg.CredentialsFile)
+	configViper.Set("log_file", cfg.LogFile)
+	configViper.Set("log_level", cfg.LogLevel)
+	configViper.Set("skip_ssl_verify", cfg.SkipSSLVerify)
+	configViper.Set("update_interval", cfg.UpdateInterval)
+	configViper.Set("report_offset", cfg.ReportOffset)
+	configViper.Set("offline_scan_cab", cfg.OfflineScanCab)
+	configViper.Set("wua_installed_criteria", cfg.WUAInstalledCriteria)
+	configViper.Set("wua_available_criteria", cfg.WUAAvailableCriteria)
+	configViper.Set("wua_search_timeout", cfg.WUASearchTimeout)
+	configViper.Set("exclude_packages", cfg.ExcludePackages)
+	configViper.Set("include_categories", cfg.IncludeCategories)
+	configViper.Set("exclude_categories", cfg.ExcludeCategories)
+	configViper.Set("cve_lookup", cfg.CVELookup)
+	configViper.Set("cve_lookup_months", cfg.CVELookupMonths)
+	configViper.Set("reboot_notification", cfg.RebootNotification)
+	configViper.Set("reboot_snooze_minutes", cfg.RebootSnoozeMinutes)
+	configViper.Set("pre_install_hooks", cfg.PreInstallHooks)
+	configViper.Set("post_install_hooks", cfg.PostInstallHooks)
+	configViper.Set("security_posture", cfg.SecurityPosture)
+	configViper.Set("cert_expiry_days", cfg.CertExpiryDays)
+	configViper.Set("extended_inventory", cfg.ExtendedInventory)
+	configViper.Set("inventory_per_user", cfg.InventoryPerUser)
+	configViper.Set("event_log_summary", cfg.EventLogSummary)
+	configViper.Set("resource_metrics", cfg.ResourceMetrics)
+	configViper.Set("metrics_sample_seconds", cfg.MetricsSampleSeconds)
+	configViper.Set("prometheus_metrics", cfg.PrometheusMetrics)
+	configViper.Set("prometheus_port", cfg.PrometheusPort)
+	configViper.Set("machine_id_source", cfg.MachineIDSource)
+	configViper.Set("public_ip", cfg.PublicIP)
+	configViper.Set("exclude_interfaces", cfg.ExcludeInterfaces)
+	configViper.Set("primary_ip_subnet", cfg.PrimaryIPSubnet)
+	configViper.Set("primary_ip_interface", cfg.PrimaryIPInterface)
+	configViper.Set("interface_counters", cfg.InterfaceCounters)
+	configViper.Set("proxy", cfg.Proxy)
+	configViper.Set("event_log", cfg.EventLog)
+	configViper.Set("syslog_server", cfg.SyslogServer)
+	configViper.Set("syslog_level", cfg.SyslogLevel)
+	configViper.Set("syslog_skip_tls_verify", cfg.SyslogSkipTLSVerify)
+	configViper.Set("log_output", cfg.LogOutput)
+	configViper.Set("log_max_size", cfg.LogMaxSize)
+	configViper.Set("log_max_backups", cfg.LogMaxBackups)
+	configViper.Set("log_max_age", cfg.LogMaxAge)
+	configViper.Set("powershell_timeout", cfg.PowerShellTimeout)
+	configViper.Set("http_timeout", cfg.HTTPTimeout)
+	configViper.Set("report_sections", cfg.ReportSections)
+	configViper.Set("full_report_interval", cfg.FullReportInterval)
+	configViper.Set("integrations_sync", cfg.IntegrationsSync)
+	configViper.Set("report_history", cfg.ReportHistory)
+	configViper.Set("tags", cfg.Tags)
+	configViper.Set("site", cfg.Site)
+	configViper.Set("environment", cfg.Environment)
+	configViper.Set("role", cfg.Role)
+
+	configViper.Set("integrations", cfg.Integrations)
 
 	if err := configViper.WriteConfigAs(m.configFile); err != nil {
 		return fmt.Errorf("error writing config file: %w", err)
 	}
 
+	// The config can hold hook commands and the proxy, so restrict it too
+	if err := RestrictFileACL(m.configFile); err != nil {
+		return fmt.Errorf("error setting config file permissions: %w", err)
+	}
+
 	return nil
 }
 
+// savedConfig returns the configuration to write to the config file, in
+// which keys set from the environment keep their values from file
+func (m *Manager) savedConfig() (*models.Config, error) {
+	if len(m.envOverrides) == 0 {
+		return m.config, nil
+	}
+
+	file := New().config
+	if _, err := os.Stat(m.configFile); err == nil {
+		fileViper := viper.New()
+		fileViper.SetConfigFile(m.configFile)
+		fileViper.SetConfigType("yaml")
+		if err := fileViper.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("error reading config file: %w", err)
+		}
+		if err := fileViper.Unmarshal(file); err != nil {
+			return nil, fmt.Errorf("error unmarshaling config: %w", err)
+		}
+	}
+	return withoutEnvOverrides(m.config, file, m.envOverrides), nil
+}
+
 // SetUpdateInterval sets the update interval and saves it to config file
 func (m *Manager) SetUpdateInterval(interval int) error {
 	if interval <= 0 {
@@ -241,6 +357,74 @@ func (m *Manager) SetIntegrationEnabled(name string, enabled bool) error {
 	return m.SaveConfig()
 }
 
+// ApplyIntegrationStatus enables or disables the available integrations as in
+// status, e.g. the toggles set on the server, and saves the config if any of
+// them changed. Unknown integrations are ignored. It returns the names of the
+// changed integrations.
+func (m *Manager) ApplyIntegrationStatus(status map[string]bool) ([]string, error) {
+	if m.config.Integrations == nil {
+		m.config.Integrations = make(map[string]bool)
+	}
+	var changed []string
+	for _, name := range AvailableIntegrations {
+		enabled, exists := status[name]
+		if !exists || m.config.Integrations[name] == enabled {
+			continue
+		}
+		m.config.Integrations[name] = enabled
+		changed = append(changed, name)
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+	return changed, m.SaveConfig()
+}
+
+// GroupingHints are the config keys that can be set with SetGroupingHint
+var GroupingHints = []string{"site", "environment", "role"}
+
+// SetGroupingHint sets the site, environment or role sent with every report
+// and saves it to the config file; an empty value clears it
+func (m *Manager) SetGroupingHint(key, value string) error {
+	value = strings.TrimSpace(value)
+	if len(value) > maxTagValueLength {
+		return fmt.Errorf("%s is longer than %d characters", key, maxTagValueLength)
+	}
+	switch strings.ToLower(key) {
+	case "site":
+		m.config.Site = value
+	case "environment":
+		m.config.Environment = value
+	case "role":
+		m.config.Role = value
+	default:
+		return fmt.Errorf("unknown key %q (expected %s)", key, strings.Join(GroupingHints, ", "))
+	}
+	return m.SaveConfig()
+}
+
+// SetTag sets a host tag and saves it to the config file; an empty value
+// removes the tag. Tag names are lower case, as config keys are
+// case-insensitive.
+func (m *Manager) SetTag(name, value string) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !tagNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid tag name %q (letters, digits, '_' and '-', at most 63 characters)", name)
+	}
+	if len(value) > maxTagValueLength {
+		return fmt.Errorf("tag value of %s is longer than %d characters", name, maxTagValueLength)
+	}
+	if value == "" {
+		delete(m.config.Tags, name)
+	} else {
+		if m.config.Tags == nil {
+			m.config.Tags = make(map[string]string)
+		}
+		m.config.Tags[name] = value
+	}
+	return m.SaveConfig()
+}
+
 // setupDirectories creates necessary directories
 func (m *Manager) setupDirectories() error {
 	dirs := []string{
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"patchmon-agent/pkg/models"
+)
+
+// EnvPrefix starts the environment variables that override the config file
+const EnvPrefix = "PATCHMON_"
+
+// Environment variables holding the API credentials
+const (
+	EnvAPIID  = EnvPrefix + "API_ID"
+	EnvAPIKey = EnvPrefix + "API_KEY"
+)
+
+// envName returns the environment variable overriding a config key, e.g.
+// PATCHMON_LOG_LEVEL for log_level and PATCHMON_SERVER for patchmon_server
+func envName(key string) string {
+	key = strings.TrimPrefix(key, "patchmon_")
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// envKeys returns every config key that can be given as a single value
+// (strings, numbers, booleans and comma-separated lists) and each
+// integration. Hooks cannot be set from the environment.
+func envKeys() []string {
+	var keys []string
+	t := reflect.TypeOf(models.Config{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("mapstructure")
+		if key == "" {
+			continue
+		}
+		switch field.Type.Kind() {
+		case reflect.String, reflect.Bool, reflect.Int:
+		case reflect.Slice:
+			if field.Type.Elem().Kind() != reflect.String {
+				continue
+			}
+		default:
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	for _, name := range AvailableIntegrations {
+		keys = append(keys, "integrations."+name)
+	}
+	return keys
+}
+
+// bindEnv binds each of the envKeys to its environment variable
+func bindEnv(v *viper.Viper) {
+	for _, key := range envKeys() {
+		_ = v.BindEnv(key, envName(key))
+	}
+}
+
+// envOverrides returns the keys set from the environment with the values
+// they were loaded with
+func envOverrides(cfg *models.Config) map[string]any {
+	overrides := make(map[string]any)
+	for _, key := range envKeys() {
+		if os.Getenv(envName(key)) != "" {
+			overrides[key] = configValue(cfg, key)
+		}
+	}
+	return overrides
+}
+
+// withoutEnvOverrides returns a copy of cfg in which the keys set from the
+// environment have their values from file again, unless they were changed
+// since they were loaded. Saving it keeps temporary overrides out of
+// config.yml.
+func withoutEnvOverrides(cfg, file *models.Config, overrides map[string]any) *models.Config {
+	saved := *cfg
+	saved.Integrations = maps.Clone(cfg.Integrations)
+	for key, loaded := range overrides {
+		if reflect.DeepEqual(configValue(cfg, key), loaded) {
+			setConfigValue(&saved, key, configValue(file, key))
+		}
+	}
+	return &saved
+}
+
+// configValue returns the value of a config key that can be set from the
+// environment
+func configValue(cfg *models.Config, key string) any {
+	if name, ok := strings.CutPrefix(key, "integrations."); ok {
+		return cfg.Integrations[name]
+	}
+	value := configField(cfg, key).Interface()
+	if list, ok := value.([]string); ok {
+		return slices.Clone(list)
+	}
+	return value
+}
+
+// setConfigValue sets a config key that can be set from the environment
+func setConfigValue(cfg *models.Config, key string, value any) {
+	if name, ok := strings.CutPrefix(key, "integrations."); ok {
+		if cfg.Integrations == nil {
+			cfg.Integrations = make(map[string]bool)
+		}
+		cfg.Integrations[name] = value.(bool)
+		return
+	}
+	configField(cfg, key).Set(reflect.ValueOf(value))
+}
+
+// configField returns the field of a config key
+func configField(cfg *models.Config, key string) reflect.Value {
+	v := reflect.ValueOf(cfg).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("mapstructure") == key {
+			return v.Field(i)
+		}
+	}
+	panic("unknown config key " + key)
+}
+
+// envCredentials returns the credentials from the environment, or nil unless
+// both the API ID and key are set
+func envCredentials() *models.Credentials {
+	apiID, apiKey := os.Getenv(EnvAPIID), os.Getenv(EnvAPIKey)
+	if apiID == "" || apiKey == "" {
+		return nil
+	}
+	return &models.Credentials{APIID: apiID, APIKey: apiKey}
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"patchmon-agent/pkg/models"
+)
+
+// Files of the Linux agent in /etc/patchmon. Agents before the Go rewrite
+// kept the credentials in a shell-style "credentials" file.
+const (
+	linuxConfigFile            = "config.yml"
+	linuxCredentialsFile       = "credentials.yml"
+	linuxLegacyCredentialsFile = "credentials"
+)
+
+// ImportResult describes what ImportLinuxConfig took over from the Linux agent
+type ImportResult struct {
+	ConfigFile      string
+	CredentialsFile string
+	Ignored         []string // settings that do not apply on Windows
+}
+
+// ImportLinuxConfig reads the configuration and credentials of the Linux agent
+// from path, either its config directory (/etc/patchmon) or its config.yml,
+// and saves them in the Windows locations. Paths are replaced by the Windows
+// defaults and integrations that do not exist on Windows are dropped.
+func (m *Manager) ImportLinuxConfig(path string) (*ImportResult, error) {
+	configFile := path
+	if info, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", path, err)
+	} else if info.IsDir() {
+		configFile = filepath.Join(path, linuxConfigFile)
+	}
+	dir := filepath.Dir(configFile)
+
+	linux := viper.New()
+	linux.SetConfigFile(configFile)
+	linux.SetConfigType("yaml")
+	if err := linux.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("error reading Linux agent config: %w", err)
+	}
+	var imported models.Config
+	if err := linux.Unmarshal(&imported); err != nil {
+		return nil, fmt.Errorf("error unmarshaling Linux agent config: %w", err)
+	}
+	if imported.PatchmonServer == "" {
+		return nil, fmt.Errorf("%s does not set patchmon_server", configFile)
+	}
+
+	// The credentials file is usually next to the config
+	credsFile := filepath.Join(dir, filepath.Base(imported.CredentialsFile))
+	if imported.CredentialsFile == "" {
+		credsFile = filepath.Join(dir, linuxCredentialsFile)
+	}
+	creds, credsFile, err := readLinuxCredentials(credsFile, filepath.Join(dir, linuxLegacyCredentialsFile))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{ConfigFile: configFile, CredentialsFile: credsFile}
+	result.Ignored = mergeLinuxConfig(m.config, &imported)
+
+	if err := m.SaveConfig(); err != nil {
+		return nil, err
+	}
+	if err := m.SaveCredentials(creds.APIID, creds.APIKey); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// mergeLinuxConfig copies the settings of the Linux agent that apply on
+// Windows into cfg and returns the ones that were left out
+func mergeLinuxConfig(cfg, linux *models.Config) []string {
+	var ignored []string
+
+	cfg.PatchmonServer = linux.PatchmonServer
+	if linux.APIVersion != "" {
+		cfg.APIVersion = linux.APIVersion
+	}
+	if linux.LogLevel != "" {
+		cfg.LogLevel = linux.LogLevel
+	}
+	cfg.SkipSSLVerify = linux.SkipSSLVerify
+	if linux.UpdateInterval > 0 {
+		cfg.UpdateInterval = linux.UpdateInterval
+	}
+	cfg.ReportOffset = linux.ReportOffset
+
+	// credentials_file and log_file keep their Windows defaults
+	if cfg.Integrations == nil {
+		cfg.Integrations = make(map[string]bool)
+	}
+	for name, enabled := range linux.Integrations {
+		if slices.Contains(AvailableIntegrations, name) {
+			cfg.Integrations[name] = enabled
+		} else {
+			ignored = append(ignored, "integrations."+name)
+		}
+	}
+	slices.Sort(ignored)
+	return ignored
+}
+
+// readLinuxCredentials reads the API credentials from the YAML credentials
+// file, or from the legacy shell-style file if there is none. It returns the
+// file that was used.
+func readLinuxCredentials(yamlFile, legacyFile string) (*models.Credentials, string, error) {
+	creds := &models.Credentials{}
+	file := yamlFile
+	if _, err := os.Stat(yamlFile); err == nil {
+		v := viper.New()
+		v.SetConfigFile(yamlFile)
+		v.SetConfigType("yaml")
+		if err := v.ReadInConfig(); err != nil {
+			return nil, "", fmt.Errorf("error reading Linux agent credentials: %w", err)
+		}
+		if err := v.Unmarshal(creds); err != nil {
+			return nil, "", fmt.Errorf("error unmarshaling Linux agent credentials: %w", err)
+		}
+	} else if errors.Is(err, fs.ErrNotExist) {
+		file = legacyFile
+		var readErr error
+		if creds, readErr = readLegacyCredentials(legacyFile); readErr != nil {
+			return nil, "", fmt.Errorf("no Linux agent credentials found next to the config: %w", readErr)
+		}
+	} else {
+		return nil, "", err
+	}
+
+	if creds.APIID == "" || creds.APIKey == "" {
+		return nil, "", fmt.Errorf("api_id and api_key must be set in %s", file)
+	}
+	return creds, file, nil
+}
+
+// readLegacyCredentials parses API_ID="..." and API_KEY="..." lines
+func readLegacyCredentials(path string) (*models.Credentials, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	creds := &models.Credentials{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		switch strings.TrimSpace(strings.TrimPrefix(key, "export ")) {
+		case "API_ID":
+			creds.APIID = value
+		case "API_KEY":
+			creds.APIKey = value
+		}
+	}
+	return creds, scanner.Err()
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	"patchmon-agent/internal/constants"
+	"patchmon-agent/pkg/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Validate checks the configuration for values the agent cannot use,
+// returning all problems found
+func Validate(cfg *models.Config) error {
+	var errs []error
+	if cfg.PatchmonServer == "" {
+		errs = append(errs, fmt.Errorf("patchmon_server is not set"))
+	} else if err := checkURL(cfg.PatchmonServer, "http", "https"); err != nil {
+		errs = append(errs, fmt.Errorf("patchmon_server: %w", err))
+	}
+	if cfg.Proxy != "" {
+		if err := checkURL(cfg.Proxy, "http", "https", "socks5"); err != nil {
+			errs = append(errs, fmt.Errorf("proxy: %w", err))
+		}
+	}
+
+	if cfg.LogLevel != "" {
+		if _, err := logrus.ParseLevel(cfg.LogLevel); err != nil {
+			errs = append(errs, fmt.Errorf("log_level %q is not one of debug, info, warn, error", cfg.LogLevel))
+		}
+	}
+	outputs := []string{constants.LogOutputFile, constants.LogOutputStdout, constants.LogOutputBoth}
+	if cfg.LogOutput != "" && !slices.Contains(outputs, cfg.LogOutput) {
+		errs = append(errs, fmt.Errorf("log_output %q is not one of %s", cfg.LogOutput, strings.Join(outputs, ", ")))
+	}
+	sources := []string{constants.MachineIDSourceMachineGUID, constants.MachineIDSourceSMBIOSUUID, constants.MachineIDSourceAgent}
+	if cfg.MachineIDSource != "" && !slices.Contains(sources, cfg.MachineIDSource) {
+		errs = append(errs, fmt.Errorf("machine_id_source %q is not one of %s", cfg.MachineIDSource, strings.Join(sources, ", ")))
+	}
+	if cfg.PrimaryIPSubnet != "" {
+		if _, _, err := net.ParseCIDR(cfg.PrimaryIPSubnet); err != nil {
+			errs = append(errs, fmt.Errorf("primary_ip_subnet %q is not a CIDR subnet", cfg.PrimaryIPSubnet))
+		}
+	}
+	if cfg.UpdateInterval <= 0 {
+		errs = append(errs, fmt.Errorf("update_interval must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+// Warnings returns settings the agent ignores, such as integrations that do
+// not exist on Windows (e.g. copied from a Linux agent config)
+func Warnings(cfg *models.Config) []string {
+	var warnings []string
+	for _, name := range slices.Sorted(maps.Keys(cfg.Integrations)) {
+		if !slices.Contains(AvailableIntegrations, name) {
+			warnings = append(warnings, fmt.Sprintf("unknown integration %q is ignored", name))
+		}
+	}
+	return warnings
+}
+
+// checkURL checks that raw is an absolute URL with one of the schemes
+func checkURL(raw string, schemes ...string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%q is not a valid URL", raw)
+	}
+	if !slices.Contains(schemes, u.Scheme) || u.Host == "" {
+		return fmt.Errorf("%q must be a %s URL with a host", raw, strings.Join(schemes, " or "))
+	}
+	return nil
+}
@@ -35,6 +35,53 @@ const (
 	RepoTypeWindowsUpdate = "windows-update"
 )
 
+// Integration names (keys of the integrations map in config.yml)
+const (
+	IntegrationScoop    = "scoop"
+	IntegrationDefender = "defender"
+	IntegrationDocker   = "docker"
+)
+
+// Machine ID sources (machine_id_source in config.yml)
+const (
+	MachineIDSourceMachineGUID = "machine_guid"
+	MachineIDSourceSMBIOSUUID  = "smbios_uuid"
+	MachineIDSourceAgent       = "agent"
+)
+
+// Update actions, used in install result payloads and server-requested actions
+const (
+	ActionInstall   = "install"
+	ActionDownload  = "download"
+	ActionUninstall = "uninstall"
+	ActionHide      = "hide"
+	ActionUnhide    = "unhide"
+	// ActionReboot and ActionCancelReboot schedule or cancel a restart
+	ActionReboot       = "reboot"
+	ActionCancelReboot = "cancel_reboot"
+	// ActionScan triggers a Windows Update detection cycle
+	ActionScan = "scan"
+	// ActionDefenderScan runs an on-demand Microsoft Defender scan
+	ActionDefenderScan = "defender_scan"
+	// ActionSelfUpdate is the agent replacing its own binary
+	ActionSelfUpdate = "self_update"
+)
+
+// Progress phases reported in install progress events
+const (
+	PhaseDownloading = "downloading"
+	PhaseVerifying   = "verifying"
+	PhaseInstalling  = "installing"
+	PhaseCompleted   = "completed"
+)
+
+// Server capabilities (capabilities in the ping response)
+const (
+	// CapabilityPartialReports means the server keeps the stored data of
+	// sections missing from a partial report
+	CapabilityPartialReports = "partial_reports"
+)
+
 // Log level constants
 const (
 	LogLevelDebug = "debug"
@@ -43,6 +90,13 @@ const (
 	LogLevelError = "error"
 )
 
+// Log output constants
+const (
+	LogOutputFile   = "file"
+	LogOutputStdout = "stdout"
+	LogOutputBoth   = "both"
+)
+
 // Common error messages
 const (
 	ErrUnknownValue = "Unknown"
@@ -0,0 +1,239 @@
+package containers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"patchmon-agent/pkg/models"
+)
+
+// Container engines reported in ContainerInfo.Engine
+const (
+	EngineDocker     = "docker"
+	EngineContainerd = "containerd"
+)
+
+// commandTimeout bounds each container CLI call, so a hung engine does not
+// stall the report
+const commandTimeout = 30 * time.Second
+
+// Manager inventories the container engine and running containers
+type Manager struct {
+	logger *logrus.Logger
+
+	// lookPath finds an executable; replaced in tests
+	lookPath func(name string) (string, error)
+	// output runs a command and returns its standard output; replaced in tests
+	output func(name string, args ...string) ([]byte, error)
+}
+
+// New creates a new container manager
+func New(logger *logrus.Logger) *Manager {
+	return &Manager{
+		logger:   logger,
+		lookPath: findExecutable,
+		output:   runOutput,
+	}
+}
+
+// runOutput runs a command with commandTimeout and returns its standard output
+func runOutput(name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%s timed out after %s", filepath.Base(name), commandTimeout)
+	}
+	return out, err
+}
+
+// findExecutable looks for a container CLI in PATH and in the default install
+// locations, which are not on the PATH of the SYSTEM account
+func findExecutable(name string) (string, error) {
+	if path, err := exec.LookPath(name); err == nil {
+		return path, nil
+	}
+	programFiles := os.Getenv("ProgramFiles")
+	if programFiles == "" {
+		programFiles = `C:\Program Files`
+	}
+	for _, dir := range []string{
+		filepath.Join(programFiles, "Docker"),
+		filepath.Join(programFiles, "Docker", "Docker", "resources", "bin"),
+		filepath.Join(programFiles, "containerd", "bin"),
+	} {
+		path := filepath.Join(dir, name+".exe")
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", exec.ErrNotFound
+}
+
+// dockerServerVersion holds the fields we need from docker version
+type dockerServerVersion struct {
+	Version string `json:"Version"`
+	Os      string `json:"Os"`
+}
+
+// dockerContainer holds the fields we need from a docker ps line
+type dockerContainer struct {
+	ID     string `json:"ID"`
+	Names  string `json:"Names"`
+	Image  string `json:"Image"`
+	Status string `json:"Status"`
+}
+
+// dockerImage holds the fields we need from docker image inspect
+type dockerImage struct {
+	ID        string `json:"Id"`
+	Os        string `json:"Os"`
+	OsVersion string `json:"OsVersion"` // Windows images only, e.g. 10.0.20348.2227
+}
+
+// GetContainerInfo returns the container engine version and the running
+// containers with the OS version of their images. Docker is queried through
+// its CLI; for a standalone containerd only the engine version is reported.
+// It returns nil if no container engine is installed or running.
+func (m *Manager) GetContainerInfo() *models.ContainerInfo {
+	if docker, err := m.lookPath("docker"); err == nil {
+		info, err := m.getDockerInfo(docker)
+		if err == nil {
+			return info
+		}
+		m.logger.WithError(err).Debug("Docker engine not available")
+	}
+
+	if containerd, err := m.lookPath("containerd"); err == nil {
+		out, err := m.output(containerd, "--version")
+		if err != nil {
+			m.logger.WithError(err).Debug("Failed to get containerd version")
+			return nil
+		}
+		return &models.ContainerInfo{
+			Engine:        EngineContainerd,
+			EngineVersion: parseContainerdVersion(string(out)),
+		}
+	}
+
+	return nil
+}
+
+// getDockerInfo queries the Docker engine through the docker CLI
+func (m *Manager) getDockerInfo(docker string) (*models.ContainerInfo, error) {
+	out, err := m.output(docker, "version", "--format", "{{json .Server}}")
+	if err != nil {
+		return nil, fmt.Errorf("docker version failed: %w", err)
+	}
+	var server dockerServerVersion
+	if err := json.Unmarshal(bytes.TrimSpace(out), &server); err != nil || server.Version == "" {
+		return nil, fmt.Errorf("unexpected docker version output: %q", strings.TrimSpace(string(out)))
+	}
+
+	info := &models.ContainerInfo{
+		Engine:        EngineDocker,
+		EngineVersion: server.Version,
+		OSType:        server.Os,
+		Containers:    []models.Container{},
+	}
+
+	out, err = m.output(docker, "ps", "--no-trunc", "--format", "{{json .}}")
+	if err != nil {
+		m.logger.WithError(err).Warn("Failed to list running containers")
+		return info, nil
+	}
+	running := decodeLines[dockerContainer](out)
+	if len(running) == 0 {
+		return info, nil
+	}
+
+	images := m.inspectImages(docker, running)
+	for _, c := range running {
+		container := models.Container{
+			ID:     shortID(c.ID),
+			Name:   c.Names,
+			Image:  c.Image,
+			Status: c.Status,
+		}
+		if image, ok := images[c.Image]; ok {
+			container.ImageID = image.ID
+			container.ImageOSVersion = image.OsVersion
+		}
+		info.Containers = append(info.Containers, container)
+	}
+	return info, nil
+}
+
+// inspectImages inspects the images of the running containers, keyed by the
+// image reference shown by docker ps. Each image is inspected on its own, so
+// one that cannot be inspected, e.g. because it was removed, is left out
+// without affecting the others.
+func (m *Manager) inspectImages(docker string, running []dockerContainer) map[string]dockerImage {
+	images := make(map[string]dockerImage)
+	tried := make(map[string]bool)
+	for _, c := range running {
+		if tried[c.Image] {
+			continue
+		}
+		tried[c.Image] = true
+
+		out, err := m.output(docker, "image", "inspect", "--format", "{{json .}}", c.Image)
+		if err != nil {
+			m.logger.WithError(err).WithField("image", c.Image).Debug("Failed to inspect container image")
+			continue
+		}
+		if inspected := decodeLines[dockerImage](out); len(inspected) > 0 {
+			images[c.Image] = inspected[0]
+		}
+	}
+	return images
+}
+
+// decodeLines decodes one JSON object per line, skipping lines that do not parse
+func decodeLines[T any](out []byte) []T {
+	var values []T
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var value T
+		if err := json.Unmarshal(line, &value); err == nil {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// parseContainerdVersion extracts the version from containerd --version
+// output, e.g. "containerd github.com/containerd/containerd v1.7.13 7c3aca7"
+func parseContainerdVersion(output string) string {
+	for _, field := range strings.Fields(output) {
+		if len(field) > 1 && field[0] == 'v' && field[1] >= '0' && field[1] <= '9' {
+			return field[1:]
+		}
+	}
+	return ""
+}
+
+// shortID shortens a container ID to the 12 characters docker shows
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
@@ -0,0 +1,135 @@
+package containers
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newTestManager(installed map[string]bool, outputs map[string]string) *Manager {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	mgr := New(logger)
+	mgr.lookPath = func(name string) (string, error) {
+		if installed[name] {
+			return name, nil
+		}
+		return "", exec.ErrNotFound
+	}
+	mgr.output = func(name string, args ...string) ([]byte, error) {
+		out, ok := outputs[name+" "+args[0]]
+		if !ok {
+			return nil, errors.New("exit status 1")
+		}
+		return []byte(out), nil
+	}
+	return mgr
+}
+
+func TestGetContainerInfo_Docker(t *testing.T) {
+	mgr := newTestManager(map[string]bool{"docker": true}, map[string]string{
+		"docker version": `{"Platform":{"Name":"Mirantis Container Runtime"},"Version":"24.0.7","Os":"windows","Arch":"amd64"}`,
+		"docker ps": strings.Join([]string{
+			`{"ID":"3f1c2a9b8d7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a","Image":"mcr.microsoft.com/windows/servercore/iis:windowsservercore-ltsc2022","Names":"web","Status":"Up 3 days"}`,
+			`{"ID":"9a8b7c6d5e4f","Image":"mcr.microsoft.com/windows/servercore/iis:windowsservercore-ltsc2022","Names":"web2","Status":"Up 2 hours"}`,
+		}, "\n"),
+		"docker image": `{"Id":"sha256:4e2b","Os":"windows","OsVersion":"10.0.20348.2227"}`,
+	})
+
+	info := mgr.GetContainerInfo()
+	if info == nil {
+		t.Fatal("GetContainerInfo() returned nil")
+	}
+	if info.Engine != EngineDocker || info.EngineVersion != "24.0.7" || info.OSType != "windows" {
+		t.Errorf("engine = %s %s (%s), want docker 24.0.7 (windows)", info.Engine, info.EngineVersion, info.OSType)
+	}
+	if len(info.Containers) != 2 {
+		t.Fatalf("got %d containers, want 2", len(info.Containers))
+	}
+	c := info.Containers[0]
+	if c.ID != "3f1c2a9b8d7e" || c.Name != "web" || c.ImageOSVersion != "10.0.20348.2227" || c.ImageID != "sha256:4e2b" {
+		t.Errorf("unexpected container %+v", c)
+	}
+	if info.Containers[1].ImageOSVersion != "10.0.20348.2227" {
+		t.Errorf("second container image OS version = %q", info.Containers[1].ImageOSVersion)
+	}
+}
+
+func TestGetContainerInfo_DockerImageInspectFails(t *testing.T) {
+	mgr := newTestManager(map[string]bool{"docker": true}, map[string]string{
+		"docker version": `{"Version":"24.0.7","Os":"windows"}`,
+		"docker ps": strings.Join([]string{
+			`{"ID":"3f1c2a9b8d7e","Image":"iis:ltsc2022","Names":"web","Status":"Up 3 days"}`,
+			`{"ID":"9a8b7c6d5e4f","Image":"removed:latest","Names":"old","Status":"Up 2 hours"}`,
+		}, "\n"),
+	})
+	var inspected []string
+	output := mgr.output
+	mgr.output = func(name string, args ...string) ([]byte, error) {
+		if args[0] != "image" {
+			return output(name, args...)
+		}
+		ref := args[len(args)-1]
+		inspected = append(inspected, ref)
+		if ref == "removed:latest" {
+			return nil, errors.New("no such image")
+		}
+		return []byte(`{"Id":"sha256:4e2b","Os":"windows","OsVersion":"10.0.20348.2227"}`), nil
+	}
+
+	info := mgr.GetContainerInfo()
+	if info == nil || len(info.Containers) != 2 {
+		t.Fatalf("GetContainerInfo() = %+v, want 2 containers", info)
+	}
+	if len(inspected) != 2 {
+		t.Errorf("inspected %v, want each image on its own", inspected)
+	}
+	if c := info.Containers[0]; c.ImageID != "sha256:4e2b" || c.ImageOSVersion != "10.0.20348.2227" {
+		t.Errorf("image of %s not reported: %+v", c.Name, c)
+	}
+	if c := info.Containers[1]; c.ImageID != "" || c.ImageOSVersion != "" {
+		t.Errorf("unexpected image data for %s: %+v", c.Name, c)
+	}
+}
+
+func TestGetContainerInfo_DockerNotRunning(t *testing.T) {
+	mgr := newTestManager(map[string]bool{"docker": true}, map[string]string{})
+	if info := mgr.GetContainerInfo(); info != nil {
+		t.Errorf("GetContainerInfo() = %+v, want nil when the engine is not running", info)
+	}
+}
+
+func TestGetContainerInfo_Containerd(t *testing.T) {
+	mgr := newTestManager(map[string]bool{"containerd": true}, map[string]string{
+		"containerd --version": "containerd github.com/containerd/containerd v1.7.13 7c3aca7a610df76212171d200ca3811ff6096eb8\n",
+	})
+
+	info := mgr.GetContainerInfo()
+	if info == nil || info.Engine != EngineContainerd || info.EngineVersion != "1.7.13" {
+		t.Errorf("GetContainerInfo() = %+v, want containerd 1.7.13", info)
+	}
+}
+
+func TestGetContainerInfo_NotInstalled(t *testing.T) {
+	mgr := newTestManager(nil, nil)
+	if info := mgr.GetContainerInfo(); info != nil {
+		t.Errorf("GetContainerInfo() = %+v, want nil", info)
+	}
+}
+
+func TestParseContainerdVersion(t *testing.T) {
+	tests := map[string]string{
+		"containerd github.com/containerd/containerd v1.7.13 7c3aca7": "1.7.13",
+		"containerd containerd.io 1.6.28":                             "",
+		"":                                                            "",
+	}
+	for in, want := range tests {
+		if got := parseContainerdVersion(in); got != want {
+			t.Errorf("parseContainerdVersion(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
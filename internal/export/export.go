@@ -0,0 +1,136 @@
+package export
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"patchmon-agent/pkg/models"
+)
+
+// FormatVersion is the version of the export file format. Version 1 only
+// signed the payload.
+const FormatVersion = 2
+
+// DefaultMaxAge is how old an exported report can be when it is submitted
+const DefaultMaxAge = 7 * 24 * time.Hour
+
+// maxClockSkew is how far in the future an export time is accepted, for
+// hosts whose clocks differ
+const maxClockSkew = time.Hour
+
+// ErrInvalidSignature is returned when an exported report was not signed
+// with the expected API key or was modified after it was exported
+var ErrInvalidSignature = errors.New("invalid report signature")
+
+// ErrExpired is returned when an exported report is older than the maximum
+// age, or claims to be exported in the future
+var ErrExpired = errors.New("exported report has expired")
+
+// New wraps a JSON report payload for export and signs it with the API key
+func New(apiID, apiKey string, payload []byte, exportedAt time.Time) (*models.ExportedReport, error) {
+	report := &models.ExportedReport{
+		Version:    FormatVersion,
+		APIID:      apiID,
+		ExportedAt: exportedAt.UTC().Format(time.RFC3339),
+		Payload:    json.RawMessage(payload),
+	}
+	signature, err := Sign(report, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	report.Signature = signature
+	return report, nil
+}
+
+// Sign returns the hex HMAC-SHA256, keyed with the API key, of the report's
+// format version, API ID, export time and compacted payload. The payload is
+// compacted so reformatting the JSON keeps the signature valid.
+func Sign(report *models.ExportedReport, apiKey string) (string, error) {
+	mac, err := reportMAC(report, apiKey)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(mac), nil
+}
+
+// Verify checks the signature of an exported report against the API key and
+// that it was exported no more than maxAge before now
+func Verify(report *models.ExportedReport, apiKey string, maxAge time.Duration, now time.Time) error {
+	want, err := reportMAC(report, apiKey)
+	if err != nil {
+		return err
+	}
+	got, err := hex.DecodeString(report.Signature)
+	if err != nil || !hmac.Equal(got, want) {
+		return ErrInvalidSignature
+	}
+
+	exportedAt, err := time.Parse(time.RFC3339, report.ExportedAt)
+	if err != nil {
+		return fmt.Errorf("invalid export time %q: %w", report.ExportedAt, err)
+	}
+	if age := now.Sub(exportedAt); age > maxAge || age < -maxClockSkew {
+		return fmt.Errorf("%w: exported at %s, the maximum age is %s", ErrExpired, report.ExportedAt, maxAge)
+	}
+	return nil
+}
+
+// CompactPayload returns the payload as it is signed
+func CompactPayload(report *models.ExportedReport) ([]byte, error) {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, report.Payload); err != nil {
+		return nil, fmt.Errorf("invalid report payload: %w", err)
+	}
+	return compact.Bytes(), nil
+}
+
+// reportMAC computes the HMAC-SHA256 of the envelope fields and the
+// compacted payload, one per line
+func reportMAC(report *models.ExportedReport, apiKey string) ([]byte, error) {
+	payload, err := CompactPayload(report)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, []byte(apiKey))
+	fmt.Fprintf(mac, "%d\n%s\n%s\n", report.Version, report.APIID, report.ExportedAt)
+	mac.Write(payload)
+	return mac.Sum(nil), nil
+}
+
+// Write saves an exported report to path
+func Write(path string, report *models.ExportedReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal exported report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write exported report: %w", err)
+	}
+	return nil
+}
+
+// Read loads an exported report from path
+func Read(path string) (*models.ExportedReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exported report: %w", err)
+	}
+	var report models.ExportedReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse exported report: %w", err)
+	}
+	if report.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported export format version %d", report.Version)
+	}
+	if report.APIID == "" || len(report.Payload) == 0 {
+		return nil, fmt.Errorf("exported report is missing the API ID or payload")
+	}
+	return &report, nil
+}
@@ -0,0 +1,70 @@
+package export
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"patchmon-agent/pkg/models"
+)
+
+func TestWriteReadVerify(t *testing.T) {
+	payload := []byte("{\n  \"hostname\": \"srv01\",\n  \"needsReboot\": false\n}\n")
+	report, err := New("patchmon_abc", "secret", payload, time.Date(2024, 3, 12, 8, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := Write(path, report); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	read, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if read.APIID != "patchmon_abc" || read.ExportedAt != "2024-03-12T08:00:00Z" {
+		t.Errorf("Read() = %+v, want the exported API ID and time", read)
+	}
+
+	// The payload is reindented when written, which must not break the signature
+	if err := Verify(read, "secret", DefaultMaxAge, time.Date(2024, 3, 13, 8, 0, 0, 0, time.UTC)); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	exportedAt := time.Date(2024, 3, 12, 8, 0, 0, 0, time.UTC)
+	now := exportedAt.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		modify  func(report *models.ExportedReport)
+		key     string
+		now     time.Time
+		wantErr error
+	}{
+		{"valid", func(*models.ExportedReport) {}, "secret", now, nil},
+		{"other key", func(*models.ExportedReport) {}, "other", now, ErrInvalidSignature},
+		{"modified payload", func(r *models.ExportedReport) { r.Payload = []byte(`{"hostname":"srv02"}`) }, "secret", now, ErrInvalidSignature},
+		{"modified export time", func(r *models.ExportedReport) { r.ExportedAt = "2024-03-20T08:00:00Z" }, "secret", now, ErrInvalidSignature},
+		{"modified API ID", func(r *models.ExportedReport) { r.APIID = "patchmon_def" }, "secret", now, ErrInvalidSignature},
+		{"malformed signature", func(r *models.ExportedReport) { r.Signature = "not hex" }, "secret", now, ErrInvalidSignature},
+		{"too old", func(*models.ExportedReport) {}, "secret", exportedAt.Add(DefaultMaxAge + time.Minute), ErrExpired},
+		{"from the future", func(*models.ExportedReport) {}, "secret", exportedAt.Add(-2 * time.Hour), ErrExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := New("patchmon_abc", "secret", []byte(`{"hostname":"srv01"}`), exportedAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.modify(report)
+			err = Verify(report, tt.key, DefaultMaxAge, tt.now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
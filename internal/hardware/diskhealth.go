@@ -0,0 +1,155 @@
+package hardware
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/yusufpapurcu/wmi"
+
+	"patchmon-agent/pkg/models"
+)
+
+// storageNamespace is the WMI namespace of the Storage Management API
+const storageNamespace = `root\Microsoft\Windows\Storage`
+
+// Disk health states reported in DiskHealth.Status
+const (
+	DiskHealthy   = "healthy"
+	DiskWarning   = "warning"
+	DiskUnhealthy = "unhealthy"
+	DiskUnknown   = "unknown"
+)
+
+// healthStatuses maps MSFT_PhysicalDisk.HealthStatus values. The status
+// includes the drive's SMART predictive failure state.
+var healthStatuses = map[uint16]string{
+	0: DiskHealthy,
+	1: DiskWarning,
+	2: DiskUnhealthy,
+}
+
+// mediaTypes maps MSFT_PhysicalDisk.MediaType values
+var mediaTypes = map[uint16]string{
+	3: "hdd",
+	4: "ssd",
+	5: "scm",
+}
+
+// busTypes maps MSFT_PhysicalDisk.BusType values
+var busTypes = map[uint16]string{
+	1:  "scsi",
+	3:  "ata",
+	6:  "fibre-channel",
+	7:  "usb",
+	8:  "raid",
+	9:  "iscsi",
+	10: "sas",
+	11: "sata",
+	12: "sd",
+	13: "mmc",
+	15: "file-backed",
+	16: "storage-spaces",
+	17: "nvme",
+}
+
+// physicalDisk is the physical disk behind a volume
+type physicalDisk struct {
+	model    string
+	firmware string
+	busType  string
+	health   *models.DiskHealth
+}
+
+// msftPartition maps the WMI MSFT_Partition class
+type msftPartition struct {
+	DiskNumber  uint32
+	DriveLetter uint16
+}
+
+// msftPhysicalDisk maps the WMI MSFT_PhysicalDisk class
+type msftPhysicalDisk struct {
+	DeviceId        string
+	FriendlyName    string
+	Model           string
+	FirmwareVersion string
+	BusType         uint16
+	MediaType       uint16
+	HealthStatus    uint16
+}
+
+// msftStorageReliabilityCounter maps the WMI MSFT_StorageReliabilityCounter class
+type msftStorageReliabilityCounter struct {
+	DeviceId               string
+	Wear                   uint8
+	Temperature            uint8
+	PowerOnHours           uint32
+	ReadErrorsUncorrected  uint64
+	WriteErrorsUncorrected uint64
+}
+
+// getPhysicalDisks returns the model, firmware and health of the physical
+// disk behind each drive letter (e.g. "C:"). Reliability counters need
+// administrative rights and are left empty if they cannot be read.
+func (m *Manager) getPhysicalDisks() map[string]physicalDisk {
+	var partitions []msftPartition
+	if err := wmi.QueryNamespace("SELECT DiskNumber, DriveLetter FROM MSFT_Partition", &partitions, storageNamespace); err != nil {
+		m.logger.WithError(err).Debug("Failed to query MSFT_Partition")
+		return nil
+	}
+	var disks []msftPhysicalDisk
+	if err := wmi.QueryNamespace("SELECT DeviceId, FriendlyName, Model, FirmwareVersion, BusType, MediaType, HealthStatus FROM MSFT_PhysicalDisk", &disks, storageNamespace); err != nil {
+		m.logger.WithError(err).Debug("Failed to query MSFT_PhysicalDisk")
+		return nil
+	}
+	var counters []msftStorageReliabilityCounter
+	if err := wmi.QueryNamespace("SELECT DeviceId, Wear, Temperature, PowerOnHours, ReadErrorsUncorrected, WriteErrorsUncorrected FROM MSFT_StorageReliabilityCounter", &counters, storageNamespace); err != nil {
+		m.logger.WithError(err).Debug("Failed to query storage reliability counters")
+	}
+
+	return buildPhysicalDisks(partitions, disks, counters)
+}
+
+// buildPhysicalDisks joins partitions to physical disks (MSFT_PhysicalDisk.DeviceId
+// is the disk number) and their reliability counters, keyed by drive letter
+func buildPhysicalDisks(partitions []msftPartition, disks []msftPhysicalDisk, counters []msftStorageReliabilityCounter) map[string]physicalDisk {
+	countersByDisk := make(map[string]msftStorageReliabilityCounter, len(counters))
+	for _, c := range counters {
+		countersByDisk[c.DeviceId] = c
+	}
+
+	byDisk := make(map[string]physicalDisk, len(disks))
+	for _, d := range disks {
+		status, ok := healthStatuses[d.HealthStatus]
+		if !ok {
+			status = DiskUnknown
+		}
+		health := &models.DiskHealth{
+			Disk:      strings.TrimSpace(d.FriendlyName),
+			MediaType: mediaTypes[d.MediaType],
+			Status:    status,
+		}
+		if c, ok := countersByDisk[d.DeviceId]; ok {
+			health.WearPercent = int(c.Wear)
+			health.TemperatureC = int(c.Temperature)
+			health.PowerOnHours = int(c.PowerOnHours)
+			health.UncorrectedErrors = c.ReadErrorsUncorrected + c.WriteErrorsUncorrected
+		}
+		byDisk[d.DeviceId] = physicalDisk{
+			model:    strings.TrimSpace(d.Model),
+			firmware: strings.TrimSpace(d.FirmwareVersion),
+			busType:  busTypes[d.BusType],
+			health:   health,
+		}
+	}
+
+	byDrive := make(map[string]physicalDisk)
+	for _, p := range partitions {
+		if p.DriveLetter == 0 {
+			continue
+		}
+		if disk, ok := byDisk[strconv.FormatUint(uint64(p.DiskNumber), 10)]; ok {
+			byDrive[strings.ToUpper(string(rune(p.DriveLetter)))+":"] = disk
+		}
+	}
+	return byDrive
+}
@@ -0,0 +1,43 @@
+package hardware
+
+import (
+	"testing"
+
+	"patchmon-agent/pkg/models"
+)
+
+func TestBuildPhysicalDisks(t *testing.T) {
+	partitions := []msftPartition{
+		{DiskNumber: 0, DriveLetter: 0}, // EFI system partition
+		{DiskNumber: 0, DriveLetter: 'C'},
+		{DiskNumber: 1, DriveLetter: 'd'},
+		{DiskNumber: 2, DriveLetter: 'E'}, // disk not reported
+	}
+	disks := []msftPhysicalDisk{
+		{DeviceId: "0", FriendlyName: "Samsung SSD 980 PRO 1TB ", Model: "Samsung SSD 980 PRO 1TB ", FirmwareVersion: "5B2QGXA7", BusType: 17, MediaType: 4, HealthStatus: 0},
+		{DeviceId: "1", FriendlyName: "WDC WD40EFRX", Model: "WDC WD40EFRX-68N32N0", FirmwareVersion: "82.00A82", BusType: 11, MediaType: 3, HealthStatus: 1},
+	}
+	counters := []msftStorageReliabilityCounter{
+		{DeviceId: "0", Wear: 3, Temperature: 41, PowerOnHours: 8123, ReadErrorsUncorrected: 1, WriteErrorsUncorrected: 2},
+	}
+
+	got := buildPhysicalDisks(partitions, disks, counters)
+	if len(got) != 2 {
+		t.Fatalf("buildPhysicalDisks() returned %d drives, want 2: %v", len(got), got)
+	}
+	c, ok := got["C:"]
+	if !ok || c.model != "Samsung SSD 980 PRO 1TB" || c.firmware != "5B2QGXA7" || c.busType != "nvme" {
+		t.Errorf("C: disk = %+v", c)
+	}
+	want := models.DiskHealth{Disk: "Samsung SSD 980 PRO 1TB", MediaType: "ssd", Status: DiskHealthy, WearPercent: 3, TemperatureC: 41, PowerOnHours: 8123, UncorrectedErrors: 3}
+	if c.health == nil || *c.health != want {
+		t.Errorf("C: health = %+v, want %+v", c.health, want)
+	}
+	d := got["D:"]
+	if d.busType != "sata" || d.firmware != "82.00A82" {
+		t.Errorf("D: disk = %+v", d)
+	}
+	if h := d.health; h == nil || h.Status != DiskWarning || h.MediaType != "hdd" || h.WearPercent != 0 {
+		t.Errorf("D: health = %+v", h)
+	}
+}
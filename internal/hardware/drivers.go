@@ -0,0 +1,70 @@
+package hardware
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yusufpapurcu/wmi"
+
+	"patchmon-agent/pkg/models"
+)
+
+// win32PnPSignedDriver maps the WMI Win32_PnPSignedDriver class
+type win32PnPSignedDriver struct {
+	DeviceName         string
+	DeviceClass        string
+	DriverProviderName string
+	DriverVersion      string
+	DriverDate         time.Time
+	InfName            string
+	IsSigned           bool
+	Signer             string
+}
+
+// GetDrivers lists the drivers of the devices present on the machine with
+// their version, date and signature
+func (m *Manager) GetDrivers() []models.Driver {
+	var rows []win32PnPSignedDriver
+	query := "SELECT DeviceName, DeviceClass, DriverProviderName, DriverVersion, DriverDate, InfName, IsSigned, Signer FROM Win32_PnPSignedDriver"
+	if err := wmi.Query(query, &rows); err != nil {
+		m.logger.WithError(err).Warn("Failed to query installed drivers")
+		return nil
+	}
+
+	drivers := convertDrivers(rows)
+	m.logger.WithField("count", len(drivers)).Debug("Collected installed drivers")
+	return drivers
+}
+
+// convertDrivers converts the WMI rows, skipping devices without a driver and
+// sorting by device class and name
+func convertDrivers(rows []win32PnPSignedDriver) []models.Driver {
+	drivers := []models.Driver{}
+	for _, row := range rows {
+		if row.DeviceName == "" || row.DriverVersion == "" {
+			continue
+		}
+		driver := models.Driver{
+			DeviceName: row.DeviceName,
+			Class:      strings.ToLower(row.DeviceClass),
+			Provider:   row.DriverProviderName,
+			Version:    row.DriverVersion,
+			InfName:    row.InfName,
+			Signed:     row.IsSigned,
+			Signer:     row.Signer,
+		}
+		if !row.DriverDate.IsZero() {
+			driver.Date = row.DriverDate.Format("2006-01-02")
+		}
+		drivers = append(drivers, driver)
+	}
+
+	sort.SliceStable(drivers, func(i, j int) bool {
+		if drivers[i].Class != drivers[j].Class {
+			return drivers[i].Class < drivers[j].Class
+		}
+		return drivers[i].DeviceName < drivers[j].DeviceName
+	})
+	return drivers
+}
@@ -0,0 +1,26 @@
+package hardware
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConvertDrivers(t *testing.T) {
+	rows := []win32PnPSignedDriver{
+		{DeviceName: "Intel(R) Ethernet Connection I219-LM", DeviceClass: "NET", DriverProviderName: "Intel", DriverVersion: "12.19.2.45", DriverDate: time.Date(2022, 3, 14, 0, 0, 0, 0, time.UTC), InfName: "oem12.inf", IsSigned: true, Signer: "Microsoft Windows Hardware Compatibility Publisher"},
+		{DeviceName: "Standard SATA AHCI Controller", DeviceClass: "HDC", DriverProviderName: "Microsoft", DriverVersion: "10.0.19041.3636", IsSigned: true},
+		{DeviceName: "", DeviceClass: "SYSTEM", DriverVersion: "10.0.19041.1"},
+		{DeviceName: "Unknown device"},
+	}
+
+	got := convertDrivers(rows)
+	if len(got) != 2 {
+		t.Fatalf("convertDrivers() returned %d drivers, want 2: %+v", len(got), got)
+	}
+	if got[0].DeviceName != "Standard SATA AHCI Controller" || got[0].Class != "hdc" || got[0].Date != "" {
+		t.Errorf("first driver = %+v", got[0])
+	}
+	if got[1].Class != "net" || got[1].Date != "2022-03-14" || got[1].InfName != "oem12.inf" || !got[1].Signed {
+		t.Errorf("second driver = %+v", got[1])
+	}
+}
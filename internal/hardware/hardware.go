@@ -3,6 +3,8 @@ package hardware
 import (
 	"context"
 	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/shirou/gopsutil/v4/cpu"
@@ -14,6 +16,15 @@ import (
 	"patchmon-agent/pkg/models"
 )
 
+// Low disk space thresholds, see isLowDisk. Volumes smaller than
+// LowDiskMinTotalBytes (recovery, EFI and other small partitions) are never
+// flagged.
+const (
+	LowDiskFreePercent   = 10
+	LowDiskFreeBytes     = 10 * 1024 * 1024 * 1024
+	LowDiskMinTotalBytes = 2 * 1024 * 1024 * 1024
+)
+
 // Manager handles hardware information collection
 type Manager struct {
 	logger *logrus.Logger
@@ -34,7 +45,11 @@ func (m *Manager) GetHardwareInfo() models.HardwareInfo {
 		RAMInstalled: m.getRAMSize(),
 		SwapSize:     m.getSwapSize(),
 		DiskDetails:  m.getDiskDetails(),
+		Identity:     m.GetSystemIdentity(),
 	}
+	info.MemoryModules = m.GetMemoryModules()
+	info.PageFiles = m.GetPageFiles()
+	info.IsVirtual, info.Hypervisor = detectVirtualization(info.Identity)
 
 	m.logger.WithFields(logrus.Fields{
 		"cpu":   info.CPUModel,
@@ -42,6 +57,7 @@ func (m *Manager) GetHardwareInfo() models.HardwareInfo {
 		"ram":   fmt.Sprintf("%.2fGB", info.RAMInstalled),
 		"swap":  fmt.Sprintf("%.2fGB", info.SwapSize),
 		"disks": len(info.DiskDetails),
+		"vm":    info.Hypervisor,
 	}).Debug("Collected CPU, memory, and disk information")
 
 	return info
@@ -109,6 +125,16 @@ func (m *Manager) getSwapSize() float64 {
 	return float64(swapInfo.Total) / (1024 * 1024 * 1024)
 }
 
+// isLowDisk reports whether a volume is low on space: less than
+// LowDiskFreePercent or LowDiskFreeBytes free, whichever is hit first. Updates
+// routinely fail to install on system drives below these limits.
+func isLowDisk(free, total uint64) bool {
+	if total < LowDiskMinTotalBytes {
+		return false
+	}
+	return free < LowDiskFreeBytes || float64(free)/float64(total)*100 < LowDiskFreePercent
+}
+
 // getDiskDetails gets disk information
 func (m *Manager) getDiskDetails() []models.DiskInfo {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -121,6 +147,7 @@ func (m *Manager) getDiskDetails() []models.DiskInfo {
 	}
 
 	var disks []models.DiskInfo
+	physicalDisks := m.getPhysicalDisks()
 
 	for _, partition := range partitions {
 		// Skip special filesystems
@@ -143,7 +170,18 @@ func (m *Manager) getDiskDetails() []models.DiskInfo {
 				float64(usage.Used)/(1024*1024*1024),
 				float64(usage.Free)/(1024*1024*1024),
 				usage.UsedPercent),
-			MountPoint: partition.Mountpoint,
+			MountPoint:  partition.Mountpoint,
+			TotalBytes:  usage.Total,
+			UsedBytes:   usage.Used,
+			FreeBytes:   usage.Free,
+			UsedPercent: math.Round(usage.UsedPercent*10) / 10,
+			LowDisk:     isLowDisk(usage.Free, usage.Total),
+		}
+		if physical, ok := physicalDisks[strings.ToUpper(partition.Device)]; ok {
+			diskInfo.Model = physical.model
+			diskInfo.FirmwareVersion = physical.firmware
+			diskInfo.BusType = physical.busType
+			diskInfo.Health = physical.health
 		}
 
 		disks = append(disks, diskInfo)
@@ -0,0 +1,26 @@
+package hardware
+
+import "testing"
+
+func TestIsLowDisk(t *testing.T) {
+	const gb = 1024 * 1024 * 1024
+	tests := []struct {
+		name        string
+		free, total uint64
+		want        bool
+	}{
+		{"unknown size", 0, 0, false},
+		{"plenty free", 200 * gb, 500 * gb, false},
+		{"under percent", 40 * gb, 1000 * gb, true},
+		{"under bytes", 8 * gb, 40 * gb, true},
+		{"full", 0, 100 * gb, true},
+		{"recovery partition", 50 * 1024 * 1024, 500 * 1024 * 1024, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isLowDisk(tt.free, tt.total); got != tt.want {
+				t.Errorf("isLowDisk(%d, %d) = %v, want %v", tt.free, tt.total, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package hardware
+
+import (
+	"strings"
+	"time"
+
+	"github.com/yusufpapurcu/wmi"
+
+	"patchmon-agent/pkg/models"
+)
+
+// win32ComputerSystemProduct maps the WMI Win32_ComputerSystemProduct class
+type win32ComputerSystemProduct struct {
+	Vendor            string
+	Name              string
+	IdentifyingNumber string
+	UUID              string
+}
+
+// win32BIOS maps the WMI Win32_BIOS class
+type win32BIOS struct {
+	Manufacturer      string
+	SMBIOSBIOSVersion string
+	SerialNumber      string
+	ReleaseDate       time.Time
+}
+
+// placeholderValues are SMBIOS strings OEMs leave in place of real data
+var placeholderValues = []string{
+	"to be filled by o.e.m.",
+	"default string",
+	"system serial number",
+	"system product name",
+	"system manufacturer",
+	"not specified",
+	"not applicable",
+	"none",
+	"n/a",
+	"0",
+}
+
+// placeholderUUIDs are SMBIOS UUIDs that do not identify a machine
+var placeholderUUIDs = []string{
+	"00000000-0000-0000-0000-000000000000",
+	"FFFFFFFF-FFFF-FFFF-FFFF-FFFFFFFFFFFF",
+}
+
+// GetSystemIdentity returns the manufacturer, model, serial number, BIOS
+// version and SMBIOS UUID used to identify the machine as an asset. It returns
+// nil if neither the computer system product nor the BIOS can be read.
+func (m *Manager) GetSystemIdentity() *models.SystemIdentity {
+	var products []win32ComputerSystemProduct
+	if err := wmi.Query("SELECT Vendor, Name, IdentifyingNumber, UUID FROM Win32_ComputerSystemProduct", &products); err != nil {
+		m.logger.WithError(err).Debug("Failed to query Win32_ComputerSystemProduct")
+	}
+	var bios []win32BIOS
+	if err := wmi.Query("SELECT Manufacturer, SMBIOSBIOSVersion, SerialNumber, ReleaseDate FROM Win32_BIOS", &bios); err != nil {
+		m.logger.WithError(err).Debug("Failed to query Win32_BIOS")
+	}
+	if len(products) == 0 && len(bios) == 0 {
+		return nil
+	}
+
+	identity := &models.SystemIdentity{}
+	if len(products) > 0 {
+		p := products[0]
+		identity.Manufacturer = cleanSMBIOSValue(p.Vendor)
+		identity.Model = cleanSMBIOSValue(p.Name)
+		identity.SerialNumber = cleanSMBIOSValue(p.IdentifyingNumber)
+		identity.UUID = cleanSMBIOSUUID(p.UUID)
+	}
+	if len(bios) > 0 {
+		b := bios[0]
+		identity.BIOSManufacturer = cleanSMBIOSValue(b.Manufacturer)
+		identity.BIOSVersion = cleanSMBIOSValue(b.SMBIOSBIOSVersion)
+		if !b.ReleaseDate.IsZero() {
+			identity.BIOSReleaseDate = b.ReleaseDate.Format("2006-01-02")
+		}
+		// Some OEMs only fill in the serial number in the BIOS table
+		if identity.SerialNumber == "" {
+			identity.SerialNumber = cleanSMBIOSValue(b.SerialNumber)
+		}
+	}
+
+	m.logger.WithField("manufacturer", identity.Manufacturer).
+		WithField("model", identity.Model).
+		WithField("bios_version", identity.BIOSVersion).
+		Debug("Collected system identity")
+	return identity
+}
+
+// GetSMBIOSUUID returns the SMBIOS system UUID, or "" if it is missing or a
+// placeholder. Unlike MachineGuid it survives sysprep and reinstalls.
+func (m *Manager) GetSMBIOSUUID() string {
+	var products []win32ComputerSystemProduct
+	if err := wmi.Query("SELECT UUID FROM Win32_ComputerSystemProduct", &products); err != nil || len(products) == 0 {
+		m.logger.WithError(err).Debug("Failed to query SMBIOS UUID")
+		return ""
+	}
+	return cleanSMBIOSUUID(products[0].UUID)
+}
+
+// cleanSMBIOSValue trims an SMBIOS string and drops OEM placeholder values
+func cleanSMBIOSValue(value string) string {
+	value = strings.TrimSpace(value)
+	for _, placeholder := range placeholderValues {
+		if strings.EqualFold(value, placeholder) {
+			return ""
+		}
+	}
+	return value
+}
+
+// cleanSMBIOSUUID upper-cases an SMBIOS UUID and drops all-zero or all-F values
+func cleanSMBIOSUUID(uuid string) string {
+	uuid = strings.ToUpper(strings.TrimSpace(uuid))
+	for _, placeholder := range placeholderUUIDs {
+		if uuid == placeholder {
+			return ""
+		}
+	}
+	return uuid
+}
@@ -0,0 +1,51 @@
+package hardware
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestCleanSMBIOSValue(t *testing.T) {
+	tests := map[string]string{
+		"  Dell Inc. ":           "Dell Inc.",
+		"To Be Filled By O.E.M.": "",
+		"Default string":         "",
+		"System Serial Number":   "",
+		"0":                      "",
+		"5CG1234XYZ":             "5CG1234XYZ",
+		"":                       "",
+	}
+	for in, want := range tests {
+		if got := cleanSMBIOSValue(in); got != want {
+			t.Errorf("cleanSMBIOSValue(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCleanSMBIOSUUID(t *testing.T) {
+	tests := map[string]string{
+		"4c4c4544-0042-3510-8052-b4c04f384d32": "4C4C4544-0042-3510-8052-B4C04F384D32",
+		"00000000-0000-0000-0000-000000000000": "",
+		"ffffffff-ffff-ffff-ffff-ffffffffffff": "",
+	}
+	for in, want := range tests {
+		if got := cleanSMBIOSUUID(in); got != want {
+			t.Errorf("cleanSMBIOSUUID(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// TestGetSystemIdentity is an integration test that queries the real WMI provider
+func TestGetSystemIdentity(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping WMI integration test in short mode")
+	}
+	mgr := New(logrus.New())
+
+	identity := mgr.GetSystemIdentity()
+	if identity == nil {
+		t.Skip("system identity not available on this machine")
+	}
+	t.Logf("identity: %+v", identity)
+}
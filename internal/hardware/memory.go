@@ -0,0 +1,70 @@
+package hardware
+
+import (
+	"github.com/yusufpapurcu/wmi"
+
+	"patchmon-agent/pkg/models"
+)
+
+// memoryTypes maps Win32_PhysicalMemory.SMBIOSMemoryType values
+var memoryTypes = map[uint32]string{
+	18: "DDR",
+	19: "DDR2",
+	20: "DDR2 FB-DIMM",
+	24: "DDR3",
+	26: "DDR4",
+	27: "LPDDR",
+	28: "LPDDR2",
+	29: "LPDDR3",
+	30: "LPDDR4",
+	34: "DDR5",
+	35: "LPDDR5",
+}
+
+// win32PhysicalMemory maps the WMI Win32_PhysicalMemory class
+type win32PhysicalMemory struct {
+	Capacity             uint64
+	Speed                uint32
+	ConfiguredClockSpeed uint32
+	Manufacturer         string
+	PartNumber           string
+	SerialNumber         string
+	DeviceLocator        string
+	BankLabel            string
+	SMBIOSMemoryType     uint32
+}
+
+// GetMemoryModules lists the installed memory modules (DIMMs)
+func (m *Manager) GetMemoryModules() []models.MemoryModule {
+	var rows []win32PhysicalMemory
+	query := "SELECT Capacity, Speed, ConfiguredClockSpeed, Manufacturer, PartNumber, SerialNumber, DeviceLocator, BankLabel, SMBIOSMemoryType FROM Win32_PhysicalMemory"
+	if err := wmi.Query(query, &rows); err != nil {
+		m.logger.WithError(err).Debug("Failed to query Win32_PhysicalMemory")
+		return nil
+	}
+
+	modules := make([]models.MemoryModule, 0, len(rows))
+	for _, row := range rows {
+		modules = append(modules, convertMemoryModule(row))
+	}
+	return modules
+}
+
+// convertMemoryModule converts a Win32_PhysicalMemory row, dropping OEM
+// placeholder strings
+func convertMemoryModule(row win32PhysicalMemory) models.MemoryModule {
+	module := models.MemoryModule{
+		Slot:         cleanSMBIOSValue(row.DeviceLocator),
+		Bank:         cleanSMBIOSValue(row.BankLabel),
+		SizeGB:       float64(row.Capacity) / (1024 * 1024 * 1024),
+		SpeedMHz:     int(row.Speed),
+		Type:         memoryTypes[row.SMBIOSMemoryType],
+		Manufacturer: cleanSMBIOSValue(row.Manufacturer),
+		PartNumber:   cleanSMBIOSValue(row.PartNumber),
+		SerialNumber: cleanSMBIOSValue(row.SerialNumber),
+	}
+	if row.ConfiguredClockSpeed > 0 {
+		module.ConfiguredSpeedMHz = int(row.ConfiguredClockSpeed)
+	}
+	return module
+}
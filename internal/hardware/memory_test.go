@@ -0,0 +1,37 @@
+package hardware
+
+import (
+	"testing"
+
+	"patchmon-agent/pkg/models"
+)
+
+func TestConvertMemoryModule(t *testing.T) {
+	row := win32PhysicalMemory{
+		Capacity:             16 * 1024 * 1024 * 1024,
+		Speed:                3200,
+		ConfiguredClockSpeed: 2933,
+		Manufacturer:         "Samsung",
+		PartNumber:           "M471A2K43DB1-CWE    ",
+		SerialNumber:         "00000000",
+		DeviceLocator:        "DIMM A1",
+		BankLabel:            "BANK 0",
+		SMBIOSMemoryType:     26,
+	}
+
+	got := convertMemoryModule(row)
+	want := models.MemoryModule{
+		Slot:               "DIMM A1",
+		Bank:               "BANK 0",
+		SizeGB:             16,
+		SpeedMHz:           3200,
+		ConfiguredSpeedMHz: 2933,
+		Type:               "DDR4",
+		Manufacturer:       "Samsung",
+		PartNumber:         "M471A2K43DB1-CWE",
+		SerialNumber:       "00000000",
+	}
+	if got != want {
+		t.Errorf("convertMemoryModule() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,80 @@
+package hardware
+
+import (
+	"strings"
+
+	"github.com/yusufpapurcu/wmi"
+
+	"patchmon-agent/pkg/models"
+)
+
+// win32ComputerSystemPagefile maps the page file flag of Win32_ComputerSystem
+type win32ComputerSystemPagefile struct {
+	AutomaticManagedPagefile bool
+}
+
+// win32PageFileSetting maps the WMI Win32_PageFileSetting class (sizes in MB)
+type win32PageFileSetting struct {
+	Name        string
+	InitialSize uint32
+	MaximumSize uint32
+}
+
+// win32PageFileUsage maps the WMI Win32_PageFileUsage class (sizes in MB)
+type win32PageFileUsage struct {
+	Name              string
+	AllocatedBaseSize uint32
+	CurrentUsage      uint32
+	PeakUsage         uint32
+}
+
+// GetPageFiles returns the page file configuration, or nil if it cannot be read
+func (m *Manager) GetPageFiles() *models.PageFileConfig {
+	var system []win32ComputerSystemPagefile
+	if err := wmi.Query("SELECT AutomaticManagedPagefile FROM Win32_ComputerSystem", &system); err != nil || len(system) == 0 {
+		m.logger.WithError(err).Debug("Failed to query page file management")
+		return nil
+	}
+	var settings []win32PageFileSetting
+	if err := wmi.Query("SELECT Name, InitialSize, MaximumSize FROM Win32_PageFileSetting", &settings); err != nil {
+		m.logger.WithError(err).Debug("Failed to query Win32_PageFileSetting")
+	}
+	var usage []win32PageFileUsage
+	if err := wmi.Query("SELECT Name, AllocatedBaseSize, CurrentUsage, PeakUsage FROM Win32_PageFileUsage", &usage); err != nil {
+		m.logger.WithError(err).Debug("Failed to query Win32_PageFileUsage")
+	}
+
+	return buildPageFiles(system[0].AutomaticManagedPagefile, settings, usage)
+}
+
+// buildPageFiles merges the configured page files with the ones in use. When
+// Windows manages the page file there are no settings; a setting with zero
+// initial and maximum size is system managed too.
+func buildPageFiles(automatic bool, settings []win32PageFileSetting, usage []win32PageFileUsage) *models.PageFileConfig {
+	config := &models.PageFileConfig{SystemManaged: automatic}
+	byPath := make(map[string]int)
+
+	for _, s := range settings {
+		byPath[strings.ToLower(s.Name)] = len(config.Files)
+		config.Files = append(config.Files, models.PageFile{
+			Path:          s.Name,
+			InitialSizeMB: int(s.InitialSize),
+			MaximumSizeMB: int(s.MaximumSize),
+			SystemManaged: automatic || (s.InitialSize == 0 && s.MaximumSize == 0),
+		})
+	}
+	for _, u := range usage {
+		i, ok := byPath[strings.ToLower(u.Name)]
+		if !ok {
+			i = len(config.Files)
+			config.Files = append(config.Files, models.PageFile{
+				Path:          u.Name,
+				SystemManaged: automatic,
+			})
+		}
+		config.Files[i].AllocatedSizeMB = int(u.AllocatedBaseSize)
+		config.Files[i].CurrentUsageMB = int(u.CurrentUsage)
+		config.Files[i].PeakUsageMB = int(u.PeakUsage)
+	}
+	return config
+}
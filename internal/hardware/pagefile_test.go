@@ -0,0 +1,37 @@
+package hardware
+
+import (
+	"reflect"
+	"testing"
+
+	"patchmon-agent/pkg/models"
+)
+
+func TestBuildPageFiles(t *testing.T) {
+	settings := []win32PageFileSetting{
+		{Name: `C:\pagefile.sys`, InitialSize: 4096, MaximumSize: 8192},
+		{Name: `D:\pagefile.sys`},
+	}
+	usage := []win32PageFileUsage{
+		{Name: `c:\pagefile.sys`, AllocatedBaseSize: 4096, CurrentUsage: 312, PeakUsage: 980},
+		{Name: `E:\pagefile.sys`, AllocatedBaseSize: 1024},
+	}
+
+	got := buildPageFiles(false, settings, usage)
+	want := &models.PageFileConfig{
+		Files: []models.PageFile{
+			{Path: `C:\pagefile.sys`, InitialSizeMB: 4096, MaximumSizeMB: 8192, AllocatedSizeMB: 4096, CurrentUsageMB: 312, PeakUsageMB: 980},
+			{Path: `D:\pagefile.sys`, SystemManaged: true},
+			{Path: `E:\pagefile.sys`, AllocatedSizeMB: 1024},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildPageFiles() = %+v, want %+v", got, want)
+	}
+
+	// Fully automatic: no settings, only the file in use
+	got = buildPageFiles(true, nil, usage[:1])
+	if !got.SystemManaged || len(got.Files) != 1 || !got.Files[0].SystemManaged || got.Files[0].AllocatedSizeMB != 4096 {
+		t.Errorf("buildPageFiles(automatic) = %+v", got)
+	}
+}
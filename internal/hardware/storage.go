@@ -0,0 +1,181 @@
+package hardware
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/yusufpapurcu/wmi"
+
+	"patchmon-agent/pkg/models"
+)
+
+// Storage array types reported in StorageVolume.Type
+const (
+	StorageSpaces = "storage-spaces"
+	HardwareRAID  = "hardware-raid"
+)
+
+// busTypeRAID is the MSFT_PhysicalDisk.BusType of a logical drive presented
+// by a hardware RAID controller
+const busTypeRAID = 8
+
+// operationalStatuses maps the OperationalStatus values of the storage classes
+var operationalStatuses = map[uint16]string{
+	2:  "ok",
+	3:  "degraded",
+	4:  "stressed",
+	5:  "predictive failure",
+	6:  "error",
+	7:  "non-recoverable error",
+	8:  "starting",
+	9:  "stopping",
+	10: "stopped",
+	11: "in service",
+	12: "no contact",
+	13: "lost communication",
+	14: "aborted",
+	15: "dormant",
+	16: "supporting entity in error",
+	17: "completed",
+	18: "power mode",
+}
+
+// msftStoragePool maps the WMI MSFT_StoragePool class
+type msftStoragePool struct {
+	FriendlyName      string
+	IsPrimordial      bool
+	IsReadOnly        bool
+	HealthStatus      uint16
+	OperationalStatus []uint16
+	Size              uint64
+	AllocatedSize     uint64
+}
+
+// msftVirtualDisk maps the WMI MSFT_VirtualDisk class
+type msftVirtualDisk struct {
+	FriendlyName          string
+	ResiliencySettingName string
+	HealthStatus          uint16
+	OperationalStatus     []uint16
+	Size                  uint64
+}
+
+// msftRAIDDisk maps the MSFT_PhysicalDisk properties needed for hardware RAID
+type msftRAIDDisk struct {
+	FriendlyName      string
+	BusType           uint16
+	HealthStatus      uint16
+	OperationalStatus []uint16
+	Size              uint64
+}
+
+// GetStorageArrays returns the Storage Spaces pools and virtual disks, and the
+// logical drives of hardware RAID controllers whose driver exposes them to the
+// Storage Management API. It returns nil if the machine has none.
+func (m *Manager) GetStorageArrays() *models.StorageArrays {
+	var pools []msftStoragePool
+	if err := wmi.QueryNamespace("SELECT FriendlyName, IsPrimordial, IsReadOnly, HealthStatus, OperationalStatus, Size, AllocatedSize FROM MSFT_StoragePool", &pools, storageNamespace); err != nil {
+		m.logger.WithError(err).Debug("Failed to query MSFT_StoragePool")
+	}
+	var virtualDisks []msftVirtualDisk
+	if err := wmi.QueryNamespace("SELECT FriendlyName, ResiliencySettingName, HealthStatus, OperationalStatus, Size FROM MSFT_VirtualDisk", &virtualDisks, storageNamespace); err != nil {
+		m.logger.WithError(err).Debug("Failed to query MSFT_VirtualDisk")
+	}
+	var raidDisks []msftRAIDDisk
+	if err := wmi.QueryNamespace("SELECT FriendlyName, BusType, HealthStatus, OperationalStatus, Size FROM MSFT_PhysicalDisk WHERE BusType = "+strconv.Itoa(busTypeRAID), &raidDisks, storageNamespace); err != nil {
+		m.logger.WithError(err).Debug("Failed to query RAID disks")
+	}
+
+	arrays := buildStorageArrays(pools, virtualDisks, raidDisks)
+	if arrays == nil {
+		return nil
+	}
+
+	degraded := 0
+	for _, v := range arrays.Volumes {
+		if v.Degraded {
+			degraded++
+		}
+	}
+	m.logger.WithFields(logrus.Fields{
+		"pools":    len(arrays.Pools),
+		"volumes":  len(arrays.Volumes),
+		"degraded": degraded,
+	}).Info("Collected storage array configuration")
+	return arrays
+}
+
+// buildStorageArrays converts the storage classes, skipping the primordial
+// pool that holds unallocated disks
+func buildStorageArrays(pools []msftStoragePool, virtualDisks []msftVirtualDisk, raidDisks []msftRAIDDisk) *models.StorageArrays {
+	arrays := &models.StorageArrays{}
+	for _, p := range pools {
+		if p.IsPrimordial {
+			continue
+		}
+		health := storageHealth(p.HealthStatus)
+		arrays.Pools = append(arrays.Pools, models.StoragePool{
+			Name:              strings.TrimSpace(p.FriendlyName),
+			Health:            health,
+			OperationalStatus: operationalStatus(p.OperationalStatus),
+			SizeBytes:         p.Size,
+			AllocatedBytes:    p.AllocatedSize,
+			ReadOnly:          p.IsReadOnly,
+			Degraded:          health != DiskHealthy,
+		})
+	}
+	for _, d := range virtualDisks {
+		health := storageHealth(d.HealthStatus)
+		arrays.Volumes = append(arrays.Volumes, models.StorageVolume{
+			Name:              strings.TrimSpace(d.FriendlyName),
+			Type:              StorageSpaces,
+			Resiliency:        d.ResiliencySettingName,
+			Health:            health,
+			OperationalStatus: operationalStatus(d.OperationalStatus),
+			SizeBytes:         d.Size,
+			Degraded:          health != DiskHealthy,
+		})
+	}
+	for _, d := range raidDisks {
+		if d.BusType != busTypeRAID {
+			continue
+		}
+		health := storageHealth(d.HealthStatus)
+		arrays.Volumes = append(arrays.Volumes, models.StorageVolume{
+			Name:              strings.TrimSpace(d.FriendlyName),
+			Type:              HardwareRAID,
+			Health:            health,
+			OperationalStatus: operationalStatus(d.OperationalStatus),
+			SizeBytes:         d.Size,
+			Degraded:          health != DiskHealthy,
+		})
+	}
+
+	if len(arrays.Pools) == 0 && len(arrays.Volumes) == 0 {
+		return nil
+	}
+	return arrays
+}
+
+// storageHealth maps a HealthStatus value of the storage classes
+func storageHealth(status uint16) string {
+	if health, ok := healthStatuses[status]; ok {
+		return health
+	}
+	return DiskUnknown
+}
+
+// operationalStatus joins the OperationalStatus values, e.g. "degraded, ok".
+// Values without a name are reported as numbers.
+func operationalStatus(values []uint16) string {
+	names := make([]string, 0, len(values))
+	for _, v := range values {
+		if name, ok := operationalStatuses[v]; ok {
+			names = append(names, name)
+		} else {
+			names = append(names, strconv.Itoa(int(v)))
+		}
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,41 @@
+package hardware
+
+import (
+	"testing"
+
+	"patchmon-agent/pkg/models"
+)
+
+func TestBuildStorageArrays(t *testing.T) {
+	pools := []msftStoragePool{
+		{FriendlyName: "Primordial", IsPrimordial: true, HealthStatus: 0, OperationalStatus: []uint16{2}},
+		{FriendlyName: "Pool1 ", HealthStatus: 1, OperationalStatus: []uint16{3}, Size: 4000, AllocatedSize: 1000},
+	}
+	virtualDisks := []msftVirtualDisk{
+		{FriendlyName: "Data", ResiliencySettingName: "Mirror", HealthStatus: 1, OperationalStatus: []uint16{3, 2}, Size: 2000},
+	}
+	raidDisks := []msftRAIDDisk{
+		{FriendlyName: "PERC H740P", BusType: busTypeRAID, HealthStatus: 0, OperationalStatus: []uint16{2, 0xD00D}, Size: 8000},
+	}
+
+	got := buildStorageArrays(pools, virtualDisks, raidDisks)
+	if got == nil || len(got.Pools) != 1 || len(got.Volumes) != 2 {
+		t.Fatalf("buildStorageArrays() = %+v", got)
+	}
+	wantPool := models.StoragePool{Name: "Pool1", Health: DiskWarning, OperationalStatus: "degraded", SizeBytes: 4000, AllocatedBytes: 1000, Degraded: true}
+	if got.Pools[0] != wantPool {
+		t.Errorf("pool = %+v, want %+v", got.Pools[0], wantPool)
+	}
+	wantSpace := models.StorageVolume{Name: "Data", Type: StorageSpaces, Resiliency: "Mirror", Health: DiskWarning, OperationalStatus: "degraded, ok", SizeBytes: 2000, Degraded: true}
+	if got.Volumes[0] != wantSpace {
+		t.Errorf("virtual disk = %+v, want %+v", got.Volumes[0], wantSpace)
+	}
+	wantRAID := models.StorageVolume{Name: "PERC H740P", Type: HardwareRAID, Health: DiskHealthy, OperationalStatus: "ok, 53261", SizeBytes: 8000}
+	if got.Volumes[1] != wantRAID {
+		t.Errorf("RAID drive = %+v, want %+v", got.Volumes[1], wantRAID)
+	}
+
+	if got := buildStorageArrays(pools[:1], nil, nil); got != nil {
+		t.Errorf("buildStorageArrays() with only the primordial pool = %+v, want nil", got)
+	}
+}
@@ -0,0 +1,61 @@
+package hardware
+
+import (
+	"strings"
+
+	"patchmon-agent/pkg/models"
+)
+
+// Hypervisors reported in HardwareInfo.Hypervisor
+const (
+	HypervisorHyperV     = "hyperv"
+	HypervisorVMware     = "vmware"
+	HypervisorKVM        = "kvm"
+	HypervisorXen        = "xen"
+	HypervisorVirtualBox = "virtualbox"
+	HypervisorParallels  = "parallels"
+)
+
+// hypervisorSignatures maps substrings of the lower-cased SMBIOS manufacturer,
+// model and BIOS version to the hypervisor that sets them, checked in order
+var hypervisorSignatures = []struct {
+	match      string
+	hypervisor string
+}{
+	{"vmware", HypervisorVMware},
+	{"virtualbox", HypervisorVirtualBox},
+	{"innotek", HypervisorVirtualBox},
+	{"parallels", HypervisorParallels},
+	{"xen", HypervisorXen},
+	{"qemu", HypervisorKVM},
+	{"kvm", HypervisorKVM},
+	{"red hat", HypervisorKVM},
+	{"nutanix", HypervisorKVM},
+	{"google compute engine", HypervisorKVM},
+	{"amazon ec2", HypervisorKVM},
+	{"virtual machine", HypervisorHyperV}, // Microsoft Corporation / Virtual Machine
+}
+
+// detectVirtualization reports whether the machine is a virtual machine and
+// which hypervisor it runs on. The SMBIOS identity is used rather than the CPU
+// hypervisor flag, which Hyper-V hosts and machines with virtualization-based
+// security report as well.
+func detectVirtualization(identity *models.SystemIdentity) (isVirtual bool, hypervisor string) {
+	if identity == nil {
+		return false, ""
+	}
+	hypervisor = hypervisorFromSMBIOS(identity.Manufacturer, identity.Model, identity.BIOSVersion)
+	return hypervisor != "", hypervisor
+}
+
+// hypervisorFromSMBIOS identifies the hypervisor from SMBIOS strings, "" if
+// they do not belong to a known virtual machine
+func hypervisorFromSMBIOS(manufacturer, model, biosVersion string) string {
+	fields := strings.ToLower(strings.Join([]string{manufacturer, model, biosVersion}, " "))
+	for _, signature := range hypervisorSignatures {
+		if strings.Contains(fields, signature.match) {
+			return signature.hypervisor
+		}
+	}
+	return ""
+}
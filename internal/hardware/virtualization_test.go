@@ -0,0 +1,28 @@
+package hardware
+
+import "testing"
+
+func TestHypervisorFromSMBIOS(t *testing.T) {
+	tests := []struct {
+		manufacturer, model, bios string
+		want                      string
+	}{
+		{"Microsoft Corporation", "Virtual Machine", "Hyper-V UEFI Release v4.1", HypervisorHyperV},
+		{"VMware, Inc.", "VMware7,1", "VMW71.00V.21100432.B64.2301110304", HypervisorVMware},
+		{"QEMU", "Standard PC (Q35 + ICH9, 2009)", "rel-1.16.2-0-gea1b7a073390-prebuilt.qemu.org", HypervisorKVM},
+		{"Red Hat", "KVM", "1.11.0-2.el7", HypervisorKVM},
+		{"Xen", "HVM domU", "4.11.amazon", HypervisorXen},
+		{"Amazon EC2", "t3.medium", "1.0", HypervisorKVM},
+		{"innotek GmbH", "VirtualBox", "VirtualBox", HypervisorVirtualBox},
+		{"Parallels International GmbH.", "Parallels ARM Virtual Machine", "19.1.0", HypervisorParallels},
+		{"Dell Inc.", "PowerEdge R740", "2.19.1", ""},
+		{"Microsoft Corporation", "Surface Pro 9", "19.100.140", ""},
+		{"Intel Corporation", "Xeon Server", "", ""},
+	}
+
+	for _, tt := range tests {
+		if got := hypervisorFromSMBIOS(tt.manufacturer, tt.model, tt.bios); got != tt.want {
+			t.Errorf("hypervisorFromSMBIOS(%q, %q, %q) = %q, want %q", tt.manufacturer, tt.model, tt.bios, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package history
+
+import (
+	"patchmon-agent/internal/store"
+	"patchmon-agent/pkg/models"
+)
+
+// DefaultLimit is the number of report runs kept when report_history is not set
+const DefaultLimit = 50
+
+// storeKey is the key of the report history in the state store
+const storeKey = "history"
+
+// Results of a report run
+const (
+	ResultSent    = "sent"
+	ResultPartial = "partial" // sent, but some data could not be collected
+	ResultFailed  = "failed"
+)
+
+// Entry describes one report run
+type Entry struct {
+	StartedAt         string   `json:"startedAt"` // RFC3339
+	DurationSeconds   float64  `json:"durationSeconds"`
+	Result            string   `json:"result"`
+	Sections          []string `json:"sections,omitempty"` // omitted for a full report
+	Packages          int      `json:"packages"`
+	UpdatesAvailable  int      `json:"updatesAvailable"`
+	SecurityUpdates   int      `json:"securityUpdates"`
+	PackagesProcessed int      `json:"packagesProcessed"` // as reported by the server
+	Actions           int      `json:"actions,omitempty"` // actions requested by the server
+	Error             string   `json:"error,omitempty"`
+}
+
+// SetPayload records the sections and package counts of the report
+func (e *Entry) SetPayload(payload *models.ReportPayload) {
+	e.Sections = payload.Sections
+	e.Packages = len(payload.Packages)
+	e.UpdatesAvailable, e.SecurityUpdates = 0, 0
+	for _, pkg := range payload.Packages {
+		if pkg.NeedsUpdate {
+			e.UpdatesAvailable++
+		}
+		if pkg.IsSecurityUpdate {
+			e.SecurityUpdates++
+		}
+	}
+}
+
+// SetResponse records the server's answer to the report
+func (e *Entry) SetResponse(response *models.UpdateResponse) {
+	e.PackagesProcessed = response.PackagesProcessed
+	e.Actions = len(response.Actions)
+}
+
+// Load returns the report runs recorded in the state store at path, oldest
+// first
+func Load(path string) ([]Entry, error) {
+	var entries []Entry
+	if _, err := store.Get(path, store.BucketReports, storeKey, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Append records a report run, keeping the last limit runs
+func Append(path string, entry Entry, limit int) error {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	var entries []Entry
+	return store.Update(path, store.BucketReports, storeKey, &entries, func(bool) (any, error) {
+		entries = append(entries, entry)
+		if len(entries) > limit {
+			entries = entries[len(entries)-limit:]
+		}
+		return entries, nil
+	})
+}
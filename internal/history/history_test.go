@@ -0,0 +1,47 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+
+	"patchmon-agent/pkg/models"
+)
+
+func TestAppendKeepsLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.db")
+
+	for _, result := range []string{ResultSent, ResultFailed, ResultPartial} {
+		if err := Append(path, Entry{Result: result}, 2); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	entries, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Result != ResultFailed || entries[1].Result != ResultPartial {
+		t.Errorf("entries = %+v, want the last two runs, oldest first", entries)
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	entries, err := Load(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil || entries != nil {
+		t.Errorf("Load() = %v, %v, want an empty history", entries, err)
+	}
+}
+
+func TestSetPayload(t *testing.T) {
+	var e Entry
+	e.SetPayload(&models.ReportPayload{
+		Packages: []models.Package{
+			{Name: "KB1", NeedsUpdate: true, IsSecurityUpdate: true},
+			{Name: "KB2", NeedsUpdate: true},
+			{Name: "KB3"},
+		},
+		Sections: []string{"packages"},
+	})
+	if e.Packages != 3 || e.UpdatesAvailable != 2 || e.SecurityUpdates != 1 || len(e.Sections) != 1 {
+		t.Errorf("entry = %+v, want 3 packages, 2 updates, 1 security update", e)
+	}
+}
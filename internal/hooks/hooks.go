@@ -0,0 +1,151 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+
+	"patchmon-agent/pkg/models"
+)
+
+// Hook stages
+const (
+	StagePreInstall  = "pre_install"
+	StagePostInstall = "post_install"
+)
+
+// DefaultTimeout bounds a hook that has no timeout configured
+const DefaultTimeout = 5 * time.Minute
+
+// waitDelay is how long to wait for a killed hook's child processes to
+// release its output before giving up
+const waitDelay = 5 * time.Second
+
+// maxOutput is how much of a hook's combined stdout/stderr is kept
+const maxOutput = 8 * 1024
+
+// Manager runs patching hook scripts
+type Manager struct {
+	logger *logrus.Logger
+}
+
+// New creates a new hooks manager
+func New(logger *logrus.Logger) *Manager {
+	return &Manager{logger: logger}
+}
+
+// Run runs hooks in order for stage and returns their results. env is added
+// to each hook's environment on top of PATCHMON_HOOK_STAGE. With stopOnFailure
+// the remaining hooks are skipped after the first failure.
+func (m *Manager) Run(stage string, hooks []models.HookConfig, env map[string]string, stopOnFailure bool) []models.HookResult {
+	results := make([]models.HookResult, 0, len(hooks))
+	for _, hook := range hooks {
+		result := m.runHook(stage, hook, env)
+		results = append(results, result)
+		if !result.Succeeded && stopOnFailure {
+			m.logger.WithField("stage", stage).Warn("Hook failed, skipping remaining hooks")
+			break
+		}
+	}
+	return results
+}
+
+// Failed reports whether any hook in results failed
+func Failed(results []models.HookResult) bool {
+	for _, result := range results {
+		if !result.Succeeded {
+			return true
+		}
+	}
+	return false
+}
+
+// runHook runs a single hook with its timeout, capturing its output
+func (m *Manager) runHook(stage string, hook models.HookConfig, env map[string]string) models.HookResult {
+	result := models.HookResult{Stage: stage, Path: hook.Path, ExitCode: -1}
+
+	timeout := DefaultTimeout
+	if hook.Timeout > 0 {
+		timeout = time.Duration(hook.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	name, args := hookCommand(hook.Path, hook.Args)
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = append(os.Environ(), "PATCHMON_HOOK_STAGE="+stage)
+	for key, value := range env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	cmd.WaitDelay = waitDelay
+
+	log := m.logger.WithFields(logrus.Fields{"stage": stage, "hook": hook.Path})
+	log.Info("Running hook...")
+
+	start := time.Now()
+	err := cmd.Run()
+	result.DurationMs = time.Since(start).Milliseconds()
+	result.Output = truncateOutput(output.String())
+
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		result.TimedOut = true
+		result.Error = fmt.Sprintf("timed out after %s", timeout)
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+		result.Error = "exit code " + strconv.Itoa(result.ExitCode)
+	case err != nil:
+		result.Error = err.Error()
+	default:
+		result.ExitCode = 0
+		result.Succeeded = true
+	}
+
+	if result.Succeeded {
+		log.WithField("duration_ms", result.DurationMs).Info("Hook completed")
+	} else {
+		log.WithField("error", result.Error).Warn("Hook failed")
+	}
+	return result
+}
+
+// hookCommand returns the program and arguments that run path: PowerShell for
+// .ps1 scripts, cmd.exe for batch files, and the file itself otherwise
+func hookCommand(path string, args []string) (string, []string) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ps1":
+		return "powershell.exe", append([]string{"-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass", "-File", path}, args...)
+	case ".cmd", ".bat":
+		return "cmd.exe", append([]string{"/c", path}, args...)
+	default:
+		return path, args
+	}
+}
+
+// truncateOutput keeps the end of output, which usually holds the error. The
+// cut is moved forward to a rune boundary so no character is split.
+func truncateOutput(output string) string {
+	output = strings.TrimSpace(output)
+	if len(output) <= maxOutput {
+		return output
+	}
+	start := len(output) - maxOutput
+	for start < len(output) && !utf8.RuneStart(output[start]) {
+		start++
+	}
+	return "..." + output[start:]
+}
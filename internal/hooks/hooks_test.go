@@ -0,0 +1,135 @@
+package hooks
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+
+	"patchmon-agent/pkg/models"
+)
+
+func newTestLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// helperHook returns a hook that re-runs the test binary as TestHelperProcess,
+// which behaves according to mode
+func helperHook(t *testing.T, mode string, timeout int) models.HookConfig {
+	t.Helper()
+	t.Setenv("PATCHMON_HOOK_HELPER", "1")
+	return models.HookConfig{
+		Path:    os.Args[0],
+		Args:    []string{"-test.run=TestHelperProcess", "--", mode},
+		Timeout: timeout,
+	}
+}
+
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("PATCHMON_HOOK_HELPER") != "1" {
+		return
+	}
+	switch os.Args[len(os.Args)-1] {
+	case "ok":
+		fmt.Printf("stage=%s reboot=%s\n", os.Getenv("PATCHMON_HOOK_STAGE"), os.Getenv("PATCHMON_REBOOT_REQUIRED"))
+		os.Exit(0)
+	case "fail":
+		fmt.Fprintln(os.Stderr, "service did not stop")
+		os.Exit(3)
+	case "hang":
+		time.Sleep(time.Minute)
+	}
+	os.Exit(2)
+}
+
+func TestRun(t *testing.T) {
+	mgr := New(newTestLogger())
+
+	results := mgr.Run(StagePostInstall, []models.HookConfig{helperHook(t, "ok", 0)}, map[string]string{"PATCHMON_REBOOT_REQUIRED": "true"}, false)
+	if len(results) != 1 || !results[0].Succeeded || results[0].ExitCode != 0 {
+		t.Fatalf("unexpected result: %+v", results)
+	}
+	if !strings.Contains(results[0].Output, "stage=post_install reboot=true") {
+		t.Errorf("hook environment not passed, output %q", results[0].Output)
+	}
+	if Failed(results) {
+		t.Error("Failed() = true for a successful hook")
+	}
+}
+
+func TestRun_Failures(t *testing.T) {
+	mgr := New(newTestLogger())
+	hooks := []models.HookConfig{helperHook(t, "fail", 0), helperHook(t, "ok", 0)}
+
+	results := mgr.Run(StagePreInstall, hooks, nil, true)
+	if len(results) != 1 {
+		t.Fatalf("expected the remaining hooks to be skipped, got %+v", results)
+	}
+	if results[0].Succeeded || results[0].ExitCode != 3 || !strings.Contains(results[0].Output, "service did not stop") {
+		t.Errorf("unexpected failed result: %+v", results[0])
+	}
+	if !Failed(results) {
+		t.Error("Failed() = false for a failed hook")
+	}
+
+	if results := mgr.Run(StagePreInstall, hooks, nil, false); len(results) != 2 {
+		t.Errorf("expected all hooks to run without stopOnFailure, got %d", len(results))
+	}
+}
+
+func TestRun_Timeout(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping timeout test in short mode")
+	}
+	mgr := New(newTestLogger())
+
+	results := mgr.Run(StagePreInstall, []models.HookConfig{helperHook(t, "hang", 1)}, nil, true)
+	if len(results) != 1 || !results[0].TimedOut || results[0].Succeeded {
+		t.Errorf("expected a timed out hook, got %+v", results)
+	}
+}
+
+func TestHookCommand(t *testing.T) {
+	tests := []struct {
+		path     string
+		wantName string
+		wantArgs []string
+	}{
+		{`C:\hooks\stop.ps1`, "powershell.exe", []string{"-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass", "-File", `C:\hooks\stop.ps1`, "-Force"}},
+		{`C:\hooks\stop.CMD`, "cmd.exe", []string{"/c", `C:\hooks\stop.CMD`, "-Force"}},
+		{`C:\hooks\check.exe`, `C:\hooks\check.exe`, []string{"-Force"}},
+	}
+
+	for _, tt := range tests {
+		name, args := hookCommand(tt.path, []string{"-Force"})
+		if name != tt.wantName || !reflect.DeepEqual(args, tt.wantArgs) {
+			t.Errorf("hookCommand(%q) = %q %v, want %q %v", tt.path, name, args, tt.wantName, tt.wantArgs)
+		}
+	}
+}
+
+func TestTruncateOutput(t *testing.T) {
+	long := strings.Repeat("a", maxOutput) + "tail"
+	got := truncateOutput(long)
+	if !strings.HasPrefix(got, "...") || !strings.HasSuffix(got, "tail") || len(got) != maxOutput+3 {
+		t.Errorf("unexpected truncation: len %d", len(got))
+	}
+	if got := truncateOutput("  short\n"); got != "short" {
+		t.Errorf("truncateOutput(short) = %q", got)
+	}
+
+	// The cut falls inside the three-byte "€"
+	multibyte := "x" + strings.Repeat("€", maxOutput/3+1)
+	got = truncateOutput(multibyte)
+	if !utf8.ValidString(got) || !strings.HasPrefix(got, "...€") || len(got) != maxOutput+1 {
+		t.Errorf("truncateOutput(multibyte) = valid %v, len %d, prefix %q", utf8.ValidString(got), len(got), got[:6])
+	}
+}
@@ -0,0 +1,144 @@
+package hyperv
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/yusufpapurcu/wmi"
+
+	"patchmon-agent/pkg/models"
+)
+
+// virtualizationNamespace is the WMI namespace of the Hyper-V provider; it
+// only exists when the Hyper-V role is installed
+const virtualizationNamespace = `root\virtualization\v2`
+
+// VM states reported in HyperVGuest.State
+const (
+	StateRunning  = "running"
+	StateOff      = "off"
+	StateSaved    = "saved"
+	StatePaused   = "paused"
+	StateStarting = "starting"
+	StateSaving   = "saving"
+	StateStopping = "stopping"
+	StateUnknown  = "unknown"
+)
+
+// vmStates maps Msvm_ComputerSystem.EnabledState values
+var vmStates = map[uint16]string{
+	2:     StateRunning,
+	3:     StateOff,
+	6:     StateSaved,
+	9:     StatePaused,
+	32768: StatePaused,
+	32769: StateSaved,
+	32770: StateStarting,
+	32773: StateSaving,
+	32774: StateStopping,
+}
+
+// Manager inventories the virtual machines of a Hyper-V host
+type Manager struct {
+	logger *logrus.Logger
+}
+
+// New creates a new Hyper-V manager
+func New(logger *logrus.Logger) *Manager {
+	return &Manager{
+		logger: logger,
+	}
+}
+
+// msvmComputerSystem maps the WMI Msvm_ComputerSystem class
+type msvmComputerSystem struct {
+	Name         string // VM ID
+	ElementName  string // display name
+	EnabledState uint16
+}
+
+// msvmKvpExchangeComponent maps the WMI Msvm_KvpExchangeComponent class
+type msvmKvpExchangeComponent struct {
+	SystemName                  string // VM ID
+	GuestIntrinsicExchangeItems []string
+}
+
+// GetGuests lists the virtual machines on this Hyper-V host with the guest OS
+// details published by the integration services (data exchange). It returns
+// nil if the Hyper-V role is not installed.
+func (m *Manager) GetGuests() []models.HyperVGuest {
+	var systems []msvmComputerSystem
+	err := wmi.QueryNamespace("SELECT Name, ElementName, EnabledState FROM Msvm_ComputerSystem WHERE Caption = 'Virtual Machine'", &systems, virtualizationNamespace)
+	if err != nil {
+		m.logger.WithError(err).Debug("Hyper-V is not available")
+		return nil
+	}
+
+	var kvps []msvmKvpExchangeComponent
+	if err := wmi.QueryNamespace("SELECT SystemName, GuestIntrinsicExchangeItems FROM Msvm_KvpExchangeComponent", &kvps, virtualizationNamespace); err != nil {
+		m.logger.WithError(err).Debug("Failed to query Hyper-V data exchange items")
+	}
+	guestItems := make(map[string]map[string]string, len(kvps))
+	for _, kvp := range kvps {
+		guestItems[kvp.SystemName] = parseKvpItems(kvp.GuestIntrinsicExchangeItems)
+	}
+
+	guests := make([]models.HyperVGuest, 0, len(systems))
+	for _, s := range systems {
+		items := guestItems[s.Name]
+		guests = append(guests, models.HyperVGuest{
+			ID:        strings.ToUpper(s.Name),
+			Name:      s.ElementName,
+			State:     vmState(s.EnabledState),
+			Hostname:  items["FullyQualifiedDomainName"],
+			OSName:    items["OSName"],
+			OSVersion: items["OSVersion"],
+		})
+	}
+
+	m.logger.WithField("count", len(guests)).Debug("Collected Hyper-V guests")
+	return guests
+}
+
+// vmState maps an EnabledState value to a VM state
+func vmState(enabledState uint16) string {
+	if state, ok := vmStates[enabledState]; ok {
+		return state
+	}
+	return StateUnknown
+}
+
+// kvpInstance is an Msvm_KvpExchangeDataItem embedded instance as returned
+// in GuestIntrinsicExchangeItems
+type kvpInstance struct {
+	Properties []struct {
+		Name  string `xml:"NAME,attr"`
+		Value string `xml:"VALUE"`
+	} `xml:"PROPERTY"`
+}
+
+// parseKvpItems parses the CIM-XML data exchange items into a Name -> Data map
+func parseKvpItems(items []string) map[string]string {
+	values := make(map[string]string, len(items))
+	for _, item := range items {
+		var instance kvpInstance
+		if err := xml.Unmarshal([]byte(item), &instance); err != nil {
+			continue
+		}
+
+		var name, data string
+		for _, p := range instance.Properties {
+			switch p.Name {
+			case "Name":
+				name = p.Value
+			case "Data":
+				data = p.Value
+			}
+		}
+		if name != "" {
+			values[name] = data
+		}
+	}
+	return values
+}
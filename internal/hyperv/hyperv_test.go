@@ -0,0 +1,52 @@
+package hyperv
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestNew(t *testing.T) {
+	if mgr := New(logrus.New()); mgr == nil {
+		t.Fatal("New() returned nil")
+	}
+}
+
+func TestVMState(t *testing.T) {
+	tests := map[uint16]string{
+		2:     StateRunning,
+		3:     StateOff,
+		32769: StateSaved,
+		32768: StatePaused,
+		1:     StateUnknown,
+	}
+	for enabledState, want := range tests {
+		if got := vmState(enabledState); got != want {
+			t.Errorf("vmState(%d) = %q, want %q", enabledState, got, want)
+		}
+	}
+}
+
+func TestParseKvpItems(t *testing.T) {
+	items := []string{
+		`<INSTANCE CLASSNAME="Msvm_KvpExchangeDataItem"><PROPERTY NAME="Caption" PROPAGATED="true" TYPE="string"></PROPERTY><PROPERTY NAME="Data" TYPE="string"><VALUE>Windows Server 2022 Datacenter</VALUE></PROPERTY><PROPERTY NAME="Name" TYPE="string"><VALUE>OSName</VALUE></PROPERTY><PROPERTY NAME="Source" TYPE="uint16"><VALUE>2</VALUE></PROPERTY></INSTANCE>`,
+		`<INSTANCE CLASSNAME="Msvm_KvpExchangeDataItem"><PROPERTY NAME="Data" TYPE="string"><VALUE>10.0.20348</VALUE></PROPERTY><PROPERTY NAME="Name" TYPE="string"><VALUE>OSVersion</VALUE></PROPERTY></INSTANCE>`,
+		`<INSTANCE CLASSNAME="Msvm_KvpExchangeDataItem"><PROPERTY NAME="Data" TYPE="string"><VALUE>web01.contoso.com</VALUE></PROPERTY><PROPERTY NAME="Name" TYPE="string"><VALUE>FullyQualifiedDomainName</VALUE></PROPERTY></INSTANCE>`,
+		`not xml`,
+	}
+
+	got := parseKvpItems(items)
+	want := map[string]string{
+		"OSName":                   "Windows Server 2022 Datacenter",
+		"OSVersion":                "10.0.20348",
+		"FullyQualifiedDomainName": "web01.contoso.com",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("parseKvpItems() = %v, want %v", got, want)
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("parseKvpItems()[%q] = %q, want %q", name, got[name], value)
+		}
+	}
+}
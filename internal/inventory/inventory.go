@@ -0,0 +1,27 @@
+package inventory
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"patchmon-agent/pkg/models"
+)
+
+// Manager collects the extended software inventory
+type Manager struct {
+	logger *logrus.Logger
+}
+
+// New creates a new inventory manager
+func New(logger *logrus.Logger) *Manager {
+	return &Manager{
+		logger: logger,
+	}
+}
+
+// GetExtendedInventory collects the extended software inventory. Per-user
+// items are only included when perUser is set.
+func (m *Manager) GetExtendedInventory(perUser bool) *models.ExtendedInventory {
+	return &models.ExtendedInventory{
+		StartupItems: m.GetStartupItems(perUser),
+	}
+}
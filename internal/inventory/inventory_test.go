@@ -0,0 +1,42 @@
+package inventory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestNew(t *testing.T) {
+	if mgr := New(logrus.New()); mgr == nil {
+		t.Fatal("New() returned nil")
+	}
+}
+
+func TestStartupFolderItems(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"OneDrive.lnk", "desktop.ini", "sync.exe"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "subdir"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	items := startupFolderItems(dir, "alice")
+	if len(items) != 2 {
+		t.Fatalf("startupFolderItems() returned %d items, want 2: %+v", len(items), items)
+	}
+	if items[0].Name != "OneDrive" || items[0].Command != filepath.Join(dir, "OneDrive.lnk") || items[0].Scope != "alice" || items[0].Location != dir {
+		t.Errorf("unexpected item %+v", items[0])
+	}
+	if items[1].Name != "sync" {
+		t.Errorf("second item name = %q, want sync", items[1].Name)
+	}
+
+	if items := startupFolderItems(filepath.Join(dir, "missing"), ScopeMachine); items != nil {
+		t.Errorf("startupFolderItems() = %+v for a missing folder, want nil", items)
+	}
+}
@@ -0,0 +1,159 @@
+package inventory
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+
+	"patchmon-agent/pkg/models"
+)
+
+// ScopeMachine is the StartupItem.Scope of items that run for every user
+const ScopeMachine = "machine"
+
+// runKeys are the Run and RunOnce keys under HKLM and each user hive
+var runKeys = []string{
+	`SOFTWARE\Microsoft\Windows\CurrentVersion\Run`,
+	`SOFTWARE\Microsoft\Windows\CurrentVersion\RunOnce`,
+	`SOFTWARE\WOW6432Node\Microsoft\Windows\CurrentVersion\Run`,
+	`SOFTWARE\WOW6432Node\Microsoft\Windows\CurrentVersion\RunOnce`,
+}
+
+// profileListKey lists the user profiles present on this machine
+const profileListKey = `SOFTWARE\Microsoft\Windows NT\CurrentVersion\ProfileList`
+
+// startupFolder is the Startup folder relative to the common or a user's
+// Start Menu programs directory
+const startupFolder = `Microsoft\Windows\Start Menu\Programs\Startup`
+
+// userProfile is a local user profile
+type userProfile struct {
+	sid  string
+	name string // profile folder name
+	path string
+}
+
+// GetStartupItems lists the programs started at logon from the Run/RunOnce
+// registry keys and Startup folders of the machine and, with perUser, of every
+// local user profile. Per-user Run keys can only be read for users whose
+// registry hive is loaded (typically logged-on users).
+func (m *Manager) GetStartupItems(perUser bool) []models.StartupItem {
+	items := []models.StartupItem{}
+	for _, keyPath := range runKeys {
+		items = append(items, readRunKey(registry.LOCAL_MACHINE, `HKLM\`+keyPath, keyPath, ScopeMachine)...)
+	}
+
+	programData := os.Getenv("ProgramData")
+	if programData == "" {
+		programData = `C:\ProgramData`
+	}
+	items = append(items, startupFolderItems(filepath.Join(programData, startupFolder), ScopeMachine)...)
+
+	if perUser {
+		for _, profile := range m.getUserProfiles() {
+			// User hives have no WOW6432Node Run keys of their own
+			for _, keyPath := range runKeys[:2] {
+				items = append(items, readRunKey(registry.USERS, `HKU\`+profile.sid+`\`+keyPath, profile.sid+`\`+keyPath, profile.name)...)
+			}
+			dir := filepath.Join(profile.path, "AppData", "Roaming", startupFolder)
+			items = append(items, startupFolderItems(dir, profile.name)...)
+		}
+	}
+
+	m.logger.WithField("count", len(items)).Debug("Collected startup items")
+	return items
+}
+
+// readRunKey lists the commands of a Run or RunOnce key
+func readRunKey(root registry.Key, location, keyPath, scope string) []models.StartupItem {
+	key, err := registry.OpenKey(root, keyPath, registry.QUERY_VALUE)
+	if err != nil {
+		return nil
+	}
+	defer key.Close()
+
+	names, err := key.ReadValueNames(-1)
+	if err != nil {
+		return nil
+	}
+
+	var items []models.StartupItem
+	for _, name := range names {
+		command, _, err := key.GetStringValue(name)
+		if err != nil || command == "" {
+			continue
+		}
+		if name == "" {
+			name = "(Default)"
+		}
+		items = append(items, models.StartupItem{
+			Name:     name,
+			Command:  command,
+			Location: location,
+			Scope:    scope,
+		})
+	}
+	return items
+}
+
+// startupFolderItems lists the shortcuts and programs in a Startup folder
+func startupFolderItems(dir, scope string) []models.StartupItem {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var items []models.StartupItem
+	for _, entry := range entries {
+		if entry.IsDir() || strings.EqualFold(entry.Name(), "desktop.ini") {
+			continue
+		}
+		items = append(items, models.StartupItem{
+			Name:     strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())),
+			Command:  filepath.Join(dir, entry.Name()),
+			Location: dir,
+			Scope:    scope,
+		})
+	}
+	return items
+}
+
+// getUserProfiles returns the local profiles of real user accounts
+func (m *Manager) getUserProfiles() []userProfile {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, profileListKey, registry.ENUMERATE_SUB_KEYS)
+	if err != nil {
+		m.logger.WithError(err).Debug("Failed to open profile list")
+		return nil
+	}
+	defer key.Close()
+
+	sids, err := key.ReadSubKeyNames(-1)
+	if err != nil {
+		m.logger.WithError(err).Debug("Failed to enumerate profiles")
+		return nil
+	}
+
+	var profiles []userProfile
+	for _, sid := range sids {
+		// Service profiles (S-1-5-18, -19, -20) have no interactive startup items
+		if !strings.HasPrefix(sid, "S-1-5-21-") {
+			continue
+		}
+		profileKey, err := registry.OpenKey(key, sid, registry.QUERY_VALUE)
+		if err != nil {
+			continue
+		}
+		path, _, err := profileKey.GetStringValue("ProfileImagePath")
+		profileKey.Close()
+		if err != nil || path == "" {
+			continue
+		}
+		if expanded, err := registry.ExpandString(path); err == nil {
+			path = expanded
+		}
+		profiles = append(profiles, userProfile{sid: sid, name: filepath.Base(path), path: path})
+	}
+	return profiles
+}
@@ -0,0 +1,89 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"patchmon-agent/internal/constants"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/windows"
+)
+
+// ConsoleHook is a logrus hook that writes entries to the console with its own
+// formatter, so the console can be colored and filtered independently of the
+// log file
+type ConsoleHook struct {
+	mu        sync.Mutex
+	writer    io.Writer
+	formatter logrus.Formatter
+	levels    []logrus.Level
+}
+
+// NewConsoleHook returns a hook writing the entries at level or above to w
+func NewConsoleHook(w io.Writer, formatter logrus.Formatter, level logrus.Level) *ConsoleHook {
+	h := &ConsoleHook{writer: w, formatter: formatter}
+	for _, l := range logrus.AllLevels {
+		if l <= level {
+			h.levels = append(h.levels, l)
+		}
+	}
+	return h
+}
+
+// Levels returns the levels the hook fires for
+func (h *ConsoleHook) Levels() []logrus.Level {
+	return h.levels
+}
+
+// Fire writes an entry to the console
+func (h *ConsoleHook) Fire(entry *logrus.Entry) error {
+	line, err := h.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err = h.writer.Write(line)
+	return err
+}
+
+// EnableConsoleColors reports whether f is an interactive console that can
+// show ANSI colors, turning on virtual terminal processing for it. Output
+// redirected to a file or pipe, e.g. under Task Scheduler, is never colored.
+func EnableConsoleColors(f *os.File) bool {
+	console := windows.Handle(f.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(console, &mode); err != nil {
+		return false
+	}
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+		return true
+	}
+	return windows.SetConsoleMode(console, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING) == nil
+}
+
+// ParseLogOutput parses the log_output setting (file, stdout or both; empty
+// means file) and returns whether logs go to the log file and to the
+// console. consoleFlag (--log-stdout) moves logging from the file to the
+// console unless the setting is both.
+func ParseLogOutput(setting string, consoleFlag bool) (toFile, toConsole bool, err error) {
+	switch setting {
+	case "", constants.LogOutputFile:
+		toFile = true
+	case constants.LogOutputStdout:
+		toConsole = true
+	case constants.LogOutputBoth:
+		return true, true, nil
+	default:
+		outputs := []string{constants.LogOutputFile, constants.LogOutputStdout, constants.LogOutputBoth}
+		return false, false, fmt.Errorf("log_output %q is not one of %s", setting, strings.Join(outputs, ", "))
+	}
+	if consoleFlag {
+		return false, true, nil
+	}
+	return toFile, toConsole, nil
+}
@@ -0,0 +1,80 @@
+package logging
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestConsoleHook(t *testing.T) {
+	tests := []struct {
+		name  string
+		level logrus.Level
+		want  []string
+		skip  []string
+	}{
+		{"all levels", logrus.TraceLevel, []string{"collecting", "search slow", "send failed"}, nil},
+		{"quiet", logrus.ErrorLevel, []string{"send failed"}, []string{"collecting", "search slow"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := logrus.New()
+			logger.SetOutput(io.Discard)
+			logger.AddHook(NewConsoleHook(&buf, &logrus.TextFormatter{DisableColors: true}, tt.level))
+
+			logger.Info("collecting")
+			logger.Warn("search slow")
+			logger.Error("send failed")
+
+			got := buf.String()
+			for _, msg := range tt.want {
+				if !strings.Contains(got, msg) {
+					t.Errorf("console output %q is missing %q", got, msg)
+				}
+			}
+			for _, msg := range tt.skip {
+				if strings.Contains(got, msg) {
+					t.Errorf("console output %q contains %q", got, msg)
+				}
+			}
+			if strings.Contains(got, "\x1b[") {
+				t.Errorf("console output %q is colored", got)
+			}
+		})
+	}
+}
+
+func TestParseLogOutput(t *testing.T) {
+	tests := []struct {
+		setting     string
+		consoleFlag bool
+		toFile      bool
+		toConsole   bool
+		wantErr     bool
+	}{
+		{"", false, true, false, false},
+		{"file", false, true, false, false},
+		{"stdout", false, false, true, false},
+		{"both", false, true, true, false},
+		{"", true, false, true, false},
+		{"file", true, false, true, false},
+		{"both", true, true, true, false},
+		{"console", false, false, false, true},
+		{"Stdout", true, false, false, true},
+	}
+	for _, tt := range tests {
+		toFile, toConsole, err := ParseLogOutput(tt.setting, tt.consoleFlag)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLogOutput(%q, %v) error = %v, wantErr %v", tt.setting, tt.consoleFlag, err, tt.wantErr)
+			continue
+		}
+		if toFile != tt.toFile || toConsole != tt.toConsole {
+			t.Errorf("ParseLogOutput(%q, %v) = %v, %v, want %v, %v", tt.setting, tt.consoleFlag, toFile, toConsole, tt.toFile, tt.toConsole)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package logging
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// EventLogSource is the Application log source the agent writes to
+const EventLogSource = "PatchMon Agent"
+
+// Event IDs by severity, so forwarding rules can filter on them
+const (
+	eventIDError   uint32 = 1
+	eventIDWarning uint32 = 2
+	eventIDInfo    uint32 = 3
+)
+
+const eventLogSourcesKey = `SYSTEM\CurrentControlSet\Services\EventLog\Application\`
+
+// EventLogHook is a logrus hook that writes entries to the Windows Event Log
+type EventLogHook struct {
+	log    *eventlog.Log
+	levels []logrus.Level
+}
+
+// NewEventLogHook opens the event source, registering it on first use, and
+// returns a hook for the entries at level or above. The source can only be
+// registered by an Administrator; without registration events are still
+// written, but Event Viewer cannot format their description.
+func NewEventLogHook(level logrus.Level) (*EventLogHook, error) {
+	if !eventSourceRegistered() {
+		_ = eventlog.InstallAsEventCreate(EventLogSource, eventlog.Error|eventlog.Warning|eventlog.Info)
+	}
+
+	l, err := eventlog.Open(EventLogSource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log source %q: %w", EventLogSource, err)
+	}
+	return &EventLogHook{log: l, levels: hookLevels(level)}, nil
+}
+
+// ParseEventLogLevel parses the event_log setting: "warn" writes warnings and
+// errors, "info" adds informational entries, and "" or "off" disables it
+func ParseEventLogLevel(setting string) (logrus.Level, bool, error) {
+	switch strings.ToLower(strings.TrimSpace(setting)) {
+	case "", "off":
+		return 0, false, nil
+	case "warn", "warning":
+		return logrus.WarnLevel, true, nil
+	case "info":
+		return logrus.InfoLevel, true, nil
+	}
+	return 0, false, fmt.Errorf("invalid event_log value %q (expected off, warn or info)", setting)
+}
+
+// Levels returns the levels the hook fires for
+func (h *EventLogHook) Levels() []logrus.Level {
+	return h.levels
+}
+
+// Fire writes an entry to the event log
+func (h *EventLogHook) Fire(entry *logrus.Entry) error {
+	msg := eventMessage(entry)
+	switch entry.Level {
+	case logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel:
+		return h.log.Error(eventIDError, msg)
+	case logrus.WarnLevel:
+		return h.log.Warning(eventIDWarning, msg)
+	default:
+		return h.log.Info(eventIDInfo, msg)
+	}
+}
+
+// Close closes the event source
+func (h *EventLogHook) Close() error {
+	return h.log.Close()
+}
+
+// hookLevels returns the levels from panic down to level, never including
+// debug and trace
+func hookLevels(level logrus.Level) []logrus.Level {
+	if level > logrus.InfoLevel {
+		level = logrus.InfoLevel
+	}
+	var levels []logrus.Level
+	for _, l := range logrus.AllLevels {
+		if l <= level {
+			levels = append(levels, l)
+		}
+	}
+	return levels
+}
+
+// eventMessage formats an entry as its message followed by one "key: value"
+// line per field, sorted by key
+func eventMessage(entry *logrus.Entry) string {
+	if len(entry.Data) == 0 {
+		return entry.Message
+	}
+
+	keys := make([]string, 0, len(entry.Data))
+	for key := range entry.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(entry.Message)
+	b.WriteString("\r\n")
+	for _, key := range keys {
+		fmt.Fprintf(&b, "\r\n%s: %v", key, entry.Data[key])
+	}
+	return b.String()
+}
+
+// eventSourceRegistered reports whether the event source exists
+func eventSourceRegistered() bool {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, eventLogSourcesKey+EventLogSource, registry.QUERY_VALUE)
+	if err != nil {
+		return false
+	}
+	key.Close()
+	return true
+}
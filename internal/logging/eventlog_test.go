@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestParseEventLogLevel(t *testing.T) {
+	tests := []struct {
+		setting   string
+		wantLevel logrus.Level
+		wantOn    bool
+		wantErr   bool
+	}{
+		{"", 0, false, false},
+		{"off", 0, false, false},
+		{"warn", logrus.WarnLevel, true, false},
+		{"Warning", logrus.WarnLevel, true, false},
+		{" info ", logrus.InfoLevel, true, false},
+		{"debug", 0, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.setting, func(t *testing.T) {
+			level, on, err := ParseEventLogLevel(tt.setting)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseEventLogLevel(%q) error = %v, wantErr %v", tt.setting, err, tt.wantErr)
+			}
+			if level != tt.wantLevel || on != tt.wantOn {
+				t.Errorf("ParseEventLogLevel(%q) = %v, %v, want %v, %v", tt.setting, level, on, tt.wantLevel, tt.wantOn)
+			}
+		})
+	}
+}
+
+func TestHookLevels(t *testing.T) {
+	tests := []struct {
+		name  string
+		level logrus.Level
+		want  []logrus.Level
+	}{
+		{"warn", logrus.WarnLevel, []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel, logrus.WarnLevel}},
+		{"info", logrus.InfoLevel, []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel, logrus.WarnLevel, logrus.InfoLevel}},
+		{"debug is capped at info", logrus.DebugLevel, []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel, logrus.WarnLevel, logrus.InfoLevel}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hookLevels(tt.level); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("hookLevels(%v) = %v, want %v", tt.level, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventMessage(t *testing.T) {
+	logger := logrus.New()
+	tests := []struct {
+		name  string
+		entry *logrus.Entry
+		want  string
+	}{
+		{"no fields", logrus.NewEntry(logger), ""},
+		{
+			"fields sorted",
+			logger.WithFields(logrus.Fields{"path": `C:\ProgramData\PatchMon`, "error": errors.New("access denied")}),
+			"\r\n\r\nerror: access denied\r\npath: C:\\ProgramData\\PatchMon",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.entry.Message = "Failed to save config"
+			if got, want := eventMessage(tt.entry), "Failed to save config"+tt.want; got != want {
+				t.Errorf("eventMessage() = %q, want %q", got, want)
+			}
+		})
+	}
+}
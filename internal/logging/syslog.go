@@ -0,0 +1,187 @@
+package logging
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// syslogAppName is the APP-NAME of forwarded messages
+const syslogAppName = "patchmon-agent"
+
+// syslogFacility is the daemon facility
+const syslogFacility = 3
+
+const syslogTimeout = 5 * time.Second
+
+// syslogRetryDelay is how long entries are dropped after a failed connection,
+// so an unreachable server does not stall every log call
+const syslogRetryDelay = time.Minute
+
+// SyslogHook is a logrus hook that forwards entries to a syslog server as
+// RFC 5424 messages, over UDP, TCP or TLS. TCP and TLS messages are framed
+// by octet counting (RFC 6587).
+type SyslogHook struct {
+	network   string
+	addr      string
+	tlsConfig *tls.Config
+	hostname  string
+	levels    []logrus.Level
+
+	mu         sync.Mutex
+	conn       net.Conn
+	retryAfter time.Time
+}
+
+// NewSyslogHook returns a hook forwarding the entries at level or above to
+// server, given as udp://host[:port], tcp://host[:port] or tls://host[:port].
+// The connection is made when the first entry is sent.
+func NewSyslogHook(server string, level logrus.Level, skipTLSVerify bool) (*SyslogHook, error) {
+	network, addr, useTLS, err := parseSyslogServer(server)
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, _ := os.Hostname()
+	h := &SyslogHook{network: network, addr: addr, hostname: hostname}
+	if useTLS {
+		host, _, _ := net.SplitHostPort(addr)
+		h.tlsConfig = &tls.Config{ServerName: host, InsecureSkipVerify: skipTLSVerify}
+	}
+	for _, l := range logrus.AllLevels {
+		if l <= level {
+			h.levels = append(h.levels, l)
+		}
+	}
+	return h, nil
+}
+
+// Levels returns the levels the hook fires for
+func (h *SyslogHook) Levels() []logrus.Level {
+	return h.levels
+}
+
+// Fire sends an entry, reconnecting once if the connection was lost
+func (h *SyslogHook) Fire(entry *logrus.Entry) error {
+	msg := syslogMessage(entry, h.hostname, os.Getpid())
+	if h.network != "udp" {
+		msg = fmt.Sprintf("%d %s", len(msg), msg)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if h.conn == nil {
+			if time.Now().Before(h.retryAfter) {
+				return nil
+			}
+			if h.conn, err = h.dial(); err != nil {
+				h.retryAfter = time.Now().Add(syslogRetryDelay)
+				return fmt.Errorf("failed to connect to syslog server %s: %w", h.addr, err)
+			}
+		}
+		_ = h.conn.SetWriteDeadline(time.Now().Add(syslogTimeout))
+		if _, err = h.conn.Write([]byte(msg)); err == nil {
+			return nil
+		}
+		h.conn.Close()
+		h.conn = nil
+	}
+	return fmt.Errorf("failed to send to syslog server %s: %w", h.addr, err)
+}
+
+// Close closes the connection to the syslog server
+func (h *SyslogHook) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.conn == nil {
+		return nil
+	}
+	err := h.conn.Close()
+	h.conn = nil
+	return err
+}
+
+func (h *SyslogHook) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: syslogTimeout}
+	if h.tlsConfig != nil {
+		return tls.DialWithDialer(dialer, "tcp", h.addr, h.tlsConfig)
+	}
+	return dialer.Dial(h.network, h.addr)
+}
+
+// parseSyslogServer parses the syslog_server setting. The port defaults to
+// 514, or 6514 for TLS.
+func parseSyslogServer(server string) (network, addr string, useTLS bool, err error) {
+	u, err := url.Parse(server)
+	if err != nil || u.Host == "" {
+		return "", "", false, fmt.Errorf("invalid syslog server %q (expected udp://, tcp:// or tls://host[:port])", server)
+	}
+
+	port := "514"
+	switch u.Scheme {
+	case "udp", "tcp":
+		network = u.Scheme
+	case "tls":
+		network, useTLS, port = "tcp", true, "6514"
+	default:
+		return "", "", false, fmt.Errorf("unsupported syslog protocol %q (expected udp, tcp or tls)", u.Scheme)
+	}
+	if u.Port() != "" {
+		port = u.Port()
+	}
+	return network, net.JoinHostPort(u.Hostname(), port), useTLS, nil
+}
+
+// syslogSeverity maps a logrus level to a syslog severity
+func syslogSeverity(level logrus.Level) int {
+	switch level {
+	case logrus.PanicLevel, logrus.FatalLevel:
+		return 2 // critical
+	case logrus.ErrorLevel:
+		return 3
+	case logrus.WarnLevel:
+		return 4
+	case logrus.InfoLevel:
+		return 6
+	}
+	return 7 // debug
+}
+
+// syslogMessage formats an entry as an RFC 5424 message, with the fields
+// appended to the message as key=value pairs sorted by key
+func syslogMessage(entry *logrus.Entry, hostname string, pid int) string {
+	if hostname == "" {
+		hostname = "-"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %d - - %s",
+		syslogFacility*8+syslogSeverity(entry.Level),
+		entry.Time.Format(time.RFC3339Nano), hostname, syslogAppName, pid,
+		strings.ReplaceAll(entry.Message, "\n", " "))
+
+	keys := make([]string, 0, len(entry.Data))
+	for key := range entry.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := fmt.Sprint(entry.Data[key])
+		if strings.ContainsAny(value, " \"=\n") {
+			value = fmt.Sprintf("%q", value)
+		}
+		fmt.Fprintf(&b, " %s=%s", key, value)
+	}
+	return b.String()
+}
@@ -0,0 +1,126 @@
+package logging
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestParseSyslogServer(t *testing.T) {
+	tests := []struct {
+		server      string
+		wantNetwork string
+		wantAddr    string
+		wantTLS     bool
+		wantErr     bool
+	}{
+		{"udp://logs.example.com", "udp", "logs.example.com:514", false, false},
+		{"tcp://10.0.0.5:1514", "tcp", "10.0.0.5:1514", false, false},
+		{"tls://logs.example.com", "tcp", "logs.example.com:6514", true, false},
+		{"tls://[2001:db8::1]:7514", "tcp", "[2001:db8::1]:7514", true, false},
+		{"http://logs.example.com", "", "", false, true},
+		{"logs.example.com:514", "", "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.server, func(t *testing.T) {
+			network, addr, useTLS, err := parseSyslogServer(tt.server)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSyslogServer(%q) error = %v, wantErr %v", tt.server, err, tt.wantErr)
+			}
+			if network != tt.wantNetwork || addr != tt.wantAddr || useTLS != tt.wantTLS {
+				t.Errorf("parseSyslogServer(%q) = %q, %q, %v, want %q, %q, %v",
+					tt.server, network, addr, useTLS, tt.wantNetwork, tt.wantAddr, tt.wantTLS)
+			}
+		})
+	}
+}
+
+func TestSyslogMessage(t *testing.T) {
+	logger := logrus.New()
+	ts := time.Date(2025, 3, 4, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		entry *logrus.Entry
+		want  string
+	}{
+		{
+			"warning without fields",
+			&logrus.Entry{Logger: logger, Time: ts, Level: logrus.WarnLevel, Message: "Update scan skipped"},
+			"<28>1 2025-03-04T10:30:00Z HOST01 patchmon-agent 1234 - - Update scan skipped",
+		},
+		{
+			"error with fields",
+			&logrus.Entry{Logger: logger, Time: ts, Level: logrus.ErrorLevel, Message: "Failed to send report",
+				Data: logrus.Fields{"status": 502, "error": errors.New("bad gateway")}},
+			`<27>1 2025-03-04T10:30:00Z HOST01 patchmon-agent 1234 - - Failed to send report error="bad gateway" status=502`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := syslogMessage(tt.entry, "HOST01", 1234); got != tt.want {
+				t.Errorf("syslogMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyslogHookTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		length, err := r.ReadString(' ')
+		if err != nil {
+			return
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(length))
+		if err != nil {
+			received <- "bad frame length " + length
+			return
+		}
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(r, msg); err == nil {
+			received <- string(msg)
+		}
+	}()
+
+	hook, err := NewSyslogHook("tcp://"+ln.Addr().String(), logrus.InfoLevel, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hook.Close()
+
+	entry := &logrus.Entry{Logger: logrus.New(), Time: time.Now(), Level: logrus.InfoLevel, Message: "Report sent"}
+	if err := hook.Fire(entry); err != nil {
+		t.Fatalf("Fire() error = %v", err)
+	}
+
+	select {
+	case got := <-received:
+		if !strings.HasPrefix(got, "<30>1 ") || !strings.HasSuffix(got, " - - Report sent") {
+			t.Errorf("received %q", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+}
@@ -0,0 +1,261 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/mem"
+	"github.com/sirupsen/logrus"
+	"github.com/yusufpapurcu/wmi"
+
+	"patchmon-agent/pkg/models"
+)
+
+// DefaultSampleSeconds is how long resource usage is sampled for when
+// metrics_sample_seconds is not set
+const DefaultSampleSeconds = 5
+
+// Load average windows, as on Unix
+var loadWindows = [3]time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute}
+
+// loadSampleInterval is how often SampleLoad adds to the load averages, as
+// the Unix kernel does
+const loadSampleInterval = 5 * time.Second
+
+// perfOSSystem maps the WMI Win32_PerfFormattedData_PerfOS_System class
+type perfOSSystem struct {
+	ProcessorQueueLength uint32
+}
+
+// perfLogicalDisk maps the WMI Win32_PerfFormattedData_PerfDisk_LogicalDisk class
+type perfLogicalDisk struct {
+	Name                   string
+	CurrentDiskQueueLength uint32
+	FreeMegabytes          uint32
+	PercentFreeSpace       uint32
+}
+
+// sample is one reading of the performance counters
+type sample struct {
+	cpuPercent     float64
+	processorQueue float64
+	disks          []perfLogicalDisk
+}
+
+// Manager samples resource utilization. It keeps Windows load averages
+// across calls to Collect; a long-running agent should reuse it and run
+// SampleLoad so the averages cover the last 15 minutes.
+type Manager struct {
+	logger *logrus.Logger
+
+	mu       sync.Mutex
+	load     LoadAverager
+	sampling bool // SampleLoad is running and feeds the averages
+}
+
+// New creates a new metrics manager
+func New(logger *logrus.Logger) *Manager {
+	return &Manager{
+		logger: logger,
+	}
+}
+
+// Collect samples CPU, memory and disk usage once a second for
+// sampleSeconds (0 = DefaultSampleSeconds) and returns the averages
+func (m *Manager) Collect(sampleSeconds int) *models.ResourceMetrics {
+	if sampleSeconds <= 0 {
+		sampleSeconds = DefaultSampleSeconds
+	}
+	ctx := context.Background()
+	cores := runtime.NumCPU()
+
+	samples := make([]sample, 0, sampleSeconds)
+	for i := 0; i < sampleSeconds; i++ {
+		// Blocks for one second while CPU time is measured
+		percents, err := cpu.PercentWithContext(ctx, time.Second, false)
+		if err != nil || len(percents) == 0 {
+			m.logger.WithError(err).Debug("Failed to sample CPU usage")
+			continue
+		}
+		s := sample{cpuPercent: percents[0]}
+
+		if queue, err := processorQueueLength(); err != nil {
+			m.logger.WithError(err).Debug("Failed to query processor queue length")
+		} else {
+			s.processorQueue = queue
+		}
+		if err := wmi.Query("SELECT Name, CurrentDiskQueueLength, FreeMegabytes, PercentFreeSpace FROM Win32_PerfFormattedData_PerfDisk_LogicalDisk", &s.disks); err != nil {
+			m.logger.WithError(err).Debug("Failed to query logical disk counters")
+		}
+
+		samples = append(samples, s)
+		m.mu.Lock()
+		if !m.sampling {
+			m.load.Add(Load(s.cpuPercent, s.processorQueue, cores), time.Second)
+		}
+		m.mu.Unlock()
+	}
+	if len(samples) == 0 {
+		m.logger.Warn("Failed to sample resource usage")
+		return nil
+	}
+
+	metrics := summarize(samples)
+	metrics.SampledAt = time.Now().UTC().Format(time.RFC3339)
+	metrics.SampleSeconds = len(samples)
+
+	if vm, err := mem.VirtualMemoryWithContext(ctx); err != nil {
+		m.logger.WithError(err).Warn("Failed to get memory usage")
+	} else {
+		metrics.MemoryTotalBytes = vm.Total
+		metrics.MemoryUsedBytes = vm.Used
+		metrics.MemoryUsedPercent = round(vm.UsedPercent)
+	}
+
+	m.logger.WithFields(logrus.Fields{
+		"cpu_percent":    metrics.CPUPercent,
+		"memory_percent": metrics.MemoryUsedPercent,
+		"disk_queue":     metrics.DiskQueueLength,
+	}).Info("Collected resource metrics")
+	return metrics
+}
+
+// SampleLoad adds the current load to the averages every five seconds until
+// ctx is cancelled. Without it the averages only cover the samples Collect
+// takes, so all three are the load at report time.
+func (m *Manager) SampleLoad(ctx context.Context) {
+	m.mu.Lock()
+	m.sampling = true
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		m.sampling = false
+		m.mu.Unlock()
+	}()
+
+	cores := runtime.NumCPU()
+	// The first call only records the CPU times the next one is measured from
+	_, _ = cpu.PercentWithContext(ctx, 0, false)
+	ticker := time.NewTicker(loadSampleInterval)
+	defer ticker.Stop()
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			percents, err := cpu.PercentWithContext(ctx, 0, false)
+			if err != nil || len(percents) == 0 {
+				m.logger.WithError(err).Debug("Failed to sample CPU usage")
+				continue
+			}
+			queue, err := processorQueueLength()
+			if err != nil {
+				m.logger.WithError(err).Debug("Failed to query processor queue length")
+			}
+			m.mu.Lock()
+			m.load.Add(Load(percents[0], queue, cores), now.Sub(last))
+			m.mu.Unlock()
+			last = now
+		}
+	}
+}
+
+// LoadAverage returns the 1, 5 and 15-minute load averages of the samples
+// taken so far, or zeros if no sample has been taken
+func (m *Manager) LoadAverage() []float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.load.Averages()
+}
+
+// processorQueueLength returns the number of threads waiting for a processor
+func processorQueueLength() (float64, error) {
+	var system []perfOSSystem
+	if err := wmi.Query("SELECT ProcessorQueueLength FROM Win32_PerfFormattedData_PerfOS_System", &system); err != nil {
+		return 0, err
+	}
+	if len(system) == 0 {
+		return 0, nil
+	}
+	return float64(system[0].ProcessorQueueLength), nil
+}
+
+// summarize averages the samples. Per-volume free space is taken from the
+// last sample; queue lengths are averaged.
+func summarize(samples []sample) *models.ResourceMetrics {
+	metrics := &models.ResourceMetrics{}
+	queues := make(map[string]float64)
+	for _, s := range samples {
+		metrics.CPUPercent += s.cpuPercent
+		metrics.ProcessorQueueLength += s.processorQueue
+		for _, d := range s.disks {
+			queues[d.Name] += float64(d.CurrentDiskQueueLength)
+		}
+	}
+	n := float64(len(samples))
+	metrics.CPUPercent = round(metrics.CPUPercent / n)
+	metrics.ProcessorQueueLength = round(metrics.ProcessorQueueLength / n)
+	metrics.DiskQueueLength = round(queues["_Total"] / n)
+
+	for _, d := range samples[len(samples)-1].disks {
+		// Skip the _Total instance and volumes without a drive letter
+		if !strings.HasSuffix(d.Name, ":") {
+			continue
+		}
+		metrics.Volumes = append(metrics.Volumes, models.VolumeMetrics{
+			Name:        d.Name,
+			FreeBytes:   uint64(d.FreeMegabytes) * 1024 * 1024,
+			FreePercent: float64(d.PercentFreeSpace),
+			QueueLength: round(queues[d.Name] / n),
+		})
+	}
+	sort.Slice(metrics.Volumes, func(i, j int) bool {
+		return metrics.Volumes[i].Name < metrics.Volumes[j].Name
+	})
+	return metrics
+}
+
+// Load is the Windows equivalent of the Unix run queue: the number of busy
+// processors plus the threads waiting for one
+func Load(cpuPercent, processorQueue float64, cores int) float64 {
+	return cpuPercent/100*float64(cores) + processorQueue
+}
+
+// LoadAverager keeps exponentially damped 1, 5 and 15-minute load averages
+// like the Unix kernel. The first sample initializes all three averages, so
+// a one-off report returns the current load rather than a value ramping up
+// from zero.
+type LoadAverager struct {
+	averages [3]float64
+	started  bool
+}
+
+// Add records a load sample taken elapsed after the previous one
+func (a *LoadAverager) Add(load float64, elapsed time.Duration) {
+	if !a.started {
+		a.averages = [3]float64{load, load, load}
+		a.started = true
+		return
+	}
+	for i, window := range loadWindows {
+		decay := math.Exp(-elapsed.Seconds() / window.Seconds())
+		a.averages[i] = a.averages[i]*decay + load*(1-decay)
+	}
+}
+
+// Averages returns the 1, 5 and 15-minute load averages
+func (a *LoadAverager) Averages() []float64 {
+	return []float64{round(a.averages[0]), round(a.averages[1]), round(a.averages[2])}
+}
+
+// round rounds to two decimal places
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"patchmon-agent/pkg/models"
+)
+
+func TestLoad(t *testing.T) {
+	if got := Load(50, 3, 4); got != 5 {
+		t.Errorf("Load(50, 3, 4) = %v, want 5", got)
+	}
+}
+
+func TestLoadAverager(t *testing.T) {
+	var a LoadAverager
+	if got := a.Averages(); !reflect.DeepEqual(got, []float64{0, 0, 0}) {
+		t.Errorf("Averages() before any sample = %v, want zeros", got)
+	}
+
+	a.Add(2, time.Second)
+	if got := a.Averages(); !reflect.DeepEqual(got, []float64{2, 2, 2}) {
+		t.Errorf("Averages() after first sample = %v, want [2 2 2]", got)
+	}
+
+	// A minute at zero load leaves 1/e of the 1-minute average
+	for i := 0; i < 60; i++ {
+		a.Add(0, time.Second)
+	}
+	got := a.Averages()
+	if got[0] != 0.74 {
+		t.Errorf("1-minute average = %v, want 0.74", got[0])
+	}
+	if !(got[0] < got[1] && got[1] < got[2] && got[2] < 2) {
+		t.Errorf("averages = %v, want longer windows to decay more slowly", got)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	samples := []sample{
+		{cpuPercent: 10, processorQueue: 1, disks: []perfLogicalDisk{
+			{Name: "_Total", CurrentDiskQueueLength: 2},
+			{Name: "C:", CurrentDiskQueueLength: 2, FreeMegabytes: 1000, PercentFreeSpace: 20},
+		}},
+		{cpuPercent: 25, processorQueue: 0, disks: []perfLogicalDisk{
+			{Name: "_Total", CurrentDiskQueueLength: 1},
+			{Name: "HarddiskVolume1", CurrentDiskQueueLength: 0, FreeMegabytes: 50, PercentFreeSpace: 10},
+			{Name: "D:", CurrentDiskQueueLength: 0, FreeMegabytes: 2048, PercentFreeSpace: 50},
+			{Name: "C:", CurrentDiskQueueLength: 1, FreeMegabytes: 900, PercentFreeSpace: 18},
+		}},
+	}
+
+	got := summarize(samples)
+	want := &models.ResourceMetrics{
+		CPUPercent:           17.5,
+		ProcessorQueueLength: 0.5,
+		DiskQueueLength:      1.5,
+		Volumes: []models.VolumeMetrics{
+			{Name: "C:", FreeBytes: 900 * 1024 * 1024, FreePercent: 18, QueueLength: 1.5},
+			{Name: "D:", FreeBytes: 2048 * 1024 * 1024, FreePercent: 50, QueueLength: 0},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("summarize() = %+v, want %+v", got, want)
+	}
+}
+
+func TestAgentStatsWriteTo(t *testing.T) {
+	var nilStats *AgentStats
+	nilStats.RecordReport(time.Now(), time.Second, nil) // must not panic
+
+	s := NewAgentStats()
+	var out strings.Builder
+	if _, err := s.WriteTo(&out); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "last_report") {
+		t.Errorf("WriteTo() before any report = %q, want only counters", out.String())
+	}
+
+	start := time.Unix(1714550400, 0)
+	s.RecordReport(start, 90*time.Second, nil)
+	s.RecordReport(start.Add(time.Hour), 30*time.Second, errors.New("server unreachable"))
+	s.SetCollectorErrors(2)
+	s.SetWUASearchDuration(1500 * time.Millisecond)
+
+	out.Reset()
+	if _, err := s.WriteTo(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# TYPE patchmon_agent_reports_total counter\npatchmon_agent_reports_total 2\n",
+		"patchmon_agent_report_failures_total 1\n",
+		"# TYPE patchmon_agent_last_report_timestamp_seconds gauge\npatchmon_agent_last_report_timestamp_seconds 1714554000\n",
+		"patchmon_agent_last_success_timestamp_seconds 1714550400\n",
+		"patchmon_agent_last_report_duration_seconds 30\n",
+		"patchmon_agent_last_report_success 0\n",
+		"patchmon_agent_collector_errors 2\n",
+		"patchmon_agent_wua_search_duration_seconds 1.5\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("WriteTo() output missing %q:\n%s", want, out.String())
+		}
+	}
+}
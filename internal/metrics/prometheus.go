@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultPrometheusPort is the localhost port of the /metrics endpoint when
+// prometheus_port is not set
+const DefaultPrometheusPort = 9198
+
+// AgentStats records the health of the agent's own reporting for the
+// Prometheus endpoint. A nil *AgentStats ignores all updates, so callers do
+// not need to check whether the endpoint is enabled.
+type AgentStats struct {
+	mu                  sync.Mutex
+	lastReport          time.Time
+	lastSuccess         time.Time
+	lastDuration        time.Duration
+	reports             uint64
+	failures            uint64
+	collectorErrors     int
+	wuaSearchDuration   time.Duration
+	wuaSearchRecorded   bool
+	lastReportSucceeded bool
+}
+
+// gauge is a single Prometheus gauge value
+type gauge struct {
+	name, help string
+	value      float64
+}
+
+// NewAgentStats creates an empty set of agent statistics
+func NewAgentStats() *AgentStats {
+	return &AgentStats{}
+}
+
+// RecordReport records a report run that started at start
+func (s *AgentStats) RecordReport(start time.Time, duration time.Duration, err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reports++
+	s.lastReport = start
+	s.lastDuration = duration
+	s.lastReportSucceeded = err == nil
+	if err != nil {
+		s.failures++
+	} else {
+		s.lastSuccess = start
+	}
+}
+
+// SetCollectorErrors records the number of collectors that failed in the last report
+func (s *AgentStats) SetCollectorErrors(n int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.collectorErrors = n
+}
+
+// SetWUASearchDuration records how long the last Windows Update search took
+func (s *AgentStats) SetWUASearchDuration(d time.Duration) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.wuaSearchDuration = d
+	s.wuaSearchRecorded = true
+}
+
+// WriteTo writes the statistics in the Prometheus text exposition format.
+// Metrics that have not been recorded yet are left out.
+func (s *AgentStats) WriteTo(w io.Writer) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var total int64
+	write := func(name, kind, help string, value float64) error {
+		n, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %s\n", name, help, name, kind, name, strconv.FormatFloat(value, 'f', -1, 64))
+		total += int64(n)
+		return err
+	}
+
+	if err := write("patchmon_agent_reports_total", "counter", "Reports attempted since the agent started.", float64(s.reports)); err != nil {
+		return total, err
+	}
+	if err := write("patchmon_agent_report_failures_total", "counter", "Reports that failed since the agent started.", float64(s.failures)); err != nil {
+		return total, err
+	}
+	if s.reports == 0 {
+		return total, nil
+	}
+
+	succeeded := 0.0
+	if s.lastReportSucceeded {
+		succeeded = 1
+	}
+	metrics := []gauge{
+		{"patchmon_agent_last_report_timestamp_seconds", "Start time of the last report as a Unix timestamp.", float64(s.lastReport.Unix())},
+		{"patchmon_agent_last_report_duration_seconds", "Duration of the last report.", s.lastDuration.Seconds()},
+		{"patchmon_agent_last_report_success", "Whether the last report was sent successfully.", succeeded},
+		{"patchmon_agent_collector_errors", "Collectors that failed during the last report.", float64(s.collectorErrors)},
+	}
+	if !s.lastSuccess.IsZero() {
+		metrics = append(metrics, gauge{"patchmon_agent_last_success_timestamp_seconds", "Start time of the last successful report as a Unix timestamp.", float64(s.lastSuccess.Unix())})
+	}
+	if s.wuaSearchRecorded {
+		metrics = append(metrics, gauge{"patchmon_agent_wua_search_duration_seconds", "Duration of the last Windows Update search.", s.wuaSearchDuration.Seconds()})
+	}
+	for _, m := range metrics {
+		if err := write(m.name, "gauge", m.help, m.value); err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// Handler serves the statistics on /metrics
+func (s *AgentStats) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = s.WriteTo(w)
+	})
+	return mux
+}
@@ -0,0 +1,268 @@
+package msrc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"patchmon-agent/internal/version"
+)
+
+// DefaultBaseURL is the MSRC CVRF v3 document endpoint; documents are named by month (e.g. "2024-Mar")
+const DefaultBaseURL = "https://api.msrc.microsoft.com/cvrf/v3.0/cvrf/"
+
+// DefaultLookupMonths is how many monthly security releases are searched for KB numbers
+const DefaultLookupMonths = 12
+
+const (
+	requestTimeout = 60 * time.Second
+	// cacheMaxAge is how long a cached document for a recent month is trusted
+	cacheMaxAge = 24 * time.Hour
+	// settledAfter is the age after which a month's document is no longer revised in practice
+	settledAfter = 60 * 24 * time.Hour
+)
+
+// remediationVendorFix is the CVRF remediation type for a vendor-supplied fix (the KB)
+const remediationVendorFix = 2
+
+// Client resolves KB numbers to CVE identifiers using the MSRC CVRF API
+type Client struct {
+	logger     *logrus.Logger
+	httpClient *http.Client
+	baseURL    string
+	cacheDir   string
+	now        func() time.Time
+}
+
+// cvrfDocument is the subset of a CVRF document needed to map KBs to CVEs
+type cvrfDocument struct {
+	Vulnerability []struct {
+		CVE          string `json:"CVE"`
+		Remediations []struct {
+			Type        int `json:"Type"`
+			Description struct {
+				Value string `json:"Value"`
+			} `json:"Description"`
+		} `json:"Remediations"`
+	} `json:"Vulnerability"`
+}
+
+// New creates a new MSRC client caching monthly KB→CVE indexes in cacheDir.
+// Requests go through transport, or http.DefaultTransport if it is nil.
+func New(logger *logrus.Logger, cacheDir string, transport http.RoundTripper) *Client {
+	return &Client{
+		logger:     logger,
+		httpClient: &http.Client{Timeout: requestTimeout, Transport: transport},
+		baseURL:    DefaultBaseURL,
+		cacheDir:   cacheDir,
+		now:        time.Now,
+	}
+}
+
+// LookupCVEs returns the CVE identifiers fixed by each of the given KB numbers,
+// searching the security release documents of the last months months. KB
+// numbers may be given with or without the "KB" prefix; the result is keyed by
+// the KB as given. Months that cannot be fetched are skipped and reported in
+// the returned error alongside whatever could be resolved.
+func (c *Client) LookupCVEs(kbs []string, months int) (map[string][]string, error) {
+	result := make(map[string][]string)
+	if len(kbs) == 0 {
+		return result, nil
+	}
+	if months <= 0 {
+		months = DefaultLookupMonths
+	}
+
+	var errs []error
+	index := make(map[string][]string)
+	now := c.now().UTC()
+	for i := 0; i < months; i++ {
+		month := time.Date(now.Year(), now.Month()-time.Month(i), 1, 0, 0, 0, 0, time.UTC)
+		monthIndex, err := c.getMonthIndex(month)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for kb, cves := range monthIndex {
+			index[kb] = append(index[kb], cves...)
+		}
+	}
+
+	for _, kb := range kbs {
+		if cves := uniqueSorted(index[normalizeKB(kb)]); len(cves) > 0 {
+			result[kb] = cves
+		}
+	}
+
+	return result, errors.Join(errs...)
+}
+
+// getMonthIndex returns the KB→CVE index for a month, from cache if fresh
+func (c *Client) getMonthIndex(month time.Time) (map[string][]string, error) {
+	id := documentID(month)
+	cachePath := filepath.Join(c.cacheDir, id+".json")
+
+	if info, err := os.Stat(cachePath); err == nil && cacheFresh(info.ModTime(), month, c.now()) {
+		if index, err := readIndex(cachePath); err == nil {
+			return index, nil
+		}
+	}
+
+	index, err := c.fetchMonthIndex(id)
+	if err != nil {
+		// A stale cache is better than nothing
+		if cached, cacheErr := readIndex(cachePath); cacheErr == nil {
+			c.logger.WithError(err).Debugf("Using stale MSRC cache for %s", id)
+			return cached, nil
+		}
+		return nil, err
+	}
+
+	if index != nil {
+		if err := writeIndex(cachePath, index); err != nil {
+			c.logger.WithError(err).Debug("Failed to write MSRC cache")
+		}
+	}
+	return index, nil
+}
+
+// fetchMonthIndex downloads and indexes a CVRF document. A document that
+// does not exist yet (the current month before Patch Tuesday) yields an empty index.
+func (c *Client) fetchMonthIndex(id string) (map[string][]string, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+id, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", fmt.Sprintf("patchmon-agent/%s", version.Version))
+
+	c.logger.Debugf("Fetching MSRC security update document %s", id)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch MSRC document %s: %w", id, err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			c.logger.WithError(closeErr).Debug("Failed to close response body")
+		}
+	}()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return map[string][]string{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("MSRC document %s: server returned status %d", id, resp.StatusCode)
+	}
+
+	var doc cvrfDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse MSRC document %s: %w", id, err)
+	}
+	return indexDocument(&doc), nil
+}
+
+// indexDocument builds a KB→CVE map from the vendor-fix remediations of a document
+func indexDocument(doc *cvrfDocument) map[string][]string {
+	index := make(map[string][]string)
+	for _, vuln := range doc.Vulnerability {
+		if vuln.CVE == "" {
+			continue
+		}
+		for _, remediation := range vuln.Remediations {
+			if remediation.Type != remediationVendorFix {
+				continue
+			}
+			kb := normalizeKB(remediation.Description.Value)
+			if kb == "" || !isDigits(kb) {
+				continue
+			}
+			index[kb] = append(index[kb], vuln.CVE)
+		}
+	}
+	for kb, cves := range index {
+		index[kb] = uniqueSorted(cves)
+	}
+	return index
+}
+
+// documentID returns the CVRF document name for a month, e.g. "2024-Mar"
+func documentID(month time.Time) string {
+	return month.Format("2006-Jan")
+}
+
+// cacheFresh reports whether a cached document can be used without refetching.
+// Documents for months long past are settled and never refetched.
+func cacheFresh(modTime, month, now time.Time) bool {
+	if now.Sub(month) > settledAfter {
+		return true
+	}
+	return now.Sub(modTime) < cacheMaxAge
+}
+
+// normalizeKB strips the "KB" prefix and surrounding whitespace
+func normalizeKB(kb string) string {
+	kb = strings.TrimSpace(kb)
+	if len(kb) > 2 && strings.EqualFold(kb[:2], "KB") {
+		kb = kb[2:]
+	}
+	return kb
+}
+
+// isDigits reports whether s consists only of ASCII digits
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// uniqueSorted returns the sorted, de-duplicated values
+func uniqueSorted(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	unique := sorted[:1]
+	for _, v := range sorted[1:] {
+		if v != unique[len(unique)-1] {
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
+
+// readIndex loads a cached index
+func readIndex(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var index map[string][]string
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// writeIndex stores an index in the cache
+func writeIndex(path string, index map[string][]string) error {
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,143 @@
+package msrc
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const testDocument = `{
+  "DocumentTitle": {"Value": "March 2024 Security Updates"},
+  "Vulnerability": [
+    {
+      "CVE": "CVE-2024-21407",
+      "Remediations": [
+        {"Description": {"Value": "5035853"}, "Type": 2},
+        {"Description": {"Value": "5035845"}, "Type": 2},
+        {"Description": {"Value": "Release Notes"}, "Type": 2},
+        {"Description": {"Value": "5035853"}, "Type": 1}
+      ]
+    },
+    {
+      "CVE": "CVE-2024-21408",
+      "Remediations": [
+        {"Description": {"Value": "5035853"}, "Type": 2},
+        {"Description": {"Value": "5035853"}, "Type": 2}
+      ]
+    }
+  ]
+}`
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) (*Client, *int32) {
+	t.Helper()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	client := New(logger, t.TempDir(), nil)
+	client.baseURL = server.URL + "/"
+	client.now = func() time.Time { return time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC) }
+	return client, &requests
+}
+
+func TestIndexDocument(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, testDocument)
+	})
+
+	index, err := client.fetchMonthIndex("2024-Mar")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]string{
+		"5035853": {"CVE-2024-21407", "CVE-2024-21408"},
+		"5035845": {"CVE-2024-21407"},
+	}
+	if !reflect.DeepEqual(index, want) {
+		t.Errorf("unexpected index: %v", index)
+	}
+}
+
+func TestLookupCVEs(t *testing.T) {
+	client, requests := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/2024-Mar") {
+			_, _ = io.WriteString(w, testDocument)
+			return
+		}
+		http.NotFound(w, r)
+	})
+
+	result, err := client.LookupCVEs([]string{"KB5035853", "kb5035845", "KB1234567"}, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result) != 2 {
+		t.Errorf("expected 2 resolved KBs, got %v", result)
+	}
+	if !reflect.DeepEqual(result["KB5035853"], []string{"CVE-2024-21407", "CVE-2024-21408"}) {
+		t.Errorf("unexpected CVEs for KB5035853: %v", result["KB5035853"])
+	}
+	if _, ok := result["kb5035845"]; !ok {
+		t.Error("result should be keyed by the KB as given")
+	}
+	if *requests != 2 {
+		t.Errorf("expected 2 requests (Mar, Feb), got %d", *requests)
+	}
+
+	// Second lookup is served from the cache
+	if _, err := client.LookupCVEs([]string{"KB5035853"}, 2); err != nil {
+		t.Fatal(err)
+	}
+	if *requests != 2 {
+		t.Errorf("expected cached documents to be reused, got %d requests", *requests)
+	}
+}
+
+func TestLookupCVEs_ServerError(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	result, err := client.LookupCVEs([]string{"KB5035853"}, 1)
+	if err == nil {
+		t.Error("expected error for server failure")
+	}
+	if len(result) != 0 {
+		t.Errorf("expected empty result, got %v", result)
+	}
+}
+
+func TestCacheFresh(t *testing.T) {
+	now := time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	november := time.Date(2023, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	if !cacheFresh(now.Add(-time.Hour), march, now) {
+		t.Error("recent cache for the current month should be fresh")
+	}
+	if cacheFresh(now.Add(-48*time.Hour), march, now) {
+		t.Error("two-day-old cache for the current month should be stale")
+	}
+	if !cacheFresh(now.Add(-90*24*time.Hour), november, now) {
+		t.Error("cache for a settled month should always be fresh")
+	}
+}
+
+func TestDocumentID(t *testing.T) {
+	if got := documentID(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)); got != "2024-Mar" {
+		t.Errorf("documentID = %q, want 2024-Mar", got)
+	}
+}
@@ -0,0 +1,164 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"unsafe"
+
+	"github.com/yusufpapurcu/wmi"
+	"golang.org/x/sys/windows"
+)
+
+// msftNetAdapter maps the duplex state of the WMI MSFT_NetAdapter class, which
+// GetAdaptersAddresses does not report
+type msftNetAdapter struct {
+	Name       string
+	FullDuplex bool
+}
+
+// getNativeAdapters reads the adapters with GetAdaptersAddresses, including
+// their gateways and DNS servers, in the order Windows lists them
+func (m *Manager) getNativeAdapters() ([]netAdapterInfo, error) {
+	flags := uint32(windows.GAA_FLAG_INCLUDE_GATEWAYS | windows.GAA_FLAG_SKIP_ANYCAST | windows.GAA_FLAG_SKIP_MULTICAST)
+
+	// The buffer size can change between calls as adapters come and go
+	size := uint32(15 * 1024)
+	var buf []byte
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		buf = make([]byte, size)
+		err = windows.GetAdaptersAddresses(windows.AF_UNSPEC, flags, 0, (*windows.IpAdapterAddresses)(unsafe.Pointer(&buf[0])), &size)
+		if !errors.Is(err, windows.ERROR_BUFFER_OVERFLOW) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetAdaptersAddresses failed: %w", err)
+	}
+
+	var adapters []netAdapterInfo
+	for aa := (*windows.IpAdapterAddresses)(unsafe.Pointer(&buf[0])); aa != nil; aa = aa.Next {
+		adapter := netAdapterInfo{
+			Name:                 windows.UTF16PtrToString(aa.FriendlyName),
+			InterfaceDescription: windows.UTF16PtrToString(aa.Description),
+			MediaType:            mediaTypeName(aa.IfType),
+			Status:               operStatusName(aa.OperStatus),
+			MacAddress:           net.HardwareAddr(aa.PhysicalAddress[:aa.PhysicalAddressLength]).String(),
+			linkSpeedMbps:        linkSpeedMbps(aa.TransmitLinkSpeed),
+			ipv4Metric:           aa.Ipv4Metric,
+			pointToPoint:         aa.IfType == windows.IF_TYPE_PPP,
+			native:               true,
+		}
+		for gw := aa.FirstGatewayAddress; gw != nil; gw = gw.Next {
+			ip := gw.Address.IP()
+			if ip == nil {
+				continue
+			}
+			if ip.To4() != nil {
+				if adapter.ipv4Gateway == "" {
+					adapter.ipv4Gateway = ip.String()
+				}
+			} else if adapter.ipv6Gateway == "" {
+				adapter.ipv6Gateway = ip.String()
+			}
+		}
+		for dns := aa.FirstDnsServerAddress; dns != nil; dns = dns.Next {
+			if ip := dns.Address.IP(); ip != nil && ip.To4() != nil {
+				adapter.dnsServers = append(adapter.dnsServers, ip.String())
+			}
+		}
+		adapters = append(adapters, adapter)
+	}
+
+	// Duplex is optional: without it the interface is reported without one
+	var duplex []msftNetAdapter
+	if err := wmi.QueryNamespace("SELECT Name, FullDuplex FROM MSFT_NetAdapter", &duplex, `root\StandardCimv2`); err != nil {
+		m.logger.WithError(err).Debug("Failed to query adapter duplex")
+	}
+	for _, d := range duplex {
+		for i := range adapters {
+			if adapters[i].Name == d.Name {
+				fullDuplex := d.FullDuplex
+				adapters[i].FullDuplex = &fullDuplex
+			}
+		}
+	}
+
+	return adapters, nil
+}
+
+// mediaTypeName maps an IF_TYPE value to the MediaType Get-NetAdapter reports
+func mediaTypeName(ifType uint32) string {
+	switch ifType {
+	case windows.IF_TYPE_ETHERNET_CSMACD:
+		return "802.3"
+	case windows.IF_TYPE_IEEE80211:
+		return "Native 802.11"
+	}
+	return ""
+}
+
+// operStatusName maps an IF_OPER_STATUS value to the Status Get-NetAdapter reports
+func operStatusName(status uint32) string {
+	switch status {
+	case windows.IfOperStatusUp:
+		return "Up"
+	case windows.IfOperStatusNotPresent:
+		return "Not Present"
+	}
+	return "Disconnected"
+}
+
+// linkSpeedMbps converts a link speed in bits per second to Mbps, or -1 if
+// unknown (Windows reports the maximum uint64 value)
+func linkSpeedMbps(bps uint64) int {
+	if bps == 0 || bps == math.MaxUint64 {
+		return -1
+	}
+	return int(bps / 1000000)
+}
+
+// defaultGateway returns the IPv4 gateway of the connected adapter with the
+// lowest interface metric, as Windows would route
+func defaultGateway(adapters []netAdapterInfo) string {
+	gateway := ""
+	var best uint32
+	for _, a := range adapters {
+		if a.ipv4Gateway == "" || a.Status != "Up" {
+			continue
+		}
+		if gateway == "" || a.ipv4Metric < best {
+			gateway, best = a.ipv4Gateway, a.ipv4Metric
+		}
+	}
+	return gateway
+}
+
+// dnsServers returns the unique IPv4 DNS servers of the connected adapters
+func dnsServers(adapters []netAdapterInfo) []string {
+	servers := []string{}
+	seen := make(map[string]bool)
+	for _, a := range adapters {
+		if a.Status != "Up" {
+			continue
+		}
+		for _, s := range a.dnsServers {
+			if !seen[s] {
+				servers = append(servers, s)
+				seen[s] = true
+			}
+		}
+	}
+	return servers
+}
+
+// adapterByName indexes adapters by interface name
+func adapterByName(adapters []netAdapterInfo) map[string]netAdapterInfo {
+	byName := make(map[string]netAdapterInfo, len(adapters))
+	for _, a := range adapters {
+		byName[a.Name] = a
+	}
+	return byName
+}
@@ -0,0 +1,39 @@
+package network
+
+import (
+	"golang.org/x/sys/windows"
+
+	"patchmon-agent/pkg/models"
+)
+
+// SetInterfaceCounters enables the traffic and error counters of each
+// reported interface (see interface_counters)
+func (m *Manager) SetInterfaceCounters(enabled bool) {
+	m.counters = enabled
+}
+
+// getInterfaceCounters reads the counters of an interface with GetIfEntry2,
+// or returns nil if they cannot be read
+func (m *Manager) getInterfaceCounters(index int) *models.InterfaceCounters {
+	row := windows.MibIfRow2{InterfaceIndex: uint32(index)}
+	if err := windows.GetIfEntry2Ex(windows.MibIfEntryNormal, &row); err != nil {
+		m.logger.WithError(err).WithField("index", index).Debug("Failed to read interface counters")
+		return nil
+	}
+	return convertIfRow(&row)
+}
+
+// convertIfRow converts the statistics of an interface row. Non-unicast
+// packets are included in the packet counts.
+func convertIfRow(row *windows.MibIfRow2) *models.InterfaceCounters {
+	return &models.InterfaceCounters{
+		BytesReceived:   row.InOctets,
+		BytesSent:       row.OutOctets,
+		PacketsReceived: row.InUcastPkts + row.InNUcastPkts,
+		PacketsSent:     row.OutUcastPkts + row.OutNUcastPkts,
+		ReceiveErrors:   row.InErrors,
+		SendErrors:      row.OutErrors,
+		ReceiveDiscards: row.InDiscards,
+		SendDiscards:    row.OutDiscards,
+	}
+}
@@ -0,0 +1,61 @@
+package network
+
+import (
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// Registry locations of the DNS client settings. Group Policy values take
+// precedence over the local TCP/IP parameters.
+const (
+	tcpipParametersKey = `SYSTEM\CurrentControlSet\Services\Tcpip\Parameters`
+	dnsClientPolicyKey = `SOFTWARE\Policies\Microsoft\Windows NT\DNSClient`
+)
+
+// getDNSSuffixes returns the primary DNS suffix of the computer and the
+// configured suffix search list. The search list is empty when Windows
+// builds it from the primary and connection-specific suffixes.
+func getDNSSuffixes() (string, []string) {
+	primary := readRegistryString(dnsClientPolicyKey, "PrimaryDnsSuffix")
+	if primary == "" {
+		primary = readRegistryString(tcpipParametersKey, "Domain")
+	}
+	searchList := readRegistryString(dnsClientPolicyKey, "SearchList")
+	if searchList == "" {
+		searchList = readRegistryString(tcpipParametersKey, "SearchList")
+	}
+	return primary, parseSearchList(searchList)
+}
+
+// parseSearchList splits a suffix search list, which Windows stores comma
+// separated (Group Policy also accepts spaces), dropping duplicates
+func parseSearchList(value string) []string {
+	var suffixes []string
+	seen := make(map[string]bool)
+	for _, suffix := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+		suffix = strings.TrimSuffix(suffix, ".")
+		if suffix == "" || seen[strings.ToLower(suffix)] {
+			continue
+		}
+		seen[strings.ToLower(suffix)] = true
+		suffixes = append(suffixes, suffix)
+	}
+	return suffixes
+}
+
+// readRegistryString reads a string value under HKLM, returning "" if the key
+// or value does not exist
+func readRegistryString(keyPath, valueName string) string {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, keyPath, registry.QUERY_VALUE)
+	if err != nil {
+		return ""
+	}
+	defer k.Close()
+
+	value, _, err := k.GetStringValue(valueName)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(value)
+}
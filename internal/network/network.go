@@ -1,23 +1,29 @@
 package network
 
 import (
-	"encoding/json"
 	"fmt"
 	"net"
-	"os/exec"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"github.com/yusufpapurcu/wmi"
 
 	"patchmon-agent/internal/constants"
+	"patchmon-agent/internal/utils"
 	"patchmon-agent/pkg/models"
 )
 
-// Manager handles network information collection using PowerShell and standard library
+// Manager handles network information collection using the IP Helper API,
+// falling back to PowerShell and WMI
 type Manager struct {
-	logger *logrus.Logger
+	logger           *logrus.Logger
+	exclude          *utils.PatternMatcher
+	primarySubnet    *net.IPNet
+	primaryInterface *utils.PatternMatcher
+	counters         bool
 }
 
 // New creates a new network manager
@@ -27,13 +33,37 @@ func New(logger *logrus.Logger) *Manager {
 	}
 }
 
+// SetInterfaceExclusions drops interfaces whose name, adapter description or
+// type matches one of patterns (see utils.PatternMatcher) from the reported
+// interfaces. Invalid patterns are skipped and returned as an error.
+func (m *Manager) SetInterfaceExclusions(patterns []string) error {
+	matcher, err := utils.NewPatternMatcher(patterns)
+	m.exclude = matcher
+	return err
+}
+
 // GetNetworkInfo collects network information
 func (m *Manager) GetNetworkInfo() models.NetworkInfo {
-	info := models.NetworkInfo{
-		GatewayIP:         m.getGatewayIP(),
-		DNSServers:        m.getDNSServers(),
-		NetworkInterfaces: m.getNetworkInterfaces(),
+	var info models.NetworkInfo
+	var adapterMap map[string]netAdapterInfo
+	if adapters, err := m.getNativeAdapters(); err == nil {
+		adapterMap = adapterByName(adapters)
+		info = models.NetworkInfo{
+			GatewayIP:  defaultGateway(adapters),
+			DNSServers: dnsServers(adapters),
+		}
+	} else {
+		m.logger.WithError(err).Debug("Failed to read adapters natively, falling back to PowerShell")
+		adapterMap = m.getAdapterInfo()
+		info = models.NetworkInfo{
+			GatewayIP:  m.getGatewayIP(),
+			DNSServers: m.getDNSServers(),
+		}
 	}
+	info.NetworkInterfaces = m.getNetworkInterfaces(adapterMap)
+	info.VPN = detectVPN(adapterMap)
+
+	info.PrimaryDNSSuffix, info.DNSSearchList = getDNSSuffixes()
 
 	m.logger.WithFields(logrus.Fields{
 		"gateway":     info.GatewayIP,
@@ -46,12 +76,10 @@ func (m *Manager) GetNetworkInfo() models.NetworkInfo {
 
 // runPowerShell executes a PowerShell command and returns trimmed output
 func runPowerShell(command string) (string, error) {
-	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", command)
-	output, err := cmd.Output()
-	return strings.TrimSpace(string(output)), err
+	return utils.RunPowerShell(command)
 }
 
-// getGatewayIP gets the default gateway IP using PowerShell, with ipconfig fallback
+// getGatewayIP gets the default gateway IP using PowerShell, with WMI fallback
 func (m *Manager) getGatewayIP() string {
 	// Primary: PowerShell Get-NetRoute
 	psCmd := "(Get-NetRoute -DestinationPrefix '0.0.0.0/0' -ErrorAction SilentlyContinue | Select-Object -First 1).NextHop"
@@ -60,40 +88,14 @@ func (m *Manager) getGatewayIP() string {
 		return output
 	}
 	if err != nil {
-		m.logger.WithError(err).Debug("PowerShell Get-NetRoute failed, trying ipconfig fallback")
+		m.logger.WithError(err).Debug("PowerShell Get-NetRoute failed, trying WMI fallback")
 	}
 
-	// Fallback: parse ipconfig output
-	return m.getGatewayFromIPConfig()
+	// Fallback: WMI adapter configuration
+	return defaultGatewayFromConfigs(m.getAdapterConfigs())
 }
 
-// getGatewayFromIPConfig parses ipconfig output to find the default gateway
-func (m *Manager) getGatewayFromIPConfig() string {
-	cmd := exec.Command("ipconfig")
-	output, err := cmd.Output()
-	if err != nil {
-		m.logger.WithError(err).Warn("Failed to run ipconfig")
-		return ""
-	}
-
-	// Look for "Default Gateway" lines with an IP address
-	for _, line := range strings.Split(string(output), "\n") {
-		line = strings.TrimSpace(line)
-		if strings.Contains(line, "Default Gateway") || strings.Contains(line, "Passerelle par") {
-			parts := strings.SplitN(line, ":", 2)
-			if len(parts) == 2 {
-				gateway := strings.TrimSpace(parts[1])
-				if gateway != "" && isValidIP(gateway) {
-					return gateway
-				}
-			}
-		}
-	}
-
-	return ""
-}
-
-// getDNSServers gets the configured DNS servers using PowerShell, with ipconfig fallback
+// getDNSServers gets the configured DNS servers using PowerShell, with WMI fallback
 func (m *Manager) getDNSServers() []string {
 	// Initialize as empty slice (not nil) to ensure JSON marshals as [] instead of null
 	servers := []string{}
@@ -108,11 +110,11 @@ func (m *Manager) getDNSServers() []string {
 		}
 	}
 	if err != nil {
-		m.logger.WithError(err).Debug("PowerShell Get-DnsClientServerAddress failed, trying ipconfig fallback")
+		m.logger.WithError(err).Debug("PowerShell Get-DnsClientServerAddress failed, trying WMI fallback")
 	}
 
-	// Fallback: parse ipconfig /all
-	return m.getDNSFromIPConfig()
+	// Fallback: WMI adapter configuration
+	return dnsServersFromConfigs(m.getAdapterConfigs())
 }
 
 // parseDNSOutput parses newline-separated DNS server addresses
@@ -129,53 +131,69 @@ func parseDNSOutput(output string) []string {
 	return servers
 }
 
-// getDNSFromIPConfig parses ipconfig /all output to find DNS servers
-func (m *Manager) getDNSFromIPConfig() []string {
-	servers := []string{}
-	cmd := exec.Command("ipconfig", "/all")
-	output, err := cmd.Output()
-	if err != nil {
-		m.logger.WithError(err).Warn("Failed to run ipconfig /all")
-		return servers
+// win32NetworkAdapterConfiguration maps the WMI Win32_NetworkAdapterConfiguration class
+type win32NetworkAdapterConfiguration struct {
+	InterfaceIndex       uint32
+	DefaultIPGateway     []string
+	DNSServerSearchOrder []string
+	DNSDomain            string
+	DHCPEnabled          bool
+	DHCPServer           string
+	DHCPLeaseObtained    time.Time
+	DHCPLeaseExpires     time.Time
+}
+
+// getAdapterConfigs reads the IP configuration of the IP-enabled adapters.
+// Unlike ipconfig output it does not depend on the display language.
+func (m *Manager) getAdapterConfigs() []win32NetworkAdapterConfiguration {
+	var configs []win32NetworkAdapterConfiguration
+	if err := wmi.Query("SELECT InterfaceIndex, DefaultIPGateway, DNSServerSearchOrder, DNSDomain, DHCPEnabled, DHCPServer, DHCPLeaseObtained, DHCPLeaseExpires FROM Win32_NetworkAdapterConfiguration WHERE IPEnabled = TRUE", &configs); err != nil {
+		m.logger.WithError(err).Warn("Failed to query Win32_NetworkAdapterConfiguration")
+		return nil
 	}
+	return configs
+}
 
-	seen := make(map[string]bool)
-	inDNS := false
-	for _, line := range strings.Split(string(output), "\n") {
-		trimmed := strings.TrimSpace(line)
-
-		if strings.Contains(line, "DNS Servers") || strings.Contains(line, "Serveurs DNS") {
-			inDNS = true
-			// Extract IP from this line (after the colon)
-			parts := strings.SplitN(trimmed, ":", 2)
-			if len(parts) == 2 {
-				addr := strings.TrimSpace(parts[1])
-				if addr != "" && isValidIP(addr) && !seen[addr] {
-					servers = append(servers, addr)
-					seen[addr] = true
-				}
+// defaultGatewayFromConfigs returns the first IPv4 default gateway
+func defaultGatewayFromConfigs(configs []win32NetworkAdapterConfiguration) string {
+	for _, c := range configs {
+		for _, gw := range c.DefaultIPGateway {
+			if ip := net.ParseIP(gw); ip != nil && ip.To4() != nil {
+				return gw
 			}
-			continue
 		}
+	}
+	return ""
+}
 
-		// Continuation lines for DNS servers (indented, no label)
-		if inDNS {
-			if trimmed == "" || strings.Contains(trimmed, ":") && !isValidIP(strings.TrimSpace(trimmed)) {
-				inDNS = false
-				continue
-			}
-			addr := strings.TrimSpace(trimmed)
-			if isValidIP(addr) && !seen[addr] {
-				servers = append(servers, addr)
-				seen[addr] = true
-			}
-		}
+// dnsServersFromConfigs returns the unique DNS servers of all adapters
+func dnsServersFromConfigs(configs []win32NetworkAdapterConfiguration) []string {
+	var all []string
+	for _, c := range configs {
+		all = append(all, c.DNSServerSearchOrder...)
 	}
+	return parseDNSOutput(strings.Join(all, "\n"))
+}
 
-	return servers
+// applyDHCP sets the DHCP state and IPv4 lease of an interface from its
+// adapter configuration
+func applyDHCP(iface *models.NetworkInterface, config win32NetworkAdapterConfiguration) {
+	enabled := config.DHCPEnabled
+	iface.DHCPEnabled = &enabled
+	if !enabled {
+		return
+	}
+	iface.DHCPServer = config.DHCPServer
+	if !config.DHCPLeaseObtained.IsZero() {
+		iface.DHCPLeaseObtained = config.DHCPLeaseObtained.UTC().Format(time.RFC3339)
+	}
+	if !config.DHCPLeaseExpires.IsZero() {
+		iface.DHCPLeaseExpires = config.DHCPLeaseExpires.UTC().Format(time.RFC3339)
+	}
 }
 
-// netAdapterInfo holds JSON output from Get-NetAdapter
+// netAdapterInfo holds adapter details, from GetAdaptersAddresses or the JSON
+// output of Get-NetAdapter
 type netAdapterInfo struct {
 	Name                 string `json:"Name"`
 	InterfaceDescription string `json:"InterfaceDescription"`
@@ -184,18 +202,37 @@ type netAdapterInfo struct {
 	LinkSpeed            string `json:"LinkSpeed"`
 	MacAddress           string `json:"MacAddress"`
 	FullDuplex           *bool  `json:"FullDuplex"`
+
+	// Only set by getNativeAdapters
+	native        bool
+	linkSpeedMbps int
+	ipv4Metric    uint32
+	ipv4Gateway   string
+	ipv6Gateway   string
+	dnsServers    []string
+	pointToPoint  bool // PPP interface, e.g. a RasClient VPN connection
 }
 
-// getNetworkInterfaces gets network interface information using standard library + PowerShell enrichment
-func (m *Manager) getNetworkInterfaces() []models.NetworkInterface {
+// getNetworkInterfaces gets network interface information using the standard
+// library, enriched with the adapter details
+func (m *Manager) getNetworkInterfaces(adapterMap map[string]netAdapterInfo) []models.NetworkInterface {
 	interfaces, err := net.Interfaces()
 	if err != nil {
 		m.logger.WithError(err).Warn("Failed to get network interfaces")
 		return []models.NetworkInterface{}
 	}
 
-	// Get enriched adapter info from PowerShell
-	adapterMap := m.getAdapterInfo()
+	// Wireless connections, keyed by adapter description
+	wifi := m.getWiFiInfo()
+
+	// IP configuration (DHCP state and lease), keyed by interface index
+	configs := make(map[int]win32NetworkAdapterConfiguration)
+	for _, c := range m.getAdapterConfigs() {
+		configs[int(c.InterfaceIndex)] = c
+	}
+
+	// Network List Manager profiles of connected networks, keyed by interface index
+	profiles := m.getConnectionProfiles()
 
 	var result []models.NetworkInterface
 
@@ -204,6 +241,9 @@ func (m *Manager) getNetworkInterfaces() []models.NetworkInterface {
 		if iface.Flags&net.FlagLoopback != 0 {
 			continue
 		}
+		if interfaceExcluded(m.exclude, iface.Name, adapterMap) {
+			continue
+		}
 
 		// Get IP addresses for this interface
 		var addresses []models.NetworkAddress
@@ -215,8 +255,13 @@ func (m *Manager) getNetworkInterfaces() []models.NetworkInterface {
 		}
 
 		// Get gateways for this interface (separate for IPv4 and IPv6)
-		ipv4Gateway := m.getInterfaceGateway(iface.Name, false)
-		ipv6Gateway := m.getInterfaceGateway(iface.Name, true)
+		var ipv4Gateway, ipv6Gateway string
+		if adapter, ok := adapterMap[iface.Name]; ok && adapter.native {
+			ipv4Gateway, ipv6Gateway = adapter.ipv4Gateway, adapter.ipv6Gateway
+		} else {
+			ipv4Gateway = m.getInterfaceGateway(iface.Name, false)
+			ipv6Gateway = m.getInterfaceGateway(iface.Name, true)
+		}
 
 		for _, addr := range addrs {
 			if ipnet, ok := addr.(*net.IPNet); ok {
@@ -266,10 +311,10 @@ func (m *Manager) getNetworkInterfaces() []models.NetworkInterface {
 				status = "up"
 			}
 
-			// Get link speed and duplex from PowerShell adapter info
+			// Get link speed and duplex from the adapter info
 			linkSpeed, duplex := m.getLinkSpeedAndDuplex(iface.Name, adapterMap)
 
-			result = append(result, models.NetworkInterface{
+			networkInterface := models.NetworkInterface{
 				Name:       iface.Name,
 				Type:       interfaceType,
 				MACAddress: macAddress,
@@ -278,13 +323,36 @@ func (m *Manager) getNetworkInterfaces() []models.NetworkInterface {
 				LinkSpeed:  linkSpeed,
 				Duplex:     duplex,
 				Addresses:  addresses,
-			})
+			}
+			if config, ok := configs[iface.Index]; ok {
+				networkInterface.DNSSuffix = config.DNSDomain
+				applyDHCP(&networkInterface, config)
+			}
+			if m.counters {
+				networkInterface.Counters = m.getInterfaceCounters(iface.Index)
+			}
+			if profile, ok := profiles[iface.Index]; ok {
+				networkInterface.NetworkName = profile.Name
+				networkInterface.NetworkCategory = networkCategory(profile.NetworkCategory)
+			}
+			if interfaceType == constants.NetTypeWiFi {
+				if adapter, ok := adapterMap[iface.Name]; ok {
+					networkInterface.WiFi = wifi[adapter.InterfaceDescription]
+				}
+			}
+			result = append(result, networkInterface)
 		}
 	}
 
 	return result
 }
 
+// interfaceExcluded reports whether an interface matches the exclusion patterns
+// by name, adapter description or interface type
+func interfaceExcluded(exclude *utils.PatternMatcher, name string, adapterMap map[string]netAdapterInfo) bool {
+	return exclude.Match(name, adapterMap[name].InterfaceDescription, detectInterfaceType(name, adapterMap))
+}
+
 // getAdapterInfo retrieves adapter details from PowerShell Get-NetAdapter
 func (m *Manager) getAdapterInfo() map[string]netAdapterInfo {
 	adapterMap := make(map[string]netAdapterInfo)
@@ -301,15 +369,10 @@ func (m *Manager) getAdapterInfo() map[string]netAdapterInfo {
 	}
 
 	// PowerShell returns a single object (not array) when there's only one adapter
-	// Try array first, then single object
-	var adapters []netAdapterInfo
-	if err := json.Unmarshal([]byte(output), &adapters); err != nil {
-		var single netAdapterInfo
-		if err2 := json.Unmarshal([]byte(output), &single); err2 != nil {
-			m.logger.WithError(err2).Debug("Failed to parse adapter JSON")
-			return adapterMap
-		}
-		adapters = []netAdapterInfo{single}
+	adapters, err := utils.UnmarshalPowerShellJSON[netAdapterInfo](output)
+	if err != nil {
+		m.logger.WithError(err).Debug("Failed to parse adapter JSON")
+		return adapterMap
 	}
 
 	for _, adapter := range adapters {
@@ -323,7 +386,7 @@ func (m *Manager) getAdapterInfo() map[string]netAdapterInfo {
 func detectInterfaceType(name string, adapterMap map[string]netAdapterInfo) string {
 	nameLower := strings.ToLower(name)
 
-	// Check PowerShell adapter info first
+	// Check adapter info first
 	if adapter, ok := adapterMap[name]; ok {
 		descLower := strings.ToLower(adapter.InterfaceDescription)
 		mediaLower := strings.ToLower(adapter.MediaType)
@@ -412,6 +475,9 @@ func (m *Manager) getLinkSpeedAndDuplex(interfaceName string, adapterMap map[str
 
 	// Parse link speed string (e.g., "1 Gbps", "100 Mbps", "10 Gbps", "2.5 Gbps")
 	linkSpeed := parseLinkSpeed(adapter.LinkSpeed)
+	if adapter.native {
+		linkSpeed = adapter.linkSpeedMbps
+	}
 
 	// Determine duplex
 	duplex := ""
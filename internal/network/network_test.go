@@ -2,11 +2,17 @@ package network
 
 import (
 	"net"
+	"reflect"
 	"testing"
+	"time"
+	"unsafe"
 
 	"patchmon-agent/internal/constants"
+	"patchmon-agent/internal/utils"
+	"patchmon-agent/pkg/models"
 
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/windows"
 )
 
 // TestRunPowerShell verifies the PowerShell helper can execute a simple command
@@ -137,6 +143,40 @@ func TestDetectInterfaceTypeWithAdapterInfo(t *testing.T) {
 	}
 }
 
+func TestInterfaceExcluded(t *testing.T) {
+	adapterMap := map[string]netAdapterInfo{
+		"Ethernet":              {Name: "Ethernet", InterfaceDescription: "Intel(R) Ethernet Connection I219-V", MediaType: "802.3"},
+		"vEthernet (nat)":       {Name: "vEthernet (nat)", InterfaceDescription: "Hyper-V Virtual Ethernet Adapter", MediaType: ""},
+		"Corp VPN":              {Name: "Corp VPN", InterfaceDescription: "WireGuard Tunnel", MediaType: ""},
+		"Local Area Connection": {Name: "Local Area Connection", InterfaceDescription: "TAP-Windows Adapter V9", MediaType: "802.3"},
+	}
+	exclude, err := utils.NewPatternMatcher([]string{"vEthernet*", "*vpn*", "re:^TAP-"})
+	if err != nil {
+		t.Fatalf("NewPatternMatcher() error = %v", err)
+	}
+
+	tests := map[string]bool{
+		"Ethernet":              false,
+		"vEthernet (nat)":       true, // name glob
+		"Corp VPN":              true, // case-insensitive name glob
+		"Local Area Connection": true, // description regex
+	}
+	for name, want := range tests {
+		if got := interfaceExcluded(exclude, name, adapterMap); got != want {
+			t.Errorf("interfaceExcluded(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	// Types can be excluded too, and no patterns exclude nothing
+	byType, _ := utils.NewPatternMatcher([]string{"virtual"})
+	if !interfaceExcluded(byType, "vEthernet (nat)", adapterMap) {
+		t.Error("interfaceExcluded() did not match the virtual type")
+	}
+	if interfaceExcluded(nil, "vEthernet (nat)", adapterMap) {
+		t.Error("interfaceExcluded(nil) = true, want false")
+	}
+}
+
 // TestIsValidIP tests IP address validation
 func TestIsValidIP(t *testing.T) {
 	tests := []struct {
@@ -338,3 +378,284 @@ func TestGetNetworkInfoDNSNeverNil(t *testing.T) {
 		t.Error("DNSServers should be an empty slice, not nil")
 	}
 }
+
+func TestLinkSpeedMbps(t *testing.T) {
+	tests := map[uint64]int{
+		0:              -1,
+		^uint64(0):     -1,
+		100000000:      100,
+		1000000000:     1000,
+		2500000000:     2500,
+		10000000000000: 10000000,
+	}
+	for bps, want := range tests {
+		if got := linkSpeedMbps(bps); got != want {
+			t.Errorf("linkSpeedMbps(%d) = %d, want %d", bps, got, want)
+		}
+	}
+}
+
+func TestDefaultGatewayAndDNSServers(t *testing.T) {
+	adapters := []netAdapterInfo{
+		{Name: "Wi-Fi", Status: "Up", ipv4Metric: 35, ipv4Gateway: "192.168.1.1", dnsServers: []string{"192.168.1.1", "1.1.1.1"}},
+		{Name: "Ethernet", Status: "Up", ipv4Metric: 25, ipv4Gateway: "10.0.0.1", dnsServers: []string{"10.0.0.53", "1.1.1.1"}},
+		{Name: "Ethernet 2", Status: "Disconnected", ipv4Metric: 5, ipv4Gateway: "172.16.0.1", dnsServers: []string{"172.16.0.53"}},
+		{Name: "vEthernet (Default Switch)", Status: "Up", ipv4Metric: 5000},
+	}
+
+	if got := defaultGateway(adapters); got != "10.0.0.1" {
+		t.Errorf("defaultGateway() = %q, want %q", got, "10.0.0.1")
+	}
+	want := []string{"192.168.1.1", "1.1.1.1", "10.0.0.53"}
+	if got := dnsServers(adapters); !reflect.DeepEqual(got, want) {
+		t.Errorf("dnsServers() = %v, want %v", got, want)
+	}
+	if got := dnsServers(nil); got == nil || len(got) != 0 {
+		t.Errorf("dnsServers(nil) = %#v, want empty slice", got)
+	}
+}
+
+func TestAdapterConfigFallback(t *testing.T) {
+	configs := []win32NetworkAdapterConfiguration{
+		{DefaultIPGateway: nil, DNSServerSearchOrder: []string{"10.0.0.53"}},
+		{DefaultIPGateway: []string{"fe80::1", "192.168.1.1"}, DNSServerSearchOrder: []string{"192.168.1.1", "10.0.0.53", "not-an-ip"}},
+	}
+
+	if got := defaultGatewayFromConfigs(configs); got != "192.168.1.1" {
+		t.Errorf("defaultGatewayFromConfigs() = %q, want %q", got, "192.168.1.1")
+	}
+	want := []string{"10.0.0.53", "192.168.1.1"}
+	if got := dnsServersFromConfigs(configs); !reflect.DeepEqual(got, want) {
+		t.Errorf("dnsServersFromConfigs() = %v, want %v", got, want)
+	}
+	if got := dnsServersFromConfigs(nil); got == nil || len(got) != 0 {
+		t.Errorf("dnsServersFromConfigs(nil) = %#v, want empty slice", got)
+	}
+}
+
+func TestWLANStructLayout(t *testing.T) {
+	// Sizes of the C structures, the same on 32 and 64-bit Windows
+	if got := unsafe.Sizeof(wlanInterfaceInfo{}); got != 532 {
+		t.Errorf("sizeof(WLAN_INTERFACE_INFO) = %d, want 532", got)
+	}
+	if got := unsafe.Sizeof(wlanConnectionAttributes{}); got != 604 {
+		t.Errorf("sizeof(WLAN_CONNECTION_ATTRIBUTES) = %d, want 604", got)
+	}
+	if got := unsafe.Sizeof(wlanBSSEntry{}); got != 360 {
+		t.Errorf("sizeof(WLAN_BSS_ENTRY) = %d, want 360", got)
+	}
+	if got := unsafe.Offsetof(wlanBSSEntry{}.ChCenterFrequency); got != 92 {
+		t.Errorf("offsetof(WLAN_BSS_ENTRY.ulChCenterFrequency) = %d, want 92", got)
+	}
+}
+
+func TestConvertConnection(t *testing.T) {
+	attrs := &wlanConnectionAttributes{
+		SSID:          dot11SSID{Length: 7, SSID: [32]byte{'O', 'f', 'f', 'i', 'c', 'e', '5'}},
+		BSSID:         [6]byte{0x00, 0x11, 0x22, 0xaa, 0xbb, 0xcc},
+		PhyType:       10,
+		SignalQuality: 82,
+		RxRate:        1201000,
+		TxRate:        866700,
+	}
+	copy(attrs.ProfileName[:], []uint16{'O', 'f', 'f', 'i', 'c', 'e'})
+
+	got := convertConnection(attrs)
+	applyBSSEntry(got, &wlanBSSEntry{RSSI: -58, ChCenterFrequency: 5180000})
+
+	want := &models.WiFiInfo{
+		SSID:          "Office5",
+		BSSID:         "00:11:22:aa:bb:cc",
+		Profile:       "Office",
+		SignalPercent: 82,
+		RSSI:          -58,
+		Band:          "5 GHz",
+		FrequencyMHz:  5180,
+		PhyType:       "802.11ax",
+		RxRateMbps:    1201,
+		TxRateMbps:    866,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("convertConnection() = %+v, want %+v", got, want)
+	}
+}
+
+func TestWiFiBand(t *testing.T) {
+	tests := map[int]string{
+		2412:  "2.4 GHz",
+		5180:  "5 GHz",
+		5955:  "6 GHz",
+		60480: "60 GHz",
+		0:     "",
+	}
+	for mhz, want := range tests {
+		if got := wifiBand(mhz); got != want {
+			t.Errorf("wifiBand(%d) = %q, want %q", mhz, got, want)
+		}
+	}
+}
+
+func TestApplyDHCP(t *testing.T) {
+	obtained := time.Date(2024, 3, 1, 8, 0, 0, 0, time.FixedZone("CET", 3600))
+
+	var leased models.NetworkInterface
+	applyDHCP(&leased, win32NetworkAdapterConfiguration{
+		DHCPEnabled:       true,
+		DHCPServer:        "192.168.1.1",
+		DHCPLeaseObtained: obtained,
+		DHCPLeaseExpires:  obtained.Add(24 * time.Hour),
+	})
+	if leased.DHCPEnabled == nil || !*leased.DHCPEnabled {
+		t.Fatalf("DHCPEnabled = %v, want true", leased.DHCPEnabled)
+	}
+	if leased.DHCPServer != "192.168.1.1" {
+		t.Errorf("DHCPServer = %q, want %q", leased.DHCPServer, "192.168.1.1")
+	}
+	if leased.DHCPLeaseObtained != "2024-03-01T07:00:00Z" || leased.DHCPLeaseExpires != "2024-03-02T07:00:00Z" {
+		t.Errorf("lease = %q to %q, want 2024-03-01T07:00:00Z to 2024-03-02T07:00:00Z", leased.DHCPLeaseObtained, leased.DHCPLeaseExpires)
+	}
+
+	// Static interfaces keep no stale DHCP server or lease
+	var static models.NetworkInterface
+	applyDHCP(&static, win32NetworkAdapterConfiguration{DHCPServer: "255.255.255.255", DHCPLeaseObtained: obtained})
+	if static.DHCPEnabled == nil || *static.DHCPEnabled {
+		t.Errorf("DHCPEnabled = %v, want false", static.DHCPEnabled)
+	}
+	if static.DHCPServer != "" || static.DHCPLeaseObtained != "" || static.DHCPLeaseExpires != "" {
+		t.Errorf("static interface has DHCP details: %+v", static)
+	}
+}
+
+func TestSelectPrimaryIP(t *testing.T) {
+	ipv4 := func(addr, gateway string) models.NetworkAddress {
+		return models.NetworkAddress{Address: addr, Family: constants.IPFamilyIPv4, Netmask: "/24", Gateway: gateway}
+	}
+	interfaces := []models.NetworkInterface{
+		{Name: "vEthernet (Default Switch)", Status: "up", Addresses: []models.NetworkAddress{ipv4("172.17.80.1", "")}},
+		{Name: "Ethernet 2", Status: "up", Addresses: []models.NetworkAddress{ipv4("169.254.10.5", ""), ipv4("10.20.5.14", "")}},
+		{Name: "Ethernet", Status: "up", Addresses: []models.NetworkAddress{
+			{Address: "fe80::1", Family: constants.IPFamilyIPv6, Netmask: "/64"},
+			ipv4("192.168.1.20", "192.168.1.1"),
+		}},
+		{Name: "Backup", Status: "down", Addresses: []models.NetworkAddress{ipv4("10.99.0.2", "")}},
+	}
+	_, subnet, _ := net.ParseCIDR("10.20.0.0/16")
+	_, downSubnet, _ := net.ParseCIDR("10.99.0.0/16")
+	byName, _ := utils.NewPatternMatcher([]string{"ethernet 2"})
+
+	tests := []struct {
+		name   string
+		subnet *net.IPNet
+		iface  *utils.PatternMatcher
+		want   string
+	}{
+		{"default route", nil, nil, "192.168.1.20"},
+		{"subnet", subnet, nil, "10.20.5.14"},
+		{"interface skips link-local", nil, byName, "10.20.5.14"},
+		{"down interface ignored", downSubnet, nil, "192.168.1.20"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectPrimaryIP(interfaces, tt.subnet, tt.iface); got != tt.want {
+				t.Errorf("selectPrimaryIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Without a default route the first address wins
+	if got := selectPrimaryIP(interfaces[:2], nil, nil); got != "172.17.80.1" {
+		t.Errorf("selectPrimaryIP() without gateway = %q, want %q", got, "172.17.80.1")
+	}
+	if got := selectPrimaryIP(nil, nil, nil); got != "" {
+		t.Errorf("selectPrimaryIP(nil) = %q, want empty", got)
+	}
+}
+
+func TestParseSearchList(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"", nil},
+		{"corp.contoso.com,contoso.com", []string{"corp.contoso.com", "contoso.com"}},
+		{"corp.contoso.com contoso.com.  CORP.contoso.com", []string{"corp.contoso.com", "contoso.com"}},
+		{" , ", nil},
+	}
+	for _, tt := range tests {
+		if got := parseSearchList(tt.value); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseSearchList(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestDetectVPN(t *testing.T) {
+	adapterMap := map[string]netAdapterInfo{
+		"Ethernet":    {Name: "Ethernet", InterfaceDescription: "Intel(R) Ethernet Connection I219-V", Status: "Up"},
+		"Office VPN":  {Name: "Office VPN", InterfaceDescription: "Office VPN", Status: "Up", pointToPoint: true},
+		"wg0":         {Name: "wg0", InterfaceDescription: "WireGuard Tunnel", Status: "Disconnected"},
+		"Ethernet 3":  {Name: "Ethernet 3", InterfaceDescription: "PANGP Virtual Ethernet Adapter Secure", Status: "Disconnected"},
+		"OpenVPN TAP": {Name: "OpenVPN TAP", InterfaceDescription: "TAP-Windows Adapter V9", Status: "Disconnected"},
+	}
+
+	want := &models.VPNInfo{
+		Active: true,
+		Adapters: []models.VPNAdapter{
+			{Name: "Ethernet 3", Description: "PANGP Virtual Ethernet Adapter Secure", Client: VPNClientGlobalProtect},
+			{Name: "Office VPN", Description: "Office VPN", Client: VPNClientWindows, Connected: true},
+			{Name: "OpenVPN TAP", Description: "TAP-Windows Adapter V9", Client: VPNClientOpenVPN},
+			{Name: "wg0", Description: "WireGuard Tunnel", Client: VPNClientWireGuard},
+		},
+	}
+	if got := detectVPN(adapterMap); !reflect.DeepEqual(got, want) {
+		t.Errorf("detectVPN() = %+v, want %+v", got, want)
+	}
+
+	delete(adapterMap, "Office VPN")
+	if got := detectVPN(adapterMap); got == nil || got.Active {
+		t.Errorf("detectVPN() without a connected tunnel = %+v, want inactive", got)
+	}
+	if got := detectVPN(map[string]netAdapterInfo{"Ethernet": adapterMap["Ethernet"]}); got != nil {
+		t.Errorf("detectVPN() without VPN adapters = %+v, want nil", got)
+	}
+}
+
+func TestNetworkCategory(t *testing.T) {
+	tests := map[uint32]string{
+		0: NetworkCategoryPublic,
+		1: NetworkCategoryPrivate,
+		2: NetworkCategoryDomain,
+		9: "",
+	}
+	for category, want := range tests {
+		if got := networkCategory(category); got != want {
+			t.Errorf("networkCategory(%d) = %q, want %q", category, got, want)
+		}
+	}
+}
+
+func TestConvertIfRow(t *testing.T) {
+	row := &windows.MibIfRow2{
+		InOctets:      1500000,
+		OutOctets:     250000,
+		InUcastPkts:   1200,
+		InNUcastPkts:  30,
+		OutUcastPkts:  900,
+		OutNUcastPkts: 4,
+		InErrors:      2,
+		OutErrors:     1,
+		InDiscards:    7,
+		OutDiscards:   0,
+	}
+	want := &models.InterfaceCounters{
+		BytesReceived:   1500000,
+		BytesSent:       250000,
+		PacketsReceived: 1230,
+		PacketsSent:     904,
+		ReceiveErrors:   2,
+		SendErrors:      1,
+		ReceiveDiscards: 7,
+	}
+	if got := convertIfRow(row); !reflect.DeepEqual(got, want) {
+		t.Errorf("convertIfRow() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"patchmon-agent/internal/constants"
+	"patchmon-agent/internal/utils"
+	"patchmon-agent/pkg/models"
+)
+
+// SetPrimaryIPPolicy sets how PrimaryIP chooses the host IP: an address in
+// subnet (CIDR) is preferred, then an address on an interface whose name
+// matches iface (see utils.PatternMatcher). Both are optional.
+func (m *Manager) SetPrimaryIPPolicy(subnet, iface string) error {
+	m.primarySubnet = nil
+	if subnet = strings.TrimSpace(subnet); subnet != "" {
+		_, ipNet, err := net.ParseCIDR(subnet)
+		if err != nil {
+			return fmt.Errorf("invalid primary_ip_subnet %q: %w", subnet, err)
+		}
+		m.primarySubnet = ipNet
+	}
+
+	m.primaryInterface = nil
+	if strings.TrimSpace(iface) != "" {
+		matcher, err := utils.NewPatternMatcher([]string{iface})
+		if err != nil {
+			return err
+		}
+		m.primaryInterface = matcher
+	}
+	return nil
+}
+
+// PrimaryIP returns the IPv4 address to report as the host IP, or "" if no
+// interface has a usable IPv4 address
+func (m *Manager) PrimaryIP(interfaces []models.NetworkInterface) string {
+	return selectPrimaryIP(interfaces, m.primarySubnet, m.primaryInterface)
+}
+
+// selectPrimaryIP picks the IPv4 address of a connected interface, preferring
+// in order: an address in subnet, an interface matching iface, the interface
+// with the default route, and finally the first address found. Link-local
+// (APIPA) addresses are never chosen.
+func selectPrimaryIP(interfaces []models.NetworkInterface, subnet *net.IPNet, iface *utils.PatternMatcher) string {
+	var byName, byGateway, first string
+	for _, ni := range interfaces {
+		if ni.Status != "up" {
+			continue
+		}
+		for _, addr := range ni.Addresses {
+			ip := net.ParseIP(addr.Address)
+			if addr.Family != constants.IPFamilyIPv4 || ip == nil || ip.IsLinkLocalUnicast() {
+				continue
+			}
+			if subnet != nil && subnet.Contains(ip) {
+				return addr.Address
+			}
+			if byName == "" && iface.Match(ni.Name) {
+				byName = addr.Address
+			}
+			if byGateway == "" && addr.Gateway != "" {
+				byGateway = addr.Address
+			}
+			if first == "" {
+				first = addr.Address
+			}
+		}
+	}
+
+	for _, ip := range []string{byName, byGateway, first} {
+		if ip != "" {
+			return ip
+		}
+	}
+	return ""
+}
@@ -0,0 +1,48 @@
+package network
+
+import (
+	"github.com/yusufpapurcu/wmi"
+)
+
+// Network categories reported in NetworkInterface.NetworkCategory
+const (
+	NetworkCategoryPublic  = "public"
+	NetworkCategoryPrivate = "private"
+	NetworkCategoryDomain  = "domain"
+)
+
+// msftNetConnectionProfile maps the WMI MSFT_NetConnectionProfile class, the
+// Network List Manager view of each connected network
+type msftNetConnectionProfile struct {
+	Name            string
+	InterfaceIndex  uint32
+	NetworkCategory uint32
+}
+
+// getConnectionProfiles returns the connection profile of each connected
+// interface, keyed by interface index
+func (m *Manager) getConnectionProfiles() map[int]msftNetConnectionProfile {
+	var profiles []msftNetConnectionProfile
+	if err := wmi.QueryNamespace("SELECT Name, InterfaceIndex, NetworkCategory FROM MSFT_NetConnectionProfile", &profiles, `root\StandardCimv2`); err != nil {
+		m.logger.WithError(err).Debug("Failed to query network connection profiles")
+		return nil
+	}
+	byIndex := make(map[int]msftNetConnectionProfile, len(profiles))
+	for _, p := range profiles {
+		byIndex[int(p.InterfaceIndex)] = p
+	}
+	return byIndex
+}
+
+// networkCategory maps a NetworkCategory value to the firewall profile name
+func networkCategory(category uint32) string {
+	switch category {
+	case 0:
+		return NetworkCategoryPublic
+	case 1:
+		return NetworkCategoryPrivate
+	case 2:
+		return NetworkCategoryDomain
+	}
+	return ""
+}
@@ -0,0 +1,88 @@
+package network
+
+import (
+	"sort"
+	"strings"
+
+	"patchmon-agent/pkg/models"
+)
+
+// VPN clients reported in VPNAdapter.Client
+const (
+	VPNClientWindows       = "windows" // built-in RasClient (IKEv2, SSTP, L2TP, PPTP)
+	VPNClientWireGuard     = "wireguard"
+	VPNClientOpenVPN       = "openvpn"
+	VPNClientAnyConnect    = "cisco-anyconnect"
+	VPNClientGlobalProtect = "globalprotect"
+	VPNClientFortiClient   = "forticlient"
+	VPNClientPulseSecure   = "pulse-secure"
+	VPNClientCheckPoint    = "checkpoint"
+	VPNClientSonicWall     = "sonicwall"
+	VPNClientZscaler       = "zscaler"
+	VPNClientTailscale     = "tailscale"
+	VPNClientZeroTier      = "zerotier"
+)
+
+// vpnAdapters maps a lower-case fragment of the adapter description to the
+// VPN client that installs it. The first match wins.
+var vpnAdapters = []struct {
+	description string
+	client      string
+}{
+	{"wireguard", VPNClientWireGuard},
+	{"tap-windows", VPNClientOpenVPN},
+	{"openvpn", VPNClientOpenVPN},
+	{"wintun", VPNClientOpenVPN},
+	{"anyconnect", VPNClientAnyConnect},
+	{"cisco secure client", VPNClientAnyConnect},
+	{"pangp", VPNClientGlobalProtect},
+	{"fortinet", VPNClientFortiClient},
+	{"juniper networks virtual adapter", VPNClientPulseSecure},
+	{"pulse secure", VPNClientPulseSecure},
+	{"ivanti", VPNClientPulseSecure},
+	{"check point virtual network adapter", VPNClientCheckPoint},
+	{"sonicwall", VPNClientSonicWall},
+	{"zscaler", VPNClientZscaler},
+	{"tailscale", VPNClientTailscale},
+	{"zerotier", VPNClientZeroTier},
+}
+
+// vpnClient returns the VPN client of an adapter, or "" if it is not a VPN
+// adapter. Connections of the built-in client appear as PPP interfaces.
+func vpnClient(adapter netAdapterInfo) string {
+	if adapter.pointToPoint {
+		return VPNClientWindows
+	}
+	description := strings.ToLower(adapter.InterfaceDescription)
+	for _, v := range vpnAdapters {
+		if strings.Contains(description, v.description) {
+			return v.client
+		}
+	}
+	return ""
+}
+
+// detectVPN returns the VPN adapters, sorted by name, and whether any tunnel
+// is up. It returns nil if there are no VPN adapters.
+func detectVPN(adapterMap map[string]netAdapterInfo) *models.VPNInfo {
+	var info models.VPNInfo
+	for _, adapter := range adapterMap {
+		client := vpnClient(adapter)
+		if client == "" {
+			continue
+		}
+		connected := adapter.Status == "Up"
+		info.Adapters = append(info.Adapters, models.VPNAdapter{
+			Name:        adapter.Name,
+			Description: adapter.InterfaceDescription,
+			Client:      client,
+			Connected:   connected,
+		})
+		info.Active = info.Active || connected
+	}
+	if len(info.Adapters) == 0 {
+		return nil
+	}
+	sort.Slice(info.Adapters, func(i, j int) bool { return info.Adapters[i].Name < info.Adapters[j].Name })
+	return &info
+}
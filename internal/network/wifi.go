@@ -0,0 +1,227 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+
+	"patchmon-agent/pkg/models"
+)
+
+var (
+	wlanapi                   = windows.NewLazySystemDLL("wlanapi.dll")
+	procWlanOpenHandle        = wlanapi.NewProc("WlanOpenHandle")
+	procWlanCloseHandle       = wlanapi.NewProc("WlanCloseHandle")
+	procWlanEnumInterfaces    = wlanapi.NewProc("WlanEnumInterfaces")
+	procWlanQueryInterface    = wlanapi.NewProc("WlanQueryInterface")
+	procWlanGetNetworkBssList = wlanapi.NewProc("WlanGetNetworkBssList")
+	procWlanFreeMemory        = wlanapi.NewProc("WlanFreeMemory")
+)
+
+// WLAN API constants (wlanapi.h)
+const (
+	wlanClientVersion               = 2 // Windows Vista and later
+	wlanIntfOpcodeCurrentConnection = 7
+	wlanInterfaceStateConnected     = 1
+	dot11BssTypeInfrastructure      = 1
+	maxWlanProfileName              = 256
+	maxWlanInterfaceDescription     = 256
+	dot11SSIDMaxLength              = 32
+	dot11RateSetMaxLength           = 126
+	// Both lists start with two DWORDs (item count and index or total size)
+	wlanListHeaderSize = 8
+)
+
+// phyTypes maps DOT11_PHY_TYPE values to the 802.11 standard
+var phyTypes = map[uint32]string{
+	1:  "802.11 FHSS",
+	2:  "802.11 DSSS",
+	3:  "802.11 IR",
+	4:  "802.11a",
+	5:  "802.11b",
+	6:  "802.11g",
+	7:  "802.11n",
+	8:  "802.11ac",
+	9:  "802.11ad",
+	10: "802.11ax",
+	11: "802.11be",
+}
+
+// dot11SSID mirrors DOT11_SSID
+type dot11SSID struct {
+	Length uint32
+	SSID   [dot11SSIDMaxLength]byte
+}
+
+// wlanInterfaceInfo mirrors WLAN_INTERFACE_INFO
+type wlanInterfaceInfo struct {
+	InterfaceGUID windows.GUID
+	Description   [maxWlanInterfaceDescription]uint16
+	State         uint32
+}
+
+// wlanConnectionAttributes mirrors WLAN_CONNECTION_ATTRIBUTES
+type wlanConnectionAttributes struct {
+	State          uint32
+	ConnectionMode uint32
+	ProfileName    [maxWlanProfileName]uint16
+	// WLAN_ASSOCIATION_ATTRIBUTES
+	SSID          dot11SSID
+	BSSType       uint32
+	BSSID         [6]byte
+	PhyType       uint32
+	PhyIndex      uint32
+	SignalQuality uint32 // percent
+	RxRate        uint32 // kbps
+	TxRate        uint32 // kbps
+	// WLAN_SECURITY_ATTRIBUTES
+	SecurityEnabled int32
+	OneXEnabled     int32
+	AuthAlgorithm   uint32
+	CipherAlgorithm uint32
+}
+
+// wlanBSSEntry mirrors WLAN_BSS_ENTRY. The 64-bit timestamps are byte arrays
+// with explicit padding so the layout is the same on 386, where Go aligns
+// uint64 to 4 bytes.
+type wlanBSSEntry struct {
+	SSID                  dot11SSID
+	PhyID                 uint32
+	BSSID                 [6]byte
+	BSSType               uint32
+	PhyType               uint32
+	RSSI                  int32 // dBm
+	LinkQuality           uint32
+	InRegDomain           uint8
+	BeaconPeriod          uint16
+	_                     [4]byte
+	Timestamp             [8]byte
+	HostTimestamp         [8]byte
+	CapabilityInformation uint16
+	ChCenterFrequency     uint32 // kHz
+	RateSetLength         uint32
+	RateSet               [dot11RateSetMaxLength]uint16
+	IEOffset              uint32
+	IESize                uint32
+}
+
+// getWiFiInfo returns the connection of each connected wireless interface,
+// keyed by adapter description. It returns nil if the WLAN AutoConfig
+// service is not running (e.g. on servers without the Wireless LAN feature).
+func (m *Manager) getWiFiInfo() map[string]*models.WiFiInfo {
+	if err := wlanapi.Load(); err != nil {
+		return nil
+	}
+
+	var negotiated uint32
+	var handle windows.Handle
+	if r, _, _ := procWlanOpenHandle.Call(wlanClientVersion, 0, uintptr(unsafe.Pointer(&negotiated)), uintptr(unsafe.Pointer(&handle))); r != 0 {
+		m.logger.WithError(windows.Errno(r)).Debug("WLAN service is not available")
+		return nil
+	}
+	defer procWlanCloseHandle.Call(uintptr(handle), 0)
+
+	var list *byte
+	if r, _, _ := procWlanEnumInterfaces.Call(uintptr(handle), 0, uintptr(unsafe.Pointer(&list))); r != 0 {
+		m.logger.WithError(windows.Errno(r)).Debug("Failed to enumerate wireless interfaces")
+		return nil
+	}
+	defer procWlanFreeMemory.Call(uintptr(unsafe.Pointer(list)))
+
+	count := *(*uint32)(unsafe.Pointer(list))
+	if count == 0 {
+		return nil
+	}
+	interfaces := unsafe.Slice((*wlanInterfaceInfo)(unsafe.Add(unsafe.Pointer(list), wlanListHeaderSize)), count)
+
+	result := make(map[string]*models.WiFiInfo)
+	for i := range interfaces {
+		iface := &interfaces[i]
+		if iface.State != wlanInterfaceStateConnected {
+			continue
+		}
+		info, err := queryConnection(handle, iface)
+		if err != nil {
+			m.logger.WithError(err).Debug("Failed to query wireless connection")
+			continue
+		}
+		result[windows.UTF16ToString(iface.Description[:])] = info
+	}
+	return result
+}
+
+// queryConnection reads the current connection of a wireless interface, and
+// the frequency and RSSI of the access point it is associated with
+func queryConnection(handle windows.Handle, iface *wlanInterfaceInfo) (*models.WiFiInfo, error) {
+	var size uint32
+	var attrs *wlanConnectionAttributes
+	if r, _, _ := procWlanQueryInterface.Call(uintptr(handle), uintptr(unsafe.Pointer(&iface.InterfaceGUID)), wlanIntfOpcodeCurrentConnection, 0,
+		uintptr(unsafe.Pointer(&size)), uintptr(unsafe.Pointer(&attrs)), 0); r != 0 {
+		return nil, fmt.Errorf("WlanQueryInterface failed: %w", windows.Errno(r))
+	}
+	defer procWlanFreeMemory.Call(uintptr(unsafe.Pointer(attrs)))
+	if uintptr(size) < unsafe.Sizeof(*attrs) {
+		return nil, fmt.Errorf("unexpected connection attributes size %d", size)
+	}
+
+	info := convertConnection(attrs)
+
+	// The BSS list adds the channel frequency and RSSI; it is optional
+	var bssList *byte
+	if r, _, _ := procWlanGetNetworkBssList.Call(uintptr(handle), uintptr(unsafe.Pointer(&iface.InterfaceGUID)), uintptr(unsafe.Pointer(&attrs.SSID)),
+		dot11BssTypeInfrastructure, uintptr(attrs.SecurityEnabled), 0, uintptr(unsafe.Pointer(&bssList))); r == 0 {
+		defer procWlanFreeMemory.Call(uintptr(unsafe.Pointer(bssList)))
+		count := *(*uint32)(unsafe.Add(unsafe.Pointer(bssList), 4))
+		if count > 0 {
+			entries := unsafe.Slice((*wlanBSSEntry)(unsafe.Add(unsafe.Pointer(bssList), wlanListHeaderSize)), count)
+			for i := range entries {
+				if entries[i].BSSID == attrs.BSSID {
+					applyBSSEntry(info, &entries[i])
+					break
+				}
+			}
+		}
+	}
+	return info, nil
+}
+
+// convertConnection converts the connection attributes of an interface
+func convertConnection(attrs *wlanConnectionAttributes) *models.WiFiInfo {
+	ssidLen := attrs.SSID.Length
+	if ssidLen > dot11SSIDMaxLength {
+		ssidLen = dot11SSIDMaxLength
+	}
+	return &models.WiFiInfo{
+		SSID:          string(attrs.SSID.SSID[:ssidLen]),
+		BSSID:         net.HardwareAddr(attrs.BSSID[:]).String(),
+		Profile:       windows.UTF16ToString(attrs.ProfileName[:]),
+		SignalPercent: int(attrs.SignalQuality),
+		PhyType:       phyTypes[attrs.PhyType],
+		RxRateMbps:    int(attrs.RxRate / 1000),
+		TxRateMbps:    int(attrs.TxRate / 1000),
+	}
+}
+
+// applyBSSEntry adds the frequency, band and RSSI of the associated access point
+func applyBSSEntry(info *models.WiFiInfo, entry *wlanBSSEntry) {
+	info.FrequencyMHz = int(entry.ChCenterFrequency / 1000)
+	info.Band = wifiBand(info.FrequencyMHz)
+	info.RSSI = int(entry.RSSI)
+}
+
+// wifiBand returns the band of a channel center frequency
+func wifiBand(mhz int) string {
+	switch {
+	case mhz >= 2400 && mhz < 2500:
+		return "2.4 GHz"
+	case mhz >= 4900 && mhz < 5925:
+		return "5 GHz"
+	case mhz >= 5925 && mhz <= 7125:
+		return "6 GHz"
+	case mhz >= 57000 && mhz <= 71000:
+		return "60 GHz"
+	}
+	return ""
+}
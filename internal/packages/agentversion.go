@@ -0,0 +1,49 @@
+package packages
+
+import (
+	"fmt"
+	"path/filepath"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// wuaEnginePath returns the path of the Windows Update Agent engine, whose
+// file version is the WUA version
+func wuaEnginePath() string {
+	return filepath.Join(systemRoot(), "System32", "wuaueng.dll")
+}
+
+// GetAgentVersion returns the Windows Update Agent version (e.g. 10.0.19041.3636)
+func (w *WindowsUpdateManager) GetAgentVersion() (string, error) {
+	return fileVersion(wuaEnginePath())
+}
+
+// fileVersion reads the fixed file version from a file's version resource
+func fileVersion(path string) (string, error) {
+	size, err := windows.GetFileVersionInfoSize(path, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get version info size of %s: %w", path, err)
+	}
+
+	info := make([]byte, size)
+	if err := windows.GetFileVersionInfo(path, 0, size, unsafe.Pointer(&info[0])); err != nil {
+		return "", fmt.Errorf("failed to get version info of %s: %w", path, err)
+	}
+
+	var fixed *windows.VS_FIXEDFILEINFO
+	var fixedLen uint32
+	if err := windows.VerQueryValue(unsafe.Pointer(&info[0]), `\`, unsafe.Pointer(&fixed), &fixedLen); err != nil {
+		return "", fmt.Errorf("failed to query version of %s: %w", path, err)
+	}
+	if fixed == nil || fixedLen == 0 {
+		return "", fmt.Errorf("%s has no fixed version info", path)
+	}
+
+	return formatFileVersion(fixed.FileVersionMS, fixed.FileVersionLS), nil
+}
+
+// formatFileVersion formats the two halves of a VS_FIXEDFILEINFO file version
+func formatFileVersion(ms, ls uint32) string {
+	return fmt.Sprintf("%d.%d.%d.%d", ms>>16, ms&0xffff, ls>>16, ls&0xffff)
+}
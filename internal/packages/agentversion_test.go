@@ -0,0 +1,20 @@
+package packages
+
+import "testing"
+
+func TestFormatFileVersion(t *testing.T) {
+	tests := []struct {
+		ms, ls uint32
+		want   string
+	}{
+		{10<<16 | 0, 19041<<16 | 3636, "10.0.19041.3636"},
+		{7<<16 | 6, 7601<<16 | 24085, "7.6.7601.24085"},
+		{0, 0, "0.0.0.0"},
+	}
+
+	for _, tt := range tests {
+		if got := formatFileVersion(tt.ms, tt.ls); got != tt.want {
+			t.Errorf("formatFileVersion(%#x, %#x) = %q, want %q", tt.ms, tt.ls, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package packages
+
+import (
+	"sort"
+
+	"github.com/sirupsen/logrus"
+
+	"patchmon-agent/internal/utils"
+	"patchmon-agent/pkg/models"
+)
+
+// AppxManager collects Microsoft Store / Appx / MSIX package inventory
+type AppxManager struct {
+	logger *logrus.Logger
+}
+
+// appxInstalledInfo holds JSON output from Get-AppxPackage
+type appxInstalledInfo struct {
+	Name            string `json:"Name"`
+	Version         string `json:"Version"`
+	Publisher       string `json:"Publisher"`
+	PackageFullName string `json:"PackageFullName"`
+	Architecture    string `json:"Architecture"`
+	SignatureKind   string `json:"SignatureKind"`
+	IsFramework     bool   `json:"IsFramework"`
+}
+
+// appxProvisionedInfo holds JSON output from Get-AppxProvisionedPackage
+type appxProvisionedInfo struct {
+	DisplayName string `json:"DisplayName"`
+	Version     string `json:"Version"`
+	PublisherID string `json:"PublisherId"`
+	PackageName string `json:"PackageName"`
+}
+
+// NewAppxManager creates a new AppxManager
+func NewAppxManager(logger *logrus.Logger) *AppxManager {
+	return &AppxManager{logger: logger}
+}
+
+// GetAppxPackages returns the Appx/MSIX packages installed for any user and
+// those provisioned in the OS image for new users.
+func (a *AppxManager) GetAppxPackages() ([]models.AppxPackage, error) {
+	// Enum properties are converted to strings, otherwise ConvertTo-Json emits numbers
+	installedCmd := "Get-AppxPackage -AllUsers -ErrorAction SilentlyContinue | " +
+		"Select-Object Name, Version, Publisher, PackageFullName, IsFramework, " +
+		"@{n='Architecture';e={$_.Architecture.ToString()}}, @{n='SignatureKind';e={$_.SignatureKind.ToString()}} | " +
+		"ConvertTo-Json -Compress"
+	output, err := utils.RunPowerShell(installedCmd)
+	if err != nil {
+		return nil, err
+	}
+	installed, err := utils.UnmarshalPowerShellJSON[appxInstalledInfo](output)
+	if err != nil {
+		return nil, err
+	}
+
+	provisionedCmd := "Get-AppxProvisionedPackage -Online -ErrorAction SilentlyContinue | " +
+		"Select-Object DisplayName, Version, PublisherId, PackageName | ConvertTo-Json -Compress"
+	var provisioned []appxProvisionedInfo
+	if output, err := utils.RunPowerShell(provisionedCmd); err != nil {
+		a.logger.WithError(err).Debug("Failed to get provisioned Appx packages")
+	} else if provisioned, err = utils.UnmarshalPowerShellJSON[appxProvisionedInfo](output); err != nil {
+		a.logger.WithError(err).Debug("Failed to parse provisioned Appx packages")
+	}
+
+	result := mergeAppxPackages(installed, provisioned)
+	a.logger.WithFields(logrus.Fields{
+		"installed":   len(installed),
+		"provisioned": len(provisioned),
+		"total":       len(result),
+	}).Debug("Collected Appx packages")
+
+	return result, nil
+}
+
+// mergeAppxPackages combines installed and provisioned packages into a single
+// list keyed by package name and version, sorted by name.
+func mergeAppxPackages(installed []appxInstalledInfo, provisioned []appxProvisionedInfo) []models.AppxPackage {
+	result := []models.AppxPackage{}
+	index := make(map[string]int)
+
+	for _, pkg := range installed {
+		// -AllUsers lists the same package once per architecture/user; keep one
+		key := pkg.Name + "|" + pkg.Version
+		if _, exists := index[key]; exists {
+			continue
+		}
+		index[key] = len(result)
+		result = append(result, models.AppxPackage{
+			Name:            pkg.Name,
+			Version:         pkg.Version,
+			Publisher:       pkg.Publisher,
+			PackageFullName: pkg.PackageFullName,
+			Architecture:    pkg.Architecture,
+			SignatureKind:   pkg.SignatureKind,
+			IsFramework:     pkg.IsFramework,
+			Installed:       true,
+		})
+	}
+
+	for _, pkg := range provisioned {
+		key := pkg.DisplayName + "|" + pkg.Version
+		if idx, exists := index[key]; exists {
+			result[idx].Provisioned = true
+			continue
+		}
+		index[key] = len(result)
+		result = append(result, models.AppxPackage{
+			Name:            pkg.DisplayName,
+			Version:         pkg.Version,
+			Publisher:       pkg.PublisherID,
+			PackageFullName: pkg.PackageName,
+			Provisioned:     true,
+		})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
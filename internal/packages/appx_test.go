@@ -0,0 +1,49 @@
+package packages
+
+import "testing"
+
+func TestMergeAppxPackages(t *testing.T) {
+	installed := []appxInstalledInfo{
+		{Name: "Microsoft.WindowsCalculator", Version: "11.2311.0.0", Publisher: "CN=Microsoft Corporation", Architecture: "X64"},
+		// Same package listed again for another user
+		{Name: "Microsoft.WindowsCalculator", Version: "11.2311.0.0", Publisher: "CN=Microsoft Corporation", Architecture: "X64"},
+		{Name: "Microsoft.VCLibs.140.00", Version: "14.0.33519.0", Publisher: "CN=Microsoft Corporation", IsFramework: true},
+	}
+	provisioned := []appxProvisionedInfo{
+		{DisplayName: "Microsoft.WindowsCalculator", Version: "11.2311.0.0", PublisherID: "8wekyb3d8bbwe"},
+		{DisplayName: "Microsoft.BingWeather", Version: "4.53.52892.0", PublisherID: "8wekyb3d8bbwe"},
+	}
+
+	result := mergeAppxPackages(installed, provisioned)
+
+	if len(result) != 3 {
+		t.Fatalf("expected 3 packages, got %d: %+v", len(result), result)
+	}
+
+	// Sorted by name
+	if result[0].Name != "Microsoft.BingWeather" || result[1].Name != "Microsoft.VCLibs.140.00" || result[2].Name != "Microsoft.WindowsCalculator" {
+		t.Errorf("unexpected order: %+v", result)
+	}
+
+	weather := result[0]
+	if weather.Installed || !weather.Provisioned || weather.Publisher != "8wekyb3d8bbwe" {
+		t.Errorf("unexpected provisioned-only package: %+v", weather)
+	}
+
+	vclibs := result[1]
+	if !vclibs.Installed || vclibs.Provisioned || !vclibs.IsFramework {
+		t.Errorf("unexpected framework package: %+v", vclibs)
+	}
+
+	calc := result[2]
+	if !calc.Installed || !calc.Provisioned || calc.Publisher != "CN=Microsoft Corporation" {
+		t.Errorf("unexpected installed and provisioned package: %+v", calc)
+	}
+}
+
+func TestMergeAppxPackages_Empty(t *testing.T) {
+	result := mergeAppxPackages(nil, nil)
+	if result == nil || len(result) != 0 {
+		t.Errorf("expected empty non-nil slice, got %v", result)
+	}
+}
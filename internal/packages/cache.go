@@ -0,0 +1,56 @@
+package packages
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"patchmon-agent/internal/store"
+	"patchmon-agent/pkg/models"
+)
+
+// installedCacheMaxAge forces a full re-enumeration of installed updates
+// at least once a day, even if the update history looks unchanged
+const installedCacheMaxAge = 24 * time.Hour
+
+// installedCacheStoreKey is the state store key of the installed updates cache
+const installedCacheStoreKey = "installed-updates"
+
+// installedUpdatesCache is the stored format of the installed updates cache
+type installedUpdatesCache struct {
+	Key      string           `json:"key"`
+	CachedAt time.Time        `json:"cachedAt"`
+	Packages []models.Package `json:"packages"`
+}
+
+// installedCacheKey builds the cache key from the newest update history entry,
+// the history size and the search settings (criteria, offline catalog, filters).
+// Any install, uninstall or settings change produces a different key.
+func installedCacheKey(latest time.Time, historyCount int, settings ...string) string {
+	return fmt.Sprintf("%s|%d|%s", latest.UTC().Format(time.RFC3339), historyCount, strings.Join(settings, "|"))
+}
+
+// loadInstalledCache returns the installed updates cached in the state store
+// at path if they match key and are younger than installedCacheMaxAge
+func loadInstalledCache(path, key string, now time.Time) ([]models.Package, bool) {
+	var cache installedUpdatesCache
+	found, err := store.Get(path, store.BucketCache, installedCacheStoreKey, &cache)
+	if err != nil || !found {
+		return nil, false
+	}
+
+	if cache.Key != key || now.Sub(cache.CachedAt) > installedCacheMaxAge || cache.Packages == nil {
+		return nil, false
+	}
+
+	return cache.Packages, true
+}
+
+// saveInstalledCache stores the installed updates in the state store at path
+func saveInstalledCache(path, key string, packages []models.Package, now time.Time) error {
+	return store.Put(path, store.BucketCache, installedCacheStoreKey, installedUpdatesCache{
+		Key:      key,
+		CachedAt: now,
+		Packages: packages,
+	})
+}
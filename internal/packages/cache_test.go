@@ -0,0 +1,62 @@
+package packages
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"patchmon-agent/pkg/models"
+)
+
+func TestInstalledCacheKey(t *testing.T) {
+	latest := time.Date(2024, 3, 12, 18, 4, 0, 0, time.UTC)
+	base := installedCacheKey(latest, 42, DefaultInstalledCriteria, "")
+
+	if base != installedCacheKey(latest.In(time.FixedZone("CET", 3600)), 42, DefaultInstalledCriteria, "") {
+		t.Error("key should not depend on the time zone of the history date")
+	}
+	if base == installedCacheKey(latest.Add(time.Minute), 42, DefaultInstalledCriteria, "") {
+		t.Error("key should change when a newer history entry exists")
+	}
+	if base == installedCacheKey(latest, 43, DefaultInstalledCriteria, "") {
+		t.Error("key should change when the history count changes")
+	}
+	if base == installedCacheKey(latest, 42, "IsInstalled=1 AND Type='Software'", "") {
+		t.Error("key should change when the criteria change")
+	}
+	if base == installedCacheKey(latest, 42, DefaultInstalledCriteria, `C:\wsusscn2.cab`) {
+		t.Error("key should change when the offline catalog changes")
+	}
+}
+
+func TestInstalledCacheRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.db")
+	now := time.Date(2024, 3, 13, 9, 0, 0, 0, time.UTC)
+	packages := []models.Package{
+		{Name: "KB5034441", Description: "Security Update", CurrentVersion: "abc.1", IsSecurityUpdate: true},
+	}
+
+	if _, ok := loadInstalledCache(path, "key", now); ok {
+		t.Fatal("expected cache miss for missing file")
+	}
+
+	if err := saveInstalledCache(path, "key", packages, now); err != nil {
+		t.Fatalf("saveInstalledCache failed: %v", err)
+	}
+
+	cached, ok := loadInstalledCache(path, "key", now.Add(time.Hour))
+	if !ok {
+		t.Fatal("expected cache hit")
+	}
+	if !reflect.DeepEqual(cached, packages) {
+		t.Errorf("unexpected cached packages: %+v", cached)
+	}
+
+	if _, ok := loadInstalledCache(path, "other-key", now.Add(time.Hour)); ok {
+		t.Error("expected cache miss for a different key")
+	}
+	if _, ok := loadInstalledCache(path, "key", now.Add(installedCacheMaxAge+time.Minute)); ok {
+		t.Error("expected cache miss for an expired cache")
+	}
+}
@@ -0,0 +1,59 @@
+package packages
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"patchmon-agent/pkg/models"
+)
+
+// upgradesCategory is the WUA classification used for Windows feature updates
+const upgradesCategory = "Upgrades"
+
+// featureUpdateTitlePattern extracts product and target version from feature
+// update titles such as "Feature update to Windows 10, version 22H2" or
+// "Windows 11, version 24H2"
+var featureUpdateTitlePattern = regexp.MustCompile(`(?i)(Windows (?:10|11|Server(?: \d{4})?))[^,]*,? version (\d{2}H\d|\d{4})`)
+
+// isFeatureUpdate reports whether an update is a Windows feature update (a new
+// Windows release) rather than a quality update
+func isFeatureUpdate(title string, categories []string) bool {
+	if slices.ContainsFunc(categories, func(c string) bool { return strings.EqualFold(c, upgradesCategory) }) {
+		return true
+	}
+	return strings.HasPrefix(strings.ToLower(title), "feature update to ")
+}
+
+// parseFeatureUpdateTitle returns the product and target version of a feature
+// update title, or empty strings if the title has no recognisable version
+func parseFeatureUpdateTitle(title string) (product, version string) {
+	match := featureUpdateTitlePattern.FindStringSubmatch(title)
+	if match == nil {
+		return "", ""
+	}
+	return match[1], strings.ToUpper(match[2])
+}
+
+// FindFeatureUpdate returns the newest feature update offered to the device
+// among the available packages, or nil if none is offered
+func FindFeatureUpdate(packages []models.Package) *models.FeatureUpdate {
+	var best *models.FeatureUpdate
+	for _, pkg := range packages {
+		if !pkg.NeedsUpdate || !pkg.IsFeatureUpdate {
+			continue
+		}
+		product, version := parseFeatureUpdateTitle(pkg.Description)
+		candidate := &models.FeatureUpdate{
+			Name:          pkg.Name,
+			Title:         pkg.Description,
+			Product:       product,
+			TargetVersion: version,
+		}
+		// Versions like "23H2" / "24H2" sort correctly as strings
+		if best == nil || candidate.TargetVersion > best.TargetVersion {
+			best = candidate
+		}
+	}
+	return best
+}
@@ -0,0 +1,63 @@
+package packages
+
+import (
+	"testing"
+
+	"patchmon-agent/pkg/models"
+)
+
+func TestIsFeatureUpdate(t *testing.T) {
+	tests := []struct {
+		title      string
+		categories []string
+		want       bool
+	}{
+		{"Windows 11, version 24H2", []string{"Windows 11", "Upgrades"}, true},
+		{"Feature update to Windows 10, version 22H2", nil, true},
+		{"2024-03 Cumulative Update for Windows 11 Version 23H2 (KB5035853)", []string{"Security Updates"}, false},
+		{"Windows 11, version 24H2", []string{"upgrades"}, true},
+	}
+
+	for _, tt := range tests {
+		if got := isFeatureUpdate(tt.title, tt.categories); got != tt.want {
+			t.Errorf("isFeatureUpdate(%q, %v) = %v, want %v", tt.title, tt.categories, got, tt.want)
+		}
+	}
+}
+
+func TestParseFeatureUpdateTitle(t *testing.T) {
+	tests := []struct {
+		title       string
+		wantProduct string
+		wantVersion string
+	}{
+		{"Windows 11, version 24H2", "Windows 11", "24H2"},
+		{"Feature update to Windows 10, version 22H2", "Windows 10", "22H2"},
+		{"Feature update to Windows 10 (business editions), version 21h2, en-us x64", "Windows 10", "21H2"},
+		{"Feature update to Windows 10, version 1909", "Windows 10", "1909"},
+		{"Windows 11 upgrade", "", ""},
+	}
+
+	for _, tt := range tests {
+		product, version := parseFeatureUpdateTitle(tt.title)
+		if product != tt.wantProduct || version != tt.wantVersion {
+			t.Errorf("parseFeatureUpdateTitle(%q) = %q, %q; want %q, %q", tt.title, product, version, tt.wantProduct, tt.wantVersion)
+		}
+	}
+}
+
+func TestFindFeatureUpdate(t *testing.T) {
+	if FindFeatureUpdate([]models.Package{{Name: "KB5035853", NeedsUpdate: true}}) != nil {
+		t.Error("expected no feature update among quality updates")
+	}
+
+	packages := []models.Package{
+		{Name: "Windows 11, version 23H2", Description: "Windows 11, version 23H2", NeedsUpdate: true, IsFeatureUpdate: true},
+		{Name: "Windows 11, version 24H2", Description: "Windows 11, version 24H2", NeedsUpdate: true, IsFeatureUpdate: true},
+		{Name: "KB5035853", Description: "2024-03 Cumulative Update", NeedsUpdate: true},
+	}
+	feature := FindFeatureUpdate(packages)
+	if feature == nil || feature.TargetVersion != "24H2" || feature.Product != "Windows 11" {
+		t.Errorf("unexpected feature update: %+v", feature)
+	}
+}
@@ -0,0 +1,156 @@
+package packages
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// healthServices are the services Windows Update depends on
+var healthServices = []string{"wuauserv", "bits", "cryptsvc", "trustedinstaller", "usosvc"}
+
+// ServiceStatus is the state of a Windows service
+type ServiceStatus struct {
+	Name      string `json:"name"`
+	State     string `json:"state,omitempty"`     // running, stopped, ...
+	StartType string `json:"startType,omitempty"` // automatic, manual, disabled, ...
+	Error     string `json:"error,omitempty"`
+}
+
+// FileStatus describes a file or folder of the Windows Update datastore
+type FileStatus struct {
+	Path       string `json:"path"`
+	Exists     bool   `json:"exists"`
+	SizeBytes  int64  `json:"sizeBytes,omitempty"`
+	ModifiedAt string `json:"modifiedAt,omitempty"` // RFC3339
+	Error      string `json:"error,omitempty"`
+}
+
+// ComponentHealth describes the Windows Update services and datastore
+type ComponentHealth struct {
+	Services  []ServiceStatus `json:"services"`
+	DataStore FileStatus      `json:"dataStore"` // DataStore.edb
+	Folders   []FileStatus    `json:"folders"`   // SoftwareDistribution and catroot2
+	// Backups are folders left behind by earlier component resets
+	Backups []string `json:"backups,omitempty"`
+}
+
+// CheckComponents reports the state of the Windows Update services and
+// datastore without changing anything
+func CheckComponents() *ComponentHealth {
+	health := &ComponentHealth{}
+	for _, name := range healthServices {
+		health.Services = append(health.Services, queryService(name))
+	}
+
+	folders := updateDataFolders()
+	health.DataStore = fileStatus(filepath.Join(folders[0], "DataStore", "DataStore.edb"))
+	for _, folder := range folders {
+		health.Folders = append(health.Folders, fileStatus(folder))
+		backups, _ := filepath.Glob(folder + ".bak-*")
+		health.Backups = append(health.Backups, backups...)
+	}
+	sort.Strings(health.Backups)
+	return health
+}
+
+// CheckSession opens a Windows Update session and searcher, to verify that
+// the Windows Update Agent COM API is available
+func (w *WindowsUpdateManager) CheckSession() error {
+	_, closeSearcher, err := w.openSearcher()
+	if err != nil {
+		return err
+	}
+	closeSearcher()
+	return nil
+}
+
+// fileStatus returns whether path exists, and its size and modification time
+func fileStatus(path string) FileStatus {
+	status := FileStatus{Path: path}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return status
+	}
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.Exists = true
+	if !info.IsDir() {
+		status.SizeBytes = info.Size()
+	}
+	status.ModifiedAt = info.ModTime().UTC().Format(time.RFC3339)
+	return status
+}
+
+// queryService returns the state and start type of a service
+func queryService(name string) ServiceStatus {
+	status := ServiceStatus{Name: name}
+	m, err := mgr.Connect()
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	defer s.Close()
+
+	if state, err := s.Query(); err == nil {
+		status.State = serviceStateName(state.State)
+	} else {
+		status.Error = err.Error()
+	}
+	if config, err := s.Config(); err == nil {
+		status.StartType = startTypeName(config.StartType, config.DelayedAutoStart)
+	} else if status.Error == "" {
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// serviceStateName returns the name of a service state
+func serviceStateName(state svc.State) string {
+	switch state {
+	case svc.Stopped:
+		return "stopped"
+	case svc.StartPending:
+		return "start_pending"
+	case svc.StopPending:
+		return "stop_pending"
+	case svc.Running:
+		return "running"
+	case svc.ContinuePending:
+		return "continue_pending"
+	case svc.PausePending:
+		return "pause_pending"
+	case svc.Paused:
+		return "paused"
+	}
+	return "unknown"
+}
+
+// startTypeName returns the name of a service start type
+func startTypeName(startType uint32, delayed bool) string {
+	switch startType {
+	case mgr.StartAutomatic:
+		if delayed {
+			return "automatic_delayed"
+		}
+		return "automatic"
+	case mgr.StartManual:
+		return "manual"
+	case mgr.StartDisabled:
+		return "disabled"
+	}
+	return "unknown"
+}
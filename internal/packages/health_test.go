@@ -0,0 +1,78 @@
+package packages
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+func TestCheckComponents_DataStore(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("SystemRoot", root)
+	writeTestFile(t, filepath.Join(root, "SoftwareDistribution", "DataStore", "DataStore.edb"), "edb")
+	writeTestFile(t, filepath.Join(root, "SoftwareDistribution.bak-20240312-180405", "x"), "")
+
+	health := CheckComponents()
+	if !health.DataStore.Exists || health.DataStore.SizeBytes != 3 {
+		t.Errorf("DataStore = %+v, want existing 3 byte file", health.DataStore)
+	}
+	if len(health.Folders) != 2 || !health.Folders[0].Exists || health.Folders[1].Exists {
+		t.Errorf("Folders = %+v, want SoftwareDistribution only", health.Folders)
+	}
+	want := []string{filepath.Join(root, "SoftwareDistribution.bak-20240312-180405")}
+	if !slices.Equal(health.Backups, want) {
+		t.Errorf("Backups = %v, want %v", health.Backups, want)
+	}
+	if len(health.Services) != len(healthServices) {
+		t.Errorf("Services = %+v, want one entry per service", health.Services)
+	}
+}
+
+func TestServiceNames(t *testing.T) {
+	states := map[svc.State]string{
+		svc.Running:     "running",
+		svc.Stopped:     "stopped",
+		svc.StopPending: "stop_pending",
+		svc.State(99):   "unknown",
+	}
+	for state, want := range states {
+		if got := serviceStateName(state); got != want {
+			t.Errorf("serviceStateName(%d) = %q, want %q", state, got, want)
+		}
+	}
+
+	tests := []struct {
+		startType uint32
+		delayed   bool
+		want      string
+	}{
+		{mgr.StartAutomatic, false, "automatic"},
+		{mgr.StartAutomatic, true, "automatic_delayed"},
+		{mgr.StartManual, false, "manual"},
+		{mgr.StartDisabled, false, "disabled"},
+		{99, false, "unknown"},
+	}
+	for _, tt := range tests {
+		if got := startTypeName(tt.startType, tt.delayed); got != tt.want {
+			t.Errorf("startTypeName(%d, %v) = %q, want %q", tt.startType, tt.delayed, got, tt.want)
+		}
+	}
+}
+
+func TestCheckSession_Fake(t *testing.T) {
+	mgr := newFakeManager(&fakeSearcher{})
+	if err := mgr.CheckSession(); err != nil {
+		t.Errorf("CheckSession() error = %v", err)
+	}
+
+	mgr.newSession = func() (UpdateSession, error) {
+		return nil, errors.New("failed to create UpdateSession: class not registered")
+	}
+	if err := mgr.CheckSession(); err == nil {
+		t.Error("CheckSession() error = nil, want the session error")
+	}
+}
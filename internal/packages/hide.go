@@ -0,0 +1,90 @@
+package packages
+
+import (
+	"errors"
+
+	"github.com/sirupsen/logrus"
+
+	"patchmon-agent/pkg/models"
+)
+
+const (
+	// hiddenUpdatesCriteria matches applicable updates that have been hidden
+	hiddenUpdatesCriteria = "IsInstalled=0 AND IsHidden=1"
+	// hideSearchCriteria matches all applicable updates, hidden or not
+	hideSearchCriteria = "IsInstalled=0"
+)
+
+// GetHiddenUpdates returns applicable updates that have been hidden on this computer
+func (w *WindowsUpdateManager) GetHiddenUpdates() ([]models.Package, error) {
+	return w.searchUpdates(hiddenUpdatesCriteria, false)
+}
+
+// SetUpdatesHidden hides (or unhides) the applicable updates with the given
+// KB articles so Windows Update no longer offers them. Requested KBs that are
+// not applicable are reported with status not_found.
+func (w *WindowsUpdateManager) SetUpdatesHidden(kbs []string, hidden bool) ([]models.UpdateInstallResult, error) {
+	results, err := w.setUpdatesHidden(kbs, hidden)
+	return results, decodeWUAError(err)
+}
+
+// setUpdatesHidden performs the search and update for SetUpdatesHidden
+func (w *WindowsUpdateManager) setUpdatesHidden(kbs []string, hidden bool) ([]models.UpdateInstallResult, error) {
+	wantedKBs := normalizeKBs(kbs)
+	if len(wantedKBs) == 0 {
+		return nil, errors.New("no KB articles given")
+	}
+
+	searcher, closeSearcher, err := w.openSearcher()
+	if err != nil {
+		return nil, err
+	}
+	defer closeSearcher()
+
+	updates, err := searcher.Search(hideSearchCriteria, w.searchTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer updates.Release()
+
+	status := InstallStatusHidden
+	if !hidden {
+		status = InstallStatusUnhidden
+	}
+
+	found := make(map[string]bool, len(wantedKBs))
+	var results []models.UpdateInstallResult
+	for i := 0; i < updates.Count(); i++ {
+		update, err := updates.Item(i)
+		if err != nil {
+			w.logger.Warnf("Failed to get update item %d: %v", i, err)
+			continue
+		}
+
+		pkg := w.parseUpdate(update, false)
+		if pkg == nil || !installSelected(update, pkg, wantedKBs, false, found) {
+			update.Release()
+			continue
+		}
+
+		result := models.UpdateInstallResult{Name: pkg.Name, Title: pkg.Description, Status: status}
+		if update.IsHidden() != hidden {
+			if err := update.SetHidden(hidden); err != nil {
+				result.Status = InstallStatusFailed
+				result.Error = decodeWUAError(err).Error()
+			}
+		}
+		update.Release()
+
+		w.logger.WithFields(logrus.Fields{"update": result.Name, "status": result.Status}).Info("Updated hidden state")
+		results = append(results, result)
+	}
+
+	for _, kb := range wantedKBs {
+		if !found[kb] {
+			w.logger.WithField("update", kb).Warn("Update is not applicable to this computer")
+			results = append(results, models.UpdateInstallResult{Name: kb, Status: InstallStatusNotFound})
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,47 @@
+package packages
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSetUpdatesHidden_Fake(t *testing.T) {
+	cumulative := &fakeUpdate{title: "Cumulative Update (KB5035853)", kbIDs: []string{"5035853"}}
+	mandatory := &fakeUpdate{title: "Servicing Stack Update (KB5034232)", kbIDs: []string{"5034232"}, hideErr: errors.New("mandatory")}
+	other := &fakeUpdate{title: "Update (KB1)", kbIDs: []string{"1"}}
+	searcher := &fakeSearcher{results: map[string][]*fakeUpdate{
+		hideSearchCriteria: {cumulative, mandatory, other},
+	}}
+	mgr := newFakeManager(searcher)
+
+	results, err := mgr.SetUpdatesHidden([]string{"KB5035853", "5034232", "KB9999999"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statuses := make(map[string]string)
+	for _, r := range results {
+		statuses[r.Name] = r.Status
+	}
+	want := map[string]string{"KB5035853": InstallStatusHidden, "KB5034232": InstallStatusFailed, "KB9999999": InstallStatusNotFound}
+	for name, status := range want {
+		if statuses[name] != status {
+			t.Errorf("%s: status %q, want %q", name, statuses[name], status)
+		}
+	}
+	if !cumulative.hidden || other.hidden {
+		t.Errorf("only the requested update should be hidden: cumulative=%v other=%v", cumulative.hidden, other.hidden)
+	}
+
+	results, err = mgr.SetUpdatesHidden([]string{"KB5035853"}, false)
+	if err != nil || len(results) != 1 || results[0].Status != InstallStatusUnhidden || cumulative.hidden {
+		t.Errorf("unhide failed: %+v, %v", results, err)
+	}
+}
+
+func TestSetUpdatesHidden_NoKBs(t *testing.T) {
+	mgr := newFakeManager(&fakeSearcher{})
+	if _, err := mgr.SetUpdatesHidden(nil, true); err == nil {
+		t.Error("expected an error without KBs")
+	}
+}
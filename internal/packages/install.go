@@ -0,0 +1,294 @@
+package packages
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"patchmon-agent/internal/constants"
+	"patchmon-agent/pkg/models"
+)
+
+// Per-update install statuses reported in models.UpdateInstallResult
+const (
+	InstallStatusInstalled      = "installed"
+	InstallStatusDownloaded     = "downloaded"
+	InstallStatusUninstalled    = "uninstalled"
+	InstallStatusNotInstalled   = "not_installed"
+	InstallStatusHidden         = "hidden"
+	InstallStatusUnhidden       = "unhidden"
+	InstallStatusFailed         = "failed"
+	InstallStatusDownloadFailed = "download_failed"
+	InstallStatusNotFound       = "not_found"
+)
+
+// InstallOptions selects which available updates InstallUpdates acts on
+type InstallOptions struct {
+	// KBs limits the run to these KB articles ("KB5034441" or "5034441").
+	// Empty means every available update.
+	KBs []string
+	// SecurityOnly limits the run to security and critical updates
+	SecurityOnly bool
+	// DownloadOnly stages updates in the local WUA cache without installing
+	// them, so a later install in the maintenance window is fast
+	DownloadOnly bool
+	// Progress, if set, receives download and install progress. Events only
+	// carry the phase and update fields; it is called from the WUA polling
+	// loop and must not block.
+	Progress func(models.InstallProgressEvent)
+}
+
+// pendingUpdate is an update selected for installation together with its result
+type pendingUpdate struct {
+	update Update
+	result models.UpdateInstallResult
+}
+
+// InstallUpdates downloads and installs the available updates selected by
+// opts, logging per-update progress. With opts.DownloadOnly updates are only
+// downloaded and reported with status downloaded. Requested KBs that are not available
+// are reported with status not_found. The returned error covers failures of
+// the run as a whole; per-update failures are reported in the results.
+func (w *WindowsUpdateManager) InstallUpdates(opts InstallOptions) ([]models.UpdateInstallResult, error) {
+	results, err := w.installUpdates(opts)
+	return results, decodeWUAError(err)
+}
+
+// installUpdates performs the installation for InstallUpdates
+func (w *WindowsUpdateManager) installUpdates(opts InstallOptions) ([]models.UpdateInstallResult, error) {
+	if w.offlineScanCab != "" {
+		return nil, errors.New("updates cannot be installed from an offline scan catalog")
+	}
+
+	session, err := w.newSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Release()
+
+	selected, results, closeUpdates, err := w.selectUpdates(session, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer closeUpdates()
+
+	if len(selected) == 0 {
+		w.logger.Info("No matching updates to install")
+		return results, nil
+	}
+
+	installer, err := session.CreateUpdateInstaller()
+	if err != nil {
+		return nil, err
+	}
+	defer installer.Release()
+
+	// Download first; only successfully downloaded updates are installed
+	w.logger.WithField("count", len(selected)).Info("Downloading updates...")
+	downloadResults, err := installer.Download(updateList(selected), w.progressLogger("Downloading", constants.PhaseDownloading, selected, opts.Progress))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download updates: %w", err)
+	}
+
+	var downloaded []*pendingUpdate
+	for i, p := range selected {
+		if operationSucceeded(downloadResults[i].ResultCode) {
+			p.result.Status = InstallStatusDownloaded
+			p.result.ResultCode = downloadResults[i].ResultCode
+			downloaded = append(downloaded, p)
+			continue
+		}
+		p.result.Status = InstallStatusDownloadFailed
+		p.result.ResultCode = downloadResults[i].ResultCode
+		p.result.Error = operationError(downloadResults[i])
+		w.logger.WithFields(logrus.Fields{"update": p.result.Name, "error": p.result.Error}).Warn("Update download failed")
+	}
+
+	if opts.DownloadOnly {
+		w.logger.WithField("count", len(downloaded)).Info("Updates downloaded, skipping installation (download only)")
+	} else if len(downloaded) > 0 {
+		w.logger.WithField("count", len(downloaded)).Info("Installing updates...")
+		installResults, err := installer.Install(updateList(downloaded), w.progressLogger("Installing", constants.PhaseInstalling, downloaded, opts.Progress))
+		if err != nil {
+			return nil, fmt.Errorf("failed to install updates: %w", err)
+		}
+
+		for i, p := range downloaded {
+			p.result.ResultCode = installResults[i].ResultCode
+			p.result.RebootRequired = installResults[i].RebootRequired
+			if operationSucceeded(installResults[i].ResultCode) {
+				p.result.Status = InstallStatusInstalled
+				w.logger.WithFields(logrus.Fields{
+					"update":          p.result.Name,
+					"reboot_required": p.result.RebootRequired,
+				}).Info("Update installed")
+			} else {
+				p.result.Status = InstallStatusFailed
+				p.result.Error = operationError(installResults[i])
+				w.logger.WithFields(logrus.Fields{"update": p.result.Name, "error": p.result.Error}).Warn("Update installation failed")
+			}
+		}
+	}
+
+	for _, p := range selected {
+		results = append(results, p.result)
+	}
+	return results, nil
+}
+
+// selectUpdates searches for available updates and keeps those matching
+// opts. It returns the selected updates, results for requested KBs that were
+// not found, and a function releasing the selected updates.
+func (w *WindowsUpdateManager) selectUpdates(session UpdateSession, opts InstallOptions) ([]*pendingUpdate, []models.UpdateInstallResult, func(), error) {
+	searcher, err := session.CreateUpdateSearcher()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer searcher.Release()
+
+	w.logger.Info("Searching for available Windows updates to install...")
+	updates, err := searcher.Search(w.availableCriteria, w.searchTimeout)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer updates.Release()
+
+	wantedKBs := normalizeKBs(opts.KBs)
+	found := make(map[string]bool, len(wantedKBs))
+
+	var selected []*pendingUpdate
+	for i := 0; i < updates.Count(); i++ {
+		update, err := updates.Item(i)
+		if err != nil {
+			w.logger.Warnf("Failed to get update item %d: %v", i, err)
+			continue
+		}
+
+		pkg := w.parseUpdate(update, false)
+		if pkg == nil || !installSelected(update, pkg, wantedKBs, opts.SecurityOnly, found) ||
+			!categoryAllowed(update.Categories(), w.includeCategories, w.excludeCategories) {
+			update.Release()
+			continue
+		}
+
+		selected = append(selected, &pendingUpdate{
+			update: update,
+			result: models.UpdateInstallResult{Name: pkg.Name, Title: pkg.Description},
+		})
+	}
+
+	var results []models.UpdateInstallResult
+	for _, kb := range wantedKBs {
+		if !found[kb] {
+			w.logger.WithField("update", kb).Warn("Requested update is not available for this computer")
+			results = append(results, models.UpdateInstallResult{Name: kb, Status: InstallStatusNotFound})
+		}
+	}
+
+	release := func() {
+		for _, p := range selected {
+			p.update.Release()
+		}
+	}
+	return selected, results, release, nil
+}
+
+// installSelected reports whether an update matches the requested KBs and
+// security filter, marking matched KBs in found
+func installSelected(update Update, pkg *models.Package, wantedKBs []string, securityOnly bool, found map[string]bool) bool {
+	if securityOnly && !pkg.IsSecurityUpdate {
+		return false
+	}
+	if len(wantedKBs) == 0 {
+		return true
+	}
+
+	matched := false
+	for _, id := range update.KBArticleIDs() {
+		kb := "KB" + id
+		if slices.Contains(wantedKBs, kb) {
+			found[kb] = true
+			matched = true
+		}
+	}
+	return matched
+}
+
+// normalizeKBs upper-cases KB identifiers and adds the "KB" prefix where missing
+func normalizeKBs(kbs []string) []string {
+	var normalized []string
+	for _, kb := range kbs {
+		kb = strings.ToUpper(strings.TrimSpace(kb))
+		if kb == "" {
+			continue
+		}
+		if !strings.HasPrefix(kb, "KB") {
+			kb = "KB" + kb
+		}
+		if !slices.Contains(normalized, kb) {
+			normalized = append(normalized, kb)
+		}
+	}
+	return normalized
+}
+
+// updateList returns the WUA updates of the pending updates
+func updateList(pending []*pendingUpdate) []Update {
+	updates := make([]Update, len(pending))
+	for i, p := range pending {
+		updates[i] = p.update
+	}
+	return updates
+}
+
+// progressLogger returns a ProgressFunc that logs when an operation moves to
+// the next update and at every 25% step of the current one. The same steps are
+// passed to notify, if set.
+func (w *WindowsUpdateManager) progressLogger(action, phase string, pending []*pendingUpdate, notify func(models.InstallProgressEvent)) ProgressFunc {
+	lastIndex, lastStep := -1, -1
+	return func(index, percent int) {
+		if index < 0 || index >= len(pending) {
+			return
+		}
+		step := percent / 25
+		if index == lastIndex && step == lastStep {
+			return
+		}
+		lastIndex, lastStep = index, step
+		w.logger.WithFields(logrus.Fields{
+			"update":   pending[index].result.Name,
+			"progress": fmt.Sprintf("%d/%d", index+1, len(pending)),
+			"percent":  percent,
+		}).Info(action + " update...")
+
+		if notify != nil {
+			notify(models.InstallProgressEvent{
+				Phase:   phase,
+				Update:  pending[index].result.Name,
+				Index:   index + 1,
+				Total:   len(pending),
+				Percent: percent,
+			})
+		}
+	}
+}
+
+// operationSucceeded reports whether a WUA OperationResultCode counts as success
+func operationSucceeded(code int) bool {
+	return code == operationResultSucceeded || code == operationResultSucceededWithErrors
+}
+
+// operationError describes a failed per-update result, decoding known WUA HRESULTs
+func operationError(result OperationResult) string {
+	if result.HResult == 0 {
+		return fmt.Sprintf("result code %d", result.ResultCode)
+	}
+	code := uint32(result.HResult)
+	if info, ok := wuaErrors[code]; ok {
+		return fmt.Sprintf("0x%08X %s: %s", code, info.Name, info.Description)
+	}
+	return fmt.Sprintf("0x%08X", code)
+}
@@ -0,0 +1,185 @@
+package packages
+
+import (
+	"reflect"
+	"testing"
+
+	"patchmon-agent/internal/constants"
+	"patchmon-agent/pkg/models"
+)
+
+// fakeInstaller is an in-memory UpdateInstaller returning canned result codes per update title
+type fakeInstaller struct {
+	downloadCodes map[string]int
+	installCodes  map[string]int
+	reboot        map[string]bool
+	downloaded    []string
+	installed     []string
+}
+
+func (i *fakeInstaller) run(updates []Update, codes map[string]int, done *[]string, progress ProgressFunc) []OperationResult {
+	results := make([]OperationResult, len(updates))
+	for n, update := range updates {
+		title, _ := update.Title()
+		*done = append(*done, title)
+		progress(n, 100)
+		results[n].ResultCode = operationResultSucceeded
+		if code, ok := codes[title]; ok {
+			results[n].ResultCode = code
+			results[n].HResult = int32(-2145124330) // 0x80240016
+		}
+		results[n].RebootRequired = i.reboot[title]
+	}
+	return results
+}
+
+func (i *fakeInstaller) Download(updates []Update, progress ProgressFunc) ([]OperationResult, error) {
+	return i.run(updates, i.downloadCodes, &i.downloaded, progress), nil
+}
+
+func (i *fakeInstaller) Install(updates []Update, progress ProgressFunc) ([]OperationResult, error) {
+	return i.run(updates, i.installCodes, &i.installed, progress), nil
+}
+
+func (i *fakeInstaller) Release() {}
+
+func TestNormalizeKBs(t *testing.T) {
+	got := normalizeKBs([]string{"KB5034441", "5035853", " kb5034441 ", ""})
+	want := []string{"KB5034441", "KB5035853"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("normalizeKBs() = %v, want %v", got, want)
+	}
+}
+
+func newInstallSearcher() *fakeSearcher {
+	return &fakeSearcher{results: map[string][]*fakeUpdate{
+		DefaultAvailableCriteria: {
+			{title: "Cumulative Update (KB5035853)", kbIDs: []string{"5035853"}, severity: "Critical"},
+			{title: "Servicing Stack Update (KB5034232)", kbIDs: []string{"5034232"}, categories: []string{"Critical Updates"}},
+			{title: "Intel - System - 10.1.1.44", categories: []string{"Drivers"}},
+		},
+	}}
+}
+
+func TestInstallUpdates_FakeAll(t *testing.T) {
+	installer := &fakeInstaller{
+		downloadCodes: map[string]int{"Intel - System - 10.1.1.44": operationResultFailed},
+		reboot:        map[string]bool{"Cumulative Update (KB5035853)": true},
+	}
+	mgr := newFakeInstallManager(newInstallSearcher(), installer)
+
+	results, err := mgr.InstallUpdates(InstallOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []models.UpdateInstallResult{
+		{Name: "KB5035853", Title: "Cumulative Update (KB5035853)", Status: InstallStatusInstalled, ResultCode: operationResultSucceeded, RebootRequired: true},
+		{Name: "KB5034232", Title: "Servicing Stack Update (KB5034232)", Status: InstallStatusInstalled, ResultCode: operationResultSucceeded},
+		{Name: "Intel - System - 10.1.1.44", Title: "Intel - System - 10.1.1.44", Status: InstallStatusDownloadFailed, ResultCode: operationResultFailed,
+			Error: "0x80240016 WU_E_INSTALL_NOT_ALLOWED: Another installation is in progress or a reboot is pending"},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("unexpected results:\n got %+v\nwant %+v", results, want)
+	}
+	if len(installer.installed) != 2 {
+		t.Errorf("only downloaded updates should be installed, got %v", installer.installed)
+	}
+}
+
+func TestInstallUpdates_FakeSelection(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      InstallOptions
+		installed []string
+		statuses  map[string]string
+	}{
+		{
+			name:      "security only",
+			opts:      InstallOptions{SecurityOnly: true},
+			installed: []string{"Cumulative Update (KB5035853)", "Servicing Stack Update (KB5034232)"},
+			statuses:  map[string]string{"KB5035853": InstallStatusInstalled, "KB5034232": InstallStatusInstalled},
+		},
+		{
+			name:      "by KB with missing KB",
+			opts:      InstallOptions{KBs: []string{"5034232", "KB1234567"}},
+			installed: []string{"Servicing Stack Update (KB5034232)"},
+			statuses:  map[string]string{"KB5034232": InstallStatusInstalled, "KB1234567": InstallStatusNotFound},
+		},
+		{
+			name:     "nothing available",
+			opts:     InstallOptions{KBs: []string{"KB1234567"}},
+			statuses: map[string]string{"KB1234567": InstallStatusNotFound},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installer := &fakeInstaller{}
+			mgr := newFakeInstallManager(newInstallSearcher(), installer)
+
+			results, err := mgr.InstallUpdates(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(installer.installed, tt.installed) {
+				t.Errorf("installed %v, want %v", installer.installed, tt.installed)
+			}
+			statuses := make(map[string]string)
+			for _, r := range results {
+				statuses[r.Name] = r.Status
+			}
+			if !reflect.DeepEqual(statuses, tt.statuses) {
+				t.Errorf("statuses %v, want %v", statuses, tt.statuses)
+			}
+		})
+	}
+}
+
+func TestInstallUpdates_FakeDownloadOnly(t *testing.T) {
+	installer := &fakeInstaller{}
+	mgr := newFakeInstallManager(newInstallSearcher(), installer)
+
+	results, err := mgr.InstallUpdates(InstallOptions{SecurityOnly: true, DownloadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(installer.downloaded) != 2 || len(installer.installed) != 0 {
+		t.Errorf("expected 2 downloads and no installs, got %v / %v", installer.downloaded, installer.installed)
+	}
+	for _, r := range results {
+		if r.Status != InstallStatusDownloaded {
+			t.Errorf("expected status %q, got %+v", InstallStatusDownloaded, r)
+		}
+	}
+}
+
+func TestInstallUpdates_FakeOfflineCatalog(t *testing.T) {
+	mgr := newFakeInstallManager(newInstallSearcher(), &fakeInstaller{})
+	mgr.offlineScanCab = `C:\wsusscn2.cab`
+
+	if _, err := mgr.InstallUpdates(InstallOptions{}); err == nil {
+		t.Error("expected an error when installing from an offline catalog")
+	}
+}
+
+func TestInstallUpdates_FakeProgress(t *testing.T) {
+	mgr := newFakeInstallManager(newInstallSearcher(), &fakeInstaller{})
+
+	var events []models.InstallProgressEvent
+	opts := InstallOptions{
+		KBs:      []string{"KB5035853"},
+		Progress: func(event models.InstallProgressEvent) { events = append(events, event) },
+	}
+	if _, err := mgr.InstallUpdates(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []models.InstallProgressEvent{
+		{Phase: constants.PhaseDownloading, Update: "KB5035853", Index: 1, Total: 1, Percent: 100},
+		{Phase: constants.PhaseInstalling, Update: "KB5035853", Index: 1, Total: 1, Percent: 100},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("progress events:\n got %+v\nwant %+v", events, want)
+	}
+}
@@ -1,6 +1,16 @@
 package packages
 
 import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"patchmon-agent/internal/client"
+	"patchmon-agent/internal/config"
+	"patchmon-agent/internal/constants"
+	"patchmon-agent/internal/msrc"
+	"patchmon-agent/internal/utils"
 	"patchmon-agent/pkg/models"
 
 	"github.com/sirupsen/logrus"
@@ -9,24 +19,54 @@ import (
 // Manager handles package information collection
 type Manager struct {
 	logger         *logrus.Logger
+	configMgr      *config.Manager
 	windowsManager *WindowsUpdateManager
+	scoopManager   *ScoopManager
+	appxManager    *AppxManager
+	// excludeMatcher filters packages out of the report by name or title
+	excludeMatcher *utils.PatternMatcher
+	msrcClient     *msrc.Client
+	// collectionErrors records searches that failed or timed out during the
+	// last GetPackages call, meaning the package list is incomplete
+	collectionErrors []string
 }
 
 // New creates a new package manager
-func New(logger *logrus.Logger) *Manager {
+func New(configMgr *config.Manager, logger *logrus.Logger) *Manager {
+	windowsManager := NewWindowsUpdateManager(logger)
+	cfg := configMgr.GetConfig()
+	windowsManager.offlineScanCab = cfg.OfflineScanCab
+	windowsManager.SetSearchCriteria(cfg.WUAInstalledCriteria, cfg.WUAAvailableCriteria)
+	windowsManager.SetSearchTimeout(time.Duration(cfg.WUASearchTimeout) * time.Second)
+	windowsManager.SetCategoryFilter(cfg.IncludeCategories, cfg.ExcludeCategories)
+	windowsManager.installedCachePath = config.DefaultStateFile
+
+	excludeMatcher, err := utils.NewPatternMatcher(cfg.ExcludePackages)
+	if err != nil {
+		logger.WithError(err).Warn("Ignoring invalid exclude_packages patterns")
+	}
+
 	return &Manager{
 		logger:         logger,
-		windowsManager: NewWindowsUpdateManager(logger),
+		configMgr:      configMgr,
+		windowsManager: windowsManager,
+		scoopManager:   NewScoopManager(logger),
+		appxManager:    NewAppxManager(logger),
+		excludeMatcher: excludeMatcher,
+		msrcClient:     msrc.New(logger, filepath.Join(config.DefaultConfigDir, "cache", "msrc"), client.NewTransport(cfg)),
 	}
 }
 
 // GetPackages gets package information from Windows Update.
 // It collects both installed updates and available (pending) updates.
 func (m *Manager) GetPackages() ([]models.Package, error) {
+	m.collectionErrors = nil
+
 	// Get installed updates
 	installed, err := m.windowsManager.GetInstalledUpdates()
 	if err != nil {
 		m.logger.Warnf("Failed to get installed updates: %v", err)
+		m.collectionErrors = append(m.collectionErrors, fmt.Sprintf("installed updates: %v", err))
 		installed = []models.Package{}
 	}
 
@@ -34,6 +74,7 @@ func (m *Manager) GetPackages() ([]models.Package, error) {
 	available, err := m.windowsManager.GetAvailableUpdates()
 	if err != nil {
 		m.logger.Warnf("Failed to get available updates: %v", err)
+		m.collectionErrors = append(m.collectionErrors, fmt.Sprintf("available updates: %v", err))
 		available = []models.Package{}
 	}
 
@@ -44,9 +85,114 @@ func (m *Manager) GetPackages() ([]models.Package, error) {
 
 	m.logger.Infof("Found %d installed updates and %d available updates", len(installed), len(available))
 
+	// Scoop apps are only collected when the integration is enabled
+	if m.configMgr.IsIntegrationEnabled(constants.IntegrationScoop) {
+		scoopPackages, err := m.scoopManager.GetPackages()
+		if err != nil {
+			m.logger.Warnf("Failed to get Scoop packages: %v", err)
+		} else {
+			m.logger.Infof("Found %d Scoop packages", len(scoopPackages))
+			allPackages = append(allPackages, scoopPackages...)
+		}
+	}
+
+	if m.excludeMatcher.Len() > 0 {
+		var excluded int
+		allPackages, excluded = filterExcludedPackages(allPackages, m.excludeMatcher)
+		m.logger.Infof("Excluded %d packages matching exclude_packages", excluded)
+	}
+
+	if cfg := m.configMgr.GetConfig(); cfg.CVELookup {
+		m.resolveCVEs(allPackages, cfg.CVELookupMonths)
+	}
+
 	return allPackages, nil
 }
 
+// resolveCVEs fills in the CVEs fixed by missing security updates using the
+// MSRC security update documents. Lookup failures are logged and leave the
+// affected packages without CVEs.
+func (m *Manager) resolveCVEs(packages []models.Package, months int) {
+	var kbs []string
+	for _, pkg := range packages {
+		if pkg.NeedsUpdate && pkg.IsSecurityUpdate && strings.HasPrefix(pkg.Name, "KB") {
+			kbs = append(kbs, pkg.Name)
+		}
+	}
+	if len(kbs) == 0 {
+		return
+	}
+
+	m.logger.Infof("Resolving CVEs for %d missing security updates via MSRC...", len(kbs))
+	cves, err := m.msrcClient.LookupCVEs(kbs, months)
+	if err != nil {
+		m.logger.WithError(err).Warn("CVE lookup was incomplete")
+	}
+
+	for i := range packages {
+		if ids, ok := cves[packages[i].Name]; ok && packages[i].NeedsUpdate {
+			packages[i].CVEs = ids
+		}
+	}
+	m.logger.Infof("Resolved CVEs for %d of %d missing security updates", len(cves), len(kbs))
+}
+
+// filterExcludedPackages removes packages whose name or description (the
+// update title) matches the exclusion patterns. It returns the kept packages
+// and the number removed.
+func filterExcludedPackages(packages []models.Package, matcher *utils.PatternMatcher) ([]models.Package, int) {
+	kept := make([]models.Package, 0, len(packages))
+	for _, pkg := range packages {
+		if matcher.Match(pkg.Name, pkg.Description) {
+			continue
+		}
+		kept = append(kept, pkg)
+	}
+	return kept, len(packages) - len(kept)
+}
+
+// CollectionErrors returns the errors from the last GetPackages call.
+// A non-empty result means the package list is partial.
+func (m *Manager) CollectionErrors() []string {
+	return m.collectionErrors
+}
+
+// GetAppxPackages gets the Microsoft Store / Appx package inventory.
+// Failures are logged and result in an empty list.
+func (m *Manager) GetAppxPackages() []models.AppxPackage {
+	appxPackages, err := m.appxManager.GetAppxPackages()
+	if err != nil {
+		m.logger.Warnf("Failed to get Appx packages: %v", err)
+		return []models.AppxPackage{}
+	}
+	return appxPackages
+}
+
+// GetHiddenUpdates gets applicable Windows updates that have been hidden.
+// Failures are logged and result in an empty list.
+func (m *Manager) GetHiddenUpdates() []models.Package {
+	hidden, err := m.windowsManager.GetHiddenUpdates()
+	if err != nil {
+		m.logger.Warnf("Failed to get hidden updates: %v", err)
+		return []models.Package{}
+	}
+	if m.excludeMatcher.Len() > 0 {
+		hidden, _ = filterExcludedPackages(hidden, m.excludeMatcher)
+	}
+	return hidden
+}
+
+// GetAgentVersion gets the Windows Update Agent version.
+// Failures are logged and result in an empty string.
+func (m *Manager) GetAgentVersion() string {
+	version, err := m.windowsManager.GetAgentVersion()
+	if err != nil {
+		m.logger.Warnf("Failed to get Windows Update Agent version: %v", err)
+		return ""
+	}
+	return version
+}
+
 // CombinePackageData combines and deduplicates installed and upgradable package lists
 func CombinePackageData(installedPackages map[string]models.Package, upgradablePackages []models.Package) []models.Package {
 	packages := make([]models.Package, 0)
@@ -78,3 +224,33 @@ func CombinePackageData(installedPackages map[string]models.Package, upgradableP
 
 	return packages
 }
+
+// InstallUpdates downloads and installs available Windows updates selected by opts
+func (m *Manager) InstallUpdates(opts InstallOptions) ([]models.UpdateInstallResult, error) {
+	return m.windowsManager.InstallUpdates(opts)
+}
+
+// UninstallUpdate removes an installed Windows update by KB article
+func (m *Manager) UninstallUpdate(kb string) (models.UpdateInstallResult, error) {
+	return m.windowsManager.UninstallUpdate(kb)
+}
+
+// SetUpdatesHidden hides or unhides applicable Windows updates by KB article
+func (m *Manager) SetUpdatesHidden(kbs []string, hidden bool) ([]models.UpdateInstallResult, error) {
+	return m.windowsManager.SetUpdatesHidden(kbs, hidden)
+}
+
+// TriggerDetection starts a Windows Update detection cycle
+func (m *Manager) TriggerDetection() error {
+	return m.windowsManager.TriggerDetection()
+}
+
+// ResetComponents resets the Windows Update components and datastore
+func (m *Manager) ResetComponents() (*RepairResult, error) {
+	return m.windowsManager.ResetComponents()
+}
+
+// CheckWUA verifies that the Windows Update Agent can be used
+func (m *Manager) CheckWUA() error {
+	return m.windowsManager.CheckSession()
+}
@@ -3,6 +3,8 @@ package packages
 import (
 	"testing"
 
+	"patchmon-agent/internal/config"
+	"patchmon-agent/internal/utils"
 	"patchmon-agent/pkg/models"
 
 	"github.com/sirupsen/logrus"
@@ -10,7 +12,7 @@ import (
 
 func TestNew(t *testing.T) {
 	logger := logrus.New()
-	mgr := New(logger)
+	mgr := New(config.New(), logger)
 
 	if mgr == nil {
 		t.Fatal("New returned nil")
@@ -21,6 +23,9 @@ func TestNew(t *testing.T) {
 	if mgr.windowsManager == nil {
 		t.Error("Manager windowsManager not initialized")
 	}
+	if mgr.scoopManager == nil {
+		t.Error("Manager scoopManager not initialized")
+	}
 }
 
 // TestGetPackages_Integration is an integration test that verifies GetPackages
@@ -28,7 +33,7 @@ func TestNew(t *testing.T) {
 func TestGetPackages_Integration(t *testing.T) {
 	logger := logrus.New()
 	logger.SetLevel(logrus.DebugLevel)
-	mgr := New(logger)
+	mgr := New(config.New(), logger)
 
 	packages, err := mgr.GetPackages()
 	if err != nil {
@@ -154,3 +159,24 @@ func TestCombinePackageData_DescriptionPreserved(t *testing.T) {
 		t.Errorf("expected description to be preserved from installed package, got %q", result[0].Description)
 	}
 }
+
+func TestFilterExcludedPackages(t *testing.T) {
+	matcher, err := utils.NewPatternMatcher([]string{"Security Intelligence Update*", "re:^KB50344[0-9]{2}$"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	packages := []models.Package{
+		{Name: "KB2267602", Description: "Security Intelligence Update for Microsoft Defender Antivirus - KB2267602"},
+		{Name: "KB5034441", Description: "Security Update for Windows"},
+		{Name: "KB5035853", Description: "2024-03 Cumulative Update for Windows 11"},
+	}
+
+	kept, excluded := filterExcludedPackages(packages, matcher)
+	if excluded != 2 {
+		t.Errorf("expected 2 excluded packages, got %d", excluded)
+	}
+	if len(kept) != 1 || kept[0].Name != "KB5035853" {
+		t.Errorf("unexpected kept packages: %+v", kept)
+	}
+}
@@ -0,0 +1,199 @@
+package packages
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// serviceStopTimeout bounds how long to wait for a service to stop
+const serviceStopTimeout = 60 * time.Second
+
+// repairServices are the services stopped while the update components are
+// reset, in stop order. They are started again in reverse order.
+var repairServices = []string{"wuauserv", "bits", "cryptsvc"}
+
+// repairDLLs are the Windows Update Agent and BITS libraries re-registered
+// after a reset, in registration order
+var repairDLLs = []string{
+	"wuapi.dll", "wuaueng.dll", "wups.dll", "wups2.dll", "wuwebv.dll",
+	"wucltux.dll", "qmgr.dll", "qmgrprxy.dll",
+}
+
+// RepairResult describes what ResetComponents did
+type RepairResult struct {
+	// Renamed maps each reset folder to the backup it was renamed to
+	Renamed map[string]string
+	// Registered holds the outcome for each re-registered library
+	Registered []DLLResult
+}
+
+// DLLResult is the outcome of re-registering one library
+type DLLResult struct {
+	Name string
+	// Error is empty when the library was registered
+	Error string
+}
+
+// updateDataFolders returns the Windows Update datastore/download folder and
+// the catalog database folder that are reset by ResetComponents
+func updateDataFolders() []string {
+	root := systemRoot()
+	return []string{
+		filepath.Join(root, "SoftwareDistribution"),
+		filepath.Join(root, "System32", "catroot2"),
+	}
+}
+
+// backupFolderName returns the name a folder is renamed to during a reset.
+// The timestamp keeps earlier backups intact.
+func backupFolderName(path string, now time.Time) string {
+	return path + ".bak-" + now.Format("20060102-150405")
+}
+
+// ResetComponents performs the standard Windows Update remediation: stop the
+// update services, rename the SoftwareDistribution and catroot2 folders so
+// Windows recreates them, start the services again and re-register the
+// update libraries. Use it when searches keep failing with datastore errors.
+// The services are started again on every path, so a failed reset does not
+// leave Windows Update stopped.
+func (w *WindowsUpdateManager) ResetComponents() (result *RepairResult, err error) {
+	result = &RepairResult{Renamed: make(map[string]string)}
+
+	// Services whose stop was attempted; one that failed to stop may still
+	// be stopping
+	stopped := make([]string, 0, len(repairServices))
+	startStopped := func() error {
+		var errs []error
+		for _, name := range slices.Backward(stopped) {
+			w.logger.WithField("service", name).Info("Starting service...")
+			if err := w.startService(name); err != nil {
+				errs = append(errs, fmt.Errorf("failed to start %s: %w", name, err))
+			}
+		}
+		stopped = nil
+		return errors.Join(errs...)
+	}
+	defer func() {
+		err = errors.Join(err, startStopped())
+	}()
+
+	for _, name := range repairServices {
+		w.logger.WithField("service", name).Info("Stopping service...")
+		stopped = append(stopped, name)
+		if err := w.stopService(name); err != nil {
+			return result, fmt.Errorf("failed to stop %s: %w", name, err)
+		}
+	}
+
+	now := time.Now()
+	for _, folder := range updateDataFolders() {
+		if _, err := os.Stat(folder); errors.Is(err, os.ErrNotExist) {
+			w.logger.WithField("path", folder).Debug("Folder does not exist, nothing to reset")
+			continue
+		}
+		backup := backupFolderName(folder, now)
+		w.logger.WithField("path", folder).WithField("backup", backup).Info("Renaming folder...")
+		if err := os.Rename(folder, backup); err != nil {
+			return result, fmt.Errorf("failed to rename %s: %w", folder, err)
+		}
+		result.Renamed[folder] = backup
+	}
+
+	if err := startStopped(); err != nil {
+		return result, err
+	}
+
+	result.Registered = w.registerDLLs()
+	w.logger.Info("Windows Update components reset")
+	return result, nil
+}
+
+// registerDLLs re-registers repairDLLs with regsvr32 and returns the outcome
+// for each library. A failed library does not stop the others.
+func (w *WindowsUpdateManager) registerDLLs() []DLLResult {
+	system32 := filepath.Join(systemRoot(), "System32")
+	regsvr32 := filepath.Join(system32, "regsvr32.exe")
+
+	w.logger.Info("Re-registering Windows Update libraries...")
+	results := make([]DLLResult, 0, len(repairDLLs))
+	for _, dll := range repairDLLs {
+		res := DLLResult{Name: dll}
+		code, err := w.runCommand(regsvr32, "/s", filepath.Join(system32, dll))
+		switch {
+		case err != nil:
+			res.Error = err.Error()
+		case code != 0:
+			res.Error = fmt.Sprintf("regsvr32 exited with code %d", code)
+		}
+		if res.Error != "" {
+			w.logger.WithField("dll", dll).Warnf("Failed to register library: %s", res.Error)
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// stopWindowsService stops a service and waits until it has stopped. A
+// service that is not running is not an error.
+func stopWindowsService(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	status, err := s.Control(svc.Stop)
+	if errors.Is(err, windows.ERROR_SERVICE_NOT_ACTIVE) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(serviceStopTimeout)
+	for status.State != svc.Stopped {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for service to stop")
+		}
+		time.Sleep(500 * time.Millisecond)
+		if status, err = s.Query(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startWindowsService starts a service. A service that is already running is
+// not an error.
+func startWindowsService(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	if err := s.Start(); err != nil && !errors.Is(err, windows.ERROR_SERVICE_ALREADY_RUNNING) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package packages
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBackupFolderName(t *testing.T) {
+	now := time.Date(2024, 3, 12, 18, 4, 5, 0, time.UTC)
+	if got := backupFolderName(`C:\Windows\SoftwareDistribution`, now); got != `C:\Windows\SoftwareDistribution.bak-20240312-180405` {
+		t.Errorf("backupFolderName = %q", got)
+	}
+}
+
+func TestResetComponents_Fake(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("SystemRoot", root)
+	writeTestFile(t, filepath.Join(root, "SoftwareDistribution", "DataStore", "DataStore.edb"), "")
+
+	var calls []string
+	mgr := NewWindowsUpdateManager(newTestLogger())
+	mgr.stopService = func(name string) error {
+		calls = append(calls, "stop "+name)
+		return nil
+	}
+	mgr.startService = func(name string) error {
+		calls = append(calls, "start "+name)
+		return nil
+	}
+	mgr.runCommand = func(name string, args ...string) (int, error) {
+		dll := filepath.Base(args[1])
+		calls = append(calls, "register "+dll)
+		if dll == "wups2.dll" {
+			return 5, nil
+		}
+		return 0, nil
+	}
+
+	result, err := mgr.ResetComponents()
+	if err != nil {
+		t.Fatalf("ResetComponents failed: %v", err)
+	}
+
+	want := []string{"stop wuauserv", "stop bits", "stop cryptsvc", "start cryptsvc", "start bits", "start wuauserv"}
+	for _, dll := range repairDLLs {
+		want = append(want, "register "+dll)
+	}
+	if !slices.Equal(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+
+	if len(result.Registered) != len(repairDLLs) {
+		t.Fatalf("expected a result per library, got %+v", result.Registered)
+	}
+	for _, res := range result.Registered {
+		if failed := res.Name == "wups2.dll"; failed != (res.Error != "") {
+			t.Errorf("unexpected result for %s: %q", res.Name, res.Error)
+		}
+	}
+
+	// catroot2 does not exist and is skipped
+	folder := filepath.Join(root, "SoftwareDistribution")
+	if len(result.Renamed) != 1 || result.Renamed[folder] == "" {
+		t.Fatalf("unexpected renamed folders: %v", result.Renamed)
+	}
+	if _, err := os.Stat(filepath.Join(result.Renamed[folder], "DataStore", "DataStore.edb")); err != nil {
+		t.Errorf("datastore not moved to backup: %v", err)
+	}
+	if _, err := os.Stat(folder); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected %s to be renamed, got %v", folder, err)
+	}
+}
+
+func TestResetComponents_FakeStopFails(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("SystemRoot", root)
+	writeTestFile(t, filepath.Join(root, "SoftwareDistribution", "ReportingEvents.log"), "")
+
+	var started []string
+	mgr := NewWindowsUpdateManager(newTestLogger())
+	mgr.stopService = func(name string) error {
+		if name == "cryptsvc" {
+			return errors.New("access denied")
+		}
+		return nil
+	}
+	mgr.startService = func(name string) error {
+		started = append(started, name)
+		return nil
+	}
+	mgr.runCommand = func(name string, args ...string) (int, error) {
+		t.Errorf("unexpected command after a failed reset: %s %v", name, args)
+		return 0, nil
+	}
+
+	if _, err := mgr.ResetComponents(); err == nil {
+		t.Fatal("expected an error when a service cannot be stopped")
+	}
+	// cryptsvc may be left stopping, so it is started too
+	if !slices.Equal(started, []string{"cryptsvc", "bits", "wuauserv"}) {
+		t.Errorf("expected the stopped services to be restarted, got %v", started)
+	}
+	if _, err := os.Stat(filepath.Join(root, "SoftwareDistribution")); err != nil {
+		t.Errorf("folder must not be renamed when services are still running: %v", err)
+	}
+}
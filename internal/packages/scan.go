@@ -0,0 +1,70 @@
+package packages
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	ole "github.com/go-ole/go-ole"
+	"github.com/go-ole/go-ole/oleutil"
+)
+
+// systemRoot returns the Windows directory
+func systemRoot() string {
+	if root := os.Getenv("SystemRoot"); root != "" {
+		return root
+	}
+	return `C:\Windows`
+}
+
+// usoClientPath returns the path of the Update Session Orchestrator client,
+// which drives Windows Update on Windows 10 / Server 2016 and later
+func usoClientPath() string {
+	return filepath.Join(systemRoot(), "System32", "UsoClient.exe")
+}
+
+// TriggerDetection asks Windows Update to run a detection cycle now instead of
+// waiting for its schedule, so newly approved or released updates are picked
+// up. UsoClient StartScan is used where available, falling back to
+// IAutomaticUpdates.DetectNow on older systems.
+func (w *WindowsUpdateManager) TriggerDetection() error {
+	usoClient := usoClientPath()
+	if _, err := os.Stat(usoClient); err == nil {
+		w.logger.Info("Starting Windows Update scan with UsoClient...")
+		code, err := w.runCommand(usoClient, "StartScan")
+		if err == nil && code == 0 {
+			return nil
+		}
+		w.logger.WithError(err).WithField("exit_code", code).Debug("UsoClient StartScan failed, falling back to DetectNow")
+	}
+
+	w.logger.Info("Starting Windows Update detection with DetectNow...")
+	return w.detectNow()
+}
+
+// comDetectNow calls IAutomaticUpdates.DetectNow
+func comDetectNow() error {
+	if err := ole.CoInitializeEx(0, ole.COINIT_MULTITHREADED); err != nil {
+		if oleErr, ok := err.(*ole.OleError); !ok || oleErr.Code() != 0x00000001 {
+			return fmt.Errorf("COM initialization failed: %w", err)
+		}
+	}
+	defer ole.CoUninitialize()
+
+	unknown, err := oleutil.CreateObject("Microsoft.Update.AutoUpdate")
+	if err != nil {
+		return fmt.Errorf("failed to create AutoUpdate: %w", err)
+	}
+	defer unknown.Release()
+
+	autoUpdate, err := unknown.QueryInterface(ole.IID_IDispatch)
+	if err != nil {
+		return fmt.Errorf("failed to query AutoUpdate interface: %w", err)
+	}
+	defer autoUpdate.Release()
+
+	if _, err := oleutil.CallMethod(autoUpdate, "DetectNow"); err != nil {
+		return decodeWUAError(fmt.Errorf("DetectNow failed: %w", err))
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package packages
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestTriggerDetection_Fake(t *testing.T) {
+	tests := []struct {
+		name         string
+		hasUsoClient bool
+		usoCode      int
+		wantUso      bool
+		wantDetect   bool
+	}{
+		{"UsoClient succeeds", true, 0, true, false},
+		{"UsoClient fails", true, 1, true, true},
+		{"no UsoClient", false, 0, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			t.Setenv("SystemRoot", root)
+			if tt.hasUsoClient {
+				writeTestFile(t, filepath.Join(root, "System32", "UsoClient.exe"), "")
+			}
+
+			ranUso, ranDetect := false, false
+			mgr := NewWindowsUpdateManager(newTestLogger())
+			mgr.runCommand = func(name string, args ...string) (int, error) {
+				ranUso = name == usoClientPath() && len(args) == 1 && args[0] == "StartScan"
+				return tt.usoCode, nil
+			}
+			mgr.detectNow = func() error {
+				ranDetect = true
+				return nil
+			}
+
+			if err := mgr.TriggerDetection(); err != nil {
+				t.Fatal(err)
+			}
+			if ranUso != tt.wantUso || ranDetect != tt.wantDetect {
+				t.Errorf("ran UsoClient=%v DetectNow=%v, want %v/%v", ranUso, ranDetect, tt.wantUso, tt.wantDetect)
+			}
+		})
+	}
+}
+
+func TestTriggerDetection_FakeError(t *testing.T) {
+	t.Setenv("SystemRoot", t.TempDir())
+	mgr := NewWindowsUpdateManager(newTestLogger())
+	mgr.detectNow = func() error { return errors.New("service disabled") }
+
+	if err := mgr.TriggerDetection(); err == nil {
+		t.Error("expected the DetectNow error to be returned")
+	}
+}
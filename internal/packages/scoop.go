@@ -0,0 +1,277 @@
+package packages
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/windows/registry"
+
+	"patchmon-agent/pkg/models"
+)
+
+// Registry key listing the user profiles present on this machine
+const profileListKey = `SOFTWARE\Microsoft\Windows NT\CurrentVersion\ProfileList`
+
+// ScoopManager collects apps installed through the Scoop package manager
+type ScoopManager struct {
+	logger *logrus.Logger
+}
+
+// scoopRoot is a Scoop installation directory and the scope it was found in
+type scoopRoot struct {
+	path  string
+	scope string // "global" or the owning user name
+}
+
+// scoopManifest holds the fields we need from a Scoop app manifest
+type scoopManifest struct {
+	Version string `json:"version"`
+}
+
+// scoopInstallInfo holds the fields we need from a Scoop install.json file
+type scoopInstallInfo struct {
+	Bucket string `json:"bucket"`
+}
+
+// NewScoopManager creates a new ScoopManager
+func NewScoopManager(logger *logrus.Logger) *ScoopManager {
+	return &ScoopManager{logger: logger}
+}
+
+// GetPackages returns the Scoop apps installed globally and for every user
+// profile on the machine. Apps whose bucket manifest carries a newer version
+// are reported with NeedsUpdate=true.
+func (s *ScoopManager) GetPackages() ([]models.Package, error) {
+	roots := s.findRoots()
+	if len(roots) == 0 {
+		s.logger.Debug("No Scoop installations found")
+		return []models.Package{}, nil
+	}
+
+	// Global apps are installed from the buckets of whoever installed them,
+	// so bucket manifests are looked up across every root we know about.
+	bucketDirs := []string{}
+	for _, root := range roots {
+		bucketDirs = append(bucketDirs, filepath.Join(root.path, "buckets"))
+	}
+
+	packages := []models.Package{}
+	seen := make(map[string]int)
+	for _, root := range roots {
+		for _, pkg := range scanScoopRoot(root, bucketDirs) {
+			// The same app may be installed for several users; keep one entry,
+			// preferring the copy that needs an update.
+			if idx, exists := seen[pkg.Name]; exists {
+				if pkg.NeedsUpdate && !packages[idx].NeedsUpdate {
+					packages[idx] = pkg
+				}
+				continue
+			}
+			seen[pkg.Name] = len(packages)
+			packages = append(packages, pkg)
+		}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"roots": len(roots),
+		"apps":  len(packages),
+	}).Debug("Collected Scoop packages")
+
+	return packages, nil
+}
+
+// findRoots locates the global Scoop directory and the per-user Scoop
+// directories of every profile listed in the registry.
+func (s *ScoopManager) findRoots() []scoopRoot {
+	candidates := []scoopRoot{}
+
+	if global := os.Getenv("SCOOP_GLOBAL"); global != "" {
+		candidates = append(candidates, scoopRoot{path: global, scope: "global"})
+	}
+	if programData := os.Getenv("ProgramData"); programData != "" {
+		candidates = append(candidates, scoopRoot{path: filepath.Join(programData, "scoop"), scope: "global"})
+	}
+	if userRoot := os.Getenv("SCOOP"); userRoot != "" {
+		candidates = append(candidates, scoopRoot{path: userRoot, scope: os.Getenv("USERNAME")})
+	}
+	for _, profile := range s.getProfilePaths() {
+		candidates = append(candidates, scoopRoot{path: filepath.Join(profile, "scoop"), scope: filepath.Base(profile)})
+	}
+
+	roots := []scoopRoot{}
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		key := strings.ToLower(filepath.Clean(candidate.path))
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if info, err := os.Stat(filepath.Join(candidate.path, "apps")); err == nil && info.IsDir() {
+			roots = append(roots, candidate)
+		}
+	}
+	return roots
+}
+
+// getProfilePaths returns the profile directories of all local user profiles
+func (s *ScoopManager) getProfilePaths() []string {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, profileListKey, registry.ENUMERATE_SUB_KEYS)
+	if err != nil {
+		s.logger.WithError(err).Debug("Failed to open profile list")
+		return nil
+	}
+	defer key.Close()
+
+	sids, err := key.ReadSubKeyNames(-1)
+	if err != nil {
+		s.logger.WithError(err).Debug("Failed to enumerate profiles")
+		return nil
+	}
+
+	paths := []string{}
+	for _, sid := range sids {
+		// Only real user accounts (S-1-5-21-...) have Scoop installs
+		if !strings.HasPrefix(sid, "S-1-5-21-") {
+			continue
+		}
+		profileKey, err := registry.OpenKey(key, sid, registry.QUERY_VALUE)
+		if err != nil {
+			continue
+		}
+		path, _, err := profileKey.GetStringValue("ProfileImagePath")
+		profileKey.Close()
+		if err == nil && path != "" {
+			paths = append(paths, os.ExpandEnv(path))
+		}
+	}
+	return paths
+}
+
+// scanScoopRoot reads every app installed under a Scoop root and resolves the
+// latest available version from the given bucket directories.
+func scanScoopRoot(root scoopRoot, bucketDirs []string) []models.Package {
+	packages := []models.Package{}
+
+	entries, err := os.ReadDir(filepath.Join(root.path, "apps"))
+	if err != nil {
+		return packages
+	}
+
+	for _, entry := range entries {
+		app := entry.Name()
+		// Scoop itself lives in apps\scoop and is updated with "scoop update"
+		if !entry.IsDir() || strings.EqualFold(app, "scoop") {
+			continue
+		}
+
+		currentDir := filepath.Join(root.path, "apps", app, "current")
+		var manifest scoopManifest
+		if err := readJSONFile(filepath.Join(currentDir, "manifest.json"), &manifest); err != nil || manifest.Version == "" {
+			continue
+		}
+
+		var install scoopInstallInfo
+		_ = readJSONFile(filepath.Join(currentDir, "install.json"), &install)
+
+		pkg := models.Package{
+			Name:           app,
+			Description:    scoopDescription(install.Bucket, root.scope),
+			CurrentVersion: manifest.Version,
+		}
+
+		latest := findBucketVersion(bucketDirs, install.Bucket, app)
+		if latest != "" && CompareScoopVersions(latest, manifest.Version) > 0 {
+			pkg.NeedsUpdate = true
+			pkg.AvailableVersion = latest
+		}
+
+		packages = append(packages, pkg)
+	}
+
+	return packages
+}
+
+// scoopDescription builds the package description shown in PatchMon
+func scoopDescription(bucket, scope string) string {
+	if bucket == "" {
+		bucket = "unknown"
+	}
+	return "Scoop app (bucket: " + bucket + ", scope: " + scope + ")"
+}
+
+// findBucketVersion returns the version in the bucket manifest for an app, or
+// an empty string if the manifest cannot be found.
+func findBucketVersion(bucketDirs []string, bucket, app string) string {
+	if bucket == "" {
+		return ""
+	}
+
+	for _, dir := range bucketDirs {
+		// Newer buckets keep manifests in a "bucket" subdirectory
+		for _, path := range []string{
+			filepath.Join(dir, bucket, "bucket", app+".json"),
+			filepath.Join(dir, bucket, app+".json"),
+		} {
+			var manifest scoopManifest
+			if err := readJSONFile(path, &manifest); err == nil && manifest.Version != "" {
+				return manifest.Version
+			}
+		}
+	}
+	return ""
+}
+
+// readJSONFile decodes a JSON file into v
+func readJSONFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// CompareScoopVersions compares two Scoop version strings segment by segment.
+// Numeric segments are compared numerically, others lexically. Returns 1 if
+// a is newer than b, -1 if older and 0 if equal. "nightly" versions are never
+// considered newer since they cannot be compared.
+// Exported for testing.
+func CompareScoopVersions(a, b string) int {
+	if a == b || strings.EqualFold(a, "nightly") || strings.EqualFold(b, "nightly") {
+		return 0
+	}
+
+	split := func(r rune) bool { return r == '.' || r == '-' || r == '_' || r == '+' }
+	aParts := strings.FieldsFunc(a, split)
+	bParts := strings.FieldsFunc(b, split)
+
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		if i >= len(aParts) {
+			return -1
+		}
+		if i >= len(bParts) {
+			return 1
+		}
+
+		aNum, aErr := strconv.Atoi(aParts[i])
+		bNum, bErr := strconv.Atoi(bParts[i])
+		if aErr == nil && bErr == nil {
+			if aNum != bNum {
+				if aNum > bNum {
+					return 1
+				}
+				return -1
+			}
+			continue
+		}
+
+		if cmp := strings.Compare(aParts[i], bParts[i]); cmp != 0 {
+			return cmp
+		}
+	}
+
+	return 0
+}
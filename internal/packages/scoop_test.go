@@ -0,0 +1,104 @@
+package packages
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestFile creates a file (and its parent directories) for test fixtures
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestCompareScoopVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2.4", "1.2.3", 1},
+		{"1.2.3", "1.2.4", -1},
+		{"1.10.0", "1.9.0", 1},
+		{"2.0", "1.99.99", 1},
+		{"1.2.3.1", "1.2.3", 1},
+		{"1.2", "1.2.1", -1},
+		{"23.01", "22.01", 1},
+		{"nightly", "1.0.0", 0},
+		{"1.0.0", "nightly", 0},
+		{"1.0.0-rc2", "1.0.0-rc1", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_vs_"+tt.b, func(t *testing.T) {
+			if got := CompareScoopVersions(tt.a, tt.b); got != tt.want {
+				t.Errorf("CompareScoopVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanScoopRoot(t *testing.T) {
+	root := t.TempDir()
+
+	// Up-to-date app from the main bucket (new "bucket" subdirectory layout)
+	writeTestFile(t, filepath.Join(root, "apps", "git", "current", "manifest.json"), `{"version": "2.44.0"}`)
+	writeTestFile(t, filepath.Join(root, "apps", "git", "current", "install.json"), `{"bucket": "main"}`)
+	writeTestFile(t, filepath.Join(root, "buckets", "main", "bucket", "git.json"), `{"version": "2.44.0"}`)
+
+	// Outdated app from the extras bucket (old flat layout)
+	writeTestFile(t, filepath.Join(root, "apps", "vscode", "current", "manifest.json"), `{"version": "1.85.0"}`)
+	writeTestFile(t, filepath.Join(root, "apps", "vscode", "current", "install.json"), `{"bucket": "extras"}`)
+	writeTestFile(t, filepath.Join(root, "buckets", "extras", "vscode.json"), `{"version": "1.86.1"}`)
+
+	// App installed from a manifest URL (no bucket)
+	writeTestFile(t, filepath.Join(root, "apps", "custom", "current", "manifest.json"), `{"version": "0.1"}`)
+
+	// Scoop itself and broken installs are skipped
+	writeTestFile(t, filepath.Join(root, "apps", "scoop", "current", "manifest.json"), `{"version": "0.3.1"}`)
+	if err := os.MkdirAll(filepath.Join(root, "apps", "broken", "current"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	packages := scanScoopRoot(scoopRoot{path: root, scope: "alice"}, []string{filepath.Join(root, "buckets")})
+
+	if len(packages) != 3 {
+		t.Fatalf("expected 3 packages, got %d: %+v", len(packages), packages)
+	}
+
+	byName := make(map[string]int)
+	for i, pkg := range packages {
+		byName[pkg.Name] = i
+	}
+
+	git := packages[byName["git"]]
+	if git.NeedsUpdate || git.CurrentVersion != "2.44.0" || git.AvailableVersion != "" {
+		t.Errorf("unexpected git package: %+v", git)
+	}
+
+	vscode := packages[byName["vscode"]]
+	if !vscode.NeedsUpdate || vscode.CurrentVersion != "1.85.0" || vscode.AvailableVersion != "1.86.1" {
+		t.Errorf("unexpected vscode package: %+v", vscode)
+	}
+	if vscode.Description != "Scoop app (bucket: extras, scope: alice)" {
+		t.Errorf("unexpected vscode description: %q", vscode.Description)
+	}
+
+	custom := packages[byName["custom"]]
+	if custom.NeedsUpdate || custom.Description != "Scoop app (bucket: unknown, scope: alice)" {
+		t.Errorf("unexpected custom package: %+v", custom)
+	}
+}
+
+func TestScanScoopRoot_MissingAppsDir(t *testing.T) {
+	packages := scanScoopRoot(scoopRoot{path: t.TempDir(), scope: "global"}, nil)
+	if packages == nil || len(packages) != 0 {
+		t.Errorf("expected empty non-nil slice, got %v", packages)
+	}
+}
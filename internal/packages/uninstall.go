@@ -0,0 +1,86 @@
+package packages
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"patchmon-agent/pkg/models"
+)
+
+// wusa.exe exit codes
+const (
+	wusaExitSuccess          = 0
+	wusaExitRebootRequired   = 3010
+	wusaExitAlreadyRemoved   = 0x00240007 // WU_S_ALREADY_UNINSTALLED
+	wusaExitNotApplicable    = 0x80240017 // WU_E_NOT_APPLICABLE
+	wusaExitInstallerRunning = 1618       // ERROR_INSTALL_ALREADY_RUNNING
+	wusaExitAccessDenied     = 5
+)
+
+// runExitCode runs a command and returns its exit code. The error is only
+// set if the command could not be started.
+func runExitCode(name string, args ...string) (int, error) {
+	err := exec.Command(name, args...).Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return -1, err
+	}
+	return 0, nil
+}
+
+// UninstallUpdate removes an installed update with wusa.exe. kb may be given
+// with or without the "KB" prefix. Per-update failures (not installed,
+// installer busy, ...) are reported in the result; the error is only set if
+// wusa could not be run.
+func (w *WindowsUpdateManager) UninstallUpdate(kb string) (models.UpdateInstallResult, error) {
+	kbs := normalizeKBs([]string{kb})
+	if len(kbs) == 0 || !isKBNumber(kbs[0]) {
+		return models.UpdateInstallResult{Name: kb, Status: InstallStatusFailed}, fmt.Errorf("invalid KB identifier %q", kb)
+	}
+	kb = kbs[0]
+
+	w.logger.WithField("update", kb).Info("Uninstalling update with wusa...")
+	code, err := w.runCommand("wusa.exe", "/uninstall", "/kb:"+kb[2:], "/quiet", "/norestart")
+	if err != nil {
+		return models.UpdateInstallResult{Name: kb, Status: InstallStatusFailed, Error: err.Error()}, fmt.Errorf("failed to run wusa: %w", err)
+	}
+
+	result := wusaResult(kb, code)
+	w.logger.WithField("update", kb).WithField("exit_code", code).Infof("Uninstall finished: %s", result.Status)
+	return result, nil
+}
+
+// isKBNumber reports whether kb is "KB" followed by digits only
+func isKBNumber(kb string) bool {
+	digits := strings.TrimPrefix(kb, "KB")
+	return digits != "" && strings.Trim(digits, "0123456789") == ""
+}
+
+// wusaResult maps a wusa.exe exit code to an UpdateInstallResult
+func wusaResult(kb string, code int) models.UpdateInstallResult {
+	result := models.UpdateInstallResult{Name: kb, ResultCode: code}
+	switch uint32(code) {
+	case wusaExitSuccess:
+		result.Status = InstallStatusUninstalled
+	case wusaExitRebootRequired:
+		result.Status = InstallStatusUninstalled
+		result.RebootRequired = true
+	case wusaExitAlreadyRemoved, wusaExitNotApplicable:
+		result.Status = InstallStatusNotInstalled
+	case wusaExitInstallerRunning:
+		result.Status = InstallStatusFailed
+		result.Error = "another installation is in progress"
+	case wusaExitAccessDenied:
+		result.Status = InstallStatusFailed
+		result.Error = "access denied; run the agent as Administrator or SYSTEM"
+	default:
+		result.Status = InstallStatusFailed
+		result.Error = fmt.Sprintf("wusa exited with code 0x%08X (the update may not be removable with wusa)", uint32(code))
+	}
+	return result
+}
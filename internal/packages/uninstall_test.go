@@ -0,0 +1,68 @@
+package packages
+
+import (
+	"reflect"
+	"testing"
+
+	"patchmon-agent/pkg/models"
+)
+
+func TestWusaResult(t *testing.T) {
+	tests := []struct {
+		code       int
+		wantStatus string
+		wantReboot bool
+	}{
+		{0, InstallStatusUninstalled, false},
+		{3010, InstallStatusUninstalled, true},
+		{0x00240007, InstallStatusNotInstalled, false},
+		{1618, InstallStatusFailed, false},
+		{87, InstallStatusFailed, false},
+	}
+
+	for _, tt := range tests {
+		result := wusaResult("KB5034441", tt.code)
+		if result.Status != tt.wantStatus || result.RebootRequired != tt.wantReboot || result.ResultCode != tt.code {
+			t.Errorf("wusaResult(%d) = %+v, want status %q reboot %v", tt.code, result, tt.wantStatus, tt.wantReboot)
+		}
+		if tt.wantStatus == InstallStatusFailed && result.Error == "" {
+			t.Errorf("wusaResult(%d) should describe the failure", tt.code)
+		}
+	}
+}
+
+func TestUninstallUpdate_Fake(t *testing.T) {
+	var gotArgs []string
+	mgr := NewWindowsUpdateManager(newTestLogger())
+	mgr.runCommand = func(name string, args ...string) (int, error) {
+		gotArgs = append([]string{name}, args...)
+		return 3010, nil
+	}
+
+	result, err := mgr.UninstallUpdate("kb5034441")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantArgs := []string{"wusa.exe", "/uninstall", "/kb:5034441", "/quiet", "/norestart"}
+	if !reflect.DeepEqual(gotArgs, wantArgs) {
+		t.Errorf("ran %v, want %v", gotArgs, wantArgs)
+	}
+	want := models.UpdateInstallResult{Name: "KB5034441", Status: InstallStatusUninstalled, ResultCode: 3010, RebootRequired: true}
+	if result != want {
+		t.Errorf("result = %+v, want %+v", result, want)
+	}
+}
+
+func TestUninstallUpdate_InvalidKB(t *testing.T) {
+	mgr := NewWindowsUpdateManager(newTestLogger())
+	mgr.runCommand = func(name string, args ...string) (int, error) {
+		t.Fatal("wusa should not run for an invalid KB")
+		return 0, nil
+	}
+
+	for _, kb := range []string{"", "KB", "KB12; rm", "abc"} {
+		if _, err := mgr.UninstallUpdate(kb); err == nil {
+			t.Errorf("expected an error for %q", kb)
+		}
+	}
+}
@@ -1,112 +1,265 @@
 package packages
 
 import (
+	"errors"
 	"fmt"
-	"runtime"
+	"os"
+	"slices"
 	"strings"
+	"time"
 
-	ole "github.com/go-ole/go-ole"
-	"github.com/go-ole/go-ole/oleutil"
 	"github.com/sirupsen/logrus"
 
 	"patchmon-agent/pkg/models"
 )
 
+// Server selection values for IUpdateSearcher.ServerSelection
+const (
+	serverSelectionOthers = 3 // search against the service identified by ServiceID
+)
+
+// Default WUA search criteria, overridable via config
+const (
+	DefaultInstalledCriteria = "IsInstalled=1"
+	DefaultAvailableCriteria = "IsInstalled=0 AND IsHidden=0"
+)
+
+// DefaultSearchTimeout bounds a single WUA search when no timeout is configured
+const DefaultSearchTimeout = 10 * time.Minute
+
+const (
+	searchPollInterval = 500 * time.Millisecond
+	// searchProgressInterval is how often a running search logs its progress
+	searchProgressInterval = 15 * time.Second
+	// searchAbortGrace is how long to wait for WUA to acknowledge an abort request
+	searchAbortGrace = 30 * time.Second
+)
+
+// ErrSearchTimeout is returned when a WUA search does not complete within the timeout
+var ErrSearchTimeout = errors.New("windows update search timed out")
+
+// offlineScanServiceName is the name under which the offline catalog is registered with WUA
+const offlineScanServiceName = "PatchMon Offline Scan"
+
 // WindowsUpdateManager handles Windows Update COM API interactions
 type WindowsUpdateManager struct {
 	logger *logrus.Logger
+	// newSession opens a WUA session; replaced with a fake in tests
+	newSession func() (UpdateSession, error)
+	// offlineScanCab is the path to a wsusscn2.cab offline scan catalog.
+	// When set, searches run against the catalog instead of WU/WSUS.
+	offlineScanCab string
+	// installedCriteria and availableCriteria are the WUA search strings
+	// used for installed and available updates respectively
+	installedCriteria string
+	availableCriteria string
+	// searchTimeout bounds each search; a search still running is aborted
+	searchTimeout time.Duration
+	// includeCategories and excludeCategories filter updates by WUA category
+	// (classification or product) name, case-insensitively
+	includeCategories []string
+	excludeCategories []string
+	// installedCachePath is the state store in which installed updates are
+	// cached between runs. Empty disables the cache.
+	installedCachePath string
+	// wsusConfigured reports whether the machine is a WSUS client; replaced in tests
+	wsusConfigured func() bool
+	// reportApproval marks available updates with their WSUS approval state
+	reportApproval bool
+	// runCommand runs an external command and returns its exit code; replaced in tests
+	runCommand func(name string, args ...string) (int, error)
+	// detectNow triggers a WUA detection cycle via COM; replaced in tests
+	detectNow func() error
+	// stopService and startService control Windows services; replaced in tests
+	stopService  func(name string) error
+	startService func(name string) error
 }
 
 // NewWindowsUpdateManager creates a new WindowsUpdateManager
 func NewWindowsUpdateManager(logger *logrus.Logger) *WindowsUpdateManager {
-	return &WindowsUpdateManager{logger: logger}
+	return &WindowsUpdateManager{
+		logger: logger,
+		newSession: func() (UpdateSession, error) {
+			return newCOMUpdateSession(logger)
+		},
+		installedCriteria: DefaultInstalledCriteria,
+		availableCriteria: DefaultAvailableCriteria,
+		searchTimeout:     DefaultSearchTimeout,
+		wsusConfigured:    isWSUSConfigured,
+		runCommand:        runExitCode,
+		detectNow:         comDetectNow,
+		stopService:       stopWindowsService,
+		startService:      startWindowsService,
+	}
 }
 
-// GetInstalledUpdates returns all installed Windows updates
-func (w *WindowsUpdateManager) GetInstalledUpdates() ([]models.Package, error) {
-	return w.searchUpdates("IsInstalled=1")
+// SetSearchTimeout overrides the per-search timeout. Values <= 0 keep the default.
+func (w *WindowsUpdateManager) SetSearchTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		w.searchTimeout = timeout
+	}
 }
 
-// GetAvailableUpdates returns all available (not installed, not hidden) updates
-func (w *WindowsUpdateManager) GetAvailableUpdates() ([]models.Package, error) {
-	w.logger.Info("Searching for available Windows updates (this may take 30-60 seconds)...")
-	return w.searchUpdates("IsInstalled=0 AND IsHidden=0")
+// SetSearchCriteria overrides the WUA search criteria. Empty values keep the defaults.
+func (w *WindowsUpdateManager) SetSearchCriteria(installed, available string) {
+	if installed = strings.TrimSpace(installed); installed != "" {
+		w.installedCriteria = installed
+	}
+	if available = strings.TrimSpace(available); available != "" {
+		w.availableCriteria = available
+	}
 }
 
-// searchUpdates queries the Windows Update Agent COM API with the given search criteria
-func (w *WindowsUpdateManager) searchUpdates(criteria string) ([]models.Package, error) {
-	// COM must be initialized on the same OS thread
-	runtime.LockOSThread()
-	defer runtime.UnlockOSThread()
+// SetCategoryFilter restricts reported updates by WUA category. If include is
+// non-empty an update must belong to at least one of those categories; updates
+// in any exclude category are always dropped.
+func (w *WindowsUpdateManager) SetCategoryFilter(include, exclude []string) {
+	w.includeCategories = normalizeCategories(include)
+	w.excludeCategories = normalizeCategories(exclude)
+}
+
+// GetInstalledUpdates returns all installed Windows updates. When a cache path
+// is set, the previous result is reused as long as the update history has not
+// changed since it was stored.
+func (w *WindowsUpdateManager) GetInstalledUpdates() ([]models.Package, error) {
+	if w.installedCachePath == "" {
+		return w.searchUpdates(w.installedCriteria, true)
+	}
 
-	err := ole.CoInitializeEx(0, ole.COINIT_APARTMENTTHREADED)
+	key := ""
+	latest, count, err := w.getLatestHistoryEntry()
 	if err != nil {
-		// S_FALSE (0x00000001) means COM is already initialized on this thread — that's OK
-		oleErr, ok := err.(*ole.OleError)
-		if !ok || oleErr.Code() != 0x00000001 {
-			return nil, fmt.Errorf("COM initialization failed: %w", err)
+		w.logger.WithError(err).Debug("Failed to read update history, not using installed updates cache")
+	} else {
+		key = installedCacheKey(latest, count, w.installedCriteria, w.offlineScanCab,
+			strings.Join(w.includeCategories, ","), strings.Join(w.excludeCategories, ","))
+		if cached, ok := loadInstalledCache(w.installedCachePath, key, time.Now()); ok {
+			w.logger.WithField("count", len(cached)).Info("Using cached installed updates (update history unchanged)")
+			return cached, nil
 		}
 	}
-	defer ole.CoUninitialize()
 
-	// Create Microsoft.Update.Session
-	unknown, err := oleutil.CreateObject("Microsoft.Update.Session")
+	installed, err := w.searchUpdates(w.installedCriteria, true)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create UpdateSession: %w", err)
+		return nil, err
+	}
+
+	if key != "" {
+		if err := saveInstalledCache(w.installedCachePath, key, installed, time.Now()); err != nil {
+			w.logger.WithError(err).Debug("Failed to save installed updates cache")
+		}
 	}
-	defer unknown.Release()
+	return installed, nil
+}
 
-	session, err := unknown.QueryInterface(ole.IID_IDispatch)
+// GetAvailableUpdates returns all available (by default: not installed, not hidden) updates.
+// On WSUS clients each update carries its approval state, and applicable
+// updates that are not approved for this computer are reported as well.
+func (w *WindowsUpdateManager) GetAvailableUpdates() ([]models.Package, error) {
+	w.reportApproval = w.offlineScanCab == "" && w.wsusConfigured()
+	defer func() { w.reportApproval = false }()
+
+	w.logger.Info("Searching for available Windows updates (this may take 30-60 seconds)...")
+	available, err := w.searchUpdates(w.availableCriteria, false)
+	if err != nil || !w.reportApproval {
+		return available, err
+	}
+
+	return w.getUnapprovedUpdates(available), nil
+}
+
+// openSearcher opens a WUA session and creates an update searcher. The
+// returned function releases both and must be called on the same goroutine.
+func (w *WindowsUpdateManager) openSearcher() (UpdateSearcher, func(), error) {
+	session, err := w.newSession()
 	if err != nil {
-		return nil, fmt.Errorf("failed to query UpdateSession interface: %w", err)
+		return nil, nil, err
 	}
-	defer session.Release()
 
-	// Create UpdateSearcher via session.CreateUpdateSearcher()
-	searcherResult, err := oleutil.CallMethod(session, "CreateUpdateSearcher")
+	searcher, err := session.CreateUpdateSearcher()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create UpdateSearcher: %w", err)
+		session.Release()
+		return nil, nil, err
 	}
-	searcher := searcherResult.ToIDispatch()
-	defer searcher.Release()
 
-	// Search for updates matching the criteria
-	w.logger.Debugf("Searching Windows Updates with criteria: %s", criteria)
-	resultVal, err := oleutil.CallMethod(searcher, "Search", criteria)
+	return searcher, func() {
+		searcher.Release()
+		session.Release()
+	}, nil
+}
+
+// getLatestHistoryEntry returns the date of the most recent update history
+// entry (install or uninstall) and the total number of history entries.
+func (w *WindowsUpdateManager) getLatestHistoryEntry() (time.Time, int, error) {
+	searcher, closeSearcher, err := w.openSearcher()
 	if err != nil {
-		return nil, fmt.Errorf("update search failed (criteria=%q): %w", criteria, err)
+		return time.Time{}, 0, err
 	}
-	result := resultVal.ToIDispatch()
-	defer result.Release()
+	defer closeSearcher()
 
-	// Get the Updates collection from the search result
-	updatesVal, err := oleutil.GetProperty(result, "Updates")
+	latest, count, err := searcher.LatestHistoryEntry()
+	return latest, count, decodeWUAError(err)
+}
+
+// searchUpdates queries the Windows Update Agent with the given search criteria.
+// installed controls whether matching updates are reported as installed or pending.
+// Well-known WUA error codes are decoded into descriptive errors.
+func (w *WindowsUpdateManager) searchUpdates(criteria string, installed bool) ([]models.Package, error) {
+	packages, err := w.runSearch(criteria, installed)
+	return packages, decodeWUAError(err)
+}
+
+// runSearch performs the search for searchUpdates
+func (w *WindowsUpdateManager) runSearch(criteria string, installed bool) ([]models.Package, error) {
+	searcher, closeSearcher, err := w.openSearcher()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get Updates collection: %w", err)
+		return nil, err
+	}
+	defer closeSearcher()
+
+	// Point the searcher at the offline scan catalog if one is configured
+	if w.offlineScanCab != "" {
+		if _, err := os.Stat(w.offlineScanCab); err != nil {
+			return nil, fmt.Errorf("offline scan catalog not accessible: %w", err)
+		}
+		w.logger.WithField("path", w.offlineScanCab).Info("Using offline scan catalog for Windows Update search")
+		removeCatalog, err := searcher.UseOfflineScanCatalog(w.offlineScanCab)
+		if err != nil {
+			return nil, err
+		}
+		defer removeCatalog()
 	}
-	updates := updatesVal.ToIDispatch()
-	defer updates.Release()
 
-	// Get the count of updates
-	countVal, err := oleutil.GetProperty(updates, "Count")
+	// Search for updates matching the criteria
+	w.logger.Debugf("Searching Windows Updates with criteria: %s", criteria)
+	searchStart := time.Now()
+	updates, err := searcher.Search(criteria, w.searchTimeout)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get update count: %w", err)
+		return nil, err
 	}
-	count := int(countVal.Val)
+	defer updates.Release()
+	w.logger.Debugf("Windows Update search completed in %s", time.Since(searchStart).Round(time.Millisecond))
 
+	count := updates.Count()
 	w.logger.Debugf("Found %d updates for criteria: %s", count, criteria)
 
 	packages := make([]models.Package, 0, count)
+	filtered := 0
 
 	for i := 0; i < count; i++ {
-		itemVal, err := oleutil.GetProperty(updates, "Item", i)
+		update, err := updates.Item(i)
 		if err != nil {
 			w.logger.Warnf("Failed to get update item %d: %v", i, err)
 			continue
 		}
-		update := itemVal.ToIDispatch()
 
-		pkg := w.parseUpdate(update, criteria)
+		if !categoryAllowed(update.Categories(), w.includeCategories, w.excludeCategories) {
+			filtered++
+			update.Release()
+			continue
+		}
+
+		pkg := w.parseUpdate(update, installed)
 		if pkg != nil {
 			packages = append(packages, *pkg)
 		}
@@ -114,42 +267,64 @@ func (w *WindowsUpdateManager) searchUpdates(criteria string) ([]models.Package,
 		update.Release()
 	}
 
+	if filtered > 0 {
+		w.logger.Debugf("Filtered out %d updates by category", filtered)
+	}
+
 	return packages, nil
 }
 
-// parseUpdate extracts package information from a single IUpdate COM object
-func (w *WindowsUpdateManager) parseUpdate(update *ole.IDispatch, criteria string) *models.Package {
+// normalizeCategories lower-cases and trims category names, dropping empty entries
+func normalizeCategories(categories []string) []string {
+	var normalized []string
+	for _, category := range categories {
+		if category = strings.ToLower(strings.TrimSpace(category)); category != "" {
+			normalized = append(normalized, category)
+		}
+	}
+	return normalized
+}
+
+// categoryAllowed applies the include/exclude category filter to an update's
+// categories. include and exclude must already be normalized.
+func categoryAllowed(categories, include, exclude []string) bool {
+	included := len(include) == 0
+	for _, category := range categories {
+		category = strings.ToLower(category)
+		if slices.Contains(exclude, category) {
+			return false
+		}
+		if !included && slices.Contains(include, category) {
+			included = true
+		}
+	}
+	return included
+}
+
+// parseUpdate extracts package information from a single update
+func (w *WindowsUpdateManager) parseUpdate(update Update, isInstalled bool) *models.Package {
 	// Get Title
-	titleVal, err := oleutil.GetProperty(update, "Title")
+	title, err := update.Title()
 	if err != nil {
 		w.logger.Warn("Failed to get update title")
 		return nil
 	}
-	title := titleVal.ToString()
-
-	// Get KB Article ID
-	kbID := w.getKBArticleID(update)
 
 	// Get Identity for version info
-	version := w.getUpdateVersion(update)
+	version := getUpdateVersion(update)
 
-	// Check if this is a security update
-	isSecurityUpdate := w.isSecurityUpdate(update)
-
-	// Determine name: use KB ID if available, otherwise use title
+	// Determine name: use the first KB ID if available, otherwise use title
 	name := title
-	if kbID != "" {
-		name = "KB" + kbID
+	if kbIDs := update.KBArticleIDs(); len(kbIDs) > 0 && kbIDs[0] != "" {
+		name = "KB" + kbIDs[0]
 	}
 
-	// Determine if this is an installed or available update based on search criteria
-	isInstalled := strings.Contains(criteria, "IsInstalled=1")
-
 	pkg := &models.Package{
 		Name:             name,
 		Description:      title,
 		NeedsUpdate:      !isInstalled,
-		IsSecurityUpdate: isSecurityUpdate,
+		IsSecurityUpdate: isSecurityUpdate(update),
+		IsFeatureUpdate:  isFeatureUpdate(title, update.Categories()),
 	}
 
 	if isInstalled {
@@ -160,91 +335,38 @@ func (w *WindowsUpdateManager) parseUpdate(update *ole.IDispatch, criteria strin
 		// no prior version on this system.
 		pkg.CurrentVersion = "not installed"
 		pkg.AvailableVersion = version
-	}
 
-	return pkg
-}
-
-// getKBArticleID extracts the first KB article ID from an update
-func (w *WindowsUpdateManager) getKBArticleID(update *ole.IDispatch) string {
-	kbIDsVal, err := oleutil.GetProperty(update, "KBArticleIDs")
-	if err != nil {
-		return ""
-	}
-	kbIDs := kbIDsVal.ToIDispatch()
-	defer kbIDs.Release()
-
-	countVal, err := oleutil.GetProperty(kbIDs, "Count")
-	if err != nil || countVal.Val == 0 {
-		return ""
+		if w.reportApproval {
+			approved := isApprovedDeploymentAction(update.DeploymentAction())
+			pkg.WSUSApproved = &approved
+		}
 	}
 
-	itemVal, err := oleutil.GetProperty(kbIDs, "Item", 0)
-	if err != nil {
-		return ""
-	}
-	return itemVal.ToString()
+	return pkg
 }
 
-// getUpdateVersion extracts version information from the update's Identity
-func (w *WindowsUpdateManager) getUpdateVersion(update *ole.IDispatch) string {
-	identityVal, err := oleutil.GetProperty(update, "Identity")
+// getUpdateVersion builds version information from the update's Identity
+func getUpdateVersion(update Update) string {
+	updateID, revision, err := update.Identity()
 	if err != nil {
 		return ""
 	}
-	identity := identityVal.ToIDispatch()
-	defer identity.Release()
-
-	revVal, err := oleutil.GetProperty(identity, "RevisionNumber")
-	if err != nil {
-		return ""
+	if updateID == "" {
+		return fmt.Sprintf("rev.%d", revision)
 	}
-
-	updateIDVal, err := oleutil.GetProperty(identity, "UpdateID")
-	if err != nil {
-		return fmt.Sprintf("rev.%d", revVal.Val)
-	}
-
-	return fmt.Sprintf("%s.%d", updateIDVal.ToString(), revVal.Val)
+	return fmt.Sprintf("%s.%d", updateID, revision)
 }
 
 // isSecurityUpdate determines if an update is security-related by checking
 // MsrcSeverity and Categories
-func (w *WindowsUpdateManager) isSecurityUpdate(update *ole.IDispatch) bool {
+func isSecurityUpdate(update Update) bool {
 	// Check MsrcSeverity first — if it has a value, it's a security update
-	severityVal, err := oleutil.GetProperty(update, "MsrcSeverity")
-	if err == nil && severityVal.ToString() != "" {
+	if update.MsrcSeverity() != "" {
 		return true
 	}
 
 	// Check Categories for "Security Updates" or "Critical Updates"
-	categoriesVal, err := oleutil.GetProperty(update, "Categories")
-	if err != nil {
-		return false
-	}
-	categories := categoriesVal.ToIDispatch()
-	defer categories.Release()
-
-	countVal, err := oleutil.GetProperty(categories, "Count")
-	if err != nil {
-		return false
-	}
-	count := int(countVal.Val)
-
-	for i := 0; i < count; i++ {
-		catVal, err := oleutil.GetProperty(categories, "Item", i)
-		if err != nil {
-			continue
-		}
-		cat := catVal.ToIDispatch()
-
-		nameVal, err := oleutil.GetProperty(cat, "Name")
-		cat.Release()
-		if err != nil {
-			continue
-		}
-
-		catName := nameVal.ToString()
+	for _, catName := range update.Categories() {
 		if catName == "Security Updates" || catName == "Critical Updates" {
 			return true
 		}
@@ -2,6 +2,7 @@ package packages
 
 import (
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -22,6 +23,40 @@ func TestNewWindowsUpdateManager(t *testing.T) {
 	if mgr.logger != logger {
 		t.Error("WindowsUpdateManager logger not set correctly")
 	}
+	if mgr.installedCriteria != DefaultInstalledCriteria || mgr.availableCriteria != DefaultAvailableCriteria {
+		t.Errorf("unexpected default criteria: %q / %q", mgr.installedCriteria, mgr.availableCriteria)
+	}
+}
+
+func TestSetSearchCriteria(t *testing.T) {
+	mgr := NewWindowsUpdateManager(newTestLogger())
+
+	mgr.SetSearchCriteria("", "  ")
+	if mgr.installedCriteria != DefaultInstalledCriteria || mgr.availableCriteria != DefaultAvailableCriteria {
+		t.Errorf("empty criteria should keep defaults, got %q / %q", mgr.installedCriteria, mgr.availableCriteria)
+	}
+
+	mgr.SetSearchCriteria("IsInstalled=1 AND Type='Software'", "IsInstalled=0")
+	if mgr.installedCriteria != "IsInstalled=1 AND Type='Software'" {
+		t.Errorf("installed criteria not overridden: %q", mgr.installedCriteria)
+	}
+	if mgr.availableCriteria != "IsInstalled=0" {
+		t.Errorf("available criteria not overridden: %q", mgr.availableCriteria)
+	}
+}
+
+func TestSetSearchTimeout(t *testing.T) {
+	mgr := NewWindowsUpdateManager(newTestLogger())
+
+	mgr.SetSearchTimeout(0)
+	if mgr.searchTimeout != DefaultSearchTimeout {
+		t.Errorf("zero timeout should keep default, got %s", mgr.searchTimeout)
+	}
+
+	mgr.SetSearchTimeout(90 * time.Second)
+	if mgr.searchTimeout != 90*time.Second {
+		t.Errorf("timeout not overridden: %s", mgr.searchTimeout)
+	}
 }
 
 // TestParseUpdateInstalledCriteria verifies that parseUpdate correctly sets
@@ -95,7 +130,7 @@ func TestSearchUpdates_InvalidCriteria(t *testing.T) {
 	logger := newTestLogger()
 	mgr := NewWindowsUpdateManager(logger)
 
-	_, err := mgr.searchUpdates("InvalidCriteria=BOGUS")
+	_, err := mgr.searchUpdates("InvalidCriteria=BOGUS", false)
 	if err == nil {
 		t.Error("Expected error for invalid search criteria, got nil")
 	} else {
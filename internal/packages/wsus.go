@@ -0,0 +1,111 @@
+package packages
+
+import (
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+
+	"patchmon-agent/pkg/models"
+)
+
+// IUpdate.DeploymentAction values
+const (
+	deploymentActionNone                 = 0
+	deploymentActionInstallation         = 1
+	deploymentActionUninstallation       = 2
+	deploymentActionDetection            = 3
+	deploymentActionOptionalInstallation = 4
+)
+
+// wsusDetectionCriteria is appended to the available criteria to find updates
+// that WSUS knows about but has not approved for this computer's target group
+const wsusDetectionCriteria = "DeploymentAction='Detection'"
+
+const (
+	windowsUpdatePolicyKey   = `SOFTWARE\Policies\Microsoft\Windows\WindowsUpdate`
+	windowsUpdateAUPolicyKey = windowsUpdatePolicyKey + `\AU`
+)
+
+// isWSUSConfigured reports whether Group Policy points Windows Update at a
+// WSUS server (WUServer set and UseWUServer enabled)
+func isWSUSConfigured() bool {
+	return WSUSServer() != ""
+}
+
+// WSUSServer returns the WSUS server URL Windows Update uses, or "" if Group
+// Policy does not point it at a WSUS server
+func WSUSServer() string {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, windowsUpdatePolicyKey, registry.QUERY_VALUE)
+	if err != nil {
+		return ""
+	}
+	server, _, err := key.GetStringValue("WUServer")
+	key.Close()
+	server = strings.TrimSpace(server)
+	if err != nil || server == "" {
+		return ""
+	}
+
+	auKey, err := registry.OpenKey(registry.LOCAL_MACHINE, windowsUpdateAUPolicyKey, registry.QUERY_VALUE)
+	if err != nil {
+		return ""
+	}
+	defer auKey.Close()
+
+	if useWUServer, _, err := auKey.GetIntegerValue("UseWUServer"); err != nil || useWUServer != 1 {
+		return ""
+	}
+	return server
+}
+
+// isApprovedDeploymentAction reports whether a WUA deployment action means the
+// update is approved for installation. Unapproved updates are only detected.
+func isApprovedDeploymentAction(action int) bool {
+	return action != deploymentActionDetection && action != deploymentActionNone
+}
+
+// wsusDetectionSearchCriteria returns the criteria used to find unapproved
+// updates, or "" if criteria cannot be safely extended (it already filters on
+// DeploymentAction, or uses OR, which WUA only allows at the top level)
+func wsusDetectionSearchCriteria(criteria string) string {
+	upper := strings.ToUpper(criteria)
+	if strings.Contains(upper, "DEPLOYMENTACTION") || strings.Contains(upper, " OR ") {
+		return ""
+	}
+	return criteria + " AND " + wsusDetectionCriteria
+}
+
+// getUnapprovedUpdates searches for updates that are applicable but not
+// approved on the WSUS server and appends those not already in approved
+func (w *WindowsUpdateManager) getUnapprovedUpdates(approved []models.Package) []models.Package {
+	criteria := wsusDetectionSearchCriteria(w.availableCriteria)
+	if criteria == "" {
+		w.logger.Debug("Custom available criteria cannot be extended, skipping search for unapproved WSUS updates")
+		return approved
+	}
+
+	w.logger.Info("Searching for updates not approved on the WSUS server...")
+	unapproved, err := w.searchUpdates(criteria, false)
+	if err != nil {
+		w.logger.WithError(err).Warn("Failed to search for unapproved WSUS updates")
+		return approved
+	}
+
+	seen := make(map[string]bool, len(approved))
+	for _, pkg := range approved {
+		seen[pkg.Name] = true
+	}
+
+	added := 0
+	for _, pkg := range unapproved {
+		if seen[pkg.Name] {
+			continue
+		}
+		seen[pkg.Name] = true
+		approved = append(approved, pkg)
+		added++
+	}
+	w.logger.WithField("count", added).Debug("Found updates not approved on the WSUS server")
+
+	return approved
+}
@@ -0,0 +1,70 @@
+package packages
+
+import "testing"
+
+func TestWSUSDetectionSearchCriteria(t *testing.T) {
+	tests := []struct {
+		criteria string
+		want     string
+	}{
+		{DefaultAvailableCriteria, DefaultAvailableCriteria + " AND DeploymentAction='Detection'"},
+		{"IsInstalled=0 AND DeploymentAction='Installation'", ""},
+		{"IsInstalled=0 AND Type='Software' OR IsInstalled=0 AND Type='Driver'", ""},
+	}
+
+	for _, tt := range tests {
+		if got := wsusDetectionSearchCriteria(tt.criteria); got != tt.want {
+			t.Errorf("wsusDetectionSearchCriteria(%q) = %q, want %q", tt.criteria, got, tt.want)
+		}
+	}
+}
+
+func TestGetAvailableUpdates_FakeWSUSApproval(t *testing.T) {
+	detectionCriteria := wsusDetectionSearchCriteria(DefaultAvailableCriteria)
+	searcher := &fakeSearcher{results: map[string][]*fakeUpdate{
+		DefaultAvailableCriteria: {
+			{title: "Cumulative Update (KB5035853)", kbIDs: []string{"5035853"}, action: deploymentActionInstallation},
+		},
+		detectionCriteria: {
+			{title: "Cumulative Update (KB5035853)", kbIDs: []string{"5035853"}, action: deploymentActionDetection},
+			{title: ".NET Framework Update (KB5034467)", kbIDs: []string{"5034467"}, action: deploymentActionDetection},
+		},
+	}}
+	mgr := newFakeManager(searcher)
+	mgr.wsusConfigured = func() bool { return true }
+
+	packages, err := mgr.GetAvailableUpdates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packages) != 2 {
+		t.Fatalf("expected approved and unapproved updates without duplicates, got %+v", packages)
+	}
+	if packages[0].WSUSApproved == nil || !*packages[0].WSUSApproved {
+		t.Errorf("expected KB5035853 to be approved: %+v", packages[0])
+	}
+	if packages[1].Name != "KB5034467" || packages[1].WSUSApproved == nil || *packages[1].WSUSApproved {
+		t.Errorf("expected KB5034467 to be unapproved: %+v", packages[1])
+	}
+	if len(searcher.searches) != 2 || searcher.searches[1] != detectionCriteria {
+		t.Errorf("unexpected searches: %v", searcher.searches)
+	}
+}
+
+func TestGetAvailableUpdates_FakeNoWSUS(t *testing.T) {
+	searcher := &fakeSearcher{results: map[string][]*fakeUpdate{
+		DefaultAvailableCriteria: {{title: "Update (KB1)", kbIDs: []string{"1"}, action: deploymentActionInstallation}},
+	}}
+	mgr := newFakeManager(searcher)
+
+	packages, err := mgr.GetAvailableUpdates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packages) != 1 || packages[0].WSUSApproved != nil {
+		t.Errorf("approval state should only be reported on WSUS clients: %+v", packages)
+	}
+	if len(searcher.searches) != 1 {
+		t.Errorf("expected a single search without WSUS, got %v", searcher.searches)
+	}
+}
@@ -0,0 +1,482 @@
+package packages
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+
+	ole "github.com/go-ole/go-ole"
+	"github.com/go-ole/go-ole/oleutil"
+	"github.com/sirupsen/logrus"
+)
+
+// UpdateSession is the subset of the WUA IUpdateSession API used by the agent.
+// The search logic only talks to these interfaces so it can be unit-tested
+// with fakes instead of a live Windows Update service.
+type UpdateSession interface {
+	CreateUpdateSearcher() (UpdateSearcher, error)
+	CreateUpdateInstaller() (UpdateInstaller, error)
+	Release()
+}
+
+// UpdateSearcher wraps IUpdateSearcher
+type UpdateSearcher interface {
+	// Search runs a search for criteria, aborting it if it exceeds timeout
+	Search(criteria string, timeout time.Duration) (UpdateCollection, error)
+	// UseOfflineScanCatalog points the searcher at a wsusscn2.cab catalog. The
+	// returned function unregisters the catalog and must be called after searching.
+	UseOfflineScanCatalog(cabPath string) (func(), error)
+	// LatestHistoryEntry returns the date of the newest update history entry
+	// and the total number of history entries
+	LatestHistoryEntry() (time.Time, int, error)
+	Release()
+}
+
+// UpdateInstaller wraps IUpdateDownloader and IUpdateInstaller
+type UpdateInstaller interface {
+	// Download downloads updates into the WUA cache, reporting progress as it advances
+	Download(updates []Update, progress ProgressFunc) ([]OperationResult, error)
+	// Install installs updates that have already been downloaded
+	Install(updates []Update, progress ProgressFunc) ([]OperationResult, error)
+	Release()
+}
+
+// ProgressFunc receives the index of the update currently being processed
+// and how far along (0-100) that update is
+type ProgressFunc func(index, percent int)
+
+// OperationResult is the outcome of downloading or installing a single update
+type OperationResult struct {
+	ResultCode     int // WUA OperationResultCode (see operationResult* constants)
+	HResult        int32
+	RebootRequired bool
+}
+
+// UpdateCollection wraps IUpdateCollection
+type UpdateCollection interface {
+	Count() int
+	Item(index int) (Update, error)
+	Release()
+}
+
+// Update wraps IUpdate
+type Update interface {
+	Title() (string, error)
+	KBArticleIDs() []string
+	Identity() (updateID string, revision int, err error)
+	MsrcSeverity() string
+	Categories() []string
+	// DeploymentAction returns IUpdate.DeploymentAction (see deploymentAction* constants)
+	DeploymentAction() int
+	IsHidden() bool
+	SetHidden(hidden bool) error
+	Release()
+}
+
+// comUpdateSession implements UpdateSession on top of Microsoft.Update.Session
+type comUpdateSession struct {
+	logger   *logrus.Logger
+	dispatch *ole.IDispatch
+	close    func()
+}
+
+// newCOMUpdateSession initialises COM on the current (locked) OS thread and
+// creates a Microsoft.Update.Session. All calls on the session and the objects
+// it creates, including Release, must happen on the same goroutine.
+func newCOMUpdateSession(logger *logrus.Logger) (UpdateSession, error) {
+	// COM must be initialized on the same OS thread
+	runtime.LockOSThread()
+
+	err := ole.CoInitializeEx(0, ole.COINIT_APARTMENTTHREADED)
+	if err != nil {
+		// S_FALSE (0x00000001) means COM is already initialized on this thread — that's OK
+		oleErr, ok := err.(*ole.OleError)
+		if !ok || oleErr.Code() != 0x00000001 {
+			runtime.UnlockOSThread()
+			return nil, fmt.Errorf("COM initialization failed: %w", err)
+		}
+	}
+
+	// Create Microsoft.Update.Session
+	unknown, err := oleutil.CreateObject("Microsoft.Update.Session")
+	if err != nil {
+		ole.CoUninitialize()
+		runtime.UnlockOSThread()
+		return nil, fmt.Errorf("failed to create UpdateSession: %w", err)
+	}
+
+	session, err := unknown.QueryInterface(ole.IID_IDispatch)
+	unknown.Release()
+	if err != nil {
+		ole.CoUninitialize()
+		runtime.UnlockOSThread()
+		return nil, fmt.Errorf("failed to query UpdateSession interface: %w", err)
+	}
+
+	return &comUpdateSession{
+		logger:   logger,
+		dispatch: session,
+		close: func() {
+			session.Release()
+			ole.CoUninitialize()
+			runtime.UnlockOSThread()
+		},
+	}, nil
+}
+
+// CreateUpdateSearcher calls IUpdateSession.CreateUpdateSearcher
+func (s *comUpdateSession) CreateUpdateSearcher() (UpdateSearcher, error) {
+	searcherResult, err := oleutil.CallMethod(s.dispatch, "CreateUpdateSearcher")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create UpdateSearcher: %w", err)
+	}
+	return &comUpdateSearcher{logger: s.logger, dispatch: searcherResult.ToIDispatch()}, nil
+}
+
+// Release releases the session and uninitialises COM
+func (s *comUpdateSession) Release() {
+	s.close()
+}
+
+// comUpdateSearcher implements UpdateSearcher on top of IUpdateSearcher
+type comUpdateSearcher struct {
+	logger   *logrus.Logger
+	dispatch *ole.IDispatch
+}
+
+// Search runs an asynchronous search (BeginSearch/EndSearch) so that a
+// stuck WUA datastore cannot hang the agent. If the search does not complete
+// within the timeout it is aborted and ErrSearchTimeout is returned.
+func (s *comUpdateSearcher) Search(criteria string, timeout time.Duration) (UpdateCollection, error) {
+	// No completion callback or state: completion is detected by polling the job
+	jobVal, err := oleutil.CallMethod(s.dispatch, "BeginSearch", criteria, (*ole.IDispatch)(nil), nil)
+	if err != nil {
+		return nil, fmt.Errorf("update search failed (criteria=%q): %w", criteria, err)
+	}
+	job := jobVal.ToIDispatch()
+	defer job.Release()
+	defer func() {
+		_, _ = oleutil.CallMethod(job, "CleanUp")
+	}()
+
+	if !s.waitForSearchJob(job, criteria, timeout) {
+		s.logger.WithField("timeout", timeout).Warn("Windows Update search timed out, aborting")
+		if _, err := oleutil.CallMethod(job, "RequestAbort"); err != nil {
+			s.logger.WithError(err).Debug("Failed to request search abort")
+		}
+		// EndSearch must still be called to release the job once WUA has stopped
+		if s.waitForSearchJob(job, criteria, searchAbortGrace) {
+			if resultVal, err := oleutil.CallMethod(s.dispatch, "EndSearch", job); err == nil {
+				resultVal.ToIDispatch().Release()
+			}
+		}
+		return nil, fmt.Errorf("%w after %s (criteria=%q)", ErrSearchTimeout, timeout, criteria)
+	}
+
+	resultVal, err := oleutil.CallMethod(s.dispatch, "EndSearch", job)
+	if err != nil {
+		return nil, fmt.Errorf("update search failed (criteria=%q): %w", criteria, err)
+	}
+	result := resultVal.ToIDispatch()
+	defer result.Release()
+
+	// Get the Updates collection from the search result
+	updatesVal, err := oleutil.GetProperty(result, "Updates")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Updates collection: %w", err)
+	}
+	return &comUpdateCollection{dispatch: updatesVal.ToIDispatch()}, nil
+}
+
+// waitForSearchJob polls an ISearchJob until it completes or the timeout elapses,
+// logging the elapsed time periodically. WUA does not report search progress
+// as a percentage, so elapsed time against the timeout is the best indication.
+// It returns true if the job completed.
+func (s *comUpdateSearcher) waitForSearchJob(job *ole.IDispatch, criteria string, timeout time.Duration) bool {
+	start := time.Now()
+	deadline := start.Add(timeout)
+	nextProgress := start.Add(searchProgressInterval)
+	for {
+		completedVal, err := oleutil.GetProperty(job, "IsCompleted")
+		if err != nil {
+			// Let EndSearch report the underlying failure
+			return true
+		}
+		if completed, ok := completedVal.Value().(bool); ok && completed {
+			return true
+		}
+		now := time.Now()
+		if now.After(deadline) {
+			return false
+		}
+		if now.After(nextProgress) {
+			s.logger.WithFields(logrus.Fields{
+				"criteria": criteria,
+				"elapsed":  now.Sub(start).Round(time.Second).String(),
+				"timeout":  timeout.String(),
+			}).Info("Windows Update search still running...")
+			nextProgress = now.Add(searchProgressInterval)
+		}
+		time.Sleep(searchPollInterval)
+	}
+}
+
+// UseOfflineScanCatalog registers a wsusscn2.cab with the WUA service manager
+// and points the searcher at it
+func (s *comUpdateSearcher) UseOfflineScanCatalog(cabPath string) (func(), error) {
+	unknown, err := oleutil.CreateObject("Microsoft.Update.ServiceManager")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create UpdateServiceManager: %w", err)
+	}
+	serviceManager, err := unknown.QueryInterface(ole.IID_IDispatch)
+	unknown.Release()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query UpdateServiceManager interface: %w", err)
+	}
+
+	serviceVal, err := oleutil.CallMethod(serviceManager, "AddScanPackageService", offlineScanServiceName, cabPath)
+	if err != nil {
+		serviceManager.Release()
+		return nil, fmt.Errorf("failed to register offline scan catalog %q: %w", cabPath, err)
+	}
+	service := serviceVal.ToIDispatch()
+	defer service.Release()
+
+	serviceIDVal, err := oleutil.GetProperty(service, "ServiceID")
+	if err != nil {
+		serviceManager.Release()
+		return nil, fmt.Errorf("failed to get offline scan service ID: %w", err)
+	}
+	serviceID := serviceIDVal.ToString()
+
+	removeService := func() {
+		if _, err := oleutil.CallMethod(serviceManager, "RemoveService", serviceID); err != nil {
+			s.logger.WithError(err).Debug("Failed to remove offline scan service")
+		}
+		serviceManager.Release()
+	}
+
+	if _, err := oleutil.PutProperty(s.dispatch, "ServerSelection", serverSelectionOthers); err != nil {
+		removeService()
+		return nil, fmt.Errorf("failed to set searcher ServerSelection: %w", err)
+	}
+	if _, err := oleutil.PutProperty(s.dispatch, "ServiceID", serviceID); err != nil {
+		removeService()
+		return nil, fmt.Errorf("failed to set searcher ServiceID: %w", err)
+	}
+
+	return removeService, nil
+}
+
+// LatestHistoryEntry reads the newest entry of the WUA update history
+func (s *comUpdateSearcher) LatestHistoryEntry() (time.Time, int, error) {
+	countVal, err := oleutil.CallMethod(s.dispatch, "GetTotalHistoryCount")
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("failed to get update history count: %w", err)
+	}
+	count := int(countVal.Val)
+	if count == 0 {
+		return time.Time{}, 0, nil
+	}
+
+	// History entries are returned newest first
+	historyVal, err := oleutil.CallMethod(s.dispatch, "QueryHistory", 0, 1)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("failed to query update history: %w", err)
+	}
+	history := historyVal.ToIDispatch()
+	defer history.Release()
+
+	entryVal, err := oleutil.GetProperty(history, "Item", 0)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("failed to get update history entry: %w", err)
+	}
+	entry := entryVal.ToIDispatch()
+	defer entry.Release()
+
+	dateVal, err := oleutil.GetProperty(entry, "Date")
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("failed to get update history date: %w", err)
+	}
+	date, ok := dateVal.Value().(time.Time)
+	if !ok {
+		return time.Time{}, 0, fmt.Errorf("unexpected update history date type %T", dateVal.Value())
+	}
+
+	return date, count, nil
+}
+
+// Release releases the searcher
+func (s *comUpdateSearcher) Release() {
+	s.dispatch.Release()
+}
+
+// comUpdateCollection implements UpdateCollection on top of IUpdateCollection
+type comUpdateCollection struct {
+	dispatch *ole.IDispatch
+}
+
+// Count returns the number of updates in the collection
+func (c *comUpdateCollection) Count() int {
+	countVal, err := oleutil.GetProperty(c.dispatch, "Count")
+	if err != nil {
+		return 0
+	}
+	return int(countVal.Val)
+}
+
+// Item returns the update at index
+func (c *comUpdateCollection) Item(index int) (Update, error) {
+	itemVal, err := oleutil.GetProperty(c.dispatch, "Item", index)
+	if err != nil {
+		return nil, err
+	}
+	return &comUpdate{dispatch: itemVal.ToIDispatch()}, nil
+}
+
+// Release releases the collection
+func (c *comUpdateCollection) Release() {
+	c.dispatch.Release()
+}
+
+// comUpdate implements Update on top of IUpdate
+type comUpdate struct {
+	dispatch *ole.IDispatch
+}
+
+// Title returns IUpdate.Title
+func (u *comUpdate) Title() (string, error) {
+	titleVal, err := oleutil.GetProperty(u.dispatch, "Title")
+	if err != nil {
+		return "", err
+	}
+	return titleVal.ToString(), nil
+}
+
+// KBArticleIDs returns IUpdate.KBArticleIDs
+func (u *comUpdate) KBArticleIDs() []string {
+	return stringCollection(u.dispatch, "KBArticleIDs")
+}
+
+// Identity returns the UpdateID and RevisionNumber of IUpdate.Identity
+func (u *comUpdate) Identity() (string, int, error) {
+	identityVal, err := oleutil.GetProperty(u.dispatch, "Identity")
+	if err != nil {
+		return "", 0, err
+	}
+	identity := identityVal.ToIDispatch()
+	defer identity.Release()
+
+	revVal, err := oleutil.GetProperty(identity, "RevisionNumber")
+	if err != nil {
+		return "", 0, err
+	}
+
+	updateIDVal, err := oleutil.GetProperty(identity, "UpdateID")
+	if err != nil {
+		return "", int(revVal.Val), nil
+	}
+
+	return updateIDVal.ToString(), int(revVal.Val), nil
+}
+
+// MsrcSeverity returns IUpdate.MsrcSeverity, or "" if not set
+func (u *comUpdate) MsrcSeverity() string {
+	severityVal, err := oleutil.GetProperty(u.dispatch, "MsrcSeverity")
+	if err != nil {
+		return ""
+	}
+	return severityVal.ToString()
+}
+
+// Categories returns the names of the update's categories
+func (u *comUpdate) Categories() []string {
+	categoriesVal, err := oleutil.GetProperty(u.dispatch, "Categories")
+	if err != nil {
+		return nil
+	}
+	categories := categoriesVal.ToIDispatch()
+	defer categories.Release()
+
+	countVal, err := oleutil.GetProperty(categories, "Count")
+	if err != nil {
+		return nil
+	}
+	count := int(countVal.Val)
+
+	names := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		catVal, err := oleutil.GetProperty(categories, "Item", i)
+		if err != nil {
+			continue
+		}
+		cat := catVal.ToIDispatch()
+
+		nameVal, err := oleutil.GetProperty(cat, "Name")
+		cat.Release()
+		if err != nil {
+			continue
+		}
+		names = append(names, nameVal.ToString())
+	}
+
+	return names
+}
+
+// DeploymentAction returns IUpdate.DeploymentAction, or 0 (none) if unavailable
+func (u *comUpdate) DeploymentAction() int {
+	actionVal, err := oleutil.GetProperty(u.dispatch, "DeploymentAction")
+	if err != nil {
+		return deploymentActionNone
+	}
+	return int(actionVal.Val)
+}
+
+// IsHidden returns IUpdate.IsHidden
+func (u *comUpdate) IsHidden() bool {
+	hiddenVal, err := oleutil.GetProperty(u.dispatch, "IsHidden")
+	if err != nil {
+		return false
+	}
+	hidden, _ := hiddenVal.Value().(bool)
+	return hidden
+}
+
+// SetHidden sets IUpdate.IsHidden. Mandatory updates cannot be hidden.
+func (u *comUpdate) SetHidden(hidden bool) error {
+	if _, err := oleutil.PutProperty(u.dispatch, "IsHidden", hidden); err != nil {
+		return fmt.Errorf("failed to set IsHidden: %w", err)
+	}
+	return nil
+}
+
+// Release releases the update
+func (u *comUpdate) Release() {
+	u.dispatch.Release()
+}
+
+// stringCollection reads a WUA IStringCollection property into a slice
+func stringCollection(dispatch *ole.IDispatch, property string) []string {
+	collectionVal, err := oleutil.GetProperty(dispatch, property)
+	if err != nil {
+		return nil
+	}
+	collection := collectionVal.ToIDispatch()
+	defer collection.Release()
+
+	countVal, err := oleutil.GetProperty(collection, "Count")
+	if err != nil {
+		return nil
+	}
+	count := int(countVal.Val)
+
+	values := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		itemVal, err := oleutil.GetProperty(collection, "Item", i)
+		if err != nil {
+			continue
+		}
+		values = append(values, itemVal.ToString())
+	}
+	return values
+}
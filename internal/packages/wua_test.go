@@ -0,0 +1,290 @@
+package packages
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeUpdate is an in-memory Update
+type fakeUpdate struct {
+	title      string
+	titleErr   error
+	kbIDs      []string
+	updateID   string
+	revision   int
+	severity   string
+	categories []string
+	action     int
+	hidden     bool
+	hideErr    error
+	released   bool
+}
+
+func (u *fakeUpdate) Title() (string, error) { return u.title, u.titleErr }
+func (u *fakeUpdate) KBArticleIDs() []string { return u.kbIDs }
+func (u *fakeUpdate) Identity() (string, int, error) {
+	return u.updateID, u.revision, nil
+}
+func (u *fakeUpdate) MsrcSeverity() string  { return u.severity }
+func (u *fakeUpdate) Categories() []string  { return u.categories }
+func (u *fakeUpdate) DeploymentAction() int { return u.action }
+func (u *fakeUpdate) IsHidden() bool        { return u.hidden }
+func (u *fakeUpdate) SetHidden(hidden bool) error {
+	if u.hideErr != nil {
+		return u.hideErr
+	}
+	u.hidden = hidden
+	return nil
+}
+func (u *fakeUpdate) Release() { u.released = true }
+
+// fakeUpdateCollection is an in-memory UpdateCollection
+type fakeUpdateCollection struct {
+	updates []*fakeUpdate
+}
+
+func (c *fakeUpdateCollection) Count() int { return len(c.updates) }
+func (c *fakeUpdateCollection) Item(index int) (Update, error) {
+	return c.updates[index], nil
+}
+func (c *fakeUpdateCollection) Release() {}
+
+// fakeSearcher is an in-memory UpdateSearcher that returns canned results per criteria
+type fakeSearcher struct {
+	results        map[string][]*fakeUpdate
+	searchErr      error
+	searches       []string
+	offlineCab     string
+	catalogRemoved bool
+	historyDate    time.Time
+	historyCount   int
+}
+
+func (s *fakeSearcher) Search(criteria string, timeout time.Duration) (UpdateCollection, error) {
+	s.searches = append(s.searches, criteria)
+	if s.searchErr != nil {
+		return nil, s.searchErr
+	}
+	return &fakeUpdateCollection{updates: s.results[criteria]}, nil
+}
+
+func (s *fakeSearcher) UseOfflineScanCatalog(cabPath string) (func(), error) {
+	s.offlineCab = cabPath
+	return func() { s.catalogRemoved = true }, nil
+}
+
+func (s *fakeSearcher) LatestHistoryEntry() (time.Time, int, error) {
+	return s.historyDate, s.historyCount, nil
+}
+
+func (s *fakeSearcher) Release() {}
+
+// fakeSession is an in-memory UpdateSession handing out a single searcher and installer
+type fakeSession struct {
+	searcher  *fakeSearcher
+	installer *fakeInstaller
+}
+
+func (s *fakeSession) CreateUpdateSearcher() (UpdateSearcher, error) { return s.searcher, nil }
+func (s *fakeSession) CreateUpdateInstaller() (UpdateInstaller, error) {
+	if s.installer == nil {
+		return nil, errors.New("no installer")
+	}
+	return s.installer, nil
+}
+func (s *fakeSession) Release() {}
+
+// newFakeManager returns a WindowsUpdateManager backed by searcher
+func newFakeManager(searcher *fakeSearcher) *WindowsUpdateManager {
+	return newFakeInstallManager(searcher, nil)
+}
+
+// newFakeInstallManager returns a WindowsUpdateManager backed by searcher and installer
+func newFakeInstallManager(searcher *fakeSearcher, installer *fakeInstaller) *WindowsUpdateManager {
+	mgr := NewWindowsUpdateManager(newTestLogger())
+	mgr.newSession = func() (UpdateSession, error) {
+		return &fakeSession{searcher: searcher, installer: installer}, nil
+	}
+	mgr.wsusConfigured = func() bool { return false }
+	return mgr
+}
+
+func TestSearchUpdates_Fake(t *testing.T) {
+	security := &fakeUpdate{
+		title:    "2024-03 Cumulative Update for Windows 11 (KB5035853)",
+		kbIDs:    []string{"5035853"},
+		updateID: "abc",
+		revision: 1,
+		severity: "Critical",
+	}
+	noKB := &fakeUpdate{
+		title:      "Intel - System - 10.1.1.44",
+		updateID:   "def",
+		revision:   200,
+		categories: []string{"Drivers"},
+	}
+	critical := &fakeUpdate{
+		title:      "Servicing Stack Update (KB5034232)",
+		kbIDs:      []string{"5034232"},
+		updateID:   "ghi",
+		revision:   2,
+		categories: []string{"Critical Updates"},
+	}
+	broken := &fakeUpdate{titleErr: errors.New("boom")}
+
+	searcher := &fakeSearcher{results: map[string][]*fakeUpdate{
+		DefaultAvailableCriteria: {security, noKB, critical, broken},
+	}}
+	mgr := newFakeManager(searcher)
+
+	packages, err := mgr.GetAvailableUpdates()
+	if err != nil {
+		t.Fatalf("GetAvailableUpdates failed: %v", err)
+	}
+	if len(packages) != 3 {
+		t.Fatalf("expected 3 packages (broken update skipped), got %d: %+v", len(packages), packages)
+	}
+
+	if packages[0].Name != "KB5035853" || !packages[0].IsSecurityUpdate || !packages[0].NeedsUpdate {
+		t.Errorf("unexpected security update: %+v", packages[0])
+	}
+	if packages[0].CurrentVersion != "not installed" || packages[0].AvailableVersion != "abc.1" {
+		t.Errorf("unexpected versions for available update: %+v", packages[0])
+	}
+	if packages[1].Name != noKB.title || packages[1].IsSecurityUpdate {
+		t.Errorf("update without KB should be named by title and not be security: %+v", packages[1])
+	}
+	if !packages[2].IsSecurityUpdate {
+		t.Errorf("Critical Updates category should count as security: %+v", packages[2])
+	}
+
+	for _, u := range []*fakeUpdate{security, noKB, critical, broken} {
+		if !u.released {
+			t.Errorf("update %q was not released", u.title)
+		}
+	}
+}
+
+func TestSearchUpdates_FakeInstalled(t *testing.T) {
+	searcher := &fakeSearcher{results: map[string][]*fakeUpdate{
+		DefaultInstalledCriteria: {{title: "Update (KB1)", kbIDs: []string{"1"}, updateID: "x", revision: 3}},
+	}}
+	mgr := newFakeManager(searcher)
+
+	packages, err := mgr.GetInstalledUpdates()
+	if err != nil {
+		t.Fatalf("GetInstalledUpdates failed: %v", err)
+	}
+	if len(packages) != 1 || packages[0].NeedsUpdate || packages[0].CurrentVersion != "x.3" || packages[0].AvailableVersion != "" {
+		t.Errorf("unexpected installed packages: %+v", packages)
+	}
+}
+
+func TestSearchUpdates_FakeSearchError(t *testing.T) {
+	searcher := &fakeSearcher{searchErr: ErrSearchTimeout}
+	mgr := newFakeManager(searcher)
+
+	if _, err := mgr.GetAvailableUpdates(); !errors.Is(err, ErrSearchTimeout) {
+		t.Errorf("expected ErrSearchTimeout, got %v", err)
+	}
+}
+
+func TestSearchUpdates_FakeOfflineCatalog(t *testing.T) {
+	cab := filepath.Join(t.TempDir(), "wsusscn2.cab")
+	writeTestFile(t, cab, "not a real cab")
+
+	searcher := &fakeSearcher{}
+	mgr := newFakeManager(searcher)
+	mgr.offlineScanCab = cab
+
+	if _, err := mgr.GetAvailableUpdates(); err != nil {
+		t.Fatalf("GetAvailableUpdates failed: %v", err)
+	}
+	if searcher.offlineCab != cab || !searcher.catalogRemoved {
+		t.Errorf("offline catalog not registered/removed: %+v", searcher)
+	}
+
+	mgr.offlineScanCab = filepath.Join(t.TempDir(), "missing.cab")
+	if _, err := mgr.GetAvailableUpdates(); err == nil {
+		t.Error("expected error for missing offline catalog")
+	}
+}
+
+func TestGetInstalledUpdates_FakeCache(t *testing.T) {
+	searcher := &fakeSearcher{
+		results: map[string][]*fakeUpdate{
+			DefaultInstalledCriteria: {{title: "Update (KB1)", kbIDs: []string{"1"}, updateID: "x", revision: 1}},
+		},
+		historyDate:  time.Date(2024, 3, 12, 18, 0, 0, 0, time.UTC),
+		historyCount: 10,
+	}
+	mgr := newFakeManager(searcher)
+	mgr.installedCachePath = filepath.Join(t.TempDir(), "state.db")
+
+	for i := 0; i < 2; i++ {
+		packages, err := mgr.GetInstalledUpdates()
+		if err != nil || len(packages) != 1 {
+			t.Fatalf("run %d: unexpected result %+v, %v", i, packages, err)
+		}
+	}
+	if len(searcher.searches) != 1 {
+		t.Errorf("expected the second run to use the cache, got %d searches", len(searcher.searches))
+	}
+
+	// A new history entry invalidates the cache
+	searcher.historyCount++
+	if _, err := mgr.GetInstalledUpdates(); err != nil {
+		t.Fatal(err)
+	}
+	if len(searcher.searches) != 2 {
+		t.Errorf("expected a new search after the history changed, got %d searches", len(searcher.searches))
+	}
+}
+
+func TestCategoryAllowed(t *testing.T) {
+	tests := []struct {
+		name       string
+		categories []string
+		include    []string
+		exclude    []string
+		want       bool
+	}{
+		{"no filter", []string{"Drivers"}, nil, nil, true},
+		{"excluded", []string{"Windows 11", "Definition Updates"}, nil, []string{"Definition Updates"}, false},
+		{"exclude is case-insensitive", []string{"Definition Updates"}, nil, []string{"definition updates"}, false},
+		{"included", []string{"Windows 11", "Security Updates"}, []string{"Security Updates", "Critical Updates"}, nil, true},
+		{"not included", []string{"Feature Packs"}, []string{"Security Updates"}, nil, false},
+		{"exclude wins over include", []string{"Security Updates", "Upgrades"}, []string{"Security Updates"}, []string{"Upgrades"}, false},
+		{"no categories with include", nil, []string{"Security Updates"}, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := categoryAllowed(tt.categories, normalizeCategories(tt.include), normalizeCategories(tt.exclude))
+			if got != tt.want {
+				t.Errorf("categoryAllowed(%v, %v, %v) = %v, want %v", tt.categories, tt.include, tt.exclude, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchUpdates_FakeCategoryFilter(t *testing.T) {
+	searcher := &fakeSearcher{results: map[string][]*fakeUpdate{
+		DefaultAvailableCriteria: {
+			{title: "Defender definitions (KB2267602)", kbIDs: []string{"2267602"}, categories: []string{"Definition Updates"}},
+			{title: "Cumulative Update (KB5035853)", kbIDs: []string{"5035853"}, categories: []string{"Security Updates"}},
+		},
+	}}
+	mgr := newFakeManager(searcher)
+	mgr.SetCategoryFilter(nil, []string{"Definition Updates"})
+
+	packages, err := mgr.GetAvailableUpdates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packages) != 1 || packages[0].Name != "KB5035853" {
+		t.Errorf("unexpected packages after category filter: %+v", packages)
+	}
+}
@@ -0,0 +1,122 @@
+package packages
+
+import (
+	"errors"
+	"fmt"
+
+	ole "github.com/go-ole/go-ole"
+)
+
+// wuaErrorInfo describes a well-known Windows Update Agent HRESULT
+type wuaErrorInfo struct {
+	Name        string
+	Description string
+	Hint        string
+}
+
+// wuaErrors maps common WUA / WinHTTP HRESULTs to descriptions and remediation hints
+var wuaErrors = map[uint32]wuaErrorInfo{
+	0x80240FFF: {"WU_E_UNEXPECTED", "Unexpected Windows Update error",
+		"often caused by WSUS offering an unsupported category (e.g. Upgrades) or a corrupt datastore; run 'patchmon-agent diagnostics' and reset the Windows Update components if it persists"},
+	0x80240032: {"WU_E_INVALID_CRITERIA", "The search criteria string was invalid",
+		"check wua_installed_criteria / wua_available_criteria in config.yml"},
+	0x8024000B: {"WU_E_CALL_CANCELLED", "The operation was cancelled", ""},
+	0x8024000E: {"WU_E_XML_INVALID", "Invalid information in the update metadata",
+		"the local datastore or WSUS metadata may be corrupt; reset the SoftwareDistribution folder"},
+	0x8024001E: {"WU_E_SERVICE_STOP", "The Windows Update service or system was shutting down", "retry after the system has restarted"},
+	0x8024002E: {"WU_E_WU_DISABLED", "Access to an unmanaged server is not allowed",
+		"a policy blocks Windows Update (DisableWindowsUpdateAccess / DoNotConnectToWindowsUpdateInternetLocations); point the host at WSUS or use offline_scan_cab"},
+	0x80240016: {"WU_E_INSTALL_NOT_ALLOWED", "Another installation is in progress or a reboot is pending", "wait for the current installation to finish or reboot"},
+	0x80240438: {"WU_E_PT_ENDPOINT_UNREACHABLE", "There is no route or network connectivity to the update endpoint",
+		"check network connectivity, proxy and firewall rules for the update server"},
+	0x80244010: {"WU_E_PT_EXCEEDED_MAX_SERVER_TRIPS", "The number of round trips to the server exceeded the maximum",
+		"run the scan again; on WSUS, decline superseded updates and run the server cleanup wizard"},
+	0x80244017: {"WU_E_PT_HTTP_STATUS_DENIED", "The update server returned HTTP 401 (authentication required)", "check proxy authentication and WSUS permissions"},
+	0x80244018: {"WU_E_PT_HTTP_STATUS_FORBIDDEN", "The update server returned HTTP 403 (forbidden)", "a proxy or firewall is likely blocking the request"},
+	0x80244019: {"WU_E_PT_HTTP_STATUS_NOT_FOUND", "The update server returned HTTP 404 (not found)", "check the WUServer URL (including port) in the WSUS policy"},
+	0x8024401C: {"WU_E_PT_HTTP_STATUS_REQUEST_TIMEOUT", "The update server timed out (HTTP 408)", "the WSUS server may be overloaded; check its IIS application pool"},
+	0x8024401F: {"WU_E_PT_HTTP_STATUS_SERVER_ERROR", "The update server returned HTTP 500 (internal error)", "check the WSUS server event log and IIS application pool"},
+	0x80244022: {"WU_E_PT_HTTP_STATUS_SERVICE_UNAVAIL", "The update server returned HTTP 503 (service unavailable)", "the WSUS IIS application pool has probably stopped; restart WsusPool"},
+	0x8024402C: {"WU_E_PT_WINHTTP_NAME_NOT_RESOLVED", "The proxy or update server name could not be resolved",
+		"check DNS resolution and the WUServer / proxy settings"},
+	0x8024402F: {"WU_E_PT_ECP_SUCCEEDED_WITH_ERRORS", "Processing of the offline scan catalog completed with errors",
+		"re-download wsusscn2.cab and update offline_scan_cab"},
+	0x80248007: {"WU_E_DS_NODATA", "The requested information is not in the Windows Update datastore",
+		"the datastore may be corrupt; run 'patchmon-agent repair-wu'"},
+	0x80248002: {"WU_E_DS_INVALID", "The Windows Update datastore is in an inconsistent state",
+		"the datastore is corrupt; run 'patchmon-agent repair-wu'"},
+	0x80248003: {"WU_E_DS_TABLEMISSING", "A table is missing from the Windows Update datastore",
+		"the datastore is corrupt; run 'patchmon-agent repair-wu'"},
+	0x8024A000: {"WU_E_AU_NOSERVICE", "Automatic Updates was unable to service the request", "restart the Windows Update (wuauserv) service"},
+	0x80072EE2: {"ERROR_INTERNET_TIMEOUT", "The connection to the update server timed out", "check network connectivity, proxy and firewall rules"},
+	0x80072EE7: {"ERROR_INTERNET_NAME_NOT_RESOLVED", "The update server name could not be resolved", "check DNS resolution and proxy settings"},
+	0x80072EFD: {"ERROR_INTERNET_CANNOT_CONNECT", "A connection to the update server could not be established", "check firewall rules and that the update server is reachable"},
+	0x80072F8F: {"ERROR_INTERNET_SECURE_FAILURE", "TLS validation with the update server failed", "check the system clock, root certificates and TLS settings"},
+	0x80070005: {"E_ACCESSDENIED", "Access denied", "run the agent as Administrator or SYSTEM"},
+	0x80070422: {"ERROR_SERVICE_DISABLED", "The Windows Update service is disabled", "set the wuauserv service startup type to Manual"},
+}
+
+// WUAError is a Windows Update Agent error decoded from its HRESULT
+type WUAError struct {
+	Code        uint32
+	Name        string
+	Description string
+	Hint        string
+	Err         error
+}
+
+// Error returns the original error followed by the decoded code and hint
+func (e *WUAError) Error() string {
+	msg := fmt.Sprintf("%v [0x%08X %s: %s", e.Err, e.Code, e.Name, e.Description)
+	if e.Hint != "" {
+		msg += "; hint: " + e.Hint
+	}
+	return msg + "]"
+}
+
+// Unwrap returns the underlying error
+func (e *WUAError) Unwrap() error {
+	return e.Err
+}
+
+// wuaErrorCode extracts the HRESULT from a COM error. For IDispatch exceptions
+// the real code is in the EXCEPINFO, not in the DISP_E_EXCEPTION wrapper.
+func wuaErrorCode(err error) (uint32, bool) {
+	var oleErr *ole.OleError
+	if !errors.As(err, &oleErr) {
+		return 0, false
+	}
+	if excepInfo, ok := oleErr.SubError().(ole.EXCEPINFO); ok && excepInfo.SCODE() != 0 {
+		return excepInfo.SCODE(), true
+	}
+	return uint32(oleErr.Code()), true
+}
+
+// decodeWUAError wraps err in a WUAError if it carries a well-known HRESULT,
+// otherwise it returns err unchanged
+func decodeWUAError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var wuaErr *WUAError
+	if errors.As(err, &wuaErr) {
+		return err
+	}
+
+	code, ok := wuaErrorCode(err)
+	if !ok {
+		return err
+	}
+	info, known := wuaErrors[code]
+	if !known {
+		return err
+	}
+
+	return &WUAError{
+		Code:        code,
+		Name:        info.Name,
+		Description: info.Description,
+		Hint:        info.Hint,
+		Err:         err,
+	}
+}
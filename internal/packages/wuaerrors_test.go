@@ -0,0 +1,49 @@
+package packages
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	ole "github.com/go-ole/go-ole"
+)
+
+func TestDecodeWUAError(t *testing.T) {
+	t.Run("known code", func(t *testing.T) {
+		err := fmt.Errorf("update search failed: %w", ole.NewError(0x8024402C))
+		decoded := decodeWUAError(err)
+
+		var wuaErr *WUAError
+		if !errors.As(decoded, &wuaErr) {
+			t.Fatalf("expected WUAError, got %T: %v", decoded, decoded)
+		}
+		if wuaErr.Name != "WU_E_PT_WINHTTP_NAME_NOT_RESOLVED" || wuaErr.Hint == "" {
+			t.Errorf("unexpected decoded error: %+v", wuaErr)
+		}
+		msg := decoded.Error()
+		if !strings.Contains(msg, "0x8024402C") || !strings.Contains(msg, "update search failed") {
+			t.Errorf("message should keep context and code: %s", msg)
+		}
+		if decodeWUAError(decoded) != decoded {
+			t.Error("decoding twice should not wrap again")
+		}
+	})
+
+	t.Run("unknown code", func(t *testing.T) {
+		err := ole.NewError(0x80001234)
+		if decodeWUAError(err) != err {
+			t.Error("unknown codes should be returned unchanged")
+		}
+	})
+
+	t.Run("not a COM error", func(t *testing.T) {
+		err := ErrSearchTimeout
+		if decodeWUAError(err) != err {
+			t.Error("non-COM errors should be returned unchanged")
+		}
+		if decodeWUAError(nil) != nil {
+			t.Error("nil should stay nil")
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package packages
+
+import (
+	"fmt"
+	"time"
+
+	ole "github.com/go-ole/go-ole"
+	"github.com/go-ole/go-ole/oleutil"
+	"github.com/sirupsen/logrus"
+)
+
+// WUA OperationResultCode values
+const (
+	operationResultNotStarted          = 0
+	operationResultInProgress          = 1
+	operationResultSucceeded           = 2
+	operationResultSucceededWithErrors = 3
+	operationResultFailed              = 4
+	operationResultAborted             = 5
+)
+
+// installPollInterval is how often a running download or installation is polled for progress
+const installPollInterval = time.Second
+
+// CreateUpdateInstaller returns an UpdateInstaller bound to the session
+func (s *comUpdateSession) CreateUpdateInstaller() (UpdateInstaller, error) {
+	return &comUpdateInstaller{logger: s.logger, session: s.dispatch}, nil
+}
+
+// comUpdateInstaller implements UpdateInstaller on top of IUpdateDownloader
+// and IUpdateInstaller created from the session
+type comUpdateInstaller struct {
+	logger  *logrus.Logger
+	session *ole.IDispatch
+}
+
+// Download downloads updates with IUpdateDownloader.BeginDownload, polling the
+// job for progress until it completes
+func (i *comUpdateInstaller) Download(updates []Update, progress ProgressFunc) ([]OperationResult, error) {
+	collection, err := newCOMUpdateCollection(updates, false)
+	if err != nil {
+		return nil, err
+	}
+	defer collection.Release()
+
+	downloaderVal, err := oleutil.CallMethod(i.session, "CreateUpdateDownloader")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create UpdateDownloader: %w", err)
+	}
+	downloader := downloaderVal.ToIDispatch()
+	defer downloader.Release()
+
+	if _, err := oleutil.PutProperty(downloader, "Updates", collection); err != nil {
+		return nil, fmt.Errorf("failed to set downloader updates: %w", err)
+	}
+
+	return runUpdateJob(downloader, "Download", len(updates), progress)
+}
+
+// Install installs updates with IUpdateInstaller.BeginInstall, polling the
+// job for progress until it completes. EULAs are accepted on the admin's behalf.
+func (i *comUpdateInstaller) Install(updates []Update, progress ProgressFunc) ([]OperationResult, error) {
+	collection, err := newCOMUpdateCollection(updates, true)
+	if err != nil {
+		return nil, err
+	}
+	defer collection.Release()
+
+	installerVal, err := oleutil.CallMethod(i.session, "CreateUpdateInstaller")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create UpdateInstaller: %w", err)
+	}
+	installer := installerVal.ToIDispatch()
+	defer installer.Release()
+
+	// Never show UI; the agent runs unattended
+	if _, err := oleutil.PutProperty(installer, "ForceQuiet", true); err != nil {
+		i.logger.WithError(err).Debug("Failed to set installer ForceQuiet")
+	}
+	if _, err := oleutil.PutProperty(installer, "Updates", collection); err != nil {
+		return nil, fmt.Errorf("failed to set installer updates: %w", err)
+	}
+
+	return runUpdateJob(installer, "Install", len(updates), progress)
+}
+
+// Release is a no-op; the downloader and installer are released after each operation
+func (i *comUpdateInstaller) Release() {}
+
+// newCOMUpdateCollection builds a Microsoft.Update.UpdateColl holding updates.
+// With acceptEula set, pending EULAs are accepted so the installation can run unattended.
+func newCOMUpdateCollection(updates []Update, acceptEula bool) (*ole.IDispatch, error) {
+	unknown, err := oleutil.CreateObject("Microsoft.Update.UpdateColl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create UpdateColl: %w", err)
+	}
+	collection, err := unknown.QueryInterface(ole.IID_IDispatch)
+	unknown.Release()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query UpdateColl interface: %w", err)
+	}
+
+	for _, update := range updates {
+		comUpd, ok := update.(*comUpdate)
+		if !ok {
+			collection.Release()
+			return nil, fmt.Errorf("unexpected update type %T", update)
+		}
+		if acceptEula {
+			if accepted, err := oleutil.GetProperty(comUpd.dispatch, "EulaAccepted"); err == nil {
+				if ok, _ := accepted.Value().(bool); !ok {
+					_, _ = oleutil.CallMethod(comUpd.dispatch, "AcceptEula")
+				}
+			}
+		}
+		if _, err := oleutil.CallMethod(collection, "Add", comUpd.dispatch); err != nil {
+			collection.Release()
+			return nil, fmt.Errorf("failed to add update to collection: %w", err)
+		}
+	}
+
+	return collection, nil
+}
+
+// runUpdateJob starts Begin<operation> on a downloader or installer, polls the
+// job until it completes, then collects per-update results from End<operation>
+func runUpdateJob(dispatch *ole.IDispatch, operation string, count int, progress ProgressFunc) ([]OperationResult, error) {
+	// No progress/completion callbacks or state: the job is polled instead
+	jobVal, err := oleutil.CallMethod(dispatch, "Begin"+operation, (*ole.IDispatch)(nil), (*ole.IDispatch)(nil), nil)
+	if err != nil {
+		return nil, fmt.Errorf("update %s failed to start: %w", operation, err)
+	}
+	job := jobVal.ToIDispatch()
+	defer job.Release()
+	defer func() {
+		_, _ = oleutil.CallMethod(job, "CleanUp")
+	}()
+
+	for {
+		completedVal, err := oleutil.GetProperty(job, "IsCompleted")
+		if err != nil {
+			break
+		}
+		if completed, ok := completedVal.Value().(bool); ok && completed {
+			break
+		}
+		if progress != nil {
+			reportJobProgress(job, progress)
+		}
+		time.Sleep(installPollInterval)
+	}
+
+	resultVal, err := oleutil.CallMethod(dispatch, "End"+operation, job)
+	if err != nil {
+		return nil, fmt.Errorf("update %s failed: %w", operation, err)
+	}
+	result := resultVal.ToIDispatch()
+	defer result.Release()
+
+	results := make([]OperationResult, count)
+	for i := 0; i < count; i++ {
+		updateResultVal, err := oleutil.CallMethod(result, "GetUpdateResult", i)
+		if err != nil {
+			results[i].ResultCode = operationResultFailed
+			continue
+		}
+		updateResult := updateResultVal.ToIDispatch()
+		if codeVal, err := oleutil.GetProperty(updateResult, "ResultCode"); err == nil {
+			results[i].ResultCode = int(codeVal.Val)
+		}
+		if hresultVal, err := oleutil.GetProperty(updateResult, "HResult"); err == nil {
+			results[i].HResult = int32(hresultVal.Val)
+		}
+		// Only installation results expose RebootRequired
+		if rebootVal, err := oleutil.GetProperty(updateResult, "RebootRequired"); err == nil {
+			results[i].RebootRequired, _ = rebootVal.Value().(bool)
+		}
+		updateResult.Release()
+	}
+
+	return results, nil
+}
+
+// reportJobProgress reads the current update index and its percentage from
+// an IDownloadJob/IInstallationJob and passes them to progress
+func reportJobProgress(job *ole.IDispatch, progress ProgressFunc) {
+	progressVal, err := oleutil.CallMethod(job, "GetProgress")
+	if err != nil {
+		return
+	}
+	jobProgress := progressVal.ToIDispatch()
+	defer jobProgress.Release()
+
+	indexVal, err := oleutil.GetProperty(jobProgress, "CurrentUpdateIndex")
+	if err != nil {
+		return
+	}
+	percentVal, err := oleutil.GetProperty(jobProgress, "CurrentUpdatePercentComplete")
+	if err != nil {
+		return
+	}
+	progress(int(indexVal.Val), int(percentVal.Val))
+}
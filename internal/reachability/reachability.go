@@ -0,0 +1,228 @@
+package reachability
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout bounds each stage (DNS, TCP, TLS) of a check
+const DefaultTimeout = 5 * time.Second
+
+// Stages at which a check can fail
+const (
+	StageURL   = "url"
+	StageDNS   = "dns"
+	StageTCP   = "tcp"
+	StageProxy = "proxy"
+	StageTLS   = "tls"
+)
+
+// ProxyFunc returns the proxy to reach an endpoint through, or nil to connect
+// directly, like http.Transport.Proxy: http.ProxyURL and
+// http.ProxyFromEnvironment can be used
+type ProxyFunc func(*http.Request) (*url.URL, error)
+
+// rootCAs verifies TLS certificates; nil uses the system roots. Replaced in tests.
+var rootCAs *x509.CertPool
+
+// Endpoint is a host that Windows Update downloads metadata or content from
+type Endpoint struct {
+	Name string `json:"name"`
+	URL  string `json:"url"` // http:// endpoints are checked without TLS
+}
+
+// Result is the outcome of checking an endpoint
+type Result struct {
+	Endpoint
+	Host       string   `json:"host"`
+	Port       string   `json:"port"`
+	Proxy      string   `json:"proxy,omitempty"`     // host:port of the proxy the check went through
+	Addresses  []string `json:"addresses,omitempty"` // of the proxy when there is one
+	Reachable  bool     `json:"reachable"`
+	FailedAt   string   `json:"failedAt,omitempty"` // url, dns, tcp, proxy or tls
+	Error      string   `json:"error,omitempty"`
+	TLSVersion string   `json:"tlsVersion,omitempty"`
+	DurationMs int64    `json:"durationMs"`
+}
+
+// MicrosoftUpdateEndpoints returns the Microsoft Windows Update service,
+// delivery and download endpoints
+func MicrosoftUpdateEndpoints() []Endpoint {
+	return []Endpoint{
+		{Name: "Windows Update", URL: "https://windowsupdate.microsoft.com"},
+		{Name: "Update service", URL: "https://fe2.update.microsoft.com"},
+		{Name: "Service locator", URL: "https://slscr.update.microsoft.com"},
+		{Name: "Delivery", URL: "https://fe3.delivery.mp.microsoft.com"},
+		{Name: "Delivery downloads", URL: "http://dl.delivery.mp.microsoft.com"},
+		{Name: "Update downloads", URL: "http://download.windowsupdate.com"},
+		{Name: "Certificate trust lists", URL: "http://ctldl.windowsupdate.com"},
+	}
+}
+
+// CheckAll checks endpoints concurrently, returning results in the same order
+func CheckAll(ctx context.Context, endpoints []Endpoint, proxy ProxyFunc, timeout time.Duration) []Result {
+	results := make([]Result, len(endpoints))
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = Check(ctx, endpoint, proxy, timeout)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// Check resolves the endpoint's host, connects to it and, for https
+// endpoints, completes a TLS handshake with certificate verification. If
+// proxy returns a proxy for the endpoint, the proxy is resolved and connected
+// to instead and the endpoint is reached through an HTTP CONNECT tunnel. Each
+// stage is bounded by timeout (DefaultTimeout if not positive).
+func Check(ctx context.Context, endpoint Endpoint, proxy ProxyFunc, timeout time.Duration) Result {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	start := time.Now()
+	result := check(ctx, endpoint, proxy, timeout)
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result
+}
+
+func check(ctx context.Context, endpoint Endpoint, proxy ProxyFunc, timeout time.Duration) Result {
+	result := Result{Endpoint: endpoint}
+	u, err := url.Parse(endpoint.URL)
+	if err != nil || u.Hostname() == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return result.fail(StageURL, fmt.Errorf("%q is not an http or https URL", endpoint.URL))
+	}
+	result.Host, result.Port = u.Hostname(), u.Port()
+	if result.Port == "" {
+		result.Port = "80"
+		if u.Scheme == "https" {
+			result.Port = "443"
+		}
+	}
+
+	var proxyURL *url.URL
+	if proxy != nil {
+		if proxyURL, err = proxy(&http.Request{Method: http.MethodGet, URL: u, Host: u.Host}); err != nil {
+			return result.fail(StageProxy, err)
+		}
+	}
+	dialHost, dialPort := result.Host, result.Port
+	if proxyURL != nil {
+		if proxyURL.Scheme != "http" && proxyURL.Scheme != "https" {
+			return result.fail(StageProxy, fmt.Errorf("%s proxies are not supported, only http and https", proxyURL.Scheme))
+		}
+		dialHost, dialPort = proxyURL.Hostname(), proxyURL.Port()
+		if dialPort == "" {
+			dialPort = "80"
+			if proxyURL.Scheme == "https" {
+				dialPort = "443"
+			}
+		}
+		result.Proxy = net.JoinHostPort(dialHost, dialPort)
+	}
+
+	dnsCtx, cancel := context.WithTimeout(ctx, timeout)
+	addresses, err := net.DefaultResolver.LookupHost(dnsCtx, dialHost)
+	cancel()
+	if err != nil {
+		return result.fail(StageDNS, err)
+	}
+	result.Addresses = addresses
+
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(dialHost, dialPort))
+	if err != nil {
+		return result.fail(StageTCP, err)
+	}
+	defer conn.Close()
+
+	if proxyURL != nil {
+		if conn, err = throughProxy(ctx, conn, proxyURL, u, net.JoinHostPort(result.Host, result.Port), timeout); err != nil {
+			return result.fail(StageProxy, err)
+		}
+	}
+
+	if u.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: result.Host, RootCAs: rootCAs})
+		tlsCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := tlsConn.HandshakeContext(tlsCtx)
+		cancel()
+		if err != nil {
+			return result.fail(StageTLS, err)
+		}
+		result.TLSVersion = tls.VersionName(tlsConn.ConnectionState().Version)
+	}
+
+	result.Reachable = true
+	return result
+}
+
+// throughProxy reaches the endpoint u through the proxy connected to by conn.
+// For https endpoints it opens a CONNECT tunnel to target and returns the
+// connection to the endpoint. http endpoints are requested through the proxy
+// instead, as proxies commonly refuse tunnels to port 80.
+func throughProxy(ctx context.Context, conn net.Conn, proxyURL *url.URL, u *url.URL, target string, timeout time.Duration) (net.Conn, error) {
+	if proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname(), RootCAs: rootCAs})
+		tlsCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := tlsConn.HandshakeContext(tlsCtx)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("TLS handshake with the proxy failed: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: target},
+		Host:   target,
+		Header: make(http.Header),
+	}
+	if u.Scheme == "http" {
+		req.Method, req.URL, req.Host = http.MethodHead, u, u.Host
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	if err := req.WriteProxy(conn); err != nil {
+		return nil, err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	switch {
+	case req.Method == http.MethodConnect && resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("the proxy refused the tunnel: %s", resp.Status)
+	case resp.StatusCode == http.StatusProxyAuthRequired, resp.StatusCode == http.StatusBadGateway,
+		resp.StatusCode == http.StatusServiceUnavailable, resp.StatusCode == http.StatusGatewayTimeout:
+		return nil, fmt.Errorf("the proxy could not reach the endpoint: %s", resp.Status)
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// fail marks the result as failed at stage
+func (r Result) fail(stage string, err error) Result {
+	r.FailedAt = stage
+	r.Error = err.Error()
+	return r
+}
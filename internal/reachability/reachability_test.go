@@ -0,0 +1,144 @@
+package reachability
+
+import (
+	"context"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheck(t *testing.T) {
+	tlsServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer tlsServer.Close()
+	plainServer := httptest.NewServer(http.NotFoundHandler())
+	defer plainServer.Close()
+
+	// A port nothing listens on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddr := listener.Addr().String()
+	listener.Close()
+
+	rootCAs = x509.NewCertPool()
+	rootCAs.AddCert(tlsServer.Certificate())
+	t.Cleanup(func() { rootCAs = nil })
+
+	tests := []struct {
+		name     string
+		url      string
+		failedAt string
+	}{
+		{"https", tlsServer.URL, ""},
+		{"http", plainServer.URL, ""},
+		{"tls to plain server", strings.Replace(plainServer.URL, "http://", "https://", 1), StageTLS},
+		{"closed port", "http://" + closedAddr, StageTCP},
+		{"unknown host", "https://host.invalid", StageDNS},
+		{"not a url", "ftp://example.com", StageURL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Check(context.Background(), Endpoint{Name: tt.name, URL: tt.url}, nil, 2*time.Second)
+			if got.FailedAt != tt.failedAt || got.Reachable != (tt.failedAt == "") {
+				t.Errorf("Check() = reachable %v, failed at %q (%s), want failed at %q", got.Reachable, got.FailedAt, got.Error, tt.failedAt)
+			}
+		})
+	}
+}
+
+func TestCheckAll(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	endpoints := []Endpoint{
+		{Name: "bad", URL: "gopher://example.com"},
+		{Name: "server", URL: server.URL},
+	}
+	results := CheckAll(context.Background(), endpoints, nil, time.Second)
+	if len(results) != 2 || results[0].Name != "bad" || results[1].Name != "server" {
+		t.Fatalf("CheckAll() = %+v, want results in endpoint order", results)
+	}
+	if results[0].Reachable || !results[1].Reachable {
+		t.Errorf("CheckAll() reachable = %v, %v, want false, true", results[0].Reachable, results[1].Reachable)
+	}
+	if results[1].Port == "" || len(results[1].Addresses) == 0 {
+		t.Errorf("CheckAll() server result = %+v, want port and addresses", results[1])
+	}
+}
+
+// testProxy is a forward proxy requiring the Basic credentials user:pass. It
+// tunnels CONNECT requests and answers plain http requests itself.
+func testProxy() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := &http.Request{Header: http.Header{"Authorization": r.Header["Proxy-Authorization"]}}
+		if user, pass, ok := auth.BasicAuth(); !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		if r.Method != http.MethodConnect {
+			if r.URL.Hostname() == "host.invalid" {
+				w.WriteHeader(http.StatusBadGateway)
+			}
+			return
+		}
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		conn, buf, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			upstream.Close()
+			return
+		}
+		go func() {
+			defer conn.Close()
+			defer upstream.Close()
+			go io.Copy(upstream, buf)
+			io.Copy(conn, upstream)
+		}()
+	}))
+}
+
+func TestCheckProxy(t *testing.T) {
+	tlsServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer tlsServer.Close()
+	proxy := testProxy()
+	defer proxy.Close()
+
+	rootCAs = x509.NewCertPool()
+	rootCAs.AddCert(tlsServer.Certificate())
+	t.Cleanup(func() { rootCAs = nil })
+
+	proxyURL, _ := url.Parse(strings.Replace(proxy.URL, "http://", "http://user:pass@", 1))
+	noAuthURL, _ := url.Parse(proxy.URL)
+	tests := []struct {
+		name     string
+		url      string
+		proxy    *url.URL
+		failedAt string
+	}{
+		{"https through tunnel", tlsServer.URL, proxyURL, ""},
+		{"http through proxy", "http://example.com", proxyURL, ""},
+		{"proxy cannot reach http endpoint", "http://host.invalid", proxyURL, StageProxy},
+		{"proxy cannot reach https endpoint", "https://host.invalid", proxyURL, StageProxy},
+		{"proxy authentication", tlsServer.URL, noAuthURL, StageProxy},
+		{"unsupported proxy", tlsServer.URL, &url.URL{Scheme: "socks5", Host: "127.0.0.1:1080"}, StageProxy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Check(context.Background(), Endpoint{Name: tt.name, URL: tt.url}, http.ProxyURL(tt.proxy), 2*time.Second)
+			if got.FailedAt != tt.failedAt || got.Reachable != (tt.failedAt == "") {
+				t.Errorf("Check() = reachable %v, failed at %q (%s), want failed at %q", got.Reachable, got.FailedAt, got.Error, tt.failedAt)
+			}
+		})
+	}
+}
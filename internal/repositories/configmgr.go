@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"strings"
+
+	"github.com/yusufpapurcu/wmi"
+	"golang.org/x/sys/windows/registry"
+
+	"patchmon-agent/pkg/models"
+)
+
+// ccmNamespace is the WMI namespace registered by the Configuration Manager client
+const ccmNamespace = `root\ccm`
+
+// ccmRegistryKey holds the Configuration Manager client settings
+const ccmRegistryKey = `SOFTWARE\Microsoft\CCM`
+
+// Patch ownership values reported in ConfigMgrInfo.UpdatesManagedBy
+const (
+	UpdatesManagedByConfigMgr = "configmgr"
+	UpdatesManagedByIntune    = "intune"
+)
+
+// coManagementEnabled is set in CoManagementFlags once co-management is enabled
+const coManagementEnabled = 1
+
+// coManagementWorkloads maps CoManagementFlags bits to the workloads that have
+// been switched to Intune
+var coManagementWorkloads = []struct {
+	flag uint64
+	name string
+}{
+	{2, "Compliance policies"},
+	{4, "Resource access policies"},
+	{8, "Device configuration"},
+	{16, "Windows Update policies"},
+	{32, "Endpoint Protection"},
+	{64, "Client apps"},
+	{128, "Office Click-to-Run apps"},
+}
+
+// windowsUpdateWorkload is the CoManagementFlags bit for Windows Update policies
+const windowsUpdateWorkload = 16
+
+// win32Service maps the fields of Win32_Service we need
+type win32Service struct {
+	State     string
+	StartMode string
+}
+
+// smsClient maps the WMI SMS_Client class
+type smsClient struct {
+	ClientVersion string
+}
+
+// smsAuthority maps the WMI SMS_Authority class
+type smsAuthority struct {
+	Name                   string
+	CurrentManagementPoint string
+}
+
+// GetConfigMgrInfo detects the Configuration Manager (SCCM) client, its
+// version, site and co-management workloads. It returns nil if the client
+// is not installed.
+func (m *Manager) GetConfigMgrInfo() *models.ConfigMgrInfo {
+	var services []win32Service
+	serviceErr := wmi.Query("SELECT State, StartMode FROM Win32_Service WHERE Name = 'CcmExec'", &services)
+	if serviceErr != nil {
+		m.logger.WithError(serviceErr).Debug("Failed to query CcmExec service")
+	}
+
+	var clients []smsClient
+	clientErr := wmi.QueryNamespace("SELECT ClientVersion FROM SMS_Client", &clients, ccmNamespace)
+
+	if len(services) == 0 && (clientErr != nil || len(clients) == 0) {
+		m.logger.Debug("Configuration Manager client not detected")
+		return nil
+	}
+
+	info := &models.ConfigMgrInfo{Installed: true}
+	if len(services) > 0 {
+		info.ServiceRunning = strings.EqualFold(services[0].State, "Running")
+	}
+	if len(clients) > 0 {
+		info.ClientVersion = clients[0].ClientVersion
+	}
+
+	var authorities []smsAuthority
+	if err := wmi.QueryNamespace("SELECT Name, CurrentManagementPoint FROM SMS_Authority", &authorities, ccmNamespace); err == nil && len(authorities) > 0 {
+		info.SiteCode = strings.TrimPrefix(authorities[0].Name, "SMS:")
+		info.ManagementPoint = authorities[0].CurrentManagementPoint
+	}
+
+	flags := m.getCoManagementFlags()
+	info.CoManaged, info.IntuneWorkloads = decodeCoManagementFlags(flags)
+	info.CoManagementFlags = int(flags)
+	info.UpdatesManagedBy = updatesOwner(flags)
+
+	m.logger.WithField("version", info.ClientVersion).Debug("Configuration Manager client detected")
+	return info
+}
+
+// getCoManagementFlags reads the CoManagementFlags value, 0 if not co-managed
+func (m *Manager) getCoManagementFlags() uint64 {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, ccmRegistryKey, registry.QUERY_VALUE)
+	if err != nil {
+		return 0
+	}
+	defer key.Close()
+
+	flags, _, err := key.GetIntegerValue("CoManagementFlags")
+	if err != nil {
+		return 0
+	}
+	return flags
+}
+
+// decodeCoManagementFlags reports whether co-management is enabled and which
+// workloads have been moved to Intune
+func decodeCoManagementFlags(flags uint64) (bool, []string) {
+	if flags&coManagementEnabled == 0 {
+		return false, nil
+	}
+	var workloads []string
+	for _, w := range coManagementWorkloads {
+		if flags&w.flag != 0 {
+			workloads = append(workloads, w.name)
+		}
+	}
+	return true, workloads
+}
+
+// updatesOwner returns which tool owns Windows Update policies on a
+// Configuration Manager client
+func updatesOwner(flags uint64) string {
+	if flags&coManagementEnabled != 0 && flags&windowsUpdateWorkload != 0 {
+		return UpdatesManagedByIntune
+	}
+	return UpdatesManagedByConfigMgr
+}
@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeCoManagementFlags(t *testing.T) {
+	tests := []struct {
+		name          string
+		flags         uint64
+		wantCoManaged bool
+		wantWorkloads []string
+		wantOwner     string
+	}{
+		{"not co-managed", 0, false, nil, UpdatesManagedByConfigMgr},
+		{"workload bits without co-management", 16, false, nil, UpdatesManagedByConfigMgr},
+		{"co-managed, no workloads moved", 1, true, nil, UpdatesManagedByConfigMgr},
+		{"compliance and updates moved", 1 | 2 | 16, true, []string{"Compliance policies", "Windows Update policies"}, UpdatesManagedByIntune},
+		{"all workloads moved", 255, true, []string{
+			"Compliance policies", "Resource access policies", "Device configuration",
+			"Windows Update policies", "Endpoint Protection", "Client apps", "Office Click-to-Run apps",
+		}, UpdatesManagedByIntune},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coManaged, workloads := decodeCoManagementFlags(tt.flags)
+			if coManaged != tt.wantCoManaged || !reflect.DeepEqual(workloads, tt.wantWorkloads) {
+				t.Errorf("decodeCoManagementFlags(%d) = %v, %v; want %v, %v", tt.flags, coManaged, workloads, tt.wantCoManaged, tt.wantWorkloads)
+			}
+			if owner := updatesOwner(tt.flags); owner != tt.wantOwner {
+				t.Errorf("updatesOwner(%d) = %q, want %q", tt.flags, owner, tt.wantOwner)
+			}
+		})
+	}
+}
+
+// TestGetConfigMgrInfo runs against the real system; most machines have no
+// Configuration Manager client and get nil.
+func TestGetConfigMgrInfo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	info := New(newTestLogger()).GetConfigMgrInfo()
+	if info == nil {
+		t.Log("Configuration Manager client not installed")
+		return
+	}
+	if !info.Installed {
+		t.Error("expected Installed to be true when info is returned")
+	}
+	t.Logf("ConfigMgr: version=%s site=%s coManaged=%v owner=%s", info.ClientVersion, info.SiteCode, info.CoManaged, info.UpdatesManagedBy)
+}
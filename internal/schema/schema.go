@@ -0,0 +1,165 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Draft is the JSON Schema dialect of the generated schemas
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	timeType           = reflect.TypeOf(time.Time{})
+	rawMessageType     = reflect.TypeOf(json.RawMessage{})
+	optionalFieldsType = reflect.TypeOf((*optionalFielder)(nil)).Elem()
+)
+
+// optionalFielder is implemented by types whose MarshalJSON can leave out
+// fields that are not omitempty. Those fields are not required.
+type optionalFielder interface {
+	OptionalFields() []string
+}
+
+// Generate returns a JSON Schema describing how encoding/json marshals v.
+// Nested structs are described once in $defs. Fields without omitempty are
+// required, unless the struct lists them in OptionalFields, and those that
+// can marshal as null (pointers, slices, maps) also accept null.
+func Generate(v any, title, description string) map[string]any {
+	g := &generator{defs: map[string]any{}}
+	root := g.object(reflect.TypeOf(v))
+	root["$schema"] = Draft
+	root["title"] = title
+	if description != "" {
+		root["description"] = description
+	}
+	if len(g.defs) > 0 {
+		root["$defs"] = g.defs
+	}
+	return root
+}
+
+type generator struct {
+	defs map[string]any
+}
+
+// schemaFor returns the schema of a type
+func (g *generator) schemaFor(t reflect.Type) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case rawMessageType:
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schemaFor(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// []byte is marshalled as a base64 string
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		name := t.Name()
+		if name == "" {
+			return g.object(t)
+		}
+		if _, ok := g.defs[name]; !ok {
+			g.defs[name] = nil // reserved, so recursive types terminate
+			g.defs[name] = g.object(t)
+		}
+		return map[string]any{"$ref": "#/$defs/" + name}
+	}
+	// Interfaces and anything else can hold any value
+	return map[string]any{}
+}
+
+// object returns the schema of a struct
+func (g *generator) object(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	properties := map[string]any{}
+	required := []string{}
+	g.addFields(t, properties, &required)
+	if t.Implements(optionalFieldsType) {
+		optional := reflect.Zero(t).Interface().(optionalFielder).OptionalFields()
+		required = slices.DeleteFunc(required, func(name string) bool {
+			return slices.Contains(optional, name)
+		})
+	}
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// addFields adds the fields of a struct, including those of embedded structs
+func (g *generator) addFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.addFields(embedded, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := g.schemaFor(field.Type)
+		omitEmpty := strings.Contains(","+opts+",", ",omitempty,")
+		if !omitEmpty {
+			*required = append(*required, name)
+			switch field.Type.Kind() {
+			case reflect.Pointer, reflect.Slice, reflect.Map:
+				if field.Type != rawMessageType {
+					schema = nullable(schema)
+				}
+			}
+		}
+		properties[name] = schema
+	}
+}
+
+// nullable allows null in addition to what schema allows
+func nullable(schema map[string]any) map[string]any {
+	if typ, ok := schema["type"].(string); ok {
+		nullableSchema := make(map[string]any, len(schema))
+		for k, v := range schema {
+			nullableSchema[k] = v
+		}
+		nullableSchema["type"] = []string{typ, "null"}
+		return nullableSchema
+	}
+	return map[string]any{"anyOf": []any{schema, map[string]any{"type": "null"}}}
+}
@@ -0,0 +1,112 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"patchmon-agent/pkg/models"
+)
+
+type testNode struct {
+	Name     string      `json:"name"`
+	Children []*testNode `json:"children,omitempty"`
+}
+
+type testBase struct {
+	ID string `json:"id"`
+}
+
+type testPayload struct {
+	testBase
+	Count    int               `json:"count"`
+	Ratio    float64           `json:"ratio,omitempty"`
+	Enabled  *bool             `json:"enabled"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	Names    []string          `json:"names"`
+	Seen     time.Time         `json:"seen"`
+	Raw      json.RawMessage   `json:"raw,omitempty"`
+	Root     *testNode         `json:"root,omitempty"`
+	Internal string            `json:"-"`
+	hidden   string
+}
+
+func TestGenerate(t *testing.T) {
+	s := Generate(testPayload{}, "Test", "")
+	if s["$schema"] != Draft || s["title"] != "Test" {
+		t.Errorf("header = %v, %v", s["$schema"], s["title"])
+	}
+
+	properties := s["properties"].(map[string]any)
+	tests := map[string]map[string]any{
+		"id":      {"type": "string"},
+		"count":   {"type": "integer"},
+		"ratio":   {"type": "number"},
+		"enabled": {"type": []string{"boolean", "null"}},
+		"tags":    {"type": "object", "additionalProperties": map[string]any{"type": "string"}},
+		"names":   {"type": []string{"array", "null"}, "items": map[string]any{"type": "string"}},
+		"seen":    {"type": "string", "format": "date-time"},
+		"raw":     {},
+		"root":    {"$ref": "#/$defs/testNode"},
+	}
+	for name, want := range tests {
+		if got := properties[name]; !reflect.DeepEqual(got, want) {
+			t.Errorf("properties[%q] = %v, want %v", name, got, want)
+		}
+	}
+	if len(properties) != len(tests) {
+		t.Errorf("properties = %v, want only the marshalled fields", properties)
+	}
+
+	wantRequired := []string{"id", "count", "enabled", "names", "seen"}
+	if got := s["required"]; !reflect.DeepEqual(got, wantRequired) {
+		t.Errorf("required = %v, want %v", got, wantRequired)
+	}
+
+	// The recursive type is defined once and refers to itself
+	node := s["$defs"].(map[string]any)["testNode"].(map[string]any)
+	children := node["properties"].(map[string]any)["children"].(map[string]any)
+	if want := map[string]any{"$ref": "#/$defs/testNode"}; !reflect.DeepEqual(children["items"], want) {
+		t.Errorf("testNode children items = %v, want %v", children["items"], want)
+	}
+
+	if _, err := json.Marshal(s); err != nil {
+		t.Errorf("schema does not marshal: %v", err)
+	}
+}
+
+func TestGenerate_PartialReport(t *testing.T) {
+	s := Generate(models.ReportPayload{}, "Report", "")
+	required := s["required"].([]string)
+	properties := s["properties"].(map[string]any)
+
+	for _, sections := range [][]string{nil, {"packages"}, {"hardware"}, {"network"}, {"hardware", "network"}} {
+		payload := models.ReportPayload{Hostname: "host", Sections: sections}
+		data, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatalf("marshal failed: %v", err)
+		}
+		var fields map[string]any
+		if err := json.Unmarshal(data, &fields); err != nil {
+			t.Fatalf("unmarshal failed: %v", err)
+		}
+
+		for _, name := range required {
+			if _, ok := fields[name]; !ok {
+				t.Errorf("sections %v: required field %q missing from payload", sections, name)
+			}
+		}
+		for name := range fields {
+			if _, ok := properties[name]; !ok {
+				t.Errorf("sections %v: field %q not in schema", sections, name)
+			}
+		}
+	}
+
+	// Fields every report includes stay required
+	if !slices.Contains(required, "hostname") {
+		t.Errorf("required = %v, want hostname", required)
+	}
+}
@@ -0,0 +1,170 @@
+package security
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yusufpapurcu/wmi"
+
+	"patchmon-agent/pkg/models"
+)
+
+// administratorsSID is the well-known SID of the built-in Administrators
+// group; its name is localized, so the group is looked up by SID
+const administratorsSID = "S-1-5-32-544"
+
+// Group member types reported in LocalGroupMember.Type
+const (
+	MemberTypeUser   = "user"
+	MemberTypeGroup  = "group"
+	MemberTypeSystem = "system"
+)
+
+// memberTypes maps the WMI class of a group member to its member type
+var memberTypes = map[string]string{
+	"Win32_UserAccount":   MemberTypeUser,
+	"Win32_Group":         MemberTypeGroup,
+	"Win32_SystemAccount": MemberTypeSystem,
+}
+
+// win32UserAccount maps the WMI Win32_UserAccount class
+type win32UserAccount struct {
+	Name             string
+	SID              string
+	Description      string
+	Disabled         bool
+	Lockout          bool
+	PasswordRequired bool
+	PasswordExpires  bool
+}
+
+// win32Group maps the WMI Win32_Group class
+type win32Group struct {
+	Name   string
+	Domain string
+}
+
+// win32GroupUser maps the WMI Win32_GroupUser association class
+type win32GroupUser struct {
+	PartComponent string
+}
+
+// GetLocalUsers lists the local user accounts
+func (m *Manager) GetLocalUsers() []models.LocalUser {
+	var accounts []win32UserAccount
+	if err := wmi.Query("SELECT Name, SID, Description, Disabled, Lockout, PasswordRequired, PasswordExpires FROM Win32_UserAccount WHERE LocalAccount = TRUE", &accounts); err != nil {
+		m.logger.WithError(err).Warn("Failed to list local user accounts")
+		return nil
+	}
+
+	users := make([]models.LocalUser, 0, len(accounts))
+	for _, a := range accounts {
+		users = append(users, models.LocalUser{
+			Name:             a.Name,
+			SID:              a.SID,
+			Description:      a.Description,
+			Enabled:          !a.Disabled,
+			LockedOut:        a.Lockout,
+			PasswordRequired: a.PasswordRequired,
+			PasswordExpires:  a.PasswordExpires,
+		})
+	}
+
+	m.logger.WithField("count", len(users)).Debug("Collected local user accounts")
+	return users
+}
+
+// GetLocalAdministrators lists the direct members of the local Administrators
+// group. Domain groups are reported by name and not expanded.
+func (m *Manager) GetLocalAdministrators() []models.LocalGroupMember {
+	var groups []win32Group
+	query := fmt.Sprintf("SELECT Name, Domain FROM Win32_Group WHERE LocalAccount = TRUE AND SID = '%s'", administratorsSID)
+	if err := wmi.Query(query, &groups); err != nil || len(groups) == 0 {
+		m.logger.WithError(err).Warn("Failed to find the local Administrators group")
+		return nil
+	}
+	group := groups[0]
+
+	var links []win32GroupUser
+	query = fmt.Sprintf(`SELECT PartComponent FROM Win32_GroupUser WHERE GroupComponent = "Win32_Group.Domain='%s',Name='%s'"`,
+		escapeWQL(group.Domain), escapeWQL(group.Name))
+	if err := wmi.Query(query, &links); err != nil {
+		m.logger.WithError(err).Warn("Failed to list local Administrators group members")
+		return nil
+	}
+
+	members := make([]models.LocalGroupMember, 0, len(links))
+	for _, link := range links {
+		member, ok := parseGroupMember(link.PartComponent, group.Domain)
+		if !ok {
+			m.logger.WithField("path", link.PartComponent).Debug("Skipping unrecognized group member")
+			continue
+		}
+		members = append(members, member)
+	}
+
+	m.logger.WithField("count", len(members)).Debug("Collected local Administrators group members")
+	return members
+}
+
+// escapeWQL escapes a value for use inside a single-quoted WQL string
+func escapeWQL(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+}
+
+// parseGroupMember parses a Win32_GroupUser.PartComponent object path such as
+// \\HOST\root\cimv2:Win32_UserAccount.Domain="CONTOSO",Name="jdoe".
+// Members whose domain is the computer name are local.
+func parseGroupMember(path, computerName string) (models.LocalGroupMember, bool) {
+	_, ref, found := strings.Cut(path, ":")
+	if !found {
+		return models.LocalGroupMember{}, false
+	}
+	class, keys, found := strings.Cut(ref, ".")
+	if !found {
+		return models.LocalGroupMember{}, false
+	}
+	memberType, known := memberTypes[class]
+	if !known {
+		return models.LocalGroupMember{}, false
+	}
+
+	values := parseObjectPathKeys(keys)
+	name := values["Name"]
+	if name == "" {
+		return models.LocalGroupMember{}, false
+	}
+	domain := values["Domain"]
+
+	return models.LocalGroupMember{
+		Name:   name,
+		Domain: domain,
+		Type:   memberType,
+		Local:  strings.EqualFold(domain, computerName),
+	}, true
+}
+
+// parseObjectPathKeys parses the Key="value" pairs of a WMI object path,
+// unescaping backslash sequences inside the quoted values
+func parseObjectPathKeys(keys string) map[string]string {
+	values := make(map[string]string)
+	for keys != "" {
+		key, rest, found := strings.Cut(keys, "=")
+		if !found || !strings.HasPrefix(rest, `"`) {
+			break
+		}
+
+		var value strings.Builder
+		i := 1
+		for ; i < len(rest) && rest[i] != '"'; i++ {
+			if rest[i] == '\\' && i+1 < len(rest) {
+				i++
+			}
+			value.WriteByte(rest[i])
+		}
+		values[key] = value.String()
+
+		keys = strings.TrimPrefix(rest[min(i+1, len(rest)):], ",")
+	}
+	return values
+}
@@ -0,0 +1,96 @@
+package security
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+
+	"patchmon-agent/pkg/models"
+)
+
+// DefaultCertExpiryDays is how far ahead expiring certificates are reported
+// when cert_expiry_days is not set
+const DefaultCertExpiryDays = 30
+
+// GetExpiringCertificates lists the certificates in the LocalMachine\My store
+// that have expired or expire within the given number of days
+func (m *Manager) GetExpiringCertificates(days int) ([]models.Certificate, error) {
+	if days <= 0 {
+		days = DefaultCertExpiryDays
+	}
+
+	certs, err := readMachineCertificates("MY")
+	if err != nil {
+		return nil, err
+	}
+
+	expiring := expiringCertificates(certs, time.Now(), time.Duration(days)*24*time.Hour)
+	m.logger.WithField("count", len(expiring)).WithField("days", days).Debug("Collected expiring machine certificates")
+	return expiring, nil
+}
+
+// readMachineCertificates reads the certificates of a LocalMachine system store
+func readMachineCertificates(storeName string) ([]*x509.Certificate, error) {
+	name, err := windows.UTF16PtrFromString(storeName)
+	if err != nil {
+		return nil, err
+	}
+	store, err := windows.CertOpenStore(windows.CERT_STORE_PROV_SYSTEM, 0, 0,
+		windows.CERT_SYSTEM_STORE_LOCAL_MACHINE|windows.CERT_STORE_OPEN_EXISTING_FLAG|windows.CERT_STORE_READONLY_FLAG,
+		uintptr(unsafe.Pointer(name)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open LocalMachine\\%s store: %w", storeName, err)
+	}
+	defer windows.CertCloseStore(store, 0)
+
+	var certs []*x509.Certificate
+	var ctx *windows.CertContext
+	for {
+		// Enumeration ends with CRYPT_E_NOT_FOUND; the previous context is freed by the call
+		ctx, err = windows.CertEnumCertificatesInStore(store, ctx)
+		if err != nil || ctx == nil {
+			break
+		}
+		// The encoded certificate is owned by the context, which the next
+		// call frees, and the parsed certificate keeps referring to it
+		der := bytes.Clone(unsafe.Slice(ctx.EncodedCert, ctx.Length))
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			continue
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// expiringCertificates returns the certificates that are expired or expire
+// within window of now, soonest first
+func expiringCertificates(certs []*x509.Certificate, now time.Time, window time.Duration) []models.Certificate {
+	cutoff := now.Add(window)
+	expiring := []models.Certificate{}
+	for _, cert := range certs {
+		if cert.NotAfter.After(cutoff) {
+			continue
+		}
+		thumbprint := sha1.Sum(cert.Raw)
+		expiring = append(expiring, models.Certificate{
+			Subject:    cert.Subject.String(),
+			Issuer:     cert.Issuer.String(),
+			Thumbprint: strings.ToUpper(hex.EncodeToString(thumbprint[:])),
+			NotAfter:   cert.NotAfter.UTC().Format(time.RFC3339),
+			Expired:    cert.NotAfter.Before(now),
+		})
+	}
+	sort.Slice(expiring, func(i, j int) bool {
+		return expiring[i].NotAfter < expiring[j].NotAfter
+	})
+	return expiring
+}
@@ -0,0 +1,115 @@
+package security
+
+import (
+	"time"
+
+	"github.com/yusufpapurcu/wmi"
+
+	"patchmon-agent/pkg/models"
+)
+
+// defenderNamespace is the WMI namespace of the Microsoft Defender provider
+const defenderNamespace = `root\Microsoft\Windows\Defender`
+
+// mpComputerStatus maps the WMI MSFT_MpComputerStatus class
+type mpComputerStatus struct {
+	AMEngineVersion               string
+	AMProductVersion              string
+	AntivirusSignatureVersion     string
+	AntispywareSignatureVersion   string
+	NISSignatureVersion           string
+	AntivirusSignatureLastUpdated time.Time
+	AntivirusSignatureAge         uint32
+	AntivirusEnabled              bool
+	RealTimeProtectionEnabled     bool
+}
+
+// mpComputerHealth maps the health and scan fields of MSFT_MpComputerStatus.
+// They are queried separately because older Defender platforms lack some of
+// them (e.g. IsTamperProtected), which must not break signature collection.
+type mpComputerHealth struct {
+	AMRunningMode             string
+	AMServiceEnabled          bool
+	AntispywareEnabled        bool
+	BehaviorMonitorEnabled    bool
+	IoavProtectionEnabled     bool
+	OnAccessProtectionEnabled bool
+	IsTamperProtected         bool
+	QuickScanEndTime          time.Time
+	FullScanEndTime           time.Time
+}
+
+// GetDefenderInfo returns Microsoft Defender engine and signature versions.
+// It returns nil if Defender is not installed or its WMI provider is unavailable
+// (e.g. a third-party antivirus has taken over).
+func (m *Manager) GetDefenderInfo() *models.DefenderInfo {
+	var status []mpComputerStatus
+	if err := wmi.QueryNamespace("SELECT * FROM MSFT_MpComputerStatus", &status, defenderNamespace); err != nil {
+		m.logger.WithError(err).Debug("Microsoft Defender status not available")
+		return nil
+	}
+	if len(status) == 0 {
+		return nil
+	}
+
+	s := status[0]
+	info := &models.DefenderInfo{
+		EngineVersion:               s.AMEngineVersion,
+		ProductVersion:              s.AMProductVersion,
+		AntivirusSignatureVersion:   s.AntivirusSignatureVersion,
+		AntispywareSignatureVersion: s.AntispywareSignatureVersion,
+		NISSignatureVersion:         s.NISSignatureVersion,
+		SignatureAgeDays:            int(s.AntivirusSignatureAge),
+		AntivirusEnabled:            s.AntivirusEnabled,
+		RealTimeProtectionEnabled:   s.RealTimeProtectionEnabled,
+	}
+	if !s.AntivirusSignatureLastUpdated.IsZero() {
+		info.SignatureLastUpdated = s.AntivirusSignatureLastUpdated.UTC().Format(time.RFC3339)
+	}
+
+	m.logger.WithField("signature_version", info.AntivirusSignatureVersion).Debug("Collected Microsoft Defender info")
+	return info
+}
+
+// GetDefenderHealth returns Microsoft Defender protection state, tamper
+// protection and the last quick and full scan times. It returns nil if the
+// status cannot be read.
+func (m *Manager) GetDefenderHealth() *models.DefenderHealth {
+	var status []mpComputerHealth
+	query := "SELECT AMRunningMode, AMServiceEnabled, AntispywareEnabled, BehaviorMonitorEnabled, IoavProtectionEnabled, " +
+		"OnAccessProtectionEnabled, IsTamperProtected, QuickScanEndTime, FullScanEndTime FROM MSFT_MpComputerStatus"
+	if err := wmi.QueryNamespace(query, &status, defenderNamespace); err != nil {
+		m.logger.WithError(err).Debug("Microsoft Defender health status not available")
+		return nil
+	}
+	if len(status) == 0 {
+		return nil
+	}
+
+	s := status[0]
+	health := &models.DefenderHealth{
+		RunningMode:               s.AMRunningMode,
+		AntimalwareServiceEnabled: s.AMServiceEnabled,
+		AntispywareEnabled:        s.AntispywareEnabled,
+		BehaviorMonitorEnabled:    s.BehaviorMonitorEnabled,
+		IOAVProtectionEnabled:     s.IoavProtectionEnabled,
+		OnAccessProtectionEnabled: s.OnAccessProtectionEnabled,
+		TamperProtected:           s.IsTamperProtected,
+		LastQuickScan:             formatScanTime(s.QuickScanEndTime),
+		LastFullScan:              formatScanTime(s.FullScanEndTime),
+	}
+
+	m.logger.WithField("running_mode", health.RunningMode).
+		WithField("tamper_protected", health.TamperProtected).
+		Debug("Collected Microsoft Defender health")
+	return health
+}
+
+// formatScanTime formats a scan end time as RFC3339. Scans that never ran are
+// reported by WMI as an empty or 1601-01-01 (FILETIME zero) date and yield "".
+func formatScanTime(t time.Time) string {
+	if t.IsZero() || t.Year() <= 1601 {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
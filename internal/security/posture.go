@@ -0,0 +1,140 @@
+package security
+
+import (
+	"slices"
+
+	"github.com/yusufpapurcu/wmi"
+
+	"patchmon-agent/pkg/models"
+)
+
+// Registry locations of the hardening settings
+const (
+	lanmanServerParametersKey = `SYSTEM\CurrentControlSet\Services\LanmanServer\Parameters`
+	smb1ClientServiceKey      = `SYSTEM\CurrentControlSet\Services\mrxsmb10`
+	lsaKey                    = `SYSTEM\CurrentControlSet\Control\Lsa`
+	uacPolicyKey              = `SOFTWARE\Microsoft\Windows\CurrentVersion\Policies\System`
+)
+
+// deviceGuardNamespace is the WMI namespace of the Device Guard provider
+const deviceGuardNamespace = `root\Microsoft\Windows\DeviceGuard`
+
+// Win32_DeviceGuard.SecurityServicesRunning values
+const securityServiceCredentialGuard = 1
+
+// serviceStartDisabled is the Start value of a disabled service or driver
+const serviceStartDisabled = 4
+
+// UAC levels reported in SecurityPosture.UACLevel, named after the Control
+// Panel slider positions
+const (
+	UACLevelAlwaysNotify = "always_notify"
+	UACLevelDefault      = "default"
+	UACLevelNoDim        = "notify_no_dim"
+	UACLevelNeverNotify  = "never_notify"
+	UACLevelCustom       = "custom"
+)
+
+// vbsStatuses maps Win32_DeviceGuard.VirtualizationBasedSecurityStatus values
+var vbsStatuses = map[uint32]string{
+	0: "off",
+	1: "enabled",
+	2: "running",
+}
+
+// win32DeviceGuard maps the WMI Win32_DeviceGuard class
+type win32DeviceGuard struct {
+	VirtualizationBasedSecurityStatus uint32
+	SecurityServicesRunning           []uint32
+}
+
+// win32OptionalFeature maps the WMI Win32_OptionalFeature class
+type win32OptionalFeature struct {
+	InstallState uint32
+}
+
+// optionalFeatureEnabled is the Win32_OptionalFeature.InstallState of an enabled feature
+const optionalFeatureEnabled = 1
+
+// GetSecurityPosture reports OS hardening settings: SMBv1, LSA protection,
+// Credential Guard / virtualization-based security and UAC
+func (m *Manager) GetSecurityPosture() *models.SecurityPosture {
+	posture := &models.SecurityPosture{}
+
+	posture.SMBv1ServerEnabled, posture.SMBv1ClientEnabled = m.getSMBv1State()
+
+	runAsPPL, _ := readDWORD(lsaKey, "RunAsPPL")
+	// 1 = enabled with a UEFI lock, 2 = enabled without one
+	posture.LSAProtectionEnabled = runAsPPL == 1 || runAsPPL == 2
+
+	var deviceGuard []win32DeviceGuard
+	if err := wmi.QueryNamespace("SELECT VirtualizationBasedSecurityStatus, SecurityServicesRunning FROM Win32_DeviceGuard", &deviceGuard, deviceGuardNamespace); err != nil {
+		m.logger.WithError(err).Debug("Device Guard status not available")
+	} else if len(deviceGuard) > 0 {
+		posture.VBSStatus = vbsStatuses[deviceGuard[0].VirtualizationBasedSecurityStatus]
+		posture.CredentialGuardRunning = slices.Contains(deviceGuard[0].SecurityServicesRunning, securityServiceCredentialGuard)
+	}
+
+	enableLUA, ok := readDWORD(uacPolicyKey, "EnableLUA")
+	posture.UACEnabled = !ok || enableLUA != 0 // enabled unless explicitly turned off
+	if posture.UACEnabled {
+		consent, consentSet := readDWORD(uacPolicyKey, "ConsentPromptBehaviorAdmin")
+		secureDesktop, secureDesktopSet := readDWORD(uacPolicyKey, "PromptOnSecureDesktop")
+		posture.UACLevel = uacLevel(consent, consentSet, secureDesktop, secureDesktopSet)
+	}
+
+	m.logger.WithField("smb1_server", posture.SMBv1ServerEnabled).
+		WithField("lsa_protection", posture.LSAProtectionEnabled).
+		WithField("credential_guard", posture.CredentialGuardRunning).
+		WithField("uac", posture.UACLevel).
+		Debug("Collected security posture")
+	return posture
+}
+
+// getSMBv1State reports whether the SMBv1 server and client are enabled. On
+// Windows 10 1709 / Server 2019 and later SMBv1 is an optional feature that is
+// usually not installed; otherwise the server and client driver settings apply.
+func (m *Manager) getSMBv1State() (server, client bool) {
+	var features []win32OptionalFeature
+	err := wmi.Query("SELECT InstallState FROM Win32_OptionalFeature WHERE Name = 'SMB1Protocol'", &features)
+	if err != nil {
+		m.logger.WithError(err).Debug("Failed to query the SMB1Protocol feature")
+	} else if len(features) > 0 && features[0].InstallState != optionalFeatureEnabled {
+		return false, false
+	}
+
+	// The server supports SMBv1 unless SMB1 is explicitly set to 0
+	smb1, ok := readDWORD(lanmanServerParametersKey, "SMB1")
+	server = !ok || smb1 != 0
+
+	// The client needs the mrxsmb10 driver
+	start, ok := readDWORD(smb1ClientServiceKey, "Start")
+	client = ok && start != serviceStartDisabled
+
+	return server, client
+}
+
+// uacLevel maps the UAC consent behavior for administrators and the secure
+// desktop setting to the Control Panel slider positions. Missing values take
+// the Windows defaults (5 and 1).
+func uacLevel(consent uint64, consentSet bool, secureDesktop uint64, secureDesktopSet bool) string {
+	if !consentSet {
+		consent = 5
+	}
+	if !secureDesktopSet {
+		secureDesktop = 1
+	}
+
+	switch {
+	case consent == 2 && secureDesktop == 1:
+		return UACLevelAlwaysNotify
+	case consent == 5 && secureDesktop == 1:
+		return UACLevelDefault
+	case consent == 5 && secureDesktop == 0:
+		return UACLevelNoDim
+	case consent == 0 && secureDesktop == 0:
+		return UACLevelNeverNotify
+	default:
+		return UACLevelCustom
+	}
+}
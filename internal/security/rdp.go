@@ -0,0 +1,54 @@
+package security
+
+import (
+	"patchmon-agent/pkg/models"
+)
+
+// Registry locations of the Remote Desktop settings. Group Policy values under
+// terminalServicesPolicyKey take precedence over the local configuration.
+const (
+	terminalServerKey         = `SYSTEM\CurrentControlSet\Control\Terminal Server`
+	rdpTcpKey                 = terminalServerKey + `\WinStations\RDP-Tcp`
+	terminalServicesPolicyKey = `SOFTWARE\Policies\Microsoft\Windows NT\Terminal Services`
+)
+
+// defaultRDPPort is the port Remote Desktop listens on unless reconfigured
+const defaultRDPPort = 3389
+
+// GetRDPInfo reports whether Remote Desktop accepts connections, whether
+// Network Level Authentication is required, and the listening port
+func (m *Manager) GetRDPInfo() *models.RDPInfo {
+	deny, ok := readDWORD(terminalServerKey, "fDenyTSConnections")
+	if !ok {
+		m.logger.Debug("Remote Desktop configuration not found")
+		return nil
+	}
+	policyDeny, policySet := readDWORD(terminalServicesPolicyKey, "fDenyTSConnections")
+
+	// A missing UserAuthentication value means NLA is not required
+	nla, _ := readDWORD(rdpTcpKey, "UserAuthentication")
+	policyNLA, policyNLASet := readDWORD(terminalServicesPolicyKey, "UserAuthentication")
+
+	info := &models.RDPInfo{
+		Enabled:     effectiveValue(deny, policyDeny, policySet) == 0,
+		NLARequired: effectiveValue(nla, policyNLA, policyNLASet) == 1,
+		Port:        defaultRDPPort,
+	}
+	if port, ok := readDWORD(rdpTcpKey, "PortNumber"); ok && port > 0 && port <= 65535 {
+		info.Port = int(port)
+	}
+
+	m.logger.WithField("enabled", info.Enabled).
+		WithField("nla_required", info.NLARequired).
+		WithField("port", info.Port).
+		Debug("Collected Remote Desktop configuration")
+	return info
+}
+
+// effectiveValue returns the Group Policy value if it is set, else the local one
+func effectiveValue(local, policy uint64, policySet bool) uint64 {
+	if policySet {
+		return policy
+	}
+	return local
+}
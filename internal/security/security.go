@@ -0,0 +1,34 @@
+package security
+
+import (
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/windows/registry"
+)
+
+// Manager collects security posture information (antimalware, platform
+// protections) from the host
+type Manager struct {
+	logger *logrus.Logger
+}
+
+// New creates a new security manager
+func New(logger *logrus.Logger) *Manager {
+	return &Manager{
+		logger: logger,
+	}
+}
+
+// readDWORD reads an integer value from HKLM
+func readDWORD(keyPath, valueName string) (uint64, bool) {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, keyPath, registry.QUERY_VALUE)
+	if err != nil {
+		return 0, false
+	}
+	defer k.Close()
+
+	value, _, err := k.GetIntegerValue(valueName)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
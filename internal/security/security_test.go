@@ -0,0 +1,202 @@
+package security
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"patchmon-agent/pkg/models"
+)
+
+func TestNew(t *testing.T) {
+	logger := logrus.New()
+	mgr := New(logger)
+
+	if mgr == nil {
+		t.Fatal("New returned nil")
+	}
+	if mgr.logger != logger {
+		t.Error("Manager logger not set correctly")
+	}
+}
+
+// TestGetDefenderInfo is an integration test that queries the real Defender WMI provider.
+// Defender may legitimately be absent (e.g. replaced by a third-party antivirus).
+func TestGetDefenderInfo(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.DebugLevel)
+	mgr := New(logger)
+
+	info := mgr.GetDefenderInfo()
+	if info == nil {
+		t.Skip("Microsoft Defender is not available on this machine")
+	}
+
+	t.Logf("Defender: engine=%s signatures=%s updated=%s age=%dd",
+		info.EngineVersion, info.AntivirusSignatureVersion, info.SignatureLastUpdated, info.SignatureAgeDays)
+
+	if info.EngineVersion == "" {
+		t.Error("EngineVersion should not be empty")
+	}
+	if info.AntivirusSignatureVersion == "" {
+		t.Error("AntivirusSignatureVersion should not be empty")
+	}
+}
+
+func TestNewThreatNames(t *testing.T) {
+	start := time.Date(2024, 3, 12, 18, 0, 0, 0, time.UTC)
+	detections := []mpThreatDetection{
+		{ThreatID: 1, InitialDetectionTime: start.Add(-time.Hour)},
+		{ThreatID: 2, InitialDetectionTime: start.Add(time.Minute)},
+		{ThreatID: 3, InitialDetectionTime: start.Add(2 * time.Minute)},
+		{ThreatID: 2, InitialDetectionTime: start.Add(3 * time.Minute)},
+	}
+	threats := []mpThreat{
+		{ThreatID: 1, ThreatName: "Trojan:Win32/Old"},
+		{ThreatID: 2, ThreatName: "Virus:DOS/EICAR_Test_File"},
+	}
+
+	got := newThreatNames(detections, threats, start)
+	want := []string{"ThreatID 3", "Virus:DOS/EICAR_Test_File"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("newThreatNames() = %v, want %v", got, want)
+	}
+}
+
+func TestRunDefenderScan_InvalidType(t *testing.T) {
+	mgr := New(logrus.New())
+	if _, err := mgr.RunDefenderScan("custom"); err == nil {
+		t.Error("expected an error for an unsupported scan type")
+	}
+}
+
+func TestFormatScanTime(t *testing.T) {
+	tests := []struct {
+		in   time.Time
+		want string
+	}{
+		{time.Time{}, ""},
+		{time.Date(1601, 1, 1, 0, 0, 0, 0, time.UTC), ""},
+		{time.Date(2024, 3, 12, 19, 30, 0, 0, time.FixedZone("CET", 3600)), "2024-03-12T18:30:00Z"},
+	}
+	for _, tt := range tests {
+		if got := formatScanTime(tt.in); got != tt.want {
+			t.Errorf("formatScanTime(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEffectiveValue(t *testing.T) {
+	if got := effectiveValue(0, 1, true); got != 1 {
+		t.Errorf("policy value should win, got %d", got)
+	}
+	if got := effectiveValue(0, 1, false); got != 0 {
+		t.Errorf("local value should be used when no policy is set, got %d", got)
+	}
+}
+
+func TestUACLevel(t *testing.T) {
+	tests := []struct {
+		name             string
+		consent          uint64
+		consentSet       bool
+		secureDesktop    uint64
+		secureDesktopSet bool
+		want             string
+	}{
+		{"defaults", 0, false, 0, false, UACLevelDefault},
+		{"always notify", 2, true, 1, true, UACLevelAlwaysNotify},
+		{"default", 5, true, 1, true, UACLevelDefault},
+		{"no dimming", 5, true, 0, true, UACLevelNoDim},
+		{"never notify", 0, true, 0, true, UACLevelNeverNotify},
+		{"prompt for credentials", 1, true, 1, true, UACLevelCustom},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := uacLevel(tt.consent, tt.consentSet, tt.secureDesktop, tt.secureDesktopSet); got != tt.want {
+				t.Errorf("uacLevel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGroupMember(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		want   models.LocalGroupMember
+		wantOK bool
+	}{
+		{
+			name:   "local user",
+			path:   `\\SRV01\root\cimv2:Win32_UserAccount.Domain="SRV01",Name="Administrator"`,
+			want:   models.LocalGroupMember{Name: "Administrator", Domain: "SRV01", Type: MemberTypeUser, Local: true},
+			wantOK: true,
+		},
+		{
+			name:   "nested domain group",
+			path:   `\\SRV01\root\cimv2:Win32_Group.Domain="CONTOSO",Name="Domain Admins"`,
+			want:   models.LocalGroupMember{Name: "Domain Admins", Domain: "CONTOSO", Type: MemberTypeGroup},
+			wantOK: true,
+		},
+		{
+			name:   "escaped quote in name",
+			path:   `\\SRV01\root\cimv2:Win32_UserAccount.Domain="CONTOSO",Name="o\"brien"`,
+			want:   models.LocalGroupMember{Name: `o"brien`, Domain: "CONTOSO", Type: MemberTypeUser},
+			wantOK: true,
+		},
+		{
+			name:   "system account",
+			path:   `\\SRV01\root\cimv2:Win32_SystemAccount.Domain="SRV01",Name="INTERACTIVE"`,
+			want:   models.LocalGroupMember{Name: "INTERACTIVE", Domain: "SRV01", Type: MemberTypeSystem, Local: true},
+			wantOK: true,
+		},
+		{name: "unknown class", path: `\\SRV01\root\cimv2:Win32_Foo.Name="x"`},
+		{name: "not an object path", path: "garbage"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseGroupMember(tt.path, "srv01")
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("parseGroupMember() = %+v, %v; want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestExpiringCertificates(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	newCert := func(cn string, notAfter time.Time) *x509.Certificate {
+		return &x509.Certificate{
+			Raw:      []byte(cn),
+			Subject:  pkix.Name{CommonName: cn},
+			Issuer:   pkix.Name{CommonName: "Contoso Issuing CA"},
+			NotAfter: notAfter,
+		}
+	}
+	certs := []*x509.Certificate{
+		newCert("far.contoso.com", now.AddDate(1, 0, 0)),
+		newCert("soon.contoso.com", now.AddDate(0, 0, 10)),
+		newCert("expired.contoso.com", now.AddDate(0, 0, -3)),
+	}
+
+	got := expiringCertificates(certs, now, 30*24*time.Hour)
+	if len(got) != 2 {
+		t.Fatalf("expiringCertificates() returned %d certificates, want 2: %+v", len(got), got)
+	}
+	if got[0].Subject != "CN=expired.contoso.com" || !got[0].Expired {
+		t.Errorf("first certificate = %+v, want the expired one", got[0])
+	}
+	if got[1].Subject != "CN=soon.contoso.com" || got[1].Expired || got[1].NotAfter != "2024-06-11T00:00:00Z" {
+		t.Errorf("second certificate = %+v, want the one expiring soon", got[1])
+	}
+	if got[1].Issuer != "CN=Contoso Issuing CA" || len(got[1].Thumbprint) != 40 {
+		t.Errorf("unexpected issuer or thumbprint: %+v", got[1])
+	}
+}
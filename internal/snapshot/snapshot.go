@@ -0,0 +1,188 @@
+package snapshot
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"patchmon-agent/internal/store"
+	"patchmon-agent/pkg/models"
+)
+
+// storeKey is the key of the last sent report in the state store
+const storeKey = "last-report"
+
+// Kinds of change
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Snapshot is the last report sent to the server
+type Snapshot struct {
+	SentAt  string                `json:"sentAt"` // RFC3339
+	Payload *models.ReportPayload `json:"payload"`
+}
+
+// Change is an item that was added, removed or changed between two reports
+type Change struct {
+	Category string `json:"category"` // update, appx, driver, disk, memory, cpu, bios
+	Name     string `json:"name"`
+	Change   string `json:"change"`
+	Old      string `json:"old,omitempty"`
+	New      string `json:"new,omitempty"`
+}
+
+// Diff holds the package and hardware changes between two reports
+type Diff struct {
+	Since    string   `json:"since"` // when the older report was sent
+	Packages []Change `json:"packages"`
+	Hardware []Change `json:"hardware"`
+}
+
+// Empty reports whether nothing changed
+func (d *Diff) Empty() bool {
+	return len(d.Packages) == 0 && len(d.Hardware) == 0
+}
+
+// Save stores payload as the last sent report in the state store at path
+func Save(path string, payload *models.ReportPayload, sentAt time.Time) error {
+	return store.Put(path, store.BucketReports, storeKey, &Snapshot{SentAt: sentAt.UTC().Format(time.RFC3339), Payload: payload})
+}
+
+// Load returns the last sent report from the state store at path, or nil if
+// no report was stored yet
+func Load(path string) (*Snapshot, error) {
+	var snap Snapshot
+	found, err := store.Get(path, store.BucketReports, storeKey, &snap)
+	if err != nil || !found || snap.Payload == nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+// Compare returns the package and hardware changes from the snapshot to the
+// current payload
+func (s *Snapshot) Compare(current *models.ReportPayload) *Diff {
+	old := s.Payload
+	diff := &Diff{Since: s.SentAt}
+
+	diff.Packages = append(diff.Packages, compare("update", packageStates(old.Packages), packageStates(current.Packages))...)
+	diff.Packages = append(diff.Packages, compare("appx", appxStates(old.AppxPackages), appxStates(current.AppxPackages))...)
+
+	diff.Hardware = append(diff.Hardware, compare("cpu", cpuStates(old), cpuStates(current))...)
+	diff.Hardware = append(diff.Hardware, compare("memory", memoryStates(old), memoryStates(current))...)
+	diff.Hardware = append(diff.Hardware, compare("disk", diskStates(old.DiskDetails), diskStates(current.DiskDetails))...)
+	diff.Hardware = append(diff.Hardware, compare("bios", biosStates(old.SystemIdentity), biosStates(current.SystemIdentity))...)
+	diff.Hardware = append(diff.Hardware, compare("driver", driverStates(old.Drivers), driverStates(current.Drivers))...)
+	return diff
+}
+
+// states maps item names to a description of their state. Items sharing a
+// name, e.g. several devices using the same driver, are described together.
+type states map[string][]string
+
+func (s states) add(name, state string) {
+	s[name] = append(s[name], state)
+}
+
+func (s states) get(name string) string {
+	values := append([]string(nil), s[name]...)
+	sort.Strings(values)
+	return strings.Join(values, ", ")
+}
+
+// compare lists the items added, removed or changed from old to current,
+// sorted by name
+func compare(category string, old, current states) []Change {
+	var changes []Change
+	for name := range old {
+		if _, ok := current[name]; !ok {
+			changes = append(changes, Change{Category: category, Name: name, Change: Removed, Old: old.get(name)})
+		} else if o, c := old.get(name), current.get(name); o != c {
+			changes = append(changes, Change{Category: category, Name: name, Change: Changed, Old: o, New: c})
+		}
+	}
+	for name := range current {
+		if _, ok := old[name]; !ok {
+			changes = append(changes, Change{Category: category, Name: name, Change: Added, New: current.get(name)})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+func packageStates(packages []models.Package) states {
+	s := states{}
+	for _, p := range packages {
+		switch {
+		case p.NeedsUpdate && p.AvailableVersion != "":
+			s.add(p.Name, "available "+p.AvailableVersion)
+		case p.NeedsUpdate:
+			s.add(p.Name, "available")
+		case p.CurrentVersion != "":
+			s.add(p.Name, "installed "+p.CurrentVersion)
+		default:
+			s.add(p.Name, "installed")
+		}
+	}
+	return s
+}
+
+func appxStates(packages []models.AppxPackage) states {
+	s := states{}
+	for _, p := range packages {
+		s.add(p.Name, p.Version)
+	}
+	return s
+}
+
+func cpuStates(p *models.ReportPayload) states {
+	s := states{}
+	if p.CPUModel != "" || p.CPUCores > 0 {
+		s.add("CPU", fmt.Sprintf("%s, %d cores", p.CPUModel, p.CPUCores))
+	}
+	return s
+}
+
+func memoryStates(p *models.ReportPayload) states {
+	s := states{}
+	if p.RAMInstalled > 0 {
+		s.add("RAM", strconv.FormatFloat(p.RAMInstalled, 'f', -1, 64)+" GB")
+	}
+	for _, m := range p.MemoryModules {
+		name := m.Slot
+		if name == "" {
+			name = m.Bank
+		}
+		s.add("module "+name, strings.TrimSpace(fmt.Sprintf("%s GB %s %s", strconv.FormatFloat(m.SizeGB, 'f', -1, 64), m.Type, m.PartNumber)))
+	}
+	return s
+}
+
+func diskStates(disks []models.DiskInfo) states {
+	s := states{}
+	for _, d := range disks {
+		s.add(d.Name, d.Size)
+	}
+	return s
+}
+
+func biosStates(identity *models.SystemIdentity) states {
+	s := states{}
+	if identity != nil && identity.BIOSVersion != "" {
+		s.add("BIOS", strings.TrimSpace(identity.BIOSVersion+" "+identity.BIOSReleaseDate))
+	}
+	return s
+}
+
+func driverStates(drivers []models.Driver) states {
+	s := states{}
+	for _, d := range drivers {
+		s.add(d.DeviceName, d.Version)
+	}
+	return s
+}
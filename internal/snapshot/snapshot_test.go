@@ -0,0 +1,81 @@
+package snapshot
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"patchmon-agent/pkg/models"
+)
+
+func TestCompare(t *testing.T) {
+	old := &models.ReportPayload{
+		Packages: []models.Package{
+			{Name: "KB5034441", NeedsUpdate: true, AvailableVersion: "KB5034441"},
+			{Name: "KB5033375", CurrentVersion: "KB5033375"},
+		},
+		CPUModel:     "Intel Xeon",
+		CPUCores:     4,
+		RAMInstalled: 16,
+		Drivers: []models.Driver{
+			{DeviceName: "WAN Miniport", Version: "10.0.1"},
+			{DeviceName: "WAN Miniport", Version: "10.0.2"},
+			{DeviceName: "Intel Ethernet", Version: "1.0"},
+		},
+	}
+	current := &models.ReportPayload{
+		Packages: []models.Package{
+			{Name: "KB5034441", CurrentVersion: "KB5034441"},
+			{Name: "KB5033375", CurrentVersion: "KB5033375"},
+			{Name: "KB5035853", NeedsUpdate: true},
+		},
+		CPUModel:     "Intel Xeon",
+		CPUCores:     4,
+		RAMInstalled: 32,
+		Drivers: []models.Driver{
+			{DeviceName: "WAN Miniport", Version: "10.0.2"},
+			{DeviceName: "WAN Miniport", Version: "10.0.1"},
+		},
+	}
+
+	diff := (&Snapshot{SentAt: "2024-03-12T08:00:00Z", Payload: old}).Compare(current)
+
+	wantPackages := []Change{
+		{Category: "update", Name: "KB5034441", Change: Changed, Old: "available KB5034441", New: "installed KB5034441"},
+		{Category: "update", Name: "KB5035853", Change: Added, New: "available"},
+	}
+	if !reflect.DeepEqual(diff.Packages, wantPackages) {
+		t.Errorf("Packages = %+v, want %+v", diff.Packages, wantPackages)
+	}
+	wantHardware := []Change{
+		{Category: "memory", Name: "RAM", Change: Changed, Old: "16 GB", New: "32 GB"},
+		{Category: "driver", Name: "Intel Ethernet", Change: Removed, Old: "1.0"},
+	}
+	if !reflect.DeepEqual(diff.Hardware, wantHardware) {
+		t.Errorf("Hardware = %+v, want %+v", diff.Hardware, wantHardware)
+	}
+
+	if diff := (&Snapshot{Payload: current}).Compare(current); !diff.Empty() {
+		t.Errorf("Compare() with itself = %+v, want no changes", diff)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.db")
+	if snap, err := Load(path); err != nil || snap != nil {
+		t.Fatalf("Load() before Save() = %v, %v, want no snapshot", snap, err)
+	}
+
+	payload := &models.ReportPayload{Hostname: "srv01"}
+	if err := Save(path, payload, time.Date(2024, 3, 12, 8, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	snap, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if snap.SentAt != "2024-03-12T08:00:00Z" || snap.Payload.Hostname != "srv01" {
+		t.Errorf("Load() = %+v, want the saved report", snap)
+	}
+}
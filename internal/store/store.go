@@ -0,0 +1,139 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Buckets of the state database
+const (
+	BucketCache   = "cache"   // data that can be rebuilt, e.g. installed updates
+	BucketReports = "reports" // last report snapshot and report history
+	BucketMarkers = "markers" // timestamps such as the last agent update
+)
+
+// lockTimeout bounds the wait for another agent process to release the
+// database. It is opened for each operation only, so a running serve does not
+// lock out other commands.
+const lockTimeout = 10 * time.Second
+
+// Get decodes the value stored under key into v. It reports false if there
+// is no such value.
+func Get(path, bucket, key string, v any) (bool, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	db, err := open(path)
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+
+	found := false
+	err = db.View(func(tx *bolt.Tx) error {
+		data := get(tx, bucket, key)
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, v)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s/%s from state store: %w", bucket, key, err)
+	}
+	return found, nil
+}
+
+// Put stores v under key
+func Put(path, bucket, key string, v any) error {
+	return Update(path, bucket, key, new(json.RawMessage), func(found bool) (any, error) {
+		return v, nil
+	})
+}
+
+// Update reads the value under key into v, calls modify with whether it was
+// found and stores the value modify returns, all in one transaction. A nil
+// value from modify leaves the store unchanged.
+func Update(path, bucket, key string, v any, modify func(found bool) (any, error)) error {
+	db, err := open(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		found := false
+		if data := get(tx, bucket, key); data != nil {
+			// A value that no longer decodes is replaced
+			found = json.Unmarshal(data, v) == nil
+		}
+		value, err := modify(found)
+		if err != nil || value == nil {
+			return err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(key), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write %s/%s to state store: %w", bucket, key, err)
+	}
+	return nil
+}
+
+// Delete removes the value under key
+func Delete(path, bucket, key string) error {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	db, err := open(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+		return b.Delete([]byte(key))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete %s/%s from state store: %w", bucket, key, err)
+	}
+	return nil
+}
+
+// open opens the database, creating it and its directory if needed
+func open(path string) (*bolt.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: lockTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state store %s: %w", path, err)
+	}
+	return db, nil
+}
+
+// get returns the raw value under key, or nil
+func get(tx *bolt.Tx, bucket, key string) []byte {
+	b := tx.Bucket([]byte(bucket))
+	if b == nil {
+		return nil
+	}
+	return b.Get([]byte(key))
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetPutDelete(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "state.db")
+
+	var got []string
+	if found, err := Get(path, BucketCache, "key", &got); err != nil || found {
+		t.Fatalf("Get() on a missing store = %v, %v, want not found", found, err)
+	}
+
+	if err := Put(path, BucketCache, "key", []string{"a", "b"}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	found, err := Get(path, BucketCache, "key", &got)
+	if err != nil || !found || len(got) != 2 || got[1] != "b" {
+		t.Errorf("Get() = %v, %v, %v, want the stored value", got, found, err)
+	}
+
+	if found, err := Get(path, BucketMarkers, "key", &got); err != nil || found {
+		t.Errorf("Get() from another bucket = %v, %v, want not found", found, err)
+	}
+
+	if err := Delete(path, BucketCache, "key"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if found, err := Get(path, BucketCache, "key", &got); err != nil || found {
+		t.Errorf("Get() after Delete() = %v, %v, want not found", found, err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.db")
+
+	for i := 0; i < 3; i++ {
+		var count int
+		err := Update(path, BucketReports, "count", &count, func(found bool) (any, error) {
+			if found != (i > 0) {
+				t.Errorf("run %d: found = %v", i, found)
+			}
+			return count + 1, nil
+		})
+		if err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+	}
+	var count int
+	if _, err := Get(path, BucketReports, "count", &count); err != nil || count != 3 {
+		t.Errorf("count = %d, %v, want 3", count, err)
+	}
+
+	// An error from modify leaves the value unchanged
+	errStop := errors.New("stop")
+	err := Update(path, BucketReports, "count", &count, func(bool) (any, error) { return 100, errStop })
+	if !errors.Is(err, errStop) {
+		t.Errorf("Update() error = %v, want the error of modify", err)
+	}
+	if _, err := Get(path, BucketReports, "count", &count); err != nil || count != 3 {
+		t.Errorf("count = %d, %v, want 3 unchanged", count, err)
+	}
+}
@@ -0,0 +1,98 @@
+package system
+
+import (
+	"os/exec"
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+
+	"patchmon-agent/pkg/models"
+)
+
+// enrollmentsKey holds one subkey per MDM enrollment
+const enrollmentsKey = `SOFTWARE\Microsoft\Enrollments`
+
+// enrollmentStateEnrolled is the EnrollmentState of an active enrollment
+const enrollmentStateEnrolled = 1
+
+// GetCloudJoinInfo returns the Microsoft Entra ID (Azure AD) join state from
+// dsregcmd /status and the MDM enrollment state from the registry. It returns
+// nil if neither is available.
+func (d *Detector) GetCloudJoinInfo() *models.CloudJoinInfo {
+	var info *models.CloudJoinInfo
+
+	output, err := exec.Command("dsregcmd", "/status").Output()
+	if err != nil {
+		d.logger.WithError(err).Debug("dsregcmd /status failed")
+	} else {
+		info = parseDsregcmdStatus(string(output))
+	}
+
+	if provider, upn, ok := readMDMEnrollment(); ok {
+		if info == nil {
+			info = &models.CloudJoinInfo{}
+		}
+		info.MDMEnrolled = true
+		info.MDMProvider = provider
+		info.MDMUser = upn
+	}
+
+	return info
+}
+
+// parseDsregcmdStatus parses the "Name : Value" lines of dsregcmd /status
+func parseDsregcmdStatus(output string) *models.CloudJoinInfo {
+	values := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		name, value, found := strings.Cut(line, " : ")
+		if !found {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		if _, seen := values[name]; !seen {
+			values[name] = strings.TrimSpace(value)
+		}
+	}
+	if _, ok := values["AzureAdJoined"]; !ok {
+		return nil
+	}
+
+	info := &models.CloudJoinInfo{
+		AzureADJoined:   strings.EqualFold(values["AzureAdJoined"], "YES"),
+		WorkplaceJoined: strings.EqualFold(values["WorkplaceJoined"], "YES"),
+		TenantName:      values["TenantName"],
+		TenantID:        values["TenantId"],
+		DeviceID:        values["DeviceId"],
+	}
+	info.HybridJoined = info.AzureADJoined && strings.EqualFold(values["DomainJoined"], "YES")
+	return info
+}
+
+// readMDMEnrollment returns the provider and user of the first active MDM
+// enrollment ("MS DM Server" for Intune)
+func readMDMEnrollment() (provider, upn string, ok bool) {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, enrollmentsKey, registry.ENUMERATE_SUB_KEYS)
+	if err != nil {
+		return "", "", false
+	}
+	defer k.Close()
+
+	names, err := k.ReadSubKeyNames(-1)
+	if err != nil {
+		return "", "", false
+	}
+
+	for _, name := range names {
+		enrollmentKey := enrollmentsKey + `\` + name
+		state, ok := readRegistryDWORD(enrollmentKey, "EnrollmentState")
+		if !ok || state != enrollmentStateEnrolled {
+			continue
+		}
+		provider = readRegistryString(enrollmentKey, "ProviderID")
+		if provider == "" {
+			continue
+		}
+		return provider, readRegistryString(enrollmentKey, "UPN"), true
+	}
+	return "", "", false
+}
@@ -0,0 +1,74 @@
+package system
+
+import (
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+
+	"patchmon-agent/pkg/models"
+)
+
+// Join types reported in DomainInfo.JoinType
+const (
+	JoinTypeDomain    = "domain"
+	JoinTypeWorkgroup = "workgroup"
+)
+
+// Registry locations of the directory placement
+const (
+	tcpipParametersKey    = `SYSTEM\CurrentControlSet\Services\Tcpip\Parameters`
+	netlogonParametersKey = `SYSTEM\CurrentControlSet\Services\Netlogon\Parameters`
+	groupPolicyMachineKey = `SOFTWARE\Microsoft\Windows\CurrentVersion\Group Policy\State\Machine`
+)
+
+// GetDomainInfo returns the domain or workgroup membership of the computer
+// and, for domain members, its DNS domain, Active Directory site and
+// distinguished name. It returns nil if the join state cannot be read.
+func (d *Detector) GetDomainInfo() *models.DomainInfo {
+	var name *uint16
+	var bufType uint32
+	if err := windows.NetGetJoinInformation(nil, &name, &bufType); err != nil {
+		d.logger.WithError(err).Warn("Failed to get domain join information")
+		return nil
+	}
+	defer windows.NetApiBufferFree((*byte)(unsafe.Pointer(name)))
+
+	info := &models.DomainInfo{Name: windows.UTF16PtrToString(name)}
+	switch bufType {
+	case windows.NetSetupDomainName:
+		info.JoinType = JoinTypeDomain
+	case windows.NetSetupWorkgroupName:
+		info.JoinType = JoinTypeWorkgroup
+	default:
+		d.logger.WithField("status", bufType).Debug("Computer is not joined to a domain or workgroup")
+		return nil
+	}
+
+	if info.JoinType == JoinTypeDomain {
+		info.DNSDomain = readRegistryString(tcpipParametersKey, "Domain")
+		info.Site = readRegistryString(netlogonParametersKey, "DynamicSiteName")
+		if info.Site == "" {
+			info.Site = readRegistryString(netlogonParametersKey, "SiteName")
+		}
+		// Written by Group Policy processing; empty until the first refresh
+		info.DistinguishedName = readRegistryString(groupPolicyMachineKey, "Distinguished-Name")
+		info.OU = parentDN(info.DistinguishedName)
+	}
+
+	return info
+}
+
+// parentDN returns the distinguished name of the container of an object, e.g.
+// OU=Servers,DC=contoso,DC=com for CN=SRV01,OU=Servers,DC=contoso,DC=com
+func parentDN(dn string) string {
+	for i := 0; i < len(dn); i++ {
+		switch dn[i] {
+		case '\\':
+			i++ // skip the escaped character
+		case ',':
+			return strings.TrimSpace(dn[i+1:])
+		}
+	}
+	return ""
+}
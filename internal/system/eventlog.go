@@ -0,0 +1,106 @@
+package system
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"patchmon-agent/internal/utils"
+	"patchmon-agent/pkg/models"
+)
+
+// EventLogWindow is how far back the System log is summarized
+const EventLogWindow = 24 * time.Hour
+
+// Event levels
+const (
+	eventLevelCritical = 1
+	eventLevelError    = 2
+)
+
+// unexpectedShutdownEvents identifies the events logged after the machine
+// went down without a clean shutdown, by provider and event ID
+var unexpectedShutdownEvents = map[string]int{
+	"EventLog":                       6008, // The previous system shutdown was unexpected
+	"Microsoft-Windows-Kernel-Power": 41,   // The system has rebooted without cleanly shutting down first
+}
+
+// eventLogQuery lists the critical and error events of the System log since
+// the start time, with timestamps in UTC
+const eventLogQuery = `Get-WinEvent -FilterHashtable @{LogName='System'; Level=1,2; StartTime=[DateTime]::Parse('%s').ToLocalTime()} -ErrorAction SilentlyContinue | ` +
+	`Select-Object ProviderName, Id, Level, @{n='TimeCreated';e={$_.TimeCreated.ToUniversalTime().ToString('o')}} | ConvertTo-Json -Compress`
+
+// winEvent holds the fields we need from Get-WinEvent
+type winEvent struct {
+	ProviderName string
+	ID           int `json:"Id"`
+	Level        int
+	TimeCreated  string
+}
+
+// GetEventLogSummary counts the critical and error events logged to the
+// System log in the last EventLogWindow, by source, and lists unexpected
+// shutdowns
+func (d *Detector) GetEventLogSummary() (*models.EventLogSummary, error) {
+	since := time.Now().Add(-EventLogWindow).UTC()
+	output, err := utils.RunPowerShell(fmt.Sprintf(eventLogQuery, since.Format(time.RFC3339)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the System event log: %w", err)
+	}
+
+	events, err := utils.UnmarshalPowerShellJSON[winEvent](output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse System event log entries: %w", err)
+	}
+
+	summary := summarizeEvents(events, since)
+	d.logger.WithField("critical", summary.Critical).
+		WithField("errors", summary.Errors).
+		WithField("unexpected_shutdowns", len(summary.UnexpectedShutdowns)).
+		Debug("Summarized System event log")
+	return summary, nil
+}
+
+// summarizeEvents counts events by level and source, most frequent source first
+func summarizeEvents(events []winEvent, since time.Time) *models.EventLogSummary {
+	summary := &models.EventLogSummary{
+		Since:               since.Format(time.RFC3339),
+		Sources:             []models.EventSourceCount{},
+		UnexpectedShutdowns: []string{},
+	}
+
+	bySource := make(map[string]*models.EventSourceCount)
+	for _, event := range events {
+		source, ok := bySource[event.ProviderName]
+		if !ok {
+			source = &models.EventSourceCount{Source: event.ProviderName}
+			bySource[event.ProviderName] = source
+		}
+
+		switch event.Level {
+		case eventLevelCritical:
+			summary.Critical++
+			source.Critical++
+		case eventLevelError:
+			summary.Errors++
+			source.Errors++
+		}
+
+		if id, ok := unexpectedShutdownEvents[event.ProviderName]; ok && id == event.ID {
+			summary.UnexpectedShutdowns = append(summary.UnexpectedShutdowns, event.TimeCreated)
+		}
+	}
+
+	for _, source := range bySource {
+		summary.Sources = append(summary.Sources, *source)
+	}
+	sort.Slice(summary.Sources, func(i, j int) bool {
+		a, b := summary.Sources[i], summary.Sources[j]
+		if a.Critical+a.Errors != b.Critical+b.Errors {
+			return a.Critical+a.Errors > b.Critical+b.Errors
+		}
+		return a.Source < b.Source
+	})
+	sort.Strings(summary.UnexpectedShutdowns)
+	return summary
+}
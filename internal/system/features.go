@@ -0,0 +1,74 @@
+package system
+
+import (
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	"github.com/yusufpapurcu/wmi"
+
+	"patchmon-agent/pkg/models"
+)
+
+// Windows feature types reported in the payload
+const (
+	FeatureTypeOptional = "optional-feature"
+	FeatureTypeServer   = "server-feature"
+)
+
+// win32OptionalFeature maps the WMI Win32_OptionalFeature class
+type win32OptionalFeature struct {
+	Name    string
+	Caption string
+}
+
+// win32ServerFeature maps the WMI Win32_ServerFeature class (Windows Server only)
+type win32ServerFeature struct {
+	ID   uint32
+	Name string
+}
+
+// GetWindowsFeatures returns the enabled Windows optional features (DISM) and,
+// on Windows Server, the installed server roles and features.
+func (d *Detector) GetWindowsFeatures() []models.WindowsFeature {
+	features := []models.WindowsFeature{}
+
+	// InstallState 1 = Enabled
+	var optional []win32OptionalFeature
+	if err := wmi.Query("SELECT Name, Caption FROM Win32_OptionalFeature WHERE InstallState = 1", &optional); err != nil {
+		d.logger.WithError(err).Warn("Failed to query optional features")
+	}
+	for _, feature := range optional {
+		features = append(features, models.WindowsFeature{
+			Name:        feature.Name,
+			DisplayName: feature.Caption,
+			Type:        FeatureTypeOptional,
+		})
+	}
+
+	// Win32_ServerFeature only exists on Windows Server, so a failure here is expected on clients
+	var serverFeatures []win32ServerFeature
+	if err := wmi.Query("SELECT ID, Name FROM Win32_ServerFeature", &serverFeatures); err != nil {
+		d.logger.WithError(err).Debug("Win32_ServerFeature not available (not a Windows Server host)")
+	}
+	for _, feature := range serverFeatures {
+		features = append(features, models.WindowsFeature{
+			Name:        feature.Name,
+			DisplayName: feature.Name,
+			Type:        FeatureTypeServer,
+		})
+	}
+
+	sort.SliceStable(features, func(i, j int) bool {
+		if features[i].Type != features[j].Type {
+			return features[i].Type < features[j].Type
+		}
+		return features[i].Name < features[j].Name
+	})
+
+	d.logger.WithFields(logrus.Fields{
+		"optional": len(optional),
+		"server":   len(serverFeatures),
+	}).Debug("Collected Windows features")
+
+	return features
+}
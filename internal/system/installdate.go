@@ -0,0 +1,84 @@
+package system
+
+import (
+	"strings"
+	"time"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// setupKey holds a "Source OS (Updated on ...)" subkey with the previous
+// CurrentVersion values for every feature update applied to the install
+const setupKey = `SYSTEM\Setup`
+
+// sourceOSPrefix is the name prefix of the pre-upgrade snapshots under setupKey
+const sourceOSPrefix = "Source OS"
+
+// osInstall is the install date and build of an OS installation or of the
+// release it was upgraded from
+type osInstall struct {
+	installDate uint64 // Unix seconds
+	build       string
+}
+
+// GetOSInstallInfo returns the date the running release was installed, which
+// is reset by feature updates, and the date and build of the original
+// installation the machine was imaged with. Dates are RFC3339 UTC, empty if
+// unknown.
+func (d *Detector) GetOSInstallInfo() (installDate, originalInstallDate, originalBuild string) {
+	current := osInstall{build: readRegistryString(ntCurrentVersionKey, "CurrentBuild")}
+	current.installDate, _ = readRegistryDWORD(ntCurrentVersionKey, "InstallDate")
+
+	original := originalInstall(current, readSourceOSInstalls())
+	d.logger.WithField("original_build", original.build).Debug("Collected OS install date")
+	return formatInstallDate(current.installDate), formatInstallDate(original.installDate), original.build
+}
+
+// readSourceOSInstalls reads the pre-upgrade snapshots left by feature updates
+func readSourceOSInstalls() []osInstall {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, setupKey, registry.ENUMERATE_SUB_KEYS)
+	if err != nil {
+		return nil
+	}
+	defer k.Close()
+
+	names, err := k.ReadSubKeyNames(-1)
+	if err != nil {
+		return nil
+	}
+
+	var installs []osInstall
+	for _, name := range names {
+		if !strings.HasPrefix(name, sourceOSPrefix) {
+			continue
+		}
+		keyPath := setupKey + `\` + name
+		install := osInstall{build: readRegistryString(keyPath, "CurrentBuild")}
+		install.installDate, _ = readRegistryDWORD(keyPath, "InstallDate")
+		installs = append(installs, install)
+	}
+	return installs
+}
+
+// originalInstall returns the earliest installation among the pre-upgrade
+// snapshots, or current if the machine has never been upgraded in place
+func originalInstall(current osInstall, sources []osInstall) osInstall {
+	original := current
+	for _, source := range sources {
+		if source.installDate == 0 {
+			continue
+		}
+		if original.installDate == 0 || source.installDate < original.installDate {
+			original = source
+		}
+	}
+	return original
+}
+
+// formatInstallDate formats an InstallDate value, returning "" if it is unset
+func formatInstallDate(seconds uint64) string {
+	if seconds == 0 {
+		return ""
+	}
+	return time.Unix(int64(seconds), 0).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,122 @@
+package system
+
+import (
+	_ "embed"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"patchmon-agent/pkg/models"
+)
+
+// Servicing channels used as keys in the lifecycle table
+const (
+	channelConsumer   = "consumer"   // Home, Pro, Pro for Workstations, Pro Education
+	channelEnterprise = "enterprise" // Enterprise, Education, IoT Enterprise (General Availability Channel)
+	channelLTSC       = "ltsc"       // Enterprise LTSC / LTSB
+	channelIoTLTSC    = "iot-ltsc"   // IoT Enterprise LTSC
+	channelServer     = "server"
+)
+
+// eolWarningPeriod is how far ahead of the end-of-support date a release is flagged as ending soon
+const eolWarningPeriod = 180 * 24 * time.Hour
+
+//go:embed lifecycle.json
+var lifecycleJSON []byte
+
+// lifecycleEntry is one Windows release in the embedded lifecycle table
+type lifecycleEntry struct {
+	Name         string            `json:"name"`
+	Type         string            `json:"type"` // "client" or "server"
+	Build        int               `json:"build"`
+	EndOfSupport map[string]string `json:"endOfSupport"` // channel -> YYYY-MM-DD
+}
+
+// lifecycleTable is parsed once from lifecycleJSON
+var lifecycleTable = mustParseLifecycle(lifecycleJSON)
+
+// mustParseLifecycle parses the embedded lifecycle table
+func mustParseLifecycle(data []byte) []lifecycleEntry {
+	var entries []lifecycleEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		panic("invalid embedded lifecycle table: " + err.Error())
+	}
+	return entries
+}
+
+// GetOSLifecycle returns the end-of-support date of the running Windows release
+// and whether it is still supported, based on the edition and build. It returns
+// nil if the release is not in the embedded lifecycle table.
+func (d *Detector) GetOSLifecycle() *models.OSLifecycle {
+	build, err := strconv.Atoi(readRegistryString(ntCurrentVersionKey, "CurrentBuild"))
+	if err != nil {
+		d.logger.WithError(err).Debug("Failed to read CurrentBuild for lifecycle lookup")
+		return nil
+	}
+	editionID := readRegistryString(ntCurrentVersionKey, "EditionID")
+	installationType := readRegistryString(ntCurrentVersionKey, "InstallationType")
+
+	lifecycle := lookupLifecycle(lifecycleTable, editionID, installationType, build, time.Now())
+	if lifecycle == nil {
+		d.logger.WithFields(logrus.Fields{
+			"edition": editionID,
+			"build":   build,
+		}).Debug("Windows release not found in lifecycle table")
+	}
+	return lifecycle
+}
+
+// servicingChannel maps the registry EditionID / InstallationType to a lifecycle channel
+func servicingChannel(editionID, installationType string) string {
+	edition := strings.ToLower(editionID)
+	switch {
+	case strings.HasPrefix(strings.ToLower(installationType), "server") || strings.HasPrefix(edition, "server"):
+		return channelServer
+	case strings.HasPrefix(edition, "iotenterprises"):
+		return channelIoTLTSC
+	case strings.HasPrefix(edition, "enterprises"):
+		return channelLTSC
+	case strings.HasPrefix(edition, "enterprise"), strings.HasPrefix(edition, "education"),
+		strings.HasPrefix(edition, "iotenterprise"):
+		return channelEnterprise
+	default:
+		return channelConsumer
+	}
+}
+
+// lookupLifecycle finds the end-of-support date for a build and edition
+func lookupLifecycle(table []lifecycleEntry, editionID, installationType string, build int, now time.Time) *models.OSLifecycle {
+	channel := servicingChannel(editionID, installationType)
+	releaseType := "client"
+	if channel == channelServer {
+		releaseType = "server"
+	}
+
+	for _, entry := range table {
+		if entry.Type != releaseType || entry.Build != build {
+			continue
+		}
+		date, ok := entry.EndOfSupport[channel]
+		if !ok {
+			return nil
+		}
+		eol, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return nil
+		}
+
+		// Support ends at the end of the published day
+		supported := now.Before(eol.AddDate(0, 0, 1))
+		return &models.OSLifecycle{
+			Release:    entry.Name,
+			Channel:    channel,
+			EOLDate:    date,
+			Supported:  supported,
+			EndingSoon: supported && eol.Sub(now) < eolWarningPeriod,
+		}
+	}
+	return nil
+}
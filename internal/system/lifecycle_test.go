@@ -0,0 +1,77 @@
+package system
+
+import (
+	"testing"
+	"time"
+)
+
+func TestServicingChannel(t *testing.T) {
+	tests := []struct {
+		editionID        string
+		installationType string
+		want             string
+	}{
+		{"Professional", "Client", channelConsumer},
+		{"Core", "Client", channelConsumer},
+		{"Enterprise", "Client", channelEnterprise},
+		{"Education", "Client", channelEnterprise},
+		{"EnterpriseS", "Client", channelLTSC},
+		{"IoTEnterpriseS", "Client", channelIoTLTSC},
+		{"ServerStandard", "Server", channelServer},
+		{"ServerDatacenter", "Server Core", channelServer},
+	}
+
+	for _, tt := range tests {
+		if got := servicingChannel(tt.editionID, tt.installationType); got != tt.want {
+			t.Errorf("servicingChannel(%q, %q) = %q, want %q", tt.editionID, tt.installationType, got, tt.want)
+		}
+	}
+}
+
+func TestLookupLifecycle(t *testing.T) {
+	now := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		editionID      string
+		install        string
+		build          int
+		wantDate       string
+		wantSupported  bool
+		wantEndingSoon bool
+	}{
+		{"Windows 10 22H2 Pro ending soon", "Professional", "Client", 19045, "2025-10-14", true, true},
+		{"Windows 10 21H2 Enterprise expired", "Enterprise", "Client", 19044, "2024-06-11", false, false},
+		{"Windows 10 LTSC 2021 supported", "EnterpriseS", "Client", 19044, "2027-01-12", true, false},
+		{"Windows 11 23H2 Enterprise supported", "Enterprise", "Client", 22631, "2026-11-10", true, false},
+		{"Server 2012 R2 expired", "ServerStandard", "Server", 9600, "2023-10-10", false, false},
+		{"Server 2022 supported", "ServerDatacenter", "Server", 20348, "2031-10-14", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := lookupLifecycle(lifecycleTable, tt.editionID, tt.install, tt.build, now)
+			if got == nil {
+				t.Fatal("expected lifecycle entry, got nil")
+			}
+			if got.EOLDate != tt.wantDate || got.Supported != tt.wantSupported || got.EndingSoon != tt.wantEndingSoon {
+				t.Errorf("got %+v, want date=%s supported=%v endingSoon=%v", got, tt.wantDate, tt.wantSupported, tt.wantEndingSoon)
+			}
+		})
+	}
+
+	if lookupLifecycle(lifecycleTable, "Professional", "Client", 99999, now) != nil {
+		t.Error("unknown build should return nil")
+	}
+	if lookupLifecycle(lifecycleTable, "EnterpriseS", "Client", 22631, now) != nil {
+		t.Error("channel without a date for the build should return nil")
+	}
+}
+
+func TestLookupLifecycle_LastDaySupported(t *testing.T) {
+	lastDay := time.Date(2025, 10, 14, 18, 0, 0, 0, time.UTC)
+	got := lookupLifecycle(lifecycleTable, "Professional", "Client", 19045, lastDay)
+	if got == nil || !got.Supported {
+		t.Errorf("release should still be supported on its end-of-support day: %+v", got)
+	}
+}
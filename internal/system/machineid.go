@@ -0,0 +1,209 @@
+package system
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/host"
+
+	"patchmon-agent/internal/config"
+	"patchmon-agent/internal/constants"
+	"patchmon-agent/internal/hardware"
+)
+
+// Files in the config directory holding the agent-generated ID and the
+// machine ID last reported to the server. Each holds the ID and, on a second
+// line, the hardware anchor of the machine that wrote it.
+var (
+	agentIDFile   = filepath.Join(config.DefaultConfigDir, "agent_id")
+	machineIDFile = filepath.Join(config.DefaultConfigDir, "machine_id")
+	// restrictIDFile limits an ID file to SYSTEM and Administrators; replaced in tests
+	restrictIDFile = config.RestrictFileACL
+)
+
+// GetMachineID returns the machine ID from the configured source (see
+// machine_id_source): the registry MachineGuid (default), the SMBIOS UUID,
+// or a random ID generated once and stored by the agent. MachineGuid is
+// used when the chosen source is unavailable.
+func (d *Detector) GetMachineID(source string) string {
+	switch source {
+	case "", constants.MachineIDSourceMachineGUID:
+	case constants.MachineIDSourceSMBIOSUUID:
+		if uuid := hardware.New(d.logger).GetSMBIOSUUID(); uuid != "" {
+			return uuid
+		}
+		d.logger.Warn("SMBIOS UUID is not available, using MachineGuid as machine ID")
+	case constants.MachineIDSourceAgent:
+		id, err := loadOrCreateAgentID(agentIDFile, d.hardwareAnchor())
+		if err == nil {
+			return id
+		}
+		d.logger.WithError(err).Warn("Failed to load agent ID, using MachineGuid as machine ID")
+	default:
+		d.logger.WithField("source", source).Warn("Unknown machine_id_source, using MachineGuid")
+	}
+	return d.machineGUID()
+}
+
+// machineGUID returns the registry MachineGuid, or the hostname if it cannot
+// be read. Sysprep and cloned images can leave several machines with the
+// same MachineGuid.
+func (d *Detector) machineGUID() string {
+	hostID, err := readMachineGUID()
+	if err != nil {
+		d.logger.WithError(err).Warn("Failed to get host ID, using hostname as fallback")
+		if hostname, err := os.Hostname(); err == nil {
+			return hostname
+		}
+		return "unknown"
+	}
+
+	return hostID
+}
+
+// readMachineGUID reads the registry MachineGuid
+func readMachineGUID() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// On Windows, gopsutil reads the MachineGuid from the registry
+	return host.HostIDWithContext(ctx)
+}
+
+// hardwareAnchor identifies the installation that wrote an ID file as
+// "<SMBIOS UUID>/<MachineGuid>". Parts that cannot be read are left empty.
+// Cloned and sysprepped images carry the ID files along, but the clones run
+// on other hardware, see sameMachine.
+func (d *Detector) hardwareAnchor() string {
+	guid, _ := readMachineGUID()
+	return hardware.New(d.logger).GetSMBIOSUUID() + "/" + guid
+}
+
+// sameMachine reports whether an ID file stored with anchor stored belongs to
+// the machine with anchor current. When both SMBIOS UUIDs are known they
+// decide, as sysprep regenerates the MachineGuid on the same machine; the
+// MachineGuid only decides when the stored anchor has no UUID. A UUID that
+// cannot be read now keeps the stored ID, so a failing WMI query does not
+// register the host twice.
+func sameMachine(stored, current string) bool {
+	storedUUID, storedGUID, _ := strings.Cut(stored, "/")
+	uuid, guid, _ := strings.Cut(current, "/")
+	switch {
+	case stored == "" || uuid == "":
+		return true
+	case storedUUID != "":
+		return storedUUID == uuid
+	default:
+		return storedGUID == "" || guid == "" || storedGUID == guid
+	}
+}
+
+// mergeAnchor returns anchor current with the parts that could not be read
+// taken from the stored anchor of the same machine
+func mergeAnchor(stored, current string) string {
+	storedUUID, storedGUID, _ := strings.Cut(stored, "/")
+	uuid, guid, _ := strings.Cut(current, "/")
+	if uuid == "" {
+		uuid = storedUUID
+	}
+	if guid == "" {
+		guid = storedGUID
+	}
+	return uuid + "/" + guid
+}
+
+// PreviousMachineID returns the machine ID this host last reported if it
+// differs from id, so the server can merge the old host record when
+// machine_id_source changes. Agents that never stored an ID reported the
+// MachineGuid.
+func (d *Detector) PreviousMachineID(id string) string {
+	stored, anchor, err := readIDFile(machineIDFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		d.logger.WithError(err).Debug("Failed to read last reported machine ID")
+		return ""
+	}
+	if stored != "" && !sameMachine(anchor, d.hardwareAnchor()) {
+		// Copied from the machine the image was taken from
+		d.logger.Info("Last reported machine ID was stored on another machine, ignoring it")
+		return ""
+	}
+	if stored == "" {
+		stored = d.machineGUID()
+	}
+	if stored == id {
+		return ""
+	}
+	return stored
+}
+
+// SaveMachineID records id as the machine ID last reported to the server
+func (d *Detector) SaveMachineID(id string) error {
+	anchor := d.hardwareAnchor()
+	if _, stored, err := readIDFile(machineIDFile); err == nil && sameMachine(stored, anchor) {
+		anchor = mergeAnchor(stored, anchor)
+	}
+	return writeIDFile(machineIDFile, id, anchor)
+}
+
+// loadOrCreateAgentID reads the agent ID stored at path, generating and
+// storing a new one on first use and when the file was written on another
+// machine (see sameMachine). IDs stored without an anchor adopt this one, and
+// the stored anchor is completed with the parts read now.
+func loadOrCreateAgentID(path, anchor string) (string, error) {
+	id, stored, err := readIDFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	if id != "" && sameMachine(stored, anchor) {
+		if anchor = mergeAnchor(stored, anchor); anchor == stored {
+			return id, nil
+		}
+	} else if id, err = newAgentID(); err != nil {
+		return "", err
+	}
+	if err := writeIDFile(path, id, anchor); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// newAgentID returns a random (version 4) UUID
+func newAgentID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate agent ID: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// readIDFile reads an ID and its hardware anchor stored by writeIDFile. Files
+// of older agents hold no anchor.
+func readIDFile(path string) (id, anchor string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+	id, anchor, _ = strings.Cut(strings.TrimSpace(string(data)), "\n")
+	return strings.TrimSpace(id), strings.TrimSpace(anchor), nil
+}
+
+// writeIDFile stores an ID with its hardware anchor, readable only by SYSTEM
+// and Administrators, creating the directory if needed
+func writeIDFile(path, id, anchor string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(id+"\n"+anchor+"\n"), 0600); err != nil {
+		return err
+	}
+	return restrictIDFile(path)
+}
@@ -0,0 +1,196 @@
+package system
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// DefaultRebootSnooze is the snooze time preselected in the reboot notification
+const DefaultRebootSnooze = 60 * time.Minute
+
+// toastAppID is the AppUserModelID the notification is shown under. Windows
+// PowerShell is registered on every supported release, so no shortcut or
+// registration of our own is needed.
+const toastAppID = `{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe`
+
+// rebootSnoozeChoices are the snooze times offered in the notification
+var rebootSnoozeChoices = []time.Duration{15 * time.Minute, time.Hour, 4 * time.Hour, 24 * time.Hour}
+
+// NotifyRebootRequired shows a toast notification with title and message to
+// every user logged on to an active session. Users can snooze the reminder
+// (snooze is preselected) or dismiss it. It returns the number of sessions
+// notified.
+//
+// When running as SYSTEM the notification is started in each user's session;
+// otherwise it is shown in the current session only.
+func NotifyRebootRequired(title, message string, snooze time.Duration) (int, error) {
+	args := []string{"-NoProfile", "-NonInteractive", "-WindowStyle", "Hidden",
+		"-EncodedCommand", encodePowerShellCommand(toastScript(rebootToastXML(title, message, snooze)))}
+	commandLine := "powershell.exe " + strings.Join(args, " ")
+
+	sessions, err := activeSessions()
+	if err != nil {
+		return 0, err
+	}
+
+	notified := 0
+	var errs []error
+	for _, session := range sessions {
+		err := startInSession(session, commandLine)
+		if errors.Is(err, windows.ERROR_PRIVILEGE_NOT_HELD) {
+			// Not running as SYSTEM: only our own session can be reached
+			if err := exec.Command("powershell.exe", args...).Run(); err != nil {
+				return 0, fmt.Errorf("failed to show notification: %w", err)
+			}
+			return 1, nil
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("session %d: %w", session, err))
+			continue
+		}
+		notified++
+	}
+	return notified, errors.Join(errs...)
+}
+
+// activeSessions returns the IDs of active (logged-on, connected) user sessions
+func activeSessions() ([]uint32, error) {
+	var info *windows.WTS_SESSION_INFO
+	var count uint32
+	if err := windows.WTSEnumerateSessions(0, 0, 1, &info, &count); err != nil {
+		return nil, fmt.Errorf("failed to enumerate sessions: %w", err)
+	}
+	defer windows.WTSFreeMemory(uintptr(unsafe.Pointer(info)))
+
+	var sessions []uint32
+	for _, session := range unsafe.Slice(info, count) {
+		// Session 0 hosts services and never has an interactive user
+		if session.State == windows.WTSActive && session.SessionID != 0 {
+			sessions = append(sessions, session.SessionID)
+		}
+	}
+	return sessions, nil
+}
+
+// startInSession starts commandLine as the user logged on to session, on
+// that user's desktop. It requires SeTcbPrivilege (running as SYSTEM).
+func startInSession(session uint32, commandLine string) error {
+	var token windows.Token
+	if err := windows.WTSQueryUserToken(session, &token); err != nil {
+		return err
+	}
+	defer token.Close()
+
+	var env *uint16
+	if err := windows.CreateEnvironmentBlock(&env, token, false); err != nil {
+		return fmt.Errorf("failed to create environment: %w", err)
+	}
+	defer windows.DestroyEnvironmentBlock(env)
+
+	cmd, err := windows.UTF16PtrFromString(commandLine)
+	if err != nil {
+		return err
+	}
+	desktop, err := windows.UTF16PtrFromString(`winsta0\default`)
+	if err != nil {
+		return err
+	}
+
+	startupInfo := &windows.StartupInfo{Desktop: desktop}
+	startupInfo.Cb = uint32(unsafe.Sizeof(*startupInfo))
+	var processInfo windows.ProcessInformation
+	if err := windows.CreateProcessAsUser(token, nil, cmd, nil, nil, false,
+		windows.CREATE_UNICODE_ENVIRONMENT|windows.CREATE_NO_WINDOW, env, nil, startupInfo, &processInfo); err != nil {
+		return fmt.Errorf("failed to start notification: %w", err)
+	}
+	windows.CloseHandle(processInfo.Thread)
+	windows.CloseHandle(processInfo.Process)
+	return nil
+}
+
+// rebootToastXML builds the toast content: a reminder that stays on screen
+// with a snooze time selection and the system snooze and dismiss buttons
+func rebootToastXML(title, message string, snooze time.Duration) string {
+	if snooze <= 0 {
+		snooze = DefaultRebootSnooze
+	}
+	choices := slices.Clone(rebootSnoozeChoices)
+	if !slices.Contains(choices, snooze) {
+		choices = append(choices, snooze)
+		slices.Sort(choices)
+	}
+
+	var b strings.Builder
+	b.WriteString(`<toast scenario="reminder"><visual><binding template="ToastGeneric">`)
+	b.WriteString("<text>" + escapeXML(title) + "</text>")
+	b.WriteString("<text>" + escapeXML(message) + "</text>")
+	b.WriteString(`</binding></visual><actions>`)
+	b.WriteString(`<input id="snoozeTime" type="selection" defaultInput="` + snoozeMinutes(snooze) + `">`)
+	for _, choice := range choices {
+		b.WriteString(`<selection id="` + snoozeMinutes(choice) + `" content="` + escapeXML(snoozeLabel(choice)) + `"/>`)
+	}
+	b.WriteString(`</input>`)
+	b.WriteString(`<action activationType="system" arguments="snooze" hint-inputId="snoozeTime" content=""/>`)
+	b.WriteString(`<action activationType="system" arguments="dismiss" content=""/>`)
+	b.WriteString(`</actions></toast>`)
+	return b.String()
+}
+
+// snoozeMinutes returns a snooze time in whole minutes, as the toast expects
+func snoozeMinutes(d time.Duration) string {
+	return strconv.Itoa(int(d / time.Minute))
+}
+
+// snoozeLabel describes a snooze time for the selection box
+func snoozeLabel(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		if d == 24*time.Hour {
+			return "1 day"
+		}
+		return fmt.Sprintf("%d days", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		if d == time.Hour {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", d/time.Hour)
+	default:
+		return fmt.Sprintf("%d minutes", d/time.Minute)
+	}
+}
+
+// escapeXML escapes text for use in XML content and attribute values
+func escapeXML(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;").Replace(s)
+}
+
+// toastScript returns the PowerShell script that shows toastXML
+func toastScript(toastXML string) string {
+	return `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+[Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] | Out-Null
+$xml = New-Object Windows.Data.Xml.Dom.XmlDocument
+$xml.LoadXml('` + strings.ReplaceAll(toastXML, "'", "''") + `')
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('` + toastAppID + `').Show([Windows.UI.Notifications.ToastNotification]::new($xml))`
+}
+
+// encodePowerShellCommand encodes script for powershell.exe -EncodedCommand
+// (base64 of UTF-16LE), which avoids any command line quoting issues
+func encodePowerShellCommand(script string) string {
+	units := utf16.Encode([]rune(script))
+	buf := make([]byte, 2*len(units))
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(buf[2*i:], u)
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,61 @@
+package system
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRebootToastXML(t *testing.T) {
+	xml := rebootToastXML("Restart required", `Updates <KB5035853> & "others"`, 30*time.Minute)
+
+	for _, want := range []string{
+		`<toast scenario="reminder">`,
+		`<text>Updates &lt;KB5035853&gt; &amp; &quot;others&quot;</text>`,
+		`defaultInput="30"`,
+		`<selection id="15" content="15 minutes"/><selection id="30" content="30 minutes"/><selection id="60" content="1 hour"/>`,
+		`<selection id="1440" content="1 day"/>`,
+		`arguments="snooze" hint-inputId="snoozeTime"`,
+		`arguments="dismiss"`,
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("toast XML missing %q:\n%s", want, xml)
+		}
+	}
+
+	if xml := rebootToastXML("t", "m", 0); !strings.Contains(xml, `defaultInput="60"`) || strings.Count(xml, `<selection id="60"`) != 1 {
+		t.Errorf("expected the default snooze to be preselected once:\n%s", xml)
+	}
+}
+
+func TestSnoozeLabel(t *testing.T) {
+	tests := map[time.Duration]string{
+		15 * time.Minute: "15 minutes",
+		time.Hour:        "1 hour",
+		4 * time.Hour:    "4 hours",
+		24 * time.Hour:   "1 day",
+		48 * time.Hour:   "2 days",
+		90 * time.Minute: "90 minutes",
+	}
+	for d, want := range tests {
+		if got := snoozeLabel(d); got != want {
+			t.Errorf("snoozeLabel(%s) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestToastScriptQuoting(t *testing.T) {
+	script := toastScript(`<text>Don't wait</text>`)
+	if !strings.Contains(script, `LoadXml('<text>Don''t wait</text>')`) {
+		t.Errorf("single quotes not escaped for PowerShell:\n%s", script)
+	}
+}
+
+func TestEncodePowerShellCommand(t *testing.T) {
+	got := encodePowerShellCommand("dir")
+	want := base64.StdEncoding.EncodeToString([]byte{'d', 0, 'i', 0, 'r', 0})
+	if got != want {
+		t.Errorf("encodePowerShellCommand(dir) = %q, want %q", got, want)
+	}
+}
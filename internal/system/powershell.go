@@ -0,0 +1,64 @@
+package system
+
+import (
+	"sort"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// Registry paths for installed PowerShell engines
+const (
+	windowsPowerShellKey   = `SOFTWARE\Microsoft\PowerShell\3\PowerShellEngine`
+	windowsPowerShellV2Key = `SOFTWARE\Microsoft\PowerShell\1\PowerShellEngine`
+	powerShellCoreVersions = `SOFTWARE\Microsoft\PowerShellCore\InstalledVersions`
+)
+
+// GetPowerShellVersions returns the Windows PowerShell engine version (e.g.
+// "5.1.19041.1") and the versions of all side-by-side PowerShell 7+
+// installations (e.g. ["7.4.1"]).
+func (d *Detector) GetPowerShellVersions() (windowsPowerShell string, powerShellCore []string) {
+	windowsPowerShell = readRegistryString(windowsPowerShellKey, "PowerShellVersion")
+	if windowsPowerShell == "" {
+		// Only PowerShell 2.0 (or nothing) is installed
+		windowsPowerShell = readRegistryString(windowsPowerShellV2Key, "PowerShellVersion")
+	}
+
+	powerShellCore = []string{}
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, powerShellCoreVersions, registry.ENUMERATE_SUB_KEYS)
+	if err != nil {
+		d.logger.Debug("No PowerShell 7+ installations found")
+		return windowsPowerShell, powerShellCore
+	}
+	defer k.Close()
+
+	// Each installation is registered under its upgrade code GUID
+	installs, err := k.ReadSubKeyNames(-1)
+	if err != nil {
+		d.logger.WithError(err).Debug("Failed to enumerate PowerShell 7+ installations")
+		return windowsPowerShell, powerShellCore
+	}
+	for _, install := range installs {
+		if version := readRegistryString(powerShellCoreVersions+`\`+install, "SemanticVersion"); version != "" {
+			powerShellCore = append(powerShellCore, version)
+		}
+	}
+	sort.Strings(powerShellCore)
+
+	return windowsPowerShell, powerShellCore
+}
+
+// readRegistryString reads a string value under HKLM, returning an empty
+// string if the key or value does not exist.
+func readRegistryString(keyPath, valueName string) string {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, keyPath, registry.QUERY_VALUE)
+	if err != nil {
+		return ""
+	}
+	defer k.Close()
+
+	value, _, err := k.GetStringValue(valueName)
+	if err != nil {
+		return ""
+	}
+	return value
+}
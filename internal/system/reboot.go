@@ -1,6 +1,7 @@
 package system
 
 import (
+	"fmt"
 	"strings"
 
 	"golang.org/x/sys/windows/registry"
@@ -10,9 +11,19 @@ import (
 const (
 	rebootRequiredKey = `SOFTWARE\Microsoft\Windows\CurrentVersion\WindowsUpdate\Auto Update\RebootRequired`
 	rebootPendingKey  = `SOFTWARE\Microsoft\Windows\CurrentVersion\Component Based Servicing\RebootPending`
+	rebootInProgress  = `SOFTWARE\Microsoft\Windows\CurrentVersion\Component Based Servicing\RebootInProgress`
+	packagesPending   = `SOFTWARE\Microsoft\Windows\CurrentVersion\Component Based Servicing\PackagesPending`
 	sessionManagerKey = `SYSTEM\CurrentControlSet\Control\Session Manager`
+	ccmRebootDataKey  = `SOFTWARE\Microsoft\SMS\Mobile Client\Reboot Management\RebootData`
+	activeNameKey     = `SYSTEM\CurrentControlSet\Control\ComputerName\ActiveComputerName`
+	pendingNameKey    = `SYSTEM\CurrentControlSet\Control\ComputerName\ComputerName`
+	netlogonKey       = `SYSTEM\CurrentControlSet\Services\Netlogon`
 )
 
+// maxRenameFilesInReason limits how many pending file renames are listed in
+// the reboot reason
+const maxRenameFilesInReason = 3
+
 // CheckRebootRequired checks if the system requires a reboot by inspecting
 // Windows registry keys for pending reboot indicators.
 //
@@ -31,14 +42,32 @@ func (d *Detector) CheckRebootRequired() (bool, string) {
 	if registryKeyExists(rebootPendingKey) {
 		reasons = append(reasons, "Component servicing pending reboot")
 	}
+	if registryKeyExists(rebootInProgress) || registryKeyExists(packagesPending) {
+		reasons = append(reasons, "Component servicing reboot in progress")
+	}
 
 	// 3. Check Pending File Rename Operations
 	if registryValueExists(sessionManagerKey, "PendingFileRenameOperations") {
-		reasons = append(reasons, "Pending file rename operations")
+		reasons = append(reasons, fileRenameReason(readRegistryStrings(sessionManagerKey, "PendingFileRenameOperations")))
+	}
+
+	// 4. Check Configuration Manager client pending reboot
+	if registryKeyExists(ccmRebootDataKey) {
+		reasons = append(reasons, "Configuration Manager pending reboot")
+	}
+
+	// 5. Check pending computer rename
+	if computerRenamePending(readRegistryString(activeNameKey, "ComputerName"), readRegistryString(pendingNameKey, "ComputerName")) {
+		reasons = append(reasons, "Pending computer rename")
+	}
+
+	// 6. Check pending domain join
+	if registryValueExists(netlogonKey, "JoinDomain") || registryValueExists(netlogonKey, "AvoidSpnSet") {
+		reasons = append(reasons, "Pending domain join")
 	}
 
 	if len(reasons) > 0 {
-		reason := strings.Join(reasons, "; ")
+		reason := BuildRebootReason(reasons)
 		d.logger.WithField("reason", reason).Debug("Reboot required")
 		return true, reason
 	}
@@ -65,14 +94,55 @@ func registryValueExists(keyPath, valueName string) bool {
 	}
 	defer k.Close()
 
-	_, _, err = k.GetStringValue(valueName)
-	if err == nil {
-		return true
+	// GetValue with a nil buffer succeeds for values of any type
+	_, _, err = k.GetValue(valueName, nil)
+	return err == nil
+}
+
+// computerRenamePending reports whether the computer has been renamed but not
+// yet restarted: the configured name differs from the active one
+func computerRenamePending(active, pending string) bool {
+	return active != "" && pending != "" && !strings.EqualFold(active, pending)
+}
+
+// readRegistryStrings reads a REG_MULTI_SZ value under HKLM, nil if absent
+func readRegistryStrings(keyPath, valueName string) []string {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, keyPath, registry.QUERY_VALUE)
+	if err != nil {
+		return nil
 	}
+	defer k.Close()
 
-	// PendingFileRenameOperations can be REG_MULTI_SZ, try GetStringsValue
-	_, _, err = k.GetStringsValue(valueName)
-	return err == nil
+	values, _, err := k.GetStringsValue(valueName)
+	if err != nil {
+		return nil
+	}
+	return values
+}
+
+// fileRenameReason describes pending file rename operations, listing the
+// first few files involved. The value holds source/destination pairs; an
+// empty destination means the file is deleted.
+func fileRenameReason(operations []string) string {
+	var files []string
+	for i := 0; i < len(operations); i += 2 {
+		// Sources look like \??\C:\path, optionally prefixed with "!" or "*1"
+		file := strings.TrimPrefix(strings.TrimPrefix(operations[i], "!"), "*1")
+		file = strings.TrimPrefix(file, `\??\`)
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+
+	if len(files) == 0 {
+		return "Pending file rename operations"
+	}
+	listed := files[:min(len(files), maxRenameFilesInReason)]
+	reason := fmt.Sprintf("Pending file rename operations (%d files: %s", len(files), strings.Join(listed, ", "))
+	if more := len(files) - len(listed); more > 0 {
+		reason += fmt.Sprintf(", +%d more", more)
+	}
+	return reason + ")"
 }
 
 // BuildRebootReason is a helper that builds a reboot reason string from a list
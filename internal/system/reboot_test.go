@@ -59,6 +59,62 @@ func TestBuildRebootReason_SemicolonSeparator(t *testing.T) {
 	}
 }
 
+func TestFileRenameReason(t *testing.T) {
+	tests := []struct {
+		name       string
+		operations []string
+		want       string
+	}{
+		{
+			name:       "unreadable value",
+			operations: nil,
+			want:       "Pending file rename operations",
+		},
+		{
+			name: "delete and replace",
+			operations: []string{
+				`\??\C:\Windows\Temp\setup.tmp`, "",
+				`\??\C:\Program Files\Contoso\agent.dll.new`, `!\??\C:\Program Files\Contoso\agent.dll`,
+			},
+			want: `Pending file rename operations (2 files: C:\Windows\Temp\setup.tmp, C:\Program Files\Contoso\agent.dll.new)`,
+		},
+		{
+			name: "truncated",
+			operations: []string{
+				`\??\C:\a`, "", `\??\C:\b`, "", `*1\??\C:\c`, "", `\??\C:\d`, "", `\??\C:\e`, "",
+			},
+			want: `Pending file rename operations (5 files: C:\a, C:\b, C:\c, +2 more)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fileRenameReason(tt.operations); got != tt.want {
+				t.Errorf("fileRenameReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputerRenamePending(t *testing.T) {
+	tests := []struct {
+		active, pending string
+		want            bool
+	}{
+		{"SRV01", "SRV01", false},
+		{"SRV01", "srv01", false},
+		{"SRV01", "SRV02", true},
+		{"", "SRV02", false},
+		{"SRV01", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := computerRenamePending(tt.active, tt.pending); got != tt.want {
+			t.Errorf("computerRenamePending(%q, %q) = %v, want %v", tt.active, tt.pending, got, tt.want)
+		}
+	}
+}
+
 // TestRegistryKeyExists_KnownKey tests that registryKeyExists returns true for
 // a well-known registry key that always exists on Windows.
 func TestRegistryKeyExists_KnownKey(t *testing.T) {
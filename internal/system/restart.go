@@ -0,0 +1,115 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/sys/windows"
+)
+
+// MaxRestartDelay is the longest delay InitiateSystemShutdownEx accepts (MAX_SHUTDOWN_TIMEOUT)
+const MaxRestartDelay = 10 * 365 * 24 * time.Hour
+
+// maxShutdownMessage is the longest message shown in the shutdown notification
+const maxShutdownMessage = 511
+
+// restartReason is recorded in the System event log as a planned restart
+// for an operating system hotfix
+const restartReason = windows.SHTDN_REASON_MAJOR_OPERATINGSYSTEM | windows.SHTDN_REASON_MINOR_HOTFIX | windows.SHTDN_REASON_FLAG_PLANNED
+
+var procAbortSystemShutdownW = windows.NewLazySystemDLL("advapi32.dll").NewProc("AbortSystemShutdownW")
+
+// ScheduleRestart restarts the computer after delay. Logged-on users see
+// message in the shutdown notification until the restart happens or it is
+// cancelled with CancelRestart. forceAppsClosed closes applications without
+// giving them a chance to save data.
+func ScheduleRestart(delay time.Duration, message string, forceAppsClosed bool) error {
+	timeout, err := shutdownTimeout(delay)
+	if err != nil {
+		return err
+	}
+
+	if err := enableShutdownPrivilege(); err != nil {
+		return err
+	}
+
+	msg, err := windows.UTF16PtrFromString(truncateMessage(message))
+	if err != nil {
+		return fmt.Errorf("invalid restart message: %w", err)
+	}
+
+	if err := windows.InitiateSystemShutdownEx(nil, msg, timeout, forceAppsClosed, true, restartReason); err != nil {
+		return fmt.Errorf("failed to schedule restart: %w", err)
+	}
+	return nil
+}
+
+// CancelRestart aborts a restart scheduled with ScheduleRestart (or shutdown.exe /r /t)
+func CancelRestart() error {
+	if err := enableShutdownPrivilege(); err != nil {
+		return err
+	}
+
+	if r, _, err := procAbortSystemShutdownW.Call(0); r == 0 {
+		return fmt.Errorf("failed to cancel restart: %w", err)
+	}
+	return nil
+}
+
+// shutdownTimeout converts delay to the whole seconds InitiateSystemShutdownEx expects
+func shutdownTimeout(delay time.Duration) (uint32, error) {
+	if delay < 0 || delay > MaxRestartDelay {
+		return 0, fmt.Errorf("restart delay %s out of range (0 to %s)", delay, MaxRestartDelay)
+	}
+	return uint32(delay / time.Second), nil
+}
+
+// truncateMessage shortens message to the maximum shutdown message length
+func truncateMessage(message string) string {
+	if utf8.RuneCountInString(message) <= maxShutdownMessage {
+		return message
+	}
+	return string([]rune(message)[:maxShutdownMessage])
+}
+
+// enableShutdownPrivilege enables SeShutdownPrivilege on the process token,
+// which InitiateSystemShutdownEx and AbortSystemShutdown require
+func enableShutdownPrivilege() error {
+	var token windows.Token
+	if err := windows.OpenProcessToken(windows.CurrentProcess(), windows.TOKEN_ADJUST_PRIVILEGES|windows.TOKEN_QUERY, &token); err != nil {
+		return fmt.Errorf("failed to open process token: %w", err)
+	}
+	defer token.Close()
+
+	name, err := windows.UTF16PtrFromString("SeShutdownPrivilege")
+	if err != nil {
+		return err
+	}
+	var luid windows.LUID
+	if err := windows.LookupPrivilegeValue(nil, name, &luid); err != nil {
+		return fmt.Errorf("failed to look up shutdown privilege: %w", err)
+	}
+
+	// The last error is per thread, so it must be read on the thread that
+	// made the call
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	privileges := windows.Tokenprivileges{
+		PrivilegeCount: 1,
+		Privileges: [1]windows.LUIDAndAttributes{
+			{Luid: luid, Attributes: windows.SE_PRIVILEGE_ENABLED},
+		},
+	}
+	if err := windows.AdjustTokenPrivileges(token, false, &privileges, 0, nil, nil); err != nil {
+		return fmt.Errorf("failed to enable shutdown privilege: %w", err)
+	}
+	// AdjustTokenPrivileges also succeeds when the token does not hold the
+	// privilege, and only reports that through the last error
+	if errors.Is(windows.GetLastError(), windows.ERROR_NOT_ALL_ASSIGNED) {
+		return errors.New("failed to enable shutdown privilege: the account does not hold SeShutdownPrivilege")
+	}
+	return nil
+}
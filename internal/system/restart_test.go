@@ -0,0 +1,39 @@
+package system
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestShutdownTimeout(t *testing.T) {
+	tests := []struct {
+		delay   time.Duration
+		want    uint32
+		wantErr bool
+	}{
+		{0, 0, false},
+		{15 * time.Minute, 900, false},
+		{90*time.Second + 500*time.Millisecond, 90, false},
+		{-time.Second, 0, true},
+		{MaxRestartDelay + time.Second, 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := shutdownTimeout(tt.delay)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("shutdownTimeout(%s) = %d, %v; want %d, error %v", tt.delay, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestTruncateMessage(t *testing.T) {
+	if got := truncateMessage("Restarting for updates"); got != "Restarting for updates" {
+		t.Errorf("short message changed: %q", got)
+	}
+	long := strings.Repeat("ü", maxShutdownMessage+10)
+	if got := truncateMessage(long); utf8.RuneCountInString(got) != maxShutdownMessage {
+		t.Errorf("expected %d runes, got %d", maxShutdownMessage, utf8.RuneCountInString(got))
+	}
+}
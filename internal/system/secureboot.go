@@ -0,0 +1,81 @@
+package system
+
+import (
+	"golang.org/x/sys/windows/registry"
+)
+
+// Boot modes reported in SystemInfo.BootMode
+const (
+	BootModeUEFI   = "uefi"
+	BootModeLegacy = "legacy"
+)
+
+// Registry locations of the firmware type and Secure Boot state
+const (
+	controlKey         = `SYSTEM\CurrentControlSet\Control`
+	secureBootStateKey = `SYSTEM\CurrentControlSet\Control\SecureBoot\State`
+)
+
+// PEFirmwareType values
+const (
+	firmwareTypeBIOS = 1
+	firmwareTypeUEFI = 2
+)
+
+// GetBootSecurity returns the firmware boot mode (uefi or legacy, "" if
+// unknown) and whether UEFI Secure Boot is enabled. secureBoot is nil when
+// the boot mode is unknown; legacy BIOS boot never has Secure Boot.
+func (d *Detector) GetBootSecurity() (bootMode string, secureBoot *bool) {
+	firmwareType, ok := readRegistryDWORD(controlKey, "PEFirmwareType")
+	if !ok {
+		d.logger.Debug("Firmware type not available")
+		return "", nil
+	}
+	bootMode = bootModeFromFirmwareType(firmwareType)
+
+	enabled, ok := readRegistryDWORD(secureBootStateKey, "UEFISecureBootEnabled")
+	return bootMode, secureBootState(bootMode, enabled, ok)
+}
+
+// bootModeFromFirmwareType maps a PEFirmwareType value to a boot mode
+func bootModeFromFirmwareType(firmwareType uint64) string {
+	switch firmwareType {
+	case firmwareTypeUEFI:
+		return BootModeUEFI
+	case firmwareTypeBIOS:
+		return BootModeLegacy
+	default:
+		return ""
+	}
+}
+
+// secureBootState derives the Secure Boot state from the boot mode and the
+// UEFISecureBootEnabled value (ok is false if the value is missing, which is
+// the case on firmware without Secure Boot support)
+func secureBootState(bootMode string, enabled uint64, ok bool) *bool {
+	var state bool
+	switch bootMode {
+	case BootModeUEFI:
+		state = ok && enabled == 1
+	case BootModeLegacy:
+		state = false
+	default:
+		return nil
+	}
+	return &state
+}
+
+// readRegistryDWORD reads an integer value from HKLM
+func readRegistryDWORD(keyPath, valueName string) (uint64, bool) {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, keyPath, registry.QUERY_VALUE)
+	if err != nil {
+		return 0, false
+	}
+	defer k.Close()
+
+	value, _, err := k.GetIntegerValue(valueName)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
@@ -6,6 +6,7 @@ import (
 	"net"
 	"os"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/shirou/gopsutil/v4/host"
@@ -103,6 +104,38 @@ func extractBaseProductName(productName string) string {
 	return match
 }
 
+// GetOSEdition returns the edition, installation type and servicing channel
+// of the running Windows installation, or nil if EditionID cannot be read
+func (d *Detector) GetOSEdition() *models.OSEdition {
+	editionID := readRegistryString(ntCurrentVersionKey, "EditionID")
+	if editionID == "" {
+		d.logger.Debug("EditionID not found in registry")
+		return nil
+	}
+	edition := osEdition(editionID, readRegistryString(ntCurrentVersionKey, "InstallationType"))
+
+	d.logger.WithFields(logrus.Fields{
+		"edition":          edition.EditionID,
+		"installationType": edition.InstallationType,
+		"channel":          edition.Channel,
+	}).Debug("Detected OS edition")
+	return edition
+}
+
+// osEdition classifies an EditionID / InstallationType pair. InstallationType
+// is "Client", "Server" (Desktop Experience), "Server Core" or "Nano Server".
+func osEdition(editionID, installationType string) *models.OSEdition {
+	channel := servicingChannel(editionID, installationType)
+	installation := strings.ToLower(installationType)
+	return &models.OSEdition{
+		EditionID:        editionID,
+		InstallationType: installationType,
+		Channel:          channel,
+		ServerCore:       installation == "server core" || installation == "nano server",
+		LTSC:             channel == channelLTSC || channel == channelIoTLTSC,
+	}
+}
+
 // detectOSFallback uses gopsutil as a fallback for OS detection
 func (d *Detector) detectOSFallback() (string, string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -191,13 +224,19 @@ func (d *Detector) GetSystemInfo() models.SystemInfo {
 	info := models.SystemInfo{
 		KernelVersion: d.GetKernelVersion(),
 		SELinuxStatus: getSELinuxStatus(),
-		SystemUptime:  d.getSystemUptime(ctx),
 		LoadAverage:   getLoadAverage(),
 	}
+	info.SystemUptime, info.SystemUptimeSeconds, info.LastBootTime = d.getSystemUptime(ctx)
+	info.PowerShellVersion, info.PowerShellCoreVersions = d.GetPowerShellVersions()
+	info.BootMode, info.SecureBootEnabled = d.GetBootSecurity()
+	info.OSInstallDate, info.OSOriginalInstallDate, info.OSOriginalBuild = d.GetOSInstallInfo()
 
 	d.logger.WithFields(logrus.Fields{
-		"kernel": info.KernelVersion,
-		"uptime": info.SystemUptime,
+		"kernel":     info.KernelVersion,
+		"uptime":     info.SystemUptime,
+		"powershell": info.PowerShellVersion,
+		"pwsh":       info.PowerShellCoreVersions,
+		"boot_mode":  info.BootMode,
 	}).Debug("Collected system information")
 
 	return info
@@ -232,7 +271,8 @@ func (d *Detector) GetHostname() (string, error) {
 	return info.Hostname, nil
 }
 
-// GetIPAddress gets the primary non-loopback IPv4 address
+// GetIPAddress gets the first non-loopback IPv4 address. Reports use the
+// network manager's PrimaryIP instead when it finds one.
 func (d *Detector) GetIPAddress() string {
 	interfaces, err := net.Interfaces()
 	if err != nil {
@@ -263,24 +303,6 @@ func (d *Detector) GetIPAddress() string {
 	return ""
 }
 
-// GetMachineID returns the system's machine ID (MachineGuid from registry via gopsutil)
-func (d *Detector) GetMachineID() string {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	// On Windows, gopsutil reads the MachineGuid from the registry
-	hostID, err := host.HostIDWithContext(ctx)
-	if err != nil {
-		d.logger.WithError(err).Warn("Failed to get host ID, using hostname as fallback")
-		if hostname, err := os.Hostname(); err == nil {
-			return hostname
-		}
-		return "unknown"
-	}
-
-	return hostID
-}
-
 // getSELinuxStatus returns the SELinux status.
 // SELinux does not exist on Windows, so this always returns "disabled".
 func getSELinuxStatus() string {
@@ -289,20 +311,31 @@ func getSELinuxStatus() string {
 
 // getLoadAverage returns the system load average.
 // Load average is a Unix/Linux concept and does not exist on Windows.
-// We return [0.0, 0.0, 0.0] as a placeholder to satisfy the API contract.
+// We return [0.0, 0.0, 0.0] as a placeholder to satisfy the API contract;
+// the report replaces it with the sampled Windows load when resource metrics
+// are enabled.
 func getLoadAverage() []float64 {
 	return []float64{0.0, 0.0, 0.0}
 }
 
-// getSystemUptime gets the system uptime as a human-readable string
-func (d *Detector) getSystemUptime(ctx context.Context) string {
+// getSystemUptime gets the system uptime as a human-readable string and in
+// seconds, and the last boot time (RFC3339, empty if unknown)
+func (d *Detector) getSystemUptime(ctx context.Context) (uptime string, seconds uint64, lastBoot string) {
 	info, err := host.InfoWithContext(ctx)
 	if err != nil {
 		d.logger.WithError(err).Warn("Failed to get uptime")
-		return "Unknown"
+		return "Unknown", 0, ""
 	}
 
-	return FormatUptime(info.Uptime)
+	return FormatUptime(info.Uptime), info.Uptime, FormatBootTime(info.BootTime)
+}
+
+// FormatBootTime converts a boot time in Unix seconds to RFC3339 UTC
+func FormatBootTime(bootTime uint64) string {
+	if bootTime == 0 {
+		return ""
+	}
+	return time.Unix(int64(bootTime), 0).UTC().Format(time.RFC3339)
 }
 
 // FormatUptime converts an uptime in seconds to a human-readable string.
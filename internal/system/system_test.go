@@ -1,9 +1,17 @@
 package system
 
 import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
+
+	"patchmon-agent/internal/config"
+	"patchmon-agent/pkg/models"
 )
 
 func TestExtractBaseProductName(t *testing.T) {
@@ -89,6 +97,15 @@ func TestExtractBaseProductName(t *testing.T) {
 	}
 }
 
+func TestFormatBootTime(t *testing.T) {
+	if got := FormatBootTime(0); got != "" {
+		t.Errorf("FormatBootTime(0) = %q, want empty", got)
+	}
+	if got, want := FormatBootTime(1717200000), "2024-06-01T00:00:00Z"; got != want {
+		t.Errorf("FormatBootTime() = %q, want %q", got, want)
+	}
+}
+
 func TestFormatUptime(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -263,6 +280,9 @@ func TestGetSystemInfo(t *testing.T) {
 	if info.SystemUptime == "" || info.SystemUptime == "Unknown" {
 		t.Error("SystemInfo.SystemUptime is empty or Unknown")
 	}
+	if info.SystemUptimeSeconds == 0 || info.LastBootTime == "" {
+		t.Errorf("SystemInfo uptime seconds = %d, last boot = %q; want both set", info.SystemUptimeSeconds, info.LastBootTime)
+	}
 	if len(info.LoadAverage) != 3 {
 		t.Errorf("SystemInfo.LoadAverage has %d elements, want 3", len(info.LoadAverage))
 	}
@@ -270,3 +290,286 @@ func TestGetSystemInfo(t *testing.T) {
 	t.Logf("SystemInfo: kernel=%q, selinux=%q, uptime=%q, load=%v",
 		info.KernelVersion, info.SELinuxStatus, info.SystemUptime, info.LoadAverage)
 }
+
+// TestGetPowerShellVersions verifies Windows PowerShell is detected from the
+// registry. Every supported Windows version ships Windows PowerShell 5.1.
+func TestGetPowerShellVersions(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.DebugLevel)
+
+	d := New(logger)
+	windowsPowerShell, powerShellCore := d.GetPowerShellVersions()
+
+	if windowsPowerShell == "" {
+		t.Error("GetPowerShellVersions() returned empty Windows PowerShell version")
+	}
+	if powerShellCore == nil {
+		t.Error("GetPowerShellVersions() returned nil PowerShell 7+ list, expected non-nil")
+	}
+
+	t.Logf("Windows PowerShell=%q, PowerShell 7+=%v", windowsPowerShell, powerShellCore)
+}
+
+func TestSecureBootState(t *testing.T) {
+	tests := []struct {
+		name         string
+		firmwareType uint64
+		enabled      uint64
+		ok           bool
+		wantMode     string
+		want         *bool
+	}{
+		{"UEFI with Secure Boot", 2, 1, true, BootModeUEFI, boolPtr(true)},
+		{"UEFI with Secure Boot off", 2, 0, true, BootModeUEFI, boolPtr(false)},
+		{"UEFI without Secure Boot support", 2, 0, false, BootModeUEFI, boolPtr(false)},
+		{"legacy BIOS", 1, 0, false, BootModeLegacy, boolPtr(false)},
+		{"unknown firmware", 0, 1, true, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode := bootModeFromFirmwareType(tt.firmwareType)
+			if mode != tt.wantMode {
+				t.Errorf("bootModeFromFirmwareType(%d) = %q, want %q", tt.firmwareType, mode, tt.wantMode)
+			}
+			got := secureBootState(mode, tt.enabled, tt.ok)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("secureBootState() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func boolPtr(b bool) *bool { return &b }
+
+func TestParentDN(t *testing.T) {
+	tests := []struct {
+		dn   string
+		want string
+	}{
+		{"CN=SRV01,OU=Servers,DC=contoso,DC=com", "OU=Servers,DC=contoso,DC=com"},
+		{`CN=Smith\, John,OU=Laptops,DC=contoso,DC=com`, "OU=Laptops,DC=contoso,DC=com"},
+		{"CN=SRV01, OU=Servers,DC=contoso,DC=com", "OU=Servers,DC=contoso,DC=com"},
+		{"DC=com", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := parentDN(tt.dn); got != tt.want {
+			t.Errorf("parentDN(%q) = %q, want %q", tt.dn, got, tt.want)
+		}
+	}
+}
+
+func TestParseDsregcmdStatus(t *testing.T) {
+	output := `
++----------------------------------------------------------------------+
+| Device State                                                         |
++----------------------------------------------------------------------+
+
+             AzureAdJoined : YES
+          EnterpriseJoined : NO
+              DomainJoined : YES
+                DomainName : CONTOSO
+
++----------------------------------------------------------------------+
+| Device Details                                                       |
++----------------------------------------------------------------------+
+
+                  DeviceId : 0d3e1c6b-5f0a-4f5e-9a0e-4a2b5c7d8e9f
+
++----------------------------------------------------------------------+
+| Tenant Details                                                       |
++----------------------------------------------------------------------+
+
+                TenantName : Contoso
+                  TenantId : 72f988bf-86f1-41af-91ab-2d7cd011db47
+
++----------------------------------------------------------------------+
+| User State                                                           |
++----------------------------------------------------------------------+
+
+                    NgcSet : NO
+           WorkplaceJoined : NO
+`
+	got := parseDsregcmdStatus(output)
+	want := &models.CloudJoinInfo{
+		AzureADJoined: true,
+		HybridJoined:  true,
+		TenantName:    "Contoso",
+		TenantID:      "72f988bf-86f1-41af-91ab-2d7cd011db47",
+		DeviceID:      "0d3e1c6b-5f0a-4f5e-9a0e-4a2b5c7d8e9f",
+	}
+	if got == nil || *got != *want {
+		t.Errorf("parseDsregcmdStatus() = %+v, want %+v", got, want)
+	}
+
+	if got := parseDsregcmdStatus("The system cannot find the file specified."); got != nil {
+		t.Errorf("parseDsregcmdStatus() = %+v for unrelated output, want nil", got)
+	}
+}
+
+func TestOriginalInstall(t *testing.T) {
+	current := osInstall{installDate: 1700000000, build: "22631"}
+
+	tests := []struct {
+		name    string
+		sources []osInstall
+		want    osInstall
+	}{
+		{"never upgraded", nil, current},
+		{
+			name:    "earliest snapshot wins",
+			sources: []osInstall{{installDate: 1650000000, build: "19044"}, {installDate: 1600000000, build: "19041"}},
+			want:    osInstall{installDate: 1600000000, build: "19041"},
+		},
+		{
+			name:    "snapshot without date ignored",
+			sources: []osInstall{{build: "17763"}},
+			want:    current,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := originalInstall(current, tt.sources); got != tt.want {
+				t.Errorf("originalInstall() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatInstallDate(t *testing.T) {
+	if got := formatInstallDate(0); got != "" {
+		t.Errorf("formatInstallDate(0) = %q, want empty", got)
+	}
+	if got, want := formatInstallDate(1700000000), "2023-11-14T22:13:20Z"; got != want {
+		t.Errorf("formatInstallDate() = %q, want %q", got, want)
+	}
+}
+
+func TestOSEdition(t *testing.T) {
+	tests := []struct {
+		editionID, installationType string
+		want                        models.OSEdition
+	}{
+		{"Professional", "Client", models.OSEdition{EditionID: "Professional", InstallationType: "Client", Channel: "consumer"}},
+		{"EnterpriseS", "Client", models.OSEdition{EditionID: "EnterpriseS", InstallationType: "Client", Channel: "ltsc", LTSC: true}},
+		{"IoTEnterpriseS", "Client", models.OSEdition{EditionID: "IoTEnterpriseS", InstallationType: "Client", Channel: "iot-ltsc", LTSC: true}},
+		{"ServerDatacenter", "Server Core", models.OSEdition{EditionID: "ServerDatacenter", InstallationType: "Server Core", Channel: "server", ServerCore: true}},
+		{"ServerStandard", "Server", models.OSEdition{EditionID: "ServerStandard", InstallationType: "Server", Channel: "server"}},
+	}
+
+	for _, tt := range tests {
+		if got := osEdition(tt.editionID, tt.installationType); *got != tt.want {
+			t.Errorf("osEdition(%q, %q) = %+v, want %+v", tt.editionID, tt.installationType, *got, tt.want)
+		}
+	}
+}
+
+func TestSummarizeEvents(t *testing.T) {
+	since := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	events := []winEvent{
+		{ProviderName: "Microsoft-Windows-Kernel-Power", ID: 41, Level: 1, TimeCreated: "2024-06-01T10:00:00.0000000Z"},
+		{ProviderName: "EventLog", ID: 6008, Level: 2, TimeCreated: "2024-06-01T09:59:00.0000000Z"},
+		{ProviderName: "Service Control Manager", ID: 7000, Level: 2},
+		{ProviderName: "Service Control Manager", ID: 7031, Level: 2},
+		{ProviderName: "EventLog", ID: 6005, Level: 2},
+	}
+
+	got := summarizeEvents(events, since)
+	if got.Since != "2024-06-01T00:00:00Z" || got.Critical != 1 || got.Errors != 4 {
+		t.Errorf("summary = %+v, want 1 critical and 4 errors since 2024-06-01", got)
+	}
+	wantSources := []models.EventSourceCount{
+		{Source: "EventLog", Errors: 2},
+		{Source: "Service Control Manager", Errors: 2},
+		{Source: "Microsoft-Windows-Kernel-Power", Critical: 1},
+	}
+	if !reflect.DeepEqual(got.Sources, wantSources) {
+		t.Errorf("sources = %+v, want %+v", got.Sources, wantSources)
+	}
+	if len(got.UnexpectedShutdowns) != 2 || got.UnexpectedShutdowns[0] != "2024-06-01T09:59:00.0000000Z" {
+		t.Errorf("unexpected shutdowns = %v", got.UnexpectedShutdowns)
+	}
+
+	if empty := summarizeEvents(nil, since); empty.Critical != 0 || len(empty.Sources) != 0 || empty.UnexpectedShutdowns == nil {
+		t.Errorf("summary of no events = %+v", empty)
+	}
+}
+
+func TestLoadOrCreateAgentID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "PatchMon", "agent_id")
+	restrictIDFile = func(string) error { return nil }
+	t.Cleanup(func() { restrictIDFile = config.RestrictFileACL })
+
+	id, err := loadOrCreateAgentID(path, "uuid-a/guid-a")
+	if err != nil {
+		t.Fatalf("loadOrCreateAgentID() error = %v", err)
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(id) {
+		t.Errorf("loadOrCreateAgentID() = %q, want a version 4 UUID", id)
+	}
+
+	again, err := loadOrCreateAgentID(path, "uuid-a/guid-a")
+	if err != nil || again != id {
+		t.Errorf("second loadOrCreateAgentID() = %q, %v, want stored ID %q", again, err, id)
+	}
+
+	// A clone of the image has another hardware anchor and gets its own ID
+	clone, err := loadOrCreateAgentID(path, "uuid-b/guid-b")
+	if err != nil || clone == id {
+		t.Errorf("loadOrCreateAgentID() on a clone = %q, %v, want a new ID", clone, err)
+	}
+	if stored, anchor, err := readIDFile(path); err != nil || stored != clone || anchor != "uuid-b/guid-b" {
+		t.Errorf("readIDFile() = %q, %q, %v, want the new ID and anchor", stored, anchor, err)
+	}
+
+	// IDs stored by older agents keep their ID and adopt the anchor
+	if err := os.WriteFile(path, []byte("legacy-id\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if legacy, err := loadOrCreateAgentID(path, "uuid-a/guid-a"); err != nil || legacy != "legacy-id" {
+		t.Errorf("loadOrCreateAgentID() with a legacy file = %q, %v, want legacy-id", legacy, err)
+	}
+	if _, anchor, _ := readIDFile(path); anchor != "uuid-a/guid-a" {
+		t.Errorf("legacy file anchor = %q, want it adopted", anchor)
+	}
+
+	// An SMBIOS UUID that cannot be read keeps the ID and the stored UUID
+	if same, err := loadOrCreateAgentID(path, "/guid-a"); err != nil || same != "legacy-id" {
+		t.Errorf("loadOrCreateAgentID() without a UUID = %q, %v, want legacy-id", same, err)
+	}
+	// Sysprep on the same machine regenerates only the MachineGuid
+	if same, err := loadOrCreateAgentID(path, "uuid-a/guid-c"); err != nil || same != "legacy-id" {
+		t.Errorf("loadOrCreateAgentID() with a new MachineGuid = %q, %v, want legacy-id", same, err)
+	}
+	if _, anchor, _ := readIDFile(path); anchor != "uuid-a/guid-c" {
+		t.Errorf("anchor after a MachineGuid change = %q, want uuid-a/guid-c", anchor)
+	}
+}
+
+func TestSameMachine(t *testing.T) {
+	tests := []struct {
+		stored, current string
+		want            bool
+	}{
+		{"uuid-a/guid-a", "uuid-a/guid-a", true},
+		{"uuid-a/guid-a", "uuid-b/guid-a", false},
+		{"uuid-a/guid-a", "uuid-a/guid-b", true},
+		{"uuid-a/guid-a", "/guid-a", true},
+		{"uuid-a/guid-a", "/guid-b", true},
+		{"/guid-a", "uuid-a/guid-a", true},
+		{"/guid-a", "uuid-a/guid-b", false},
+		{"/guid-a", "uuid-a/", true},
+		{"", "uuid-a/guid-a", true},
+	}
+	for _, tt := range tests {
+		if got := sameMachine(tt.stored, tt.current); got != tt.want {
+			t.Errorf("sameMachine(%q, %q) = %v, want %v", tt.stored, tt.current, got, tt.want)
+		}
+	}
+	if got := mergeAnchor("uuid-a/guid-a", "/guid-b"); got != "uuid-a/guid-b" {
+		t.Errorf("mergeAnchor() = %q, want uuid-a/guid-b", got)
+	}
+}
@@ -0,0 +1,113 @@
+package updatepolicy
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/go-ole/go-ole"
+	"github.com/go-ole/go-ole/oleutil"
+
+	"patchmon-agent/pkg/models"
+)
+
+// autoUpdateResultsKey holds the Detect and Install results of the automatic
+// updates client on Windows 8.1 / Server 2012 R2 and earlier
+const autoUpdateResultsKey = `SOFTWARE\Microsoft\Windows\CurrentVersion\WindowsUpdate\Auto Update\Results`
+
+// resultsTimeLayout is the format of LastSuccessTime (UTC)
+const resultsTimeLayout = "2006-01-02 15:04:05"
+
+// GetUpdateActivity returns when Windows Update last successfully checked for
+// and installed updates. The Auto Update results keys are used where they
+// exist; newer releases only expose the times through the
+// Microsoft.Update.AutoUpdate COM object.
+func (m *Manager) GetUpdateActivity() *models.UpdateActivity {
+	detect := parseResultsTime(readString(autoUpdateResultsKey+`\Detect`, "LastSuccessTime"))
+	install := parseResultsTime(readString(autoUpdateResultsKey+`\Install`, "LastSuccessTime"))
+
+	if detect.IsZero() || install.IsZero() {
+		comDetect, comInstall, err := readAutoUpdateResults()
+		if err != nil {
+			m.logger.WithError(err).Debug("Failed to read automatic update results")
+		}
+		if detect.IsZero() {
+			detect = comDetect
+		}
+		if install.IsZero() {
+			install = comInstall
+		}
+	}
+
+	if detect.IsZero() && install.IsZero() {
+		return nil
+	}
+	return &models.UpdateActivity{
+		LastDetectSuccess:  formatTime(detect),
+		LastInstallSuccess: formatTime(install),
+	}
+}
+
+// readAutoUpdateResults reads IAutomaticUpdatesResults from
+// Microsoft.Update.AutoUpdate
+func readAutoUpdateResults() (detect, install time.Time, err error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := ole.CoInitializeEx(0, ole.COINIT_APARTMENTTHREADED); err != nil {
+		// S_FALSE means COM is already initialized on this thread
+		if oleErr, ok := err.(*ole.OleError); !ok || oleErr.Code() != 0x00000001 {
+			return time.Time{}, time.Time{}, fmt.Errorf("COM initialization failed: %w", err)
+		}
+	}
+	defer ole.CoUninitialize()
+
+	unknown, err := oleutil.CreateObject("Microsoft.Update.AutoUpdate")
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("failed to create AutoUpdate: %w", err)
+	}
+	defer unknown.Release()
+
+	autoUpdate, err := unknown.QueryInterface(ole.IID_IDispatch)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("failed to query AutoUpdate interface: %w", err)
+	}
+	defer autoUpdate.Release()
+
+	resultsVal, err := oleutil.GetProperty(autoUpdate, "Results")
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("failed to get AutoUpdate results: %w", err)
+	}
+	results := resultsVal.ToIDispatch()
+	defer results.Release()
+
+	return dateProperty(results, "LastSearchSuccessDate"), dateProperty(results, "LastInstallationSuccessDate"), nil
+}
+
+// dateProperty reads a DATE property, zero if it is empty or missing
+func dateProperty(dispatch *ole.IDispatch, name string) time.Time {
+	value, err := oleutil.GetProperty(dispatch, name)
+	if err != nil {
+		return time.Time{}
+	}
+	date, _ := value.Value().(time.Time)
+	return date
+}
+
+// parseResultsTime parses a LastSuccessTime value, zero if empty or invalid
+func parseResultsTime(value string) time.Time {
+	t, err := time.Parse(resultsTimeLayout, strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// formatTime formats t as RFC3339 UTC, "" for the zero time
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,28 @@
+package updatepolicy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseResultsTime(t *testing.T) {
+	want := time.Date(2024, 3, 12, 8, 30, 5, 0, time.UTC)
+	if got := parseResultsTime("2024-03-12 08:30:05"); !got.Equal(want) {
+		t.Errorf("parseResultsTime() = %v, want %v", got, want)
+	}
+	for _, value := range []string{"", "garbage", "12/03/2024"} {
+		if got := parseResultsTime(value); !got.IsZero() {
+			t.Errorf("parseResultsTime(%q) = %v, want zero", value, got)
+		}
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	if got := formatTime(time.Time{}); got != "" {
+		t.Errorf("formatTime(zero) = %q, want empty", got)
+	}
+	local := time.Date(2024, 3, 12, 10, 30, 5, 0, time.FixedZone("CET", 2*60*60))
+	if got, want := formatTime(local), "2024-03-12T08:30:05Z"; got != want {
+		t.Errorf("formatTime() = %q, want %q", got, want)
+	}
+}
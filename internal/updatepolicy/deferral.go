@@ -0,0 +1,113 @@
+package updatepolicy
+
+import (
+	"fmt"
+
+	"patchmon-agent/pkg/models"
+)
+
+// Maximum deferral periods accepted by Windows Update for Business
+const (
+	MaxQualityDeferralDays = 30
+	MaxFeatureDeferralDays = 365
+)
+
+// deferralValueNames are the policy value names for one update type
+type deferralValueNames struct {
+	flag, days string
+}
+
+// deferralValues maps update types to their deferral policy values
+var deferralValues = map[string]deferralValueNames{
+	TypeQuality: {"DeferQualityUpdates", "DeferQualityUpdatesPeriodInDays"},
+	TypeFeature: {"DeferFeatureUpdates", "DeferFeatureUpdatesPeriodInDays"},
+}
+
+// GetDeferral returns the effective quality and feature update deferral
+// periods. It returns nil if no deferral policy is set.
+func (m *Manager) GetDeferral() *models.UpdateDeferral {
+	deferral := &models.UpdateDeferral{}
+	configured := false
+
+	for _, updateType := range []string{TypeQuality, TypeFeature} {
+		names := deferralValues[updateType]
+		if flag, ok := readDWORD(policyKey, names.flag); !ok || flag != 1 {
+			continue
+		}
+		days, ok := readDWORD(policyKey, names.days)
+		if !ok {
+			continue
+		}
+		value := int(days)
+		if updateType == TypeQuality {
+			deferral.QualityDays = &value
+		} else {
+			deferral.FeatureDays = &value
+		}
+		configured = true
+	}
+
+	if !configured {
+		return nil
+	}
+	return deferral
+}
+
+// SetDeferral sets the deferral period in days for updateType (quality or
+// feature) through the Windows Update for Business policy values
+func (m *Manager) SetDeferral(updateType string, days int) error {
+	if err := validateDeferralDays(updateType, days); err != nil {
+		return err
+	}
+	names := deferralValues[updateType]
+
+	key, err := openForWrite(policyKey)
+	if err != nil {
+		return fmt.Errorf("failed to open Windows Update policy: %w", err)
+	}
+	defer key.Close()
+
+	if err := key.SetDWordValue(names.flag, 1); err != nil {
+		return fmt.Errorf("failed to enable %s update deferral: %w", updateType, err)
+	}
+	if err := key.SetDWordValue(names.days, uint32(days)); err != nil {
+		return fmt.Errorf("failed to set %s update deferral: %w", updateType, err)
+	}
+
+	m.logger.WithField("days", days).Infof("Set %s update deferral", updateType)
+	return nil
+}
+
+// ClearDeferral removes the deferral policy for updateType (quality, feature or all)
+func (m *Manager) ClearDeferral(updateType string) error {
+	types, err := updateTypes(updateType)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range types {
+		names := deferralValues[t]
+		if err := deleteValues(policyKey, names.flag, names.days); err != nil {
+			return fmt.Errorf("failed to clear %s update deferral: %w", t, err)
+		}
+		m.logger.Infof("Cleared %s update deferral", t)
+	}
+	return nil
+}
+
+// validateDeferralDays checks days against the range allowed for updateType
+func validateDeferralDays(updateType string, days int) error {
+	var limit int
+	switch updateType {
+	case TypeQuality:
+		limit = MaxQualityDeferralDays
+	case TypeFeature:
+		limit = MaxFeatureDeferralDays
+	default:
+		return fmt.Errorf("deferral update type must be %s or %s", TypeQuality, TypeFeature)
+	}
+	if days < 0 || days > limit {
+		return fmt.Errorf("%s update deferral must be between 0 and %d days", updateType, limit)
+	}
+	return nil
+}
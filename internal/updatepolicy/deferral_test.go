@@ -0,0 +1,25 @@
+package updatepolicy
+
+import "testing"
+
+func TestValidateDeferralDays(t *testing.T) {
+	tests := []struct {
+		updateType string
+		days       int
+		wantErr    bool
+	}{
+		{TypeQuality, 0, false},
+		{TypeQuality, 30, false},
+		{TypeQuality, 31, true},
+		{TypeFeature, 365, false},
+		{TypeFeature, 366, true},
+		{TypeFeature, -1, true},
+		{TypeAll, 7, true},
+	}
+
+	for _, tt := range tests {
+		if err := validateDeferralDays(tt.updateType, tt.days); (err != nil) != tt.wantErr {
+			t.Errorf("validateDeferralDays(%q, %d) error = %v, wantErr %v", tt.updateType, tt.days, err, tt.wantErr)
+		}
+	}
+}
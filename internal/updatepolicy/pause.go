@@ -0,0 +1,189 @@
+package updatepolicy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"patchmon-agent/pkg/models"
+)
+
+// MaxPauseDuration is the longest Windows lets updates be paused in one go
+const MaxPauseDuration = 35 * 24 * time.Hour
+
+// pauseTimeLayout is the timestamp format of the UX pause settings
+const pauseTimeLayout = "2006-01-02T15:04:05Z"
+
+// policyPauseDateLayout is the date format of the Group Policy pause start times
+const policyPauseDateLayout = "2006-01-02"
+
+// pauseValueNames are the UX\Settings value names for one update type
+type pauseValueNames struct {
+	start, end string
+}
+
+// uxPauseValues maps update types to their UX\Settings value names
+var uxPauseValues = map[string]pauseValueNames{
+	TypeQuality: {"PauseQualityUpdatesStartTime", "PauseQualityUpdatesEndTime"},
+	TypeFeature: {"PauseFeatureUpdatesStartTime", "PauseFeatureUpdatesEndTime"},
+}
+
+// policyPauseValueNames are the Group Policy value names for one update type
+type policyPauseValueNames struct {
+	flag, startDate string
+}
+
+// policyPauseValues maps update types to the Group Policy pause flag and
+// start date values. A policy pause always lasts MaxPauseDuration.
+var policyPauseValues = map[string]policyPauseValueNames{
+	TypeQuality: {"PauseQualityUpdates", "PauseQualityUpdatesStartTime"},
+	TypeFeature: {"PauseFeatureUpdates", "PauseFeatureUpdatesStartTime"},
+}
+
+// GetPauseState returns until when quality and feature updates are paused,
+// from either the Settings app or Group Policy. It returns nil if no update
+// type is currently paused.
+func (m *Manager) GetPauseState() *models.UpdatePauseState {
+	now := time.Now()
+	state := &models.UpdatePauseState{}
+
+	for _, updateType := range []string{TypeQuality, TypeFeature} {
+		until := m.pausedUntil(updateType, now)
+		if until.IsZero() {
+			continue
+		}
+		formatted := until.UTC().Format(time.RFC3339)
+		if updateType == TypeQuality {
+			state.QualityPausedUntil = formatted
+		} else {
+			state.FeaturePausedUntil = formatted
+		}
+	}
+
+	if state.QualityPausedUntil == "" && state.FeaturePausedUntil == "" {
+		return nil
+	}
+	return state
+}
+
+// pausedUntil returns the end of the active pause for an update type, or
+// the zero time if it is not paused
+func (m *Manager) pausedUntil(updateType string, now time.Time) time.Time {
+	ux := uxPauseValues[updateType]
+	until := parsePauseTime(readString(uxSettingsKey, ux.end))
+
+	policy := policyPauseValues[updateType]
+	if flag, ok := readDWORD(policyKey, policy.flag); ok && flag == 1 {
+		policyUntil := policyPauseEnd(readString(policyKey, policy.startDate))
+		if policyUntil.After(until) {
+			until = policyUntil
+		}
+	}
+
+	if !until.After(now) {
+		return time.Time{}
+	}
+	return until
+}
+
+// Pause pauses updates of updateType (quality, feature or all) until the given time
+func (m *Manager) Pause(updateType string, until time.Time) error {
+	types, err := updateTypes(updateType)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := validatePauseUntil(until, now); err != nil {
+		return err
+	}
+
+	key, err := openForWrite(uxSettingsKey)
+	if err != nil {
+		return fmt.Errorf("failed to open Windows Update settings: %w", err)
+	}
+	defer key.Close()
+
+	start := now.UTC().Format(pauseTimeLayout)
+	end := until.UTC().Format(pauseTimeLayout)
+	for _, t := range types {
+		names := uxPauseValues[t]
+		if err := key.SetStringValue(names.start, start); err != nil {
+			return fmt.Errorf("failed to pause %s updates: %w", t, err)
+		}
+		if err := key.SetStringValue(names.end, end); err != nil {
+			return fmt.Errorf("failed to pause %s updates: %w", t, err)
+		}
+		m.logger.WithField("until", end).Infof("Paused %s updates", t)
+	}
+
+	// The overall expiry drives the "Updates paused" banner in Settings
+	if len(types) == 2 {
+		if err := key.SetStringValue("PauseUpdatesExpiryTime", end); err != nil {
+			return fmt.Errorf("failed to set pause expiry: %w", err)
+		}
+	}
+	return nil
+}
+
+// Resume clears the pause of updateType (quality, feature or all). Pauses
+// set by Group Policy are also cleared when the agent manages them.
+func (m *Manager) Resume(updateType string) error {
+	types, err := updateTypes(updateType)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range types {
+		ux := uxPauseValues[t]
+		if err := deleteValues(uxSettingsKey, ux.start, ux.end); err != nil {
+			return fmt.Errorf("failed to resume %s updates: %w", t, err)
+		}
+		policy := policyPauseValues[t]
+		if err := deleteValues(policyKey, policy.flag, policy.startDate); err != nil {
+			return fmt.Errorf("failed to clear %s updates pause policy: %w", t, err)
+		}
+		m.logger.Infof("Resumed %s updates", t)
+	}
+
+	if len(types) == 2 {
+		if err := deleteValues(uxSettingsKey, "PauseUpdatesStartTime", "PauseUpdatesExpiryTime"); err != nil {
+			return fmt.Errorf("failed to clear pause expiry: %w", err)
+		}
+	}
+	return nil
+}
+
+// validatePauseUntil checks that a pause ends in the future and within MaxPauseDuration
+func validatePauseUntil(until, now time.Time) error {
+	if !until.After(now) {
+		return fmt.Errorf("pause end %s is not in the future", until.Format(time.RFC3339))
+	}
+	if until.Sub(now) > MaxPauseDuration {
+		return fmt.Errorf("updates can be paused for at most %d days", int(MaxPauseDuration.Hours()/24))
+	}
+	return nil
+}
+
+// parsePauseTime parses a UX\Settings pause timestamp, zero if empty or invalid
+func parsePauseTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+	if t, err := time.Parse(pauseTimeLayout, value); err == nil {
+		return t
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
+// policyPauseEnd returns the end of a Group Policy pause that started on startDate
+func policyPauseEnd(startDate string) time.Time {
+	start, err := time.Parse(policyPauseDateLayout, strings.TrimSpace(startDate))
+	if err != nil {
+		return time.Time{}
+	}
+	return start.Add(MaxPauseDuration)
+}
@@ -0,0 +1,73 @@
+package updatepolicy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUpdateTypes(t *testing.T) {
+	tests := []struct {
+		updateType string
+		want       int
+		wantErr    bool
+	}{
+		{TypeQuality, 1, false},
+		{TypeFeature, 1, false},
+		{TypeAll, 2, false},
+		{"", 2, false},
+		{"drivers", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := updateTypes(tt.updateType)
+		if (err != nil) != tt.wantErr || len(got) != tt.want {
+			t.Errorf("updateTypes(%q) = %v, %v", tt.updateType, got, err)
+		}
+	}
+}
+
+func TestValidatePauseUntil(t *testing.T) {
+	now := time.Date(2024, 3, 12, 10, 0, 0, 0, time.UTC)
+
+	if err := validatePauseUntil(now.Add(7*24*time.Hour), now); err != nil {
+		t.Errorf("7 days should be valid: %v", err)
+	}
+	if err := validatePauseUntil(now.Add(-time.Hour), now); err == nil {
+		t.Error("a pause ending in the past should be rejected")
+	}
+	if err := validatePauseUntil(now.Add(36*24*time.Hour), now); err == nil {
+		t.Error("a pause longer than 35 days should be rejected")
+	}
+}
+
+func TestParsePauseTime(t *testing.T) {
+	want := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	for _, value := range []string{"2024-04-01T00:00:00Z", " 2024-04-01T00:00:00Z "} {
+		if got := parsePauseTime(value); !got.Equal(want) {
+			t.Errorf("parsePauseTime(%q) = %v, want %v", value, got, want)
+		}
+	}
+	if got := parsePauseTime("not a date"); !got.IsZero() {
+		t.Errorf("expected zero time for invalid value, got %v", got)
+	}
+}
+
+func TestPolicyPauseEnd(t *testing.T) {
+	want := time.Date(2024, 4, 16, 0, 0, 0, 0, time.UTC)
+	if got := policyPauseEnd("2024-03-12"); !got.Equal(want) {
+		t.Errorf("policyPauseEnd() = %v, want %v", got, want)
+	}
+	if got := policyPauseEnd(""); !got.IsZero() {
+		t.Errorf("expected zero time for empty start, got %v", got)
+	}
+}
+
+// TestGetPauseState reads the real pause settings; most machines are not paused.
+func TestGetPauseState(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	state := New(newTestLogger()).GetPauseState()
+	t.Logf("pause state: %+v", state)
+}
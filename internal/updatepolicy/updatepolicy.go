@@ -0,0 +1,105 @@
+package updatepolicy
+
+import (
+	"errors"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/windows/registry"
+)
+
+// Registry keys holding Windows Update settings
+const (
+	// uxSettingsKey holds the pause settings shown in the Settings app
+	uxSettingsKey = `SOFTWARE\Microsoft\WindowsUpdate\UX\Settings`
+	// policyKey holds Windows Update for Business Group Policy / MDM settings
+	policyKey = `SOFTWARE\Policies\Microsoft\Windows\WindowsUpdate`
+)
+
+// Update types that can be paused or deferred independently
+const (
+	TypeQuality = "quality"
+	TypeFeature = "feature"
+	TypeAll     = "all"
+)
+
+// Manager reads and changes Windows Update pause and deferral settings
+type Manager struct {
+	logger *logrus.Logger
+}
+
+// New creates a new update policy manager
+func New(logger *logrus.Logger) *Manager {
+	return &Manager{
+		logger: logger,
+	}
+}
+
+// updateTypes expands an update type (quality, feature or all) into the
+// individual types it covers
+func updateTypes(updateType string) ([]string, error) {
+	switch updateType {
+	case TypeQuality:
+		return []string{TypeQuality}, nil
+	case TypeFeature:
+		return []string{TypeFeature}, nil
+	case TypeAll, "":
+		return []string{TypeQuality, TypeFeature}, nil
+	default:
+		return nil, errors.New("update type must be quality, feature or all")
+	}
+}
+
+// readString reads a string value under HKLM, "" if absent
+func readString(keyPath, name string) string {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, keyPath, registry.QUERY_VALUE)
+	if err != nil {
+		return ""
+	}
+	defer key.Close()
+
+	value, _, err := key.GetStringValue(name)
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+// readDWORD reads a DWORD value under HKLM
+func readDWORD(keyPath, name string) (uint64, bool) {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, keyPath, registry.QUERY_VALUE)
+	if err != nil {
+		return 0, false
+	}
+	defer key.Close()
+
+	value, _, err := key.GetIntegerValue(name)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
+// openForWrite opens (creating if needed) a key under HKLM for writing
+func openForWrite(keyPath string) (registry.Key, error) {
+	key, _, err := registry.CreateKey(registry.LOCAL_MACHINE, keyPath, registry.SET_VALUE)
+	return key, err
+}
+
+// deleteValues removes values under HKLM, ignoring values that do not exist
+func deleteValues(keyPath string, names ...string) error {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, keyPath, registry.SET_VALUE)
+	if errors.Is(err, registry.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer key.Close()
+
+	for _, name := range names {
+		if err := key.DeleteValue(name); err != nil && !errors.Is(err, registry.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
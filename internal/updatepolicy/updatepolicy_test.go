@@ -0,0 +1,25 @@
+package updatepolicy
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newTestLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetLevel(logrus.DebugLevel)
+	return logger
+}
+
+func TestNew(t *testing.T) {
+	logger := newTestLogger()
+	mgr := New(logger)
+
+	if mgr == nil {
+		t.Fatal("New returned nil")
+	}
+	if mgr.logger != logger {
+		t.Error("Manager logger not set correctly")
+	}
+}
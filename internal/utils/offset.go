@@ -32,6 +32,16 @@ func CalculateReportOffset(apiId string, intervalMinutes int) time.Duration {
 	}
 }
 
+// NextReportTime returns the first report time after now when reporting every
+// interval, shifted by offset from the interval boundary
+func NextReportTime(now time.Time, interval, offset time.Duration) time.Time {
+	next := now.Truncate(interval).Add(offset)
+	for !next.After(now) {
+		next = next.Add(interval)
+	}
+	return next
+}
+
 // hashString creates a deterministic hash from a string using FNV-1a algorithm
 // This ensures the same input always produces the same hash value
 func hashString(s string) uint64 {
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextReportTime(t *testing.T) {
+	at := func(hh, mm, ss int) time.Time {
+		return time.Date(2024, 5, 1, hh, mm, ss, 0, time.UTC)
+	}
+	tests := []struct {
+		name     string
+		now      time.Time
+		interval time.Duration
+		offset   time.Duration
+		want     time.Time
+	}{
+		{"later this hour", at(10, 5, 0), time.Hour, 10 * time.Minute, at(10, 10, 0)},
+		{"next hour", at(10, 15, 0), time.Hour, 10 * time.Minute, at(11, 10, 0)},
+		{"exactly on time", at(10, 10, 0), time.Hour, 10 * time.Minute, at(11, 10, 0)},
+		{"sub-hourly", at(10, 6, 0), 5 * time.Minute, 7 * time.Second, at(10, 10, 7)},
+		{"no offset", at(10, 59, 59), time.Hour, 0, at(11, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextReportTime(tt.now, tt.interval, tt.offset); !got.Equal(tt.want) {
+				t.Errorf("NextReportTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RegexPatternPrefix marks a pattern as a regular expression rather than a glob
+const RegexPatternPrefix = "re:"
+
+// PatternMatcher matches strings against a list of glob or regular expression
+// patterns. Patterns prefixed with "re:" are Go regular expressions; all others
+// are case-insensitive globs where * matches any run of characters and ? a
+// single character. Globs must match the whole string.
+type PatternMatcher struct {
+	patterns []*regexp.Regexp
+}
+
+// NewPatternMatcher compiles patterns. Invalid patterns are skipped and reported
+// in the returned error; the matcher is always usable.
+func NewPatternMatcher(patterns []string) (*PatternMatcher, error) {
+	m := &PatternMatcher{}
+	var errs []error
+
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+
+		var expr string
+		if strings.HasPrefix(pattern, RegexPatternPrefix) {
+			expr = strings.TrimPrefix(pattern, RegexPatternPrefix)
+		} else {
+			expr = globToRegex(pattern)
+		}
+
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid pattern %q: %w", pattern, err))
+			continue
+		}
+		m.patterns = append(m.patterns, re)
+	}
+
+	return m, errors.Join(errs...)
+}
+
+// Match reports whether any of values matches any pattern
+func (m *PatternMatcher) Match(values ...string) bool {
+	if m == nil {
+		return false
+	}
+	for _, re := range m.patterns {
+		for _, value := range values {
+			if value != "" && re.MatchString(value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Len returns the number of valid patterns
+func (m *PatternMatcher) Len() int {
+	if m == nil {
+		return 0
+	}
+	return len(m.patterns)
+}
+
+// globToRegex converts a glob into an anchored, case-insensitive regular expression
+func globToRegex(glob string) string {
+	var b strings.Builder
+	b.WriteString("(?i)^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
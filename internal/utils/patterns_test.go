@@ -0,0 +1,58 @@
+package utils
+
+import "testing"
+
+func TestPatternMatcher(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{
+		"Security Intelligence Update for Microsoft Defender*",
+		"KB50?4441",
+		"re:^Intel - (System|Net) - ",
+		"  ",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if matcher.Len() != 3 {
+		t.Errorf("expected 3 patterns, got %d", matcher.Len())
+	}
+
+	tests := []struct {
+		name   string
+		values []string
+		want   bool
+	}{
+		{"glob prefix", []string{"KB2267602", "Security Intelligence Update for Microsoft Defender Antivirus - KB2267602 (Version 1.407.1)"}, true},
+		{"glob is case-insensitive", []string{"security intelligence update for microsoft defender antivirus"}, true},
+		{"glob single character", []string{"KB5034441"}, true},
+		{"glob must match whole string", []string{"KB5034441-v2"}, false},
+		{"regex", []string{"Intel - System - 10.1.1.44"}, true},
+		{"regex is case-sensitive by default", []string{"intel - system - 10.1.1.44"}, false},
+		{"no match", []string{"KB5035853", "2024-03 Cumulative Update for Windows 11"}, false},
+		{"no values", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matcher.Match(tt.values...); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatternMatcher_InvalidPattern(t *testing.T) {
+	matcher, err := NewPatternMatcher([]string{"re:(unclosed", "KB*"})
+	if err == nil {
+		t.Error("expected error for invalid regex")
+	}
+	if matcher.Len() != 1 || !matcher.Match("KB123") {
+		t.Error("valid patterns should still be usable")
+	}
+}
+
+func TestPatternMatcher_Nil(t *testing.T) {
+	var matcher *PatternMatcher
+	if matcher.Match("anything") || matcher.Len() != 0 {
+		t.Error("nil matcher should match nothing")
+	}
+}
@@ -0,0 +1,258 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultPowerShellTimeout bounds a PowerShell command when no timeout is
+// configured
+const DefaultPowerShellTimeout = 2 * time.Minute
+
+// ErrPowerShellTimeout is returned when a command does not complete within the
+// timeout; the PowerShell process running it is stopped
+var ErrPowerShellTimeout = errors.New("powershell command timed out")
+
+// errPowerShellFailed is returned when a command run in the shared session
+// fails, where a separate process would have exited with a non-zero code. It
+// is wrapped with the command's error records.
+var errPowerShellFailed = errors.New("powershell command failed")
+
+// errPowerShellSessionEnded is returned when the shared session exits while
+// running a command, e.g. because the command called exit
+var errPowerShellSessionEnded = errors.New("powershell session ended")
+
+var (
+	psMu      sync.Mutex
+	psSession *powerShellSession
+	psTimeout = DefaultPowerShellTimeout
+)
+
+// SetPowerShellTimeout overrides the per-command timeout. Values <= 0 keep the
+// default.
+func SetPowerShellTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		psTimeout = timeout
+	}
+}
+
+// RunPowerShell executes a read-only PowerShell command and returns trimmed
+// output. Commands run in a long-lived powershell.exe shared by the whole
+// agent, so each query does not pay the PowerShell startup time. When the
+// session is busy with another goroutine, or cannot be used, the command runs
+// in a new process instead; that includes running it again if the session
+// dies part way through, so commands that change the system must use
+// RunPowerShellOnce.
+func RunPowerShell(command string) (string, error) {
+	return runPowerShell(command, true)
+}
+
+// RunPowerShellOnce executes a PowerShell command that changes the system.
+// It is not run a second time if the shared session dies while running it.
+func RunPowerShellOnce(command string) (string, error) {
+	return runPowerShell(command, false)
+}
+
+func runPowerShell(command string, retry bool) (string, error) {
+	if !psMu.TryLock() {
+		return runPowerShellProcess(command)
+	}
+	defer psMu.Unlock()
+
+	if psSession == nil {
+		session, err := startPowerShellSession()
+		if err != nil {
+			return runPowerShellProcess(command)
+		}
+		psSession = session
+	}
+
+	output, err := psSession.run(command, psTimeout)
+	if err == nil || errors.Is(err, errPowerShellFailed) {
+		return output, err
+	}
+
+	// The session died (e.g. the command called exit) or was stopped after a
+	// timeout; start a new one next time. The command may have run in part,
+	// so only read-only commands are run again.
+	psSession.close()
+	psSession = nil
+	if errors.Is(err, ErrPowerShellTimeout) || !retry {
+		return "", err
+	}
+	return runPowerShellProcess(command)
+}
+
+// ClosePowerShell stops the shared PowerShell session, if one is running
+func ClosePowerShell() {
+	psMu.Lock()
+	defer psMu.Unlock()
+	if psSession != nil {
+		psSession.close()
+		psSession = nil
+	}
+}
+
+// runPowerShellProcess runs a command in its own powershell.exe
+func runPowerShellProcess(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), psTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", command)
+	output, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("%w after %s", ErrPowerShellTimeout, psTimeout)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(bytes.TrimSpace(exitErr.Stderr)) > 0 {
+		err = fmt.Errorf("%w: %s", err, bytes.TrimSpace(exitErr.Stderr))
+	}
+	return strings.TrimSpace(string(output)), err
+}
+
+// powerShellSession is a powershell.exe reading commands from stdin, one per
+// line. The output of each command is followed by a marker line carrying
+// its success and, base64 encoded, its errors.
+type powerShellSession struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	marker string
+}
+
+// startPowerShellSession starts a PowerShell process for RunPowerShell
+func startPowerShellSession() (*powerShellSession, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-NoLogo", "-Command", "-")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start powershell: %w", err)
+	}
+
+	return &powerShellSession{
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: bufio.NewReader(stdout),
+		marker: "__PATCHMON_END_" + hex.EncodeToString(b[:]) + "__",
+	}, nil
+}
+
+// run executes a command in the session and waits for its output. If the
+// command takes longer than timeout the session process is killed.
+func (s *powerShellSession) run(command string, timeout time.Duration) (string, error) {
+	if _, err := io.WriteString(s.stdin, sessionCommand(command, s.marker)+"\n"); err != nil {
+		return "", err
+	}
+
+	type result struct {
+		output string
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		output, err := readSessionOutput(s.stdout, s.marker)
+		done <- result{output, err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.output, r.err
+	case <-timer.C:
+		_ = s.cmd.Process.Kill()
+		<-done
+		return "", fmt.Errorf("%w after %s", ErrPowerShellTimeout, timeout)
+	}
+}
+
+// close ends the session; PowerShell exits when its stdin is closed
+func (s *powerShellSession) close() {
+	_ = s.stdin.Close()
+	_ = s.cmd.Wait()
+}
+
+// sessionCommand wraps a command into a single line for the session. The
+// command is passed base64 encoded so quoting and line breaks survive, and
+// runs in its own scope so variables do not leak into later commands. Its
+// success is taken before the output is formatted, and the marker line
+// reports False where a separate process would have failed, followed by the
+// error records (or the last entry of $Error) that stderr would have shown.
+func sessionCommand(command, marker string) string {
+	encoded := base64.StdEncoding.EncodeToString([]byte(command))
+	return "$__ok=$true;$__err='';$Error.Clear();" +
+		"try{$__res=@(& ([ScriptBlock]::Create([Text.Encoding]::UTF8.GetString([Convert]::FromBase64String('" + encoded + "')))) 2>&1);if(-not $?){$__ok=$false};" +
+		"$__err=(@($__res|Where-Object{$_ -is [Management.Automation.ErrorRecord]})|ForEach-Object{$_.ToString()}) -join [Environment]::NewLine;" +
+		"$__out=$__res|Where-Object{$_ -isnot [Management.Automation.ErrorRecord]}|Out-String}" +
+		"catch{$__out='';$__ok=$false;$__err=$_.ToString()};" +
+		"if(-not $__ok -and -not $__err -and $Error.Count){$__err=$Error[0].ToString()};" +
+		"[Console]::Out.Write($__out);[Console]::Out.WriteLine();" +
+		"[Console]::Out.WriteLine('" + marker + "|'+$__ok+'|'+[Convert]::ToBase64String([Text.Encoding]::UTF8.GetBytes($__err)));[Console]::Out.Flush()"
+}
+
+// readSessionOutput reads the output of one command up to its marker line
+func readSessionOutput(r *bufio.Reader, marker string) (string, error) {
+	var output strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		if status, ok := strings.CutPrefix(strings.TrimRight(line, "\r\n"), marker+"|"); ok {
+			result := strings.TrimSpace(output.String())
+			status, encodedErr, _ := strings.Cut(status, "|")
+			if status == "True" {
+				return result, nil
+			}
+			if message, _ := base64.StdEncoding.DecodeString(encodedErr); len(bytes.TrimSpace(message)) > 0 {
+				return result, fmt.Errorf("%w: %s", errPowerShellFailed, bytes.TrimSpace(message))
+			}
+			return result, errPowerShellFailed
+		}
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", errPowerShellSessionEnded, err)
+		}
+		output.WriteString(line)
+	}
+}
+
+// UnmarshalPowerShellJSON decodes ConvertTo-Json output into a slice.
+// PowerShell emits a single object (not an array) when the pipeline yields
+// exactly one item, so both shapes are accepted. Empty output yields an
+// empty slice.
+func UnmarshalPowerShellJSON[T any](output string) ([]T, error) {
+	items := []T{}
+	if strings.TrimSpace(output) == "" {
+		return items, nil
+	}
+
+	if err := json.Unmarshal([]byte(output), &items); err != nil {
+		var single T
+		if err2 := json.Unmarshal([]byte(output), &single); err2 != nil {
+			return nil, err2
+		}
+		items = []T{single}
+	}
+
+	return items, nil
+}
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+type psTestItem struct {
+	Name  string `json:"Name"`
+	Count int    `json:"Count"`
+}
+
+func TestUnmarshalPowerShellJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{name: "empty output", input: "", want: 0},
+		{name: "whitespace output", input: "  \r\n", want: 0},
+		{name: "single object", input: `{"Name":"a","Count":1}`, want: 1},
+		{name: "array", input: `[{"Name":"a","Count":1},{"Name":"b","Count":2}]`, want: 2},
+		{name: "invalid JSON", input: `not json`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := UnmarshalPowerShellJSON[psTestItem](tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if items == nil {
+				t.Fatal("expected non-nil slice")
+			}
+			if len(items) != tt.want {
+				t.Errorf("got %d items, want %d", len(items), tt.want)
+			}
+		})
+	}
+}
+
+func TestReadSessionOutput(t *testing.T) {
+	const marker = "__PATCHMON_END_0011223344556677__"
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+		wantMsg string
+	}{
+		{name: "success", input: "line 1\r\nline 2\r\n\r\n" + marker + "|True\r\nnext", want: "line 1\r\nline 2"},
+		{name: "empty output", input: "\r\n" + marker + "|True\r\n", want: ""},
+		{name: "failure", input: "partial\r\n\r\n" + marker + "|False\r\n", want: "partial", wantErr: errPowerShellFailed},
+		{name: "failure with errors", input: "\r\n" + marker + "|False|" + base64.StdEncoding.EncodeToString([]byte("Access is denied")) + "\r\n", wantErr: errPowerShellFailed, wantMsg: "Access is denied"},
+		{name: "success with errors", input: "ok\r\n" + marker + "|True|" + base64.StdEncoding.EncodeToString([]byte("warning")) + "\r\n", want: "ok"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readSessionOutput(bufio.NewReader(strings.NewReader(tt.input)), marker)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("readSessionOutput() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("readSessionOutput() error = %v, want it to contain %q", err, tt.wantMsg)
+			}
+			if got != tt.want {
+				t.Errorf("readSessionOutput() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// The session ending before the marker is not a command failure
+	_, err := readSessionOutput(bufio.NewReader(strings.NewReader("output\r\n")), marker)
+	if !errors.Is(err, errPowerShellSessionEnded) || errors.Is(err, errPowerShellFailed) {
+		t.Errorf("readSessionOutput() at EOF error = %v, want session ended", err)
+	}
+}
+
+func TestSessionCommand(t *testing.T) {
+	const marker = "__PATCHMON_END_0011223344556677__"
+	command := "Get-Date -Format 'yyyy'\nWrite-Output \"done\""
+	line := sessionCommand(command, marker)
+
+	if strings.ContainsAny(line, "\r\n") {
+		t.Errorf("sessionCommand() spans several lines: %q", line)
+	}
+	if !strings.Contains(line, base64.StdEncoding.EncodeToString([]byte(command))) {
+		t.Error("sessionCommand() does not contain the encoded command")
+	}
+	if !strings.Contains(line, "'"+marker+"|'") {
+		t.Error("sessionCommand() does not write the marker")
+	}
+}
+
+// TestHungSessionHelper stands in for a PowerShell session that never answers
+func TestHungSessionHelper(t *testing.T) {
+	if os.Getenv("PATCHMON_HUNG_SESSION") != "1" {
+		t.Skip("helper process")
+	}
+	time.Sleep(time.Minute)
+}
+
+func TestSessionRunTimeout(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHungSessionHelper$")
+	cmd.Env = append(os.Environ(), "PATCHMON_HUNG_SESSION=1")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	session := &powerShellSession{cmd: cmd, stdin: stdin, stdout: bufio.NewReader(stdout), marker: "__END__"}
+	defer session.close()
+
+	start := time.Now()
+	_, err = session.run("Get-Date", 200*time.Millisecond)
+	if !errors.Is(err, ErrPowerShellTimeout) {
+		t.Fatalf("run() error = %v, want ErrPowerShellTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("run() returned after %s", elapsed)
+	}
+}
@@ -1,16 +1,76 @@
 package models
 
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+)
+
 // Config holds the agent configuration
 type Config struct {
-	PatchmonServer  string          `mapstructure:"patchmon_server" json:"patchmon_server"`
-	APIVersion      string          `mapstructure:"api_version" json:"api_version"`
-	CredentialsFile string          `mapstructure:"credentials_file" json:"credentials_file"`
-	LogFile         string          `mapstructure:"log_file" json:"log_file"`
-	LogLevel        string          `mapstructure:"log_level" json:"log_level"`
-	SkipSSLVerify   bool            `mapstructure:"skip_ssl_verify" json:"skip_ssl_verify"`
-	UpdateInterval  int             `mapstructure:"update_interval" json:"update_interval"`
-	ReportOffset    int             `mapstructure:"report_offset" json:"report_offset"`
-	Integrations    map[string]bool `mapstructure:"integrations" json:"integrations"`
+	PatchmonServer       string            `mapstructure:"patchmon_server" json:"patchmon_server"`
+	APIVersion           string            `mapstructure:"api_version" json:"api_version"`
+	CredentialsFile      string            `mapstructure:"credentials_file" json:"credentials_file"`
+	LogFile              string            `mapstructure:"log_file" json:"log_file"`
+	LogLevel             string            `mapstructure:"log_level" json:"log_level"`
+	SkipSSLVerify        bool              `mapstructure:"skip_ssl_verify" json:"skip_ssl_verify"`
+	UpdateInterval       int               `mapstructure:"update_interval" json:"update_interval"`
+	ReportOffset         int               `mapstructure:"report_offset" json:"report_offset"`
+	Integrations         map[string]bool   `mapstructure:"integrations" json:"integrations"`
+	OfflineScanCab       string            `mapstructure:"offline_scan_cab" json:"offline_scan_cab"`
+	WUAInstalledCriteria string            `mapstructure:"wua_installed_criteria" json:"wua_installed_criteria"`
+	WUAAvailableCriteria string            `mapstructure:"wua_available_criteria" json:"wua_available_criteria"`
+	WUASearchTimeout     int               `mapstructure:"wua_search_timeout" json:"wua_search_timeout"` // seconds, 0 = default
+	ExcludePackages      []string          `mapstructure:"exclude_packages" json:"exclude_packages"`
+	IncludeCategories    []string          `mapstructure:"include_categories" json:"include_categories"`
+	ExcludeCategories    []string          `mapstructure:"exclude_categories" json:"exclude_categories"`
+	CVELookup            bool              `mapstructure:"cve_lookup" json:"cve_lookup"`
+	CVELookupMonths      int               `mapstructure:"cve_lookup_months" json:"cve_lookup_months"` // 0 = default
+	RebootNotification   bool              `mapstructure:"reboot_notification" json:"reboot_notification"`
+	RebootSnoozeMinutes  int               `mapstructure:"reboot_snooze_minutes" json:"reboot_snooze_minutes"` // 0 = default
+	PreInstallHooks      []HookConfig      `mapstructure:"pre_install_hooks" json:"pre_install_hooks"`
+	PostInstallHooks     []HookConfig      `mapstructure:"post_install_hooks" json:"post_install_hooks"`
+	SecurityPosture      bool              `mapstructure:"security_posture" json:"security_posture"`
+	CertExpiryDays       int               `mapstructure:"cert_expiry_days" json:"cert_expiry_days"` // 0 = default
+	ExtendedInventory    bool              `mapstructure:"extended_inventory" json:"extended_inventory"`
+	InventoryPerUser     bool              `mapstructure:"inventory_per_user" json:"inventory_per_user"`
+	EventLogSummary      bool              `mapstructure:"event_log_summary" json:"event_log_summary"`
+	ResourceMetrics      bool              `mapstructure:"resource_metrics" json:"resource_metrics"`
+	MetricsSampleSeconds int               `mapstructure:"metrics_sample_seconds" json:"metrics_sample_seconds"` // 0 = default
+	PrometheusMetrics    bool              `mapstructure:"prometheus_metrics" json:"prometheus_metrics"`
+	PrometheusPort       int               `mapstructure:"prometheus_port" json:"prometheus_port"`     // 0 = default
+	MachineIDSource      string            `mapstructure:"machine_id_source" json:"machine_id_source"` // machine_guid (default), smbios_uuid or agent
+	PublicIP             bool              `mapstructure:"public_ip" json:"public_ip"`
+	ExcludeInterfaces    []string          `mapstructure:"exclude_interfaces" json:"exclude_interfaces"`
+	PrimaryIPSubnet      string            `mapstructure:"primary_ip_subnet" json:"primary_ip_subnet"`       // CIDR, e.g. 10.20.0.0/16
+	PrimaryIPInterface   string            `mapstructure:"primary_ip_interface" json:"primary_ip_interface"` // interface name pattern
+	InterfaceCounters    bool              `mapstructure:"interface_counters" json:"interface_counters"`
+	Proxy                string            `mapstructure:"proxy" json:"proxy"`                 // e.g. http://proxy:8080, empty = HTTPS_PROXY
+	EventLog             string            `mapstructure:"event_log" json:"event_log"`         // off (default), warn or info
+	SyslogServer         string            `mapstructure:"syslog_server" json:"syslog_server"` // udp://, tcp:// or tls://host[:port]
+	SyslogLevel          string            `mapstructure:"syslog_level" json:"syslog_level"`   // minimum level forwarded, default info
+	SyslogSkipTLSVerify  bool              `mapstructure:"syslog_skip_tls_verify" json:"syslog_skip_tls_verify"`
+	LogOutput            string            `mapstructure:"log_output" json:"log_output"`     // file (default), stdout or both
+	LogMaxSize           int               `mapstructure:"log_max_size" json:"log_max_size"` // megabytes before the log file is rotated
+	LogMaxBackups        int               `mapstructure:"log_max_backups" json:"log_max_backups"`
+	LogMaxAge            int               `mapstructure:"log_max_age" json:"log_max_age"`                   // days rotated files are kept
+	PowerShellTimeout    int               `mapstructure:"powershell_timeout" json:"powershell_timeout"`     // seconds per command, 0 = default
+	HTTPTimeout          int               `mapstructure:"http_timeout" json:"http_timeout"`                 // seconds per request, 0 = default
+	ReportSections       []string          `mapstructure:"report_sections" json:"report_sections"`           // empty = full report
+	FullReportInterval   int               `mapstructure:"full_report_interval" json:"full_report_interval"` // minutes between full reports in serve, 0 = at startup only
+	IntegrationsSync     bool              `mapstructure:"integrations_sync" json:"integrations_sync"`       // take the integration toggles from the server
+	ReportHistory        int               `mapstructure:"report_history" json:"report_history"`             // report runs kept for history, 0 = default
+	Tags                 map[string]string `mapstructure:"tags" json:"tags"`                                 // owner, environment, site, ... sent with every report
+	Site                 string            `mapstructure:"site" json:"site"`                                 // site or datacenter, for server-side group assignment
+	Environment          string            `mapstructure:"environment" json:"environment"`                   // e.g. prod, test
+	Role                 string            `mapstructure:"role" json:"role"`                                 // e.g. web, sql, dc
+}
+
+// HookConfig is a script run before or after updates are installed
+type HookConfig struct {
+	Path    string   `mapstructure:"path" json:"path"` // .ps1, .cmd/.bat or executable
+	Args    []string `mapstructure:"args" json:"args"`
+	Timeout int      `mapstructure:"timeout" json:"timeout"` // seconds, 0 = default
 }
 
 // Credentials holds API authentication credentials
@@ -21,10 +81,37 @@ type Credentials struct {
 
 // SystemInfo holds system-level information
 type SystemInfo struct {
-	KernelVersion string    `json:"kernelVersion"`
-	SELinuxStatus string    `json:"selinuxStatus"`
-	SystemUptime  string    `json:"systemUptime"`
-	LoadAverage   []float64 `json:"loadAverage"`
+	KernelVersion          string    `json:"kernelVersion"`
+	SELinuxStatus          string    `json:"selinuxStatus"`
+	SystemUptime           string    `json:"systemUptime"`
+	SystemUptimeSeconds    uint64    `json:"systemUptimeSeconds"`
+	LastBootTime           string    `json:"lastBootTime,omitempty"` // RFC3339
+	LoadAverage            []float64 `json:"loadAverage"`
+	PowerShellVersion      string    `json:"powershellVersion,omitempty"`
+	PowerShellCoreVersions []string  `json:"powershellCoreVersions,omitempty"`
+	BootMode               string    `json:"bootMode,omitempty"`              // uefi or legacy
+	SecureBootEnabled      *bool     `json:"secureBootEnabled,omitempty"`     // nil if unknown
+	OSInstallDate          string    `json:"osInstallDate,omitempty"`         // RFC3339, reset by feature updates
+	OSOriginalInstallDate  string    `json:"osOriginalInstallDate,omitempty"` // RFC3339, before any feature update
+	OSOriginalBuild        string    `json:"osOriginalBuild,omitempty"`
+}
+
+// OSEdition holds the Windows edition and servicing channel
+type OSEdition struct {
+	EditionID        string `json:"editionId"`        // e.g. Professional, EnterpriseS, ServerDatacenter
+	InstallationType string `json:"installationType"` // Client, Server, Server Core or Nano Server
+	Channel          string `json:"channel"`          // consumer, enterprise, ltsc, iot-ltsc or server
+	ServerCore       bool   `json:"serverCore"`
+	LTSC             bool   `json:"ltsc"`
+}
+
+// OSLifecycle holds the servicing lifecycle status of the running Windows release
+type OSLifecycle struct {
+	Release    string `json:"release"`
+	Channel    string `json:"channel"`
+	EOLDate    string `json:"eolDate"` // YYYY-MM-DD
+	Supported  bool   `json:"supported"`
+	EndingSoon bool   `json:"endingSoon"`
 }
 
 // HardwareInfo holds hardware information
@@ -34,20 +121,76 @@ type HardwareInfo struct {
 	RAMInstalled float64    `json:"ramInstalled"`
 	SwapSize     float64    `json:"swapSize"`
 	DiskDetails  []DiskInfo `json:"diskDetails"`
+	// Identity is nil if it could not be read
+	Identity      *SystemIdentity `json:"identity,omitempty"`
+	IsVirtual     bool            `json:"isVirtual"`
+	Hypervisor    string          `json:"hypervisor,omitempty"` // hyperv, vmware, kvm, xen, virtualbox, parallels
+	MemoryModules []MemoryModule  `json:"memoryModules,omitempty"`
+	PageFiles     *PageFileConfig `json:"pageFiles,omitempty"`
+}
+
+// SystemIdentity identifies the machine as an asset (SMBIOS system and BIOS data)
+type SystemIdentity struct {
+	Manufacturer     string `json:"manufacturer,omitempty"`
+	Model            string `json:"model,omitempty"`
+	SerialNumber     string `json:"serialNumber,omitempty"`
+	UUID             string `json:"uuid,omitempty"` // SMBIOS UUID
+	BIOSManufacturer string `json:"biosManufacturer,omitempty"`
+	BIOSVersion      string `json:"biosVersion,omitempty"`
+	BIOSReleaseDate  string `json:"biosReleaseDate,omitempty"` // YYYY-MM-DD
 }
 
 // DiskInfo holds information about a single disk
 type DiskInfo struct {
-	Name       string `json:"name"`
-	Size       string `json:"size"`
-	MountPoint string `json:"mountPoint"`
+	Name            string      `json:"name"`
+	Size            string      `json:"size"`
+	MountPoint      string      `json:"mountPoint"`
+	TotalBytes      uint64      `json:"totalBytes"`
+	UsedBytes       uint64      `json:"usedBytes"`
+	FreeBytes       uint64      `json:"freeBytes"`
+	UsedPercent     float64     `json:"usedPercent"`
+	LowDisk         bool        `json:"lowDisk"`
+	Model           string      `json:"model,omitempty"` // physical disk holding the volume
+	FirmwareVersion string      `json:"firmwareVersion,omitempty"`
+	BusType         string      `json:"busType,omitempty"` // nvme, sata, sas, usb, raid, ...
+	Health          *DiskHealth `json:"health,omitempty"`  // nil if unknown
 }
 
 // NetworkInfo holds network information
 type NetworkInfo struct {
 	GatewayIP         string             `json:"gatewayIp"`
 	DNSServers        []string           `json:"dnsServers"`
+	PrimaryDNSSuffix  string             `json:"primaryDnsSuffix,omitempty"`
+	DNSSearchList     []string           `json:"dnsSearchList,omitempty"` // configured suffix search list
 	NetworkInterfaces []NetworkInterface `json:"networkInterfaces"`
+	VPN               *VPNInfo           `json:"vpn,omitempty"`
+}
+
+// InterfaceCounters holds the traffic and error counters of an interface,
+// cumulative since the interface was last initialized (usually boot)
+type InterfaceCounters struct {
+	BytesReceived   uint64 `json:"bytesReceived"`
+	BytesSent       uint64 `json:"bytesSent"`
+	PacketsReceived uint64 `json:"packetsReceived"`
+	PacketsSent     uint64 `json:"packetsSent"`
+	ReceiveErrors   uint64 `json:"receiveErrors"`
+	SendErrors      uint64 `json:"sendErrors"`
+	ReceiveDiscards uint64 `json:"receiveDiscards"`
+	SendDiscards    uint64 `json:"sendDiscards"`
+}
+
+// VPNInfo holds the VPN adapters found on the host and whether a tunnel is up
+type VPNInfo struct {
+	Active   bool         `json:"active"`
+	Adapters []VPNAdapter `json:"adapters"`
+}
+
+// VPNAdapter is the virtual adapter of a VPN client
+type VPNAdapter struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Client      string `json:"client"` // windows, wireguard, openvpn, cisco-anyconnect, ...
+	Connected   bool   `json:"connected"`
 }
 
 // NetworkInterface holds information about a single network interface
@@ -60,6 +203,32 @@ type NetworkInterface struct {
 	LinkSpeed  int              `json:"linkSpeed"`
 	Duplex     string           `json:"duplex"`
 	Addresses  []NetworkAddress `json:"addresses"`
+	WiFi       *WiFiInfo        `json:"wifi,omitempty"`      // connected wireless interfaces only
+	DNSSuffix  string           `json:"dnsSuffix,omitempty"` // connection-specific DNS suffix
+	// Network List Manager profile, connected networks only
+	NetworkName     string `json:"networkName,omitempty"`
+	NetworkCategory string `json:"networkCategory,omitempty"` // domain, private or public
+	// Opt-in (interface_counters)
+	Counters *InterfaceCounters `json:"counters,omitempty"`
+	// IPv4 addressing, nil/empty if the interface has no IP configuration
+	DHCPEnabled       *bool  `json:"dhcpEnabled,omitempty"`
+	DHCPServer        string `json:"dhcpServer,omitempty"`
+	DHCPLeaseObtained string `json:"dhcpLeaseObtained,omitempty"` // RFC3339
+	DHCPLeaseExpires  string `json:"dhcpLeaseExpires,omitempty"`  // RFC3339
+}
+
+// WiFiInfo is the current connection of a wireless interface
+type WiFiInfo struct {
+	SSID          string `json:"ssid"`
+	BSSID         string `json:"bssid,omitempty"` // access point MAC address
+	Profile       string `json:"profile,omitempty"`
+	SignalPercent int    `json:"signalPercent"`
+	RSSI          int    `json:"rssi,omitempty"` // dBm
+	Band          string `json:"band,omitempty"` // 2.4 GHz, 5 GHz, 6 GHz or 60 GHz
+	FrequencyMHz  int    `json:"frequencyMhz,omitempty"`
+	PhyType       string `json:"phyType,omitempty"` // e.g. 802.11ax
+	RxRateMbps    int    `json:"rxRateMbps,omitempty"`
+	TxRateMbps    int    `json:"txRateMbps,omitempty"`
 }
 
 // NetworkAddress holds a single IP address configuration
@@ -72,12 +241,45 @@ type NetworkAddress struct {
 
 // Package holds information about a single package/update
 type Package struct {
-	Name             string `json:"name"`
-	Description      string `json:"description,omitempty"`
-	CurrentVersion   string `json:"currentVersion,omitempty"`
-	AvailableVersion string `json:"availableVersion,omitempty"`
-	NeedsUpdate      bool   `json:"needsUpdate"`
-	IsSecurityUpdate bool   `json:"isSecurityUpdate"`
+	Name             string   `json:"name"`
+	Description      string   `json:"description,omitempty"`
+	CurrentVersion   string   `json:"currentVersion,omitempty"`
+	AvailableVersion string   `json:"availableVersion,omitempty"`
+	NeedsUpdate      bool     `json:"needsUpdate"`
+	IsSecurityUpdate bool     `json:"isSecurityUpdate"`
+	CVEs             []string `json:"cves,omitempty"`
+	IsFeatureUpdate  bool     `json:"isFeatureUpdate,omitempty"`
+	// WSUSApproved is set for pending updates on WSUS clients: false means the
+	// update applies but is not approved for this computer's target group
+	WSUSApproved *bool `json:"wsusApproved,omitempty"`
+}
+
+// FeatureUpdate describes a Windows feature release (e.g. 23H2 → 24H2) offered to the device
+type FeatureUpdate struct {
+	Name          string `json:"name"`
+	Title         string `json:"title"`
+	Product       string `json:"product,omitempty"`
+	TargetVersion string `json:"targetVersion,omitempty"`
+}
+
+// AppxPackage holds information about a Microsoft Store / Appx / MSIX package
+type AppxPackage struct {
+	Name            string `json:"name"`
+	Version         string `json:"version"`
+	Publisher       string `json:"publisher"`
+	PackageFullName string `json:"packageFullName,omitempty"`
+	Architecture    string `json:"architecture,omitempty"`
+	SignatureKind   string `json:"signatureKind,omitempty"`
+	IsFramework     bool   `json:"isFramework"`
+	Installed       bool   `json:"installed"`
+	Provisioned     bool   `json:"provisioned"`
+}
+
+// WindowsFeature holds information about an enabled Windows optional feature or server role/feature
+type WindowsFeature struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	Type        string `json:"type"`
 }
 
 // Repository holds information about a package repository/update source
@@ -91,6 +293,34 @@ type Repository struct {
 	IsSecure     bool   `json:"isSecure"`
 }
 
+// UpdatePauseState reports until when Windows Update is paused (RFC3339).
+// Empty fields mean that update type is not paused.
+type UpdatePauseState struct {
+	QualityPausedUntil string `json:"qualityPausedUntil,omitempty"`
+	FeaturePausedUntil string `json:"featurePausedUntil,omitempty"`
+}
+
+// UpdateDeferral reports the Windows Update for Business deferral periods in
+// days. Nil fields mean no deferral is configured for that update type.
+type UpdateDeferral struct {
+	QualityDays *int `json:"qualityDays,omitempty"`
+	FeatureDays *int `json:"featureDays,omitempty"`
+}
+
+// ConfigMgrInfo describes the Configuration Manager (SCCM) client and its
+// co-management state
+type ConfigMgrInfo struct {
+	Installed         bool     `json:"installed"`
+	ServiceRunning    bool     `json:"serviceRunning"`
+	ClientVersion     string   `json:"clientVersion,omitempty"`
+	SiteCode          string   `json:"siteCode,omitempty"`
+	ManagementPoint   string   `json:"managementPoint,omitempty"`
+	CoManaged         bool     `json:"coManaged"`
+	CoManagementFlags int      `json:"coManagementFlags,omitempty"`
+	IntuneWorkloads   []string `json:"intuneWorkloads,omitempty"`
+	UpdatesManagedBy  string   `json:"updatesManagedBy"` // "configmgr" or "intune"
+}
+
 // ReportPayload is the full payload sent to the PatchMon server
 type ReportPayload struct {
 	Packages               []Package          `json:"packages"`
@@ -102,28 +332,198 @@ type ReportPayload struct {
 	Architecture           string             `json:"architecture"`
 	AgentVersion           string             `json:"agentVersion"`
 	MachineID              string             `json:"machineId"`
+	PreviousMachineID      string             `json:"previousMachineId,omitempty"` // set once after machine_id_source changes
 	KernelVersion          string             `json:"kernelVersion"`
 	InstalledKernelVersion string             `json:"installedKernelVersion"`
 	SELinuxStatus          string             `json:"selinuxStatus"`
 	SystemUptime           string             `json:"systemUptime"`
+	SystemUptimeSeconds    uint64             `json:"systemUptimeSeconds"`
+	LastBootTime           string             `json:"lastBootTime,omitempty"`
 	LoadAverage            []float64          `json:"loadAverage"`
 	CPUModel               string             `json:"cpuModel"`
 	CPUCores               int                `json:"cpuCores"`
 	RAMInstalled           float64            `json:"ramInstalled"`
 	SwapSize               float64            `json:"swapSize"`
+	PageFiles              *PageFileConfig    `json:"pageFiles,omitempty"`
 	DiskDetails            []DiskInfo         `json:"diskDetails"`
+	MemoryModules          []MemoryModule     `json:"memoryModules,omitempty"`
+	SystemIdentity         *SystemIdentity    `json:"systemIdentity,omitempty"`
+	IsVirtual              bool               `json:"isVirtual"`
+	Hypervisor             string             `json:"hypervisor,omitempty"`
+	HyperVGuests           []HyperVGuest      `json:"hyperVGuests,omitempty"`
+	Drivers                []Driver           `json:"drivers,omitempty"`
+	StorageArrays          *StorageArrays     `json:"storageArrays,omitempty"`
+	Containers             *ContainerInfo     `json:"containers,omitempty"`
+	ExtendedInventory      *ExtendedInventory `json:"extendedInventory,omitempty"`
+	EventLog               *EventLogSummary   `json:"eventLog,omitempty"`
+	Metrics                *ResourceMetrics   `json:"metrics,omitempty"`
 	GatewayIP              string             `json:"gatewayIp"`
 	DNSServers             []string           `json:"dnsServers"`
+	PrimaryDNSSuffix       string             `json:"primaryDnsSuffix,omitempty"`
+	DNSSearchList          []string           `json:"dnsSearchList,omitempty"`
 	NetworkInterfaces      []NetworkInterface `json:"networkInterfaces"`
+	PublicIP               string             `json:"publicIp,omitempty"` // as seen by the PatchMon server
+	VPN                    *VPNInfo           `json:"vpn,omitempty"`
 	ExecutionTime          float64            `json:"executionTime"`
 	NeedsReboot            bool               `json:"needsReboot"`
 	RebootReason           string             `json:"rebootReason"`
+	AppxPackages           []AppxPackage      `json:"appxPackages,omitempty"`
+	WindowsFeatures        []WindowsFeature   `json:"windowsFeatures,omitempty"`
+	PowerShellVersion      string             `json:"powershellVersion,omitempty"`
+	PowerShellCoreVersions []string           `json:"powershellCoreVersions,omitempty"`
+	BootMode               string             `json:"bootMode,omitempty"`
+	SecureBootEnabled      *bool              `json:"secureBootEnabled,omitempty"`
+	Domain                 *DomainInfo        `json:"domain,omitempty"`
+	CloudJoin              *CloudJoinInfo     `json:"cloudJoin,omitempty"`
+	OSInstallDate          string             `json:"osInstallDate,omitempty"`
+	OSOriginalInstallDate  string             `json:"osOriginalInstallDate,omitempty"`
+	OSOriginalBuild        string             `json:"osOriginalBuild,omitempty"`
+	PackagesIncomplete     bool               `json:"packagesIncomplete,omitempty"`
+	CollectionErrors       []string           `json:"collectionErrors,omitempty"`
+	Defender               *DefenderInfo      `json:"defender,omitempty"`
+	RDP                    *RDPInfo           `json:"rdp,omitempty"`
+	SecurityPosture        *SecurityPosture   `json:"securityPosture,omitempty"`
+	LocalUsers             []LocalUser        `json:"localUsers,omitempty"`
+	LocalAdministrators    []LocalGroupMember `json:"localAdministrators,omitempty"`
+	ExpiringCertificates   []Certificate      `json:"expiringCertificates,omitempty"`
+	FeatureUpdate          *FeatureUpdate     `json:"featureUpdate,omitempty"`
+	OSEdition              *OSEdition         `json:"osEdition,omitempty"`
+	OSEolDate              string             `json:"osEolDate,omitempty"`
+	OSSupported            *bool              `json:"osSupported,omitempty"`
+	OSSupportEndingSoon    bool               `json:"osSupportEndingSoon,omitempty"`
+	ConfigMgr              *ConfigMgrInfo     `json:"configMgr,omitempty"`
+	HiddenUpdates          []Package          `json:"hiddenUpdates,omitempty"`
+	UpdatePause            *UpdatePauseState  `json:"updatePause,omitempty"`
+	UpdateDeferral         *UpdateDeferral    `json:"updateDeferral,omitempty"`
+	UpdateActivity         *UpdateActivity    `json:"updateActivity,omitempty"`
+	WUAVersion             string             `json:"wuaVersion,omitempty"`
+	Sections               []string           `json:"sections,omitempty"` // sections collected, omitted for a full report
+	Tags                   map[string]string  `json:"tags,omitempty"`
+	Site                   string             `json:"site,omitempty"`
+	Environment            string             `json:"environment,omitempty"`
+	Role                   string             `json:"role,omitempty"`
+}
+
+// sectionFields are the payload fields of optional report sections that a
+// full report always includes. A partial report leaves out those of the
+// sections it did not collect, so the server keeps the values it has.
+var sectionFields = map[string][]string{
+	"packages": {"packages", "repositories"},
+	"hardware": {"cpuModel", "cpuCores", "ramInstalled", "swapSize", "diskDetails", "isVirtual"},
+	"network":  {"gatewayIp", "dnsServers", "networkInterfaces"},
+}
+
+// MarshalJSON omits the fields of the sections a partial report did not
+// collect
+func (p ReportPayload) MarshalJSON() ([]byte, error) {
+	type plain ReportPayload
+	data, err := json.Marshal(plain(p))
+	if err != nil || len(p.Sections) == 0 {
+		return data, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for section, names := range sectionFields {
+		if slices.Contains(p.Sections, section) {
+			continue
+		}
+		for _, name := range names {
+			delete(fields, name)
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// OptionalFields returns the fields MarshalJSON leaves out of partial reports,
+// so the generated schema does not require them
+func (ReportPayload) OptionalFields() []string {
+	var names []string
+	for _, section := range slices.Sorted(maps.Keys(sectionFields)) {
+		names = append(names, sectionFields[section]...)
+	}
+	return names
+}
+
+// ExportedReport is a report payload exported for submission from another
+// host. The signature is the hex HMAC-SHA256, keyed with the API key of the
+// exporting host, of the version, API ID and export time, one per line,
+// followed by the compacted payload.
+type ExportedReport struct {
+	Version    int             `json:"version"`
+	APIID      string          `json:"apiId"`
+	ExportedAt string          `json:"exportedAt"` // RFC3339
+	Payload    json.RawMessage `json:"payload"`
+	Signature  string          `json:"signature"`
+}
+
+// UpdateInstallResult is the outcome of installing a single update
+type UpdateInstallResult struct {
+	Name           string `json:"name"`
+	Title          string `json:"title,omitempty"`
+	Status         string `json:"status"` // installed, downloaded, uninstalled, hidden, unhidden, failed, download_failed, not_found, not_installed
+	ResultCode     int    `json:"resultCode,omitempty"`
+	Error          string `json:"error,omitempty"`
+	RebootRequired bool   `json:"rebootRequired,omitempty"`
+}
+
+// InstallResultPayload reports the outcome of an update action (install,
+// download, uninstall, hide/unhide) to the server
+type InstallResultPayload struct {
+	Hostname       string                `json:"hostname"`
+	MachineID      string                `json:"machineId"`
+	AgentVersion   string                `json:"agentVersion"`
+	Action         string                `json:"action"`             // install, download, uninstall, hide, unhide, reboot, cancel_reboot, scan, defender_scan
+	ActionID       string                `json:"actionId,omitempty"` // set when the server requested the action
+	StartedAt      string                `json:"startedAt"`          // RFC3339
+	FinishedAt     string                `json:"finishedAt"`         // RFC3339
+	Results        []UpdateInstallResult `json:"results"`
+	RebootRequired bool                  `json:"rebootRequired"`
+	Hooks          []HookResult          `json:"hooks,omitempty"`
+	DefenderScan   *DefenderScanResult   `json:"defenderScan,omitempty"`
+	Error          string                `json:"error,omitempty"`
+}
+
+// HookResult is the outcome of a pre- or post-install hook
+type HookResult struct {
+	Stage      string `json:"stage"` // pre_install or post_install
+	Path       string `json:"path"`
+	Succeeded  bool   `json:"succeeded"`
+	ExitCode   int    `json:"exitCode"`
+	TimedOut   bool   `json:"timedOut,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+	Output     string `json:"output,omitempty"` // combined stdout/stderr, truncated
+	Error      string `json:"error,omitempty"`
+}
+
+// InstallResultResponse is the server response to an InstallResultPayload
+type InstallResultResponse struct {
+	Message string `json:"message"`
+}
+
+// InstallProgressEvent is an intermediate progress update sent while updates
+// are downloaded or installed, or while the agent updates itself
+type InstallProgressEvent struct {
+	Hostname  string `json:"hostname"`
+	MachineID string `json:"machineId"`
+	Action    string `json:"action"`
+	ActionID  string `json:"actionId,omitempty"`
+	Phase     string `json:"phase"`
+	Update    string `json:"update,omitempty"` // KB or title of the current update
+	Index     int    `json:"index,omitempty"`  // 1-based position of the current update
+	Total     int    `json:"total,omitempty"`  // number of updates in this phase
+	Percent   int    `json:"percent"`          // progress of the current update or step
+	Timestamp string `json:"timestamp"`
 }
 
 // PingResponse is the response from the server ping endpoint
 type PingResponse struct {
 	Status  string `json:"status"`
 	Message string `json:"message"`
+	// Capabilities lists optional features the server supports, e.g. partial_reports
+	Capabilities []string `json:"capabilities,omitempty"`
 }
 
 // AutoUpdateInfo holds server-initiated auto-update information
@@ -134,10 +534,25 @@ type AutoUpdateInfo struct {
 	Message        string `json:"message"`
 }
 
+// AgentAction is an update action requested by the server in the report response
+type AgentAction struct {
+	ID   string   `json:"id"`
+	Type string   `json:"type"` // hide, unhide, reboot, cancel_reboot, scan, defender_scan
+	KBs  []string `json:"kbs,omitempty"`
+	// DelaySeconds, Message and Force apply to reboot actions. Without a
+	// delay the agent's default delay is used.
+	DelaySeconds int    `json:"delaySeconds,omitempty"`
+	Message      string `json:"message,omitempty"`
+	Force        bool   `json:"force,omitempty"`
+	// ScanType applies to defender_scan actions (quick or full)
+	ScanType string `json:"scanType,omitempty"`
+}
+
 // UpdateResponse is the response from the server update endpoint
 type UpdateResponse struct {
 	PackagesProcessed int             `json:"packagesProcessed"`
 	AutoUpdate        *AutoUpdateInfo `json:"autoUpdate,omitempty"`
+	Actions           []AgentAction   `json:"actions,omitempty"`
 }
 
 // UpdateIntervalResponse is the response from the server update-interval endpoint
@@ -145,7 +560,204 @@ type UpdateIntervalResponse struct {
 	Interval int `json:"interval"`
 }
 
+// PublicIPResponse is the response from the public IP endpoint, which echoes
+// the source address of the request
+type PublicIPResponse struct {
+	IP string `json:"ip"`
+}
+
 // IntegrationStatusResponse is the response from the integration status endpoint
 type IntegrationStatusResponse struct {
 	Integrations map[string]bool `json:"integrations"`
 }
+
+// DomainInfo holds the domain or workgroup membership of the computer
+type DomainInfo struct {
+	JoinType          string `json:"joinType"`                    // domain or workgroup
+	Name              string `json:"name"`                        // NetBIOS domain or workgroup name
+	DNSDomain         string `json:"dnsDomain,omitempty"`         // domain members only
+	Site              string `json:"site,omitempty"`              // Active Directory site
+	DistinguishedName string `json:"distinguishedName,omitempty"` // computer object DN
+	OU                string `json:"ou,omitempty"`                // DN of the containing OU
+}
+
+// CloudJoinInfo holds the Microsoft Entra ID (Azure AD) join and MDM enrollment state
+type CloudJoinInfo struct {
+	AzureADJoined   bool   `json:"azureAdJoined"`
+	HybridJoined    bool   `json:"hybridJoined"`    // joined to both Azure AD and an AD domain
+	WorkplaceJoined bool   `json:"workplaceJoined"` // registered, not joined
+	TenantName      string `json:"tenantName,omitempty"`
+	TenantID        string `json:"tenantId,omitempty"`
+	DeviceID        string `json:"deviceId,omitempty"`
+	MDMEnrolled     bool   `json:"mdmEnrolled"`
+	MDMProvider     string `json:"mdmProvider,omitempty"` // "MS DM Server" for Intune
+	MDMUser         string `json:"mdmUser,omitempty"`
+}
+
+// UpdateActivity reports when Windows Update last succeeded in checking for
+// and installing updates (RFC3339). Empty fields mean unknown or never.
+type UpdateActivity struct {
+	LastDetectSuccess  string `json:"lastDetectSuccess,omitempty"`
+	LastInstallSuccess string `json:"lastInstallSuccess,omitempty"`
+}
+
+// HyperVGuest is a virtual machine on a Hyper-V host. Guest OS details come
+// from the integration services and are empty when the VM is off or the
+// data exchange service is disabled.
+type HyperVGuest struct {
+	ID        string `json:"id"` // Hyper-V VM ID
+	Name      string `json:"name"`
+	State     string `json:"state"` // running, off, saved, paused, starting, saving, stopping, unknown
+	Hostname  string `json:"hostname,omitempty"`
+	OSName    string `json:"osName,omitempty"`
+	OSVersion string `json:"osVersion,omitempty"`
+}
+
+// ContainerInfo describes the container engine and its running containers
+type ContainerInfo struct {
+	Engine        string      `json:"engine"` // docker or containerd
+	EngineVersion string      `json:"engineVersion"`
+	OSType        string      `json:"osType,omitempty"` // windows or linux containers
+	Containers    []Container `json:"containers,omitempty"`
+}
+
+// Container is a running container
+type Container struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Image          string `json:"image"`
+	ImageID        string `json:"imageId,omitempty"`
+	ImageOSVersion string `json:"imageOsVersion,omitempty"` // base image build, Windows containers only
+	Status         string `json:"status"`
+}
+
+// ExtendedInventory holds the optional extended software inventory
+type ExtendedInventory struct {
+	StartupItems []StartupItem `json:"startupItems"`
+}
+
+// StartupItem is a program started at logon
+type StartupItem struct {
+	Name     string `json:"name"`
+	Command  string `json:"command"`
+	Location string `json:"location"` // registry key or Startup folder
+	Scope    string `json:"scope"`    // machine or the user profile name
+}
+
+// Driver is the driver of a device present on the machine
+type Driver struct {
+	DeviceName string `json:"deviceName"`
+	Class      string `json:"class,omitempty"` // lower-case device class, e.g. net, scsiadapter, display
+	Provider   string `json:"provider,omitempty"`
+	Version    string `json:"version"`
+	Date       string `json:"date,omitempty"` // YYYY-MM-DD
+	InfName    string `json:"infName,omitempty"`
+	Signed     bool   `json:"signed"`
+	Signer     string `json:"signer,omitempty"`
+}
+
+// EventLogSummary counts the critical and error events in the System log
+type EventLogSummary struct {
+	Since               string             `json:"since"` // RFC3339
+	Critical            int                `json:"critical"`
+	Errors              int                `json:"errors"`
+	Sources             []EventSourceCount `json:"sources"`             // most frequent first
+	UnexpectedShutdowns []string           `json:"unexpectedShutdowns"` // RFC3339 times of the events reporting them
+}
+
+// EventSourceCount is the number of critical and error events from one source
+type EventSourceCount struct {
+	Source   string `json:"source"`
+	Critical int    `json:"critical"`
+	Errors   int    `json:"errors"`
+}
+
+// MemoryModule is an installed memory module (DIMM)
+type MemoryModule struct {
+	Slot               string  `json:"slot,omitempty"` // e.g. DIMM A1
+	Bank               string  `json:"bank,omitempty"`
+	SizeGB             float64 `json:"sizeGb"`
+	SpeedMHz           int     `json:"speedMhz,omitempty"`           // rated speed
+	ConfiguredSpeedMHz int     `json:"configuredSpeedMhz,omitempty"` // speed it runs at
+	Type               string  `json:"type,omitempty"`               // DDR3, DDR4, DDR5, ...
+	Manufacturer       string  `json:"manufacturer,omitempty"`
+	PartNumber         string  `json:"partNumber,omitempty"`
+	SerialNumber       string  `json:"serialNumber,omitempty"`
+}
+
+// DiskHealth is the health of the physical disk holding a volume
+type DiskHealth struct {
+	Disk              string `json:"disk"`                // physical disk name
+	MediaType         string `json:"mediaType,omitempty"` // hdd, ssd or scm
+	Status            string `json:"status"`              // healthy, warning, unhealthy or unknown
+	WearPercent       int    `json:"wearPercent,omitempty"`
+	TemperatureC      int    `json:"temperatureC,omitempty"`
+	PowerOnHours      int    `json:"powerOnHours,omitempty"`
+	UncorrectedErrors uint64 `json:"uncorrectedErrors,omitempty"` // read and write errors
+}
+
+// StorageArrays holds the Storage Spaces and hardware RAID configuration
+type StorageArrays struct {
+	Pools   []StoragePool   `json:"pools,omitempty"`
+	Volumes []StorageVolume `json:"volumes,omitempty"`
+}
+
+// StoragePool is a Storage Spaces pool
+type StoragePool struct {
+	Name              string `json:"name"`
+	Health            string `json:"health"`                      // healthy, warning, unhealthy or unknown
+	OperationalStatus string `json:"operationalStatus,omitempty"` // e.g. "degraded"
+	SizeBytes         uint64 `json:"sizeBytes"`
+	AllocatedBytes    uint64 `json:"allocatedBytes"`
+	ReadOnly          bool   `json:"readOnly"`
+	Degraded          bool   `json:"degraded"` // health is not healthy
+}
+
+// StorageVolume is a Storage Spaces virtual disk or a hardware RAID logical drive
+type StorageVolume struct {
+	Name              string `json:"name"`
+	Type              string `json:"type"`                 // storage-spaces or hardware-raid
+	Resiliency        string `json:"resiliency,omitempty"` // Simple, Mirror or Parity (Storage Spaces only)
+	Health            string `json:"health"`
+	OperationalStatus string `json:"operationalStatus,omitempty"`
+	SizeBytes         uint64 `json:"sizeBytes"`
+	Degraded          bool   `json:"degraded"`
+}
+
+// ResourceMetrics is the resource utilization averaged over a short sample
+type ResourceMetrics struct {
+	SampledAt            string          `json:"sampledAt"` // RFC3339
+	SampleSeconds        int             `json:"sampleSeconds"`
+	CPUPercent           float64         `json:"cpuPercent"`
+	ProcessorQueueLength float64         `json:"processorQueueLength"` // threads waiting for a processor
+	MemoryTotalBytes     uint64          `json:"memoryTotalBytes"`
+	MemoryUsedBytes      uint64          `json:"memoryUsedBytes"`
+	MemoryUsedPercent    float64         `json:"memoryUsedPercent"`
+	DiskQueueLength      float64         `json:"diskQueueLength"` // all volumes
+	Volumes              []VolumeMetrics `json:"volumes,omitempty"`
+}
+
+// VolumeMetrics is the free space and I/O queue of a volume
+type VolumeMetrics struct {
+	Name        string  `json:"name"` // e.g. C:
+	FreeBytes   uint64  `json:"freeBytes"`
+	FreePercent float64 `json:"freePercent"`
+	QueueLength float64 `json:"queueLength"`
+}
+
+// PageFileConfig is the page file configuration
+type PageFileConfig struct {
+	SystemManaged bool       `json:"systemManaged"` // Windows sizes all page files automatically
+	Files         []PageFile `json:"files,omitempty"`
+}
+
+// PageFile is a configured or active page file (sizes in MB)
+type PageFile struct {
+	Path            string `json:"path"` // e.g. C:\pagefile.sys
+	InitialSizeMB   int    `json:"initialSizeMb,omitempty"`
+	MaximumSizeMB   int    `json:"maximumSizeMb,omitempty"`
+	SystemManaged   bool   `json:"systemManaged"`
+	AllocatedSizeMB int    `json:"allocatedSizeMb,omitempty"` // current size on disk
+	CurrentUsageMB  int    `json:"currentUsageMb,omitempty"`
+	PeakUsageMB     int    `json:"peakUsageMb,omitempty"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestReportPayloadMarshalSections(t *testing.T) {
+	tests := []struct {
+		name     string
+		sections []string
+		present  []string
+		absent   []string
+	}{
+		{"full report", nil, []string{"packages", "repositories", "cpuModel", "diskDetails", "networkInterfaces", "dnsServers"}, []string{"sections"}},
+		{"packages only", []string{"packages"}, []string{"packages", "repositories", "hostname", "sections"}, []string{"cpuModel", "cpuCores", "diskDetails", "networkInterfaces", "dnsServers", "gatewayIp"}},
+		{"hardware and network", []string{"hardware", "network"}, []string{"cpuModel", "diskDetails", "networkInterfaces"}, []string{"packages", "repositories"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := ReportPayload{Hostname: "host", Packages: []Package{}, Sections: tt.sections}
+			data, err := json.Marshal(&payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(data, &fields); err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range tt.present {
+				if _, ok := fields[name]; !ok {
+					t.Errorf("%s missing from %s", name, data)
+				}
+			}
+			for _, name := range tt.absent {
+				if _, ok := fields[name]; ok {
+					t.Errorf("%s present in %s", name, data)
+				}
+			}
+
+			var decoded ReportPayload
+			if err := json.Unmarshal(data, &decoded); err != nil || decoded.Hostname != "host" {
+				t.Errorf("round trip = %+v, %v", decoded, err)
+			}
+		})
+	}
+}
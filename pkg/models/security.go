@@ -0,0 +1,84 @@
+package models
+
+// DefenderInfo holds Microsoft Defender engine and signature information
+type DefenderInfo struct {
+	EngineVersion               string `json:"engineVersion"`
+	ProductVersion              string `json:"productVersion"`
+	AntivirusSignatureVersion   string `json:"antivirusSignatureVersion"`
+	AntispywareSignatureVersion string `json:"antispywareSignatureVersion"`
+	NISSignatureVersion         string `json:"nisSignatureVersion,omitempty"`
+	SignatureLastUpdated        string `json:"signatureLastUpdated,omitempty"` // RFC3339
+	SignatureAgeDays            int    `json:"signatureAgeDays"`
+	AntivirusEnabled            bool   `json:"antivirusEnabled"`
+	RealTimeProtectionEnabled   bool   `json:"realTimeProtectionEnabled"`
+	// Health is only collected when the defender integration is enabled
+	Health *DefenderHealth `json:"health,omitempty"`
+}
+
+// DefenderHealth holds Microsoft Defender protection and scan status
+type DefenderHealth struct {
+	RunningMode               string `json:"runningMode,omitempty"` // Normal, Passive, EDR Block Mode, ...
+	AntimalwareServiceEnabled bool   `json:"antimalwareServiceEnabled"`
+	AntispywareEnabled        bool   `json:"antispywareEnabled"`
+	BehaviorMonitorEnabled    bool   `json:"behaviorMonitorEnabled"`
+	IOAVProtectionEnabled     bool   `json:"ioavProtectionEnabled"`
+	OnAccessProtectionEnabled bool   `json:"onAccessProtectionEnabled"`
+	TamperProtected           bool   `json:"tamperProtected"`
+	LastQuickScan             string `json:"lastQuickScan,omitempty"` // RFC3339, empty if never run
+	LastFullScan              string `json:"lastFullScan,omitempty"`  // RFC3339, empty if never run
+}
+
+// DefenderScanResult is the outcome of an on-demand Microsoft Defender scan
+type DefenderScanResult struct {
+	ScanType        string   `json:"scanType"` // quick or full
+	DurationSeconds int      `json:"durationSeconds"`
+	ThreatsFound    int      `json:"threatsFound"`
+	Threats         []string `json:"threats,omitempty"` // threat names detected during the scan
+	ExitCode        int      `json:"exitCode"`
+}
+
+// RDPInfo describes the Remote Desktop exposure of the host
+type RDPInfo struct {
+	Enabled     bool `json:"enabled"`     // accepts Remote Desktop connections
+	NLARequired bool `json:"nlaRequired"` // Network Level Authentication required
+	Port        int  `json:"port"`
+}
+
+// SecurityPosture holds OS hardening settings
+type SecurityPosture struct {
+	SMBv1ServerEnabled     bool   `json:"smbv1ServerEnabled"`
+	SMBv1ClientEnabled     bool   `json:"smbv1ClientEnabled"`
+	LSAProtectionEnabled   bool   `json:"lsaProtectionEnabled"` // LSA runs as a protected process (RunAsPPL)
+	CredentialGuardRunning bool   `json:"credentialGuardRunning"`
+	VBSStatus              string `json:"vbsStatus,omitempty"` // virtualization-based security: off, enabled, running
+	UACEnabled             bool   `json:"uacEnabled"`
+	UACLevel               string `json:"uacLevel,omitempty"` // always_notify, default, notify_no_dim, never_notify, custom
+}
+
+// LocalUser represents a local user account
+type LocalUser struct {
+	Name             string `json:"name"`
+	SID              string `json:"sid"`
+	Description      string `json:"description,omitempty"`
+	Enabled          bool   `json:"enabled"`
+	LockedOut        bool   `json:"lockedOut"`
+	PasswordRequired bool   `json:"passwordRequired"`
+	PasswordExpires  bool   `json:"passwordExpires"`
+}
+
+// LocalGroupMember represents a direct member of a local group
+type LocalGroupMember struct {
+	Name   string `json:"name"`
+	Domain string `json:"domain"` // computer name for local accounts
+	Type   string `json:"type"`   // user, group or system
+	Local  bool   `json:"local"`
+}
+
+// Certificate is a machine certificate that has expired or expires soon
+type Certificate struct {
+	Subject    string `json:"subject"`
+	Issuer     string `json:"issuer"`
+	Thumbprint string `json:"thumbprint"` // SHA-1, upper-case hex
+	NotAfter   string `json:"notAfter"`   // RFC3339
+	Expired    bool   `json:"expired"`
+}